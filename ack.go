@@ -0,0 +1,192 @@
+package timequeue
+
+import "time"
+
+//WithAckMode causes a TimeQueue to track every released Message as unacknowledged
+//until it is acknowledged with Ack(), AckAll(), or AckUpTo(), or negatively
+//acknowledged with Nack().
+//Every Message released in ack mode is given a Sequence() number that increases
+//with every release.
+//
+//A Dispatcher consuming a TimeQueue in ack mode acknowledges every Message after
+//its Handler returns, whether or not it failed, since failures are handled by the
+//Dispatcher's retry and dead letter options.
+//WithAckMode may not be given to Reconfigure().
+func WithAckMode() Option {
+	return func(c *config) {
+		c.fixed = true
+		c.ackMode = true
+	}
+}
+
+//WithVisibilityTimeout causes a TimeQueue in ack mode to push every released
+//Message back to itself if it is not acknowledged within timeout of its release.
+//The Message is pushed again as is, keeping its ID, to be released immediately.
+//This gives at-least-once delivery to consumers that may crash after receiving a
+//Message and before processing it.
+//A Message that is redelivered is pushed even if another Message with its Key is
+//in the TimeQueue and without checking its Tenant's quota.
+//
+//WithVisibilityTimeout has no effect unless WithAckMode() is also given. A timeout
+//less than or equal to zero never redelivers Messages, which is the default.
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.visibilityTimeout = timeout
+	}
+}
+
+//Sequence returns the release sequence number of m in a TimeQueue in ack mode,
+//or 0 if m has not been released in ack mode.
+func (m *Message) Sequence() uint64 {
+	return m.seq
+}
+
+//trackUnacked records message as released and unacknowledged if q is in ack mode.
+//It should only be called when q is locked.
+func (q *TimeQueue) trackUnacked(message *Message) {
+	if !q.config.ackMode || message.fn != nil {
+		return
+	}
+	q.releaseSeq++
+	message.seq = q.releaseSeq
+	message.deliveries++
+	q.unacked[message.ID] = message
+	if q.config.visibilityTimeout > 0 {
+		message.visibleAt = q.now().Add(q.config.visibilityTimeout)
+		q.armVisibility(message.visibleAt)
+	}
+}
+
+//Nack negatively acknowledges the released Message with ID id by pushing it back
+//to q to be released delay from now. It keeps its ID.
+//The Message is dead-lettered instead if it has been delivered the maximum
+//number of times, see WithMaxDeliveries().
+//Returns false if there is no unacknowledged Message with id.
+func (q *TimeQueue) Nack(id string, delay time.Duration) bool {
+	return q.NackError(id, delay, nil)
+}
+
+//NackError is like Nack() except that err is recorded as the reason for the
+//failed delivery. It is given to DeadLetters() if the Message is dead-lettered.
+func (q *TimeQueue) NackError(id string, delay time.Duration, err error) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	message, ok := q.unacked[id]
+	if !ok {
+		return false
+	}
+	delete(q.unacked, id)
+	q.requeue(message, q.now().Add(delay), err)
+	q.afterHeapUpdate()
+	return true
+}
+
+//requeue pushes the released message back to q to be released at t, or
+//dead-letters it with err if it has been delivered the maximum number of times.
+//It should only be called when q is locked.
+func (q *TimeQueue) requeue(message *Message, t time.Time, err error) {
+	if max := q.config.maxDeliveries; max > 0 && message.deliveries >= max {
+		q.storeRemove(message)
+		q.deadLetter(message, err)
+		return
+	}
+	message.Time = t
+	message.visibleAt = time.Time{}
+	q.pushStored(message)
+}
+
+//armVisibility ensures that unacknowledged Messages are checked for expired
+//visibility timeouts no later than t. Timeouts are checked by Advance() instead
+//when q is advanced manually.
+//It should only be called when q is locked.
+func (q *TimeQueue) armVisibility(t time.Time) {
+	if q.config.manual || (q.visibility != nil && !q.visibilityAt.After(t)) {
+		return
+	}
+	if q.visibility != nil {
+		q.visibility.Stop()
+	}
+	q.visibilityAt = t
+	q.visibility = time.AfterFunc(waitDuration(t, time.Now()), q.onVisibilityTimeout)
+}
+
+//onVisibilityTimeout redelivers the Messages whose visibility timeouts have
+//expired. It is called by the visibility timer.
+func (q *TimeQueue) onVisibilityTimeout() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.visibility = nil
+	q.expireUnacked(q.now())
+	q.afterHeapUpdate()
+}
+
+//expireUnacked pushes back every unacknowledged Message whose visibility timeout
+//expired at or before now to be released at now, and arms the visibility timer
+//for the rest.
+//It should only be called when q is locked.
+func (q *TimeQueue) expireUnacked(now time.Time) {
+	earliest := time.Time{}
+	for id, message := range q.unacked {
+		if message.visibleAt.IsZero() {
+			continue
+		}
+		if !message.visibleAt.After(now) {
+			delete(q.unacked, id)
+			q.requeue(message, now, ErrVisibilityTimeout)
+			continue
+		}
+		if earliest.IsZero() || message.visibleAt.Before(earliest) {
+			earliest = message.visibleAt
+		}
+	}
+	if !earliest.IsZero() {
+		q.armVisibility(earliest)
+	}
+}
+
+//Ack acknowledges the released Message with ID id.
+//Returns false if there is no unacknowledged Message with id.
+func (q *TimeQueue) Ack(id string) bool {
+	return q.AckAll(id) == 1
+}
+
+//AckAll acknowledges the released Messages with ids while locking q only once.
+//Returns the number of Messages acknowledged. Unknown ids are ignored.
+func (q *TimeQueue) AckAll(ids ...string) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	count := 0
+	for _, id := range ids {
+		if message, ok := q.unacked[id]; ok {
+			q.ack(message)
+			count++
+		}
+	}
+	q.saveCursor()
+	return count
+}
+
+//AckUpTo acknowledges every released Message with a Sequence() less than or equal
+//to seq. This allows a consumer that processes Messages in release order to
+//acknowledge an entire batch with its last Message.
+//Returns the number of Messages acknowledged.
+func (q *TimeQueue) AckUpTo(seq uint64) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	count := 0
+	for _, message := range q.unacked {
+		if message.seq <= seq {
+			q.ack(message)
+			count++
+		}
+	}
+	q.saveCursor()
+	return count
+}
+
+//Unacked returns the number of released Messages that have not been acknowledged.
+func (q *TimeQueue) Unacked() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.unacked)
+}
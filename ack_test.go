@@ -0,0 +1,114 @@
+package timequeue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_ackMode(t *testing.T) {
+	q := New(WithAckMode(), WithCapacity(5))
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		q.Push(now.Add(time.Duration(i)*time.Millisecond), i)
+	}
+	released := q.PopAll(true)
+	for i, message := range released {
+		if seq := message.Sequence(); seq != uint64(i+1) {
+			t.Errorf("message.Sequence() = %v WANT %v", seq, i+1)
+		}
+	}
+	if unacked := q.Unacked(); unacked != 5 {
+		t.Errorf("q.Unacked() = %v WANT %v", unacked, 5)
+	}
+
+	if !q.Ack(released[4].ID) || q.Ack(released[4].ID) {
+		t.Errorf("q.Ack() WANT true then false")
+	}
+	if count := q.AckAll(released[0].ID, released[0].ID, "unknown"); count != 1 {
+		t.Errorf("q.AckAll() = %v WANT %v", count, 1)
+	}
+	if count := q.AckUpTo(released[2].Sequence()); count != 2 {
+		t.Errorf("q.AckUpTo() = %v WANT %v", count, 2)
+	}
+	if unacked := q.Stats().Unacked; unacked != 1 {
+		t.Errorf("q.Stats().Unacked = %v WANT %v", unacked, 1)
+	}
+}
+
+func TestTimeQueue_ackMode_off(t *testing.T) {
+	q := New()
+	message := q.Push(time.Now(), 0)
+	q.Pop(true)
+	if message.Sequence() != 0 || q.Unacked() != 0 || q.Ack(message.ID) {
+		t.Errorf("ack state tracked without ack mode")
+	}
+}
+
+func TestDispatcher_acks(t *testing.T) {
+	q := New(WithAckMode())
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := make(chan struct{})
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		handled <- struct{}{}
+		return nil
+	})
+	q.Push(time.Now(), 0)
+	<-handled
+	for q.Unacked() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	d.Wait()
+}
+
+func TestWithVisibilityTimeout(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start), WithAckMode(), WithVisibilityTimeout(time.Minute))
+	q.Push(start, "a")
+	q.Push(start, "b")
+	released := q.Advance(start)
+	if len(released) != 2 {
+		t.Fatalf("len(q.Advance()) = %v WANT %v", len(released), 2)
+	}
+	q.Ack(released[0].ID)
+
+	if redelivered := q.Advance(start.Add(59 * time.Second)); len(redelivered) != 0 {
+		t.Errorf("q.Advance() before timeout = %v WANT none", redelivered)
+	}
+	redelivered := q.Advance(start.Add(time.Minute))
+	if len(redelivered) != 1 || redelivered[0] != released[1] {
+		t.Fatalf("q.Advance() at timeout = %v WANT %v", redelivered, released[1])
+	}
+	if seq := redelivered[0].Sequence(); seq != 3 {
+		t.Errorf("redelivered.Sequence() = %v WANT %v", seq, 3)
+	}
+
+	if !q.Nack(released[1].ID, 10*time.Second) {
+		t.Errorf("q.Nack() = %v WANT %v", false, true)
+	}
+	if q.Nack(released[1].ID, 0) {
+		t.Errorf("q.Nack() again = %v WANT %v", true, false)
+	}
+	if message := q.PeekMessage(); message != released[1] || !message.Time.Equal(start.Add(70*time.Second)) {
+		t.Errorf("q.PeekMessage() = %v WANT %v at %v", message, released[1], start.Add(70*time.Second))
+	}
+}
+
+func TestWithVisibilityTimeout_timer(t *testing.T) {
+	q := New(WithAckMode(), WithVisibilityTimeout(10*time.Millisecond))
+	q.Start()
+	defer q.Stop()
+	q.Push(time.Now(), 0)
+	first := <-q.Messages()
+	select {
+	case second := <-q.Messages():
+		if second != first {
+			t.Errorf("redelivered = %v WANT %v", second, first)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Message was not redelivered")
+	}
+}
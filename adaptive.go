@@ -0,0 +1,222 @@
+package timequeue
+
+import "time"
+
+const (
+	//DefaultAdaptiveSize is the default number of pending Messages at which an
+	//AdaptiveStorage considers switching to a timing wheel.
+	DefaultAdaptiveSize = 10000
+
+	//DefaultAdaptiveRate is the default number of pushes per second at which an
+	//AdaptiveStorage considers switching to a timing wheel.
+	DefaultAdaptiveRate = 1000
+
+	//DefaultWheelTick is the default duration of each slot of the timing wheel of
+	//an AdaptiveStorage.
+	DefaultWheelTick = time.Millisecond
+
+	//DefaultWheelSlots is the default number of slots of the timing wheel of an
+	//AdaptiveStorage.
+	DefaultWheelSlots = 4096
+
+	//adaptiveWindow is how often an AdaptiveStorage measures its push rate and
+	//considers switching.
+	adaptiveWindow = time.Second
+)
+
+//Strategy is the data structure that an AdaptiveStorage keeps Messages in.
+type Strategy int
+
+const (
+	//StrategyHeap keeps Messages in a single heap, like the default Storage.
+	StrategyHeap Strategy = iota
+	//StrategyWheel keeps Messages in a timing wheel of small heaps.
+	StrategyWheel
+)
+
+//String returns the name of s.
+func (s Strategy) String() string {
+	if s == StrategyWheel {
+		return "wheel"
+	}
+	return "heap"
+}
+
+//StrategySwitch describes a switch between Strategies by an AdaptiveStorage.
+type StrategySwitch struct {
+	From, To Strategy
+	//the number of pending Messages when the switch was made.
+	Size int
+	//the push rate, in pushes per second, when the switch was made.
+	Rate float64
+	Time time.Time
+}
+
+//AdaptiveOption configures an AdaptiveStorage created with NewAdaptiveStorage().
+type AdaptiveOption func(*adaptiveConfig)
+
+//adaptiveConfig holds all values that may be set by AdaptiveOptions.
+type adaptiveConfig struct {
+	size  int
+	rate  float64
+	tick  time.Duration
+	slots int
+	hook  func(change StrategySwitch)
+}
+
+//WithAdaptiveThreshold sets when an AdaptiveStorage switches Strategies. It
+//switches to StrategyWheel once at least size Messages are pending and at least
+//rate Messages are pushed per second, and back to StrategyHeap once fewer than
+//half of either are. The defaults are DefaultAdaptiveSize and DefaultAdaptiveRate.
+func WithAdaptiveThreshold(size int, rate float64) AdaptiveOption {
+	return func(c *adaptiveConfig) {
+		c.size = size
+		c.rate = rate
+	}
+}
+
+//WithWheel sets the duration of each slot and the number of slots of the timing
+//wheel used by StrategyWheel. Messages due more than tick*slots after the earliest
+//are kept in a single overflow heap. The defaults are DefaultWheelTick and
+//DefaultWheelSlots.
+func WithWheel(tick time.Duration, slots int) AdaptiveOption {
+	return func(c *adaptiveConfig) {
+		c.tick = tick
+		c.slots = slots
+	}
+}
+
+//WithSwitchHook sets a function that is called with every switch between
+//Strategies. hook is called while the TimeQueue of the AdaptiveStorage is locked
+//and must not call any of its methods.
+func WithSwitchHook(hook func(change StrategySwitch)) AdaptiveOption {
+	return func(c *adaptiveConfig) {
+		c.hook = hook
+	}
+}
+
+//AdaptiveStorage is a Storage that switches between a heap with a single timer,
+//the default Storage, and a timing wheel as the number of pending Messages and
+//the rate at which they are pushed change. A heap is best for few or slowly
+//pushed Messages, and a timing wheel is cheaper to push to when many Messages are
+//pending and pushed quickly.
+//
+//The push rate is measured every second, which is also when AdaptiveStorage
+//considers switching. Switching moves every Message to the new Strategy, so the
+//thresholds for switching back are lower than those for switching, to avoid
+//switching repeatedly around a threshold.
+type AdaptiveStorage struct {
+	config   adaptiveConfig
+	strategy Strategy
+	storage  Storage
+	//the pushes and start of the current rate window.
+	pushes int
+	start  time.Time
+	//returns the current time. replaced in tests.
+	now func() time.Time
+}
+
+//NewAdaptiveStorage creates an empty AdaptiveStorage that starts with
+//StrategyHeap.
+func NewAdaptiveStorage(opts ...AdaptiveOption) *AdaptiveStorage {
+	c := adaptiveConfig{
+		size:  DefaultAdaptiveSize,
+		rate:  DefaultAdaptiveRate,
+		tick:  DefaultWheelTick,
+		slots: DefaultWheelSlots,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &AdaptiveStorage{
+		config:  c,
+		storage: newHeapStorage(),
+		now:     time.Now,
+	}
+}
+
+//Strategy returns the Strategy that s currently uses.
+func (s *AdaptiveStorage) Strategy() Strategy {
+	return s.strategy
+}
+
+//measure counts a push and switches Strategies if the current rate window is over
+//and the thresholds are crossed.
+func (s *AdaptiveStorage) measure() {
+	now := s.now()
+	if s.start.IsZero() {
+		s.start = now
+	}
+	s.pushes++
+	elapsed := now.Sub(s.start)
+	if elapsed < adaptiveWindow {
+		return
+	}
+	rate := float64(s.pushes) / elapsed.Seconds()
+	size := s.storage.Len()
+	s.pushes, s.start = 0, now
+	switch {
+	case s.strategy == StrategyHeap && size >= s.config.size && rate >= s.config.rate:
+		s.switchTo(StrategyWheel, size, rate, now)
+	case s.strategy == StrategyWheel && (size < s.config.size/2 || rate < s.config.rate/2):
+		s.switchTo(StrategyHeap, size, rate, now)
+	}
+}
+
+//switchTo moves every Message in s to a new Storage for strategy.
+func (s *AdaptiveStorage) switchTo(strategy Strategy, size int, rate float64, now time.Time) {
+	var storage Storage = newHeapStorage()
+	if strategy == StrategyWheel {
+		storage = newWheelStorage(s.config.tick, s.config.slots, now)
+	}
+	messages := make([]*Message, 0, size)
+	s.storage.Each(func(message *Message) {
+		messages = append(messages, message)
+	})
+	for _, message := range messages {
+		s.storage.Remove(message)
+		storage.Push(message)
+	}
+	change := StrategySwitch{
+		From: s.strategy,
+		To:   strategy,
+		Size: size,
+		Rate: rate,
+		Time: now,
+	}
+	s.strategy, s.storage = strategy, storage
+	if s.config.hook != nil {
+		s.config.hook(change)
+	}
+}
+
+//Push adds message to s.
+func (s *AdaptiveStorage) Push(message *Message) {
+	s.storage.Push(message)
+	s.measure()
+}
+
+//Peek returns the earliest Message in s.
+func (s *AdaptiveStorage) Peek() *Message {
+	return s.storage.Peek()
+}
+
+//PopDue removes and returns the earliest Message in s if it is before until.
+func (s *AdaptiveStorage) PopDue(until time.Time) *Message {
+	return s.storage.PopDue(until)
+}
+
+//Remove removes message from s.
+func (s *AdaptiveStorage) Remove(message *Message) bool {
+	return s.storage.Remove(message)
+}
+
+//Len returns the number of Messages in s.
+func (s *AdaptiveStorage) Len() int {
+	return s.storage.Len()
+}
+
+//Each calls fn with every Message in s.
+func (s *AdaptiveStorage) Each(fn func(message *Message)) {
+	s.storage.Each(fn)
+}
@@ -0,0 +1,55 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveStorage(t *testing.T) {
+	now := time.Now()
+	switches := []StrategySwitch{}
+	s := NewAdaptiveStorage(
+		WithAdaptiveThreshold(10, 10),
+		WithWheel(time.Millisecond, 16),
+		WithSwitchHook(func(change StrategySwitch) { switches = append(switches, change) }),
+	)
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 60; i++ {
+		s.Push(&Message{Time: now.Add(time.Duration(60-i) * time.Millisecond)})
+		now = now.Add(20 * time.Millisecond)
+	}
+	if s.Strategy() != StrategyWheel || len(switches) != 1 || switches[0].From != StrategyHeap || switches[0].Size != 51 || switches[0].Rate != 51 {
+		t.Errorf("s.Strategy(), switches = %v, %+v WANT wheel, one switch at 51", s.Strategy(), switches)
+	}
+
+	//removing Messages does not switch back until the next rate window.
+	for i := 0; i < 58; i++ {
+		s.PopDue(now.Add(time.Hour))
+	}
+	if s.Strategy() != StrategyWheel || s.Len() != 2 {
+		t.Errorf("s.Strategy(), s.Len() = %v, %v WANT wheel, 2", s.Strategy(), s.Len())
+	}
+	now = now.Add(time.Second)
+	s.Push(&Message{Time: now})
+	if s.Strategy() != StrategyHeap || len(switches) != 2 || switches[1].To != StrategyHeap {
+		t.Errorf("s.Strategy(), switches = %v, %+v WANT heap, two switches", s.Strategy(), switches)
+	}
+	var last time.Time
+	for message := s.PopDue(now.Add(time.Hour)); message != nil; message = s.PopDue(now.Add(time.Hour)) {
+		if message.Time.Before(last) {
+			t.Errorf("s.PopDue() = %v before %v", message.Time, last)
+		}
+		last = message.Time
+	}
+}
+
+func TestAdaptiveStorage_TimeQueue(t *testing.T) {
+	q := New(WithStorage(NewAdaptiveStorage()))
+	now := time.Now()
+	q.Push(now.Add(time.Second), 1)
+	q.Push(now, 0)
+	if messages := q.PopAll(false); len(messages) != 2 || messages[0].Data != 0 {
+		t.Errorf("q.PopAll() = %v WANT 0, 1", messages)
+	}
+}
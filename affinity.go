@@ -0,0 +1,129 @@
+package timequeue
+
+import (
+	"errors"
+	"hash/fnv"
+)
+
+//ErrSubscribed is returned from Subscribe() when a Subscriber with the same name
+//is already subscribed.
+var ErrSubscribed = errors.New("timequeue: already subscribed")
+
+//Subscriber receives a share of the Messages released by a TimeQueue.
+//See Subscribe().
+type Subscriber struct {
+	q    *TimeQueue
+	name string
+	c    chan *Message
+	//closed when the Subscriber is closed.
+	done chan struct{}
+}
+
+//Subscribe adds a Subscriber identified by name to q. While q has Subscribers,
+//Messages are released to them instead of on Messages() or Outputs().
+//
+//Messages with an Affinity are released to the Subscriber chosen for that Affinity
+//by rendezvous hashing of the Affinity and the names of the Subscribers, so all
+//Messages with the same Affinity are processed by the same Subscriber. When a
+//Subscriber is closed, only the Affinities that were routed to it move, and when
+//one subscribes, only the Affinities that it is chosen for move to it. Since
+//routing only depends on names, Subscribers with the same names in different
+//processes receive the same Affinities. Messages without an Affinity are released
+//round-robin across the Subscribers.
+//
+//ErrSubscribed is returned if a Subscriber with name is already subscribed.
+func (q *TimeQueue) Subscribe(name string) (*Subscriber, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, s := range q.subscribers {
+		if s.name == name {
+			return nil, ErrSubscribed
+		}
+	}
+	s := &Subscriber{
+		q:    q,
+		name: name,
+		c:    make(chan *Message),
+		done: make(chan struct{}),
+	}
+	q.subscribers = append(q.subscribers, s)
+	return s, nil
+}
+
+//Name returns the name that s subscribed with.
+func (s *Subscriber) Name() string {
+	return s.name
+}
+
+//Messages returns the channel that s receives its Messages on. The channel is
+//unbuffered and never closed. Messages that are not received before s is closed
+//are released to another Subscriber, or on Messages() or Outputs() if there are
+//none.
+func (s *Subscriber) Messages() <-chan *Message {
+	return s.c
+}
+
+//Close removes s from its TimeQueue. Close is safe to call more than once.
+func (s *Subscriber) Close() {
+	q := s.q
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i, other := range q.subscribers {
+		if other == s {
+			q.subscribers = append(q.subscribers[:i:i], q.subscribers[i+1:]...)
+			close(s.done)
+			return
+		}
+	}
+}
+
+//subscribed releases message to one of q.subscribers without waiting and returns
+//true, or returns false if there are none.
+//It should only be called when q is locked.
+func (q *TimeQueue) subscribed(message *Message) bool {
+	if len(q.subscribers) == 0 {
+		return false
+	}
+	s := q.route(message)
+	go q.deliver(s, message)
+	return true
+}
+
+//route returns the Subscriber to release message to.
+//It should only be called when q is locked.
+func (q *TimeQueue) route(message *Message) *Subscriber {
+	if message.Affinity == "" {
+		q.subscriberIndex = (q.subscriberIndex + 1) % len(q.subscribers)
+		return q.subscribers[q.subscriberIndex]
+	}
+	var result *Subscriber
+	max := uint64(0)
+	for _, s := range q.subscribers {
+		if weight := rendezvousWeight(message.Affinity, s.name); result == nil || weight > max {
+			result, max = s, weight
+		}
+	}
+	return result
+}
+
+//deliver sends message to s, or releases it again if s is closed first.
+func (q *TimeQueue) deliver(s *Subscriber, message *Message) {
+	select {
+	case s.c <- message:
+	case <-s.done:
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		if !q.subscribed(message) {
+			q.send(q.nextOutput(), message)
+		}
+	}
+}
+
+//rendezvousWeight returns the weight of the Subscriber with name for affinity.
+func rendezvousWeight(affinity, name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(affinity))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	return h.Sum64()
+}
@@ -0,0 +1,69 @@
+package timequeue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Subscribe(t *testing.T) {
+	q := New()
+	a, _ := q.Subscribe("a")
+	b, _ := q.Subscribe("b")
+	if _, err := q.Subscribe("a"); err != ErrSubscribed {
+		t.Errorf("Subscribe(a) again = %v WANT %v", err, ErrSubscribed)
+	}
+	receive := func(s *Subscriber) string {
+		select {
+		case message := <-s.Messages():
+			return message.Affinity
+		case <-time.After(time.Second):
+			return ""
+		}
+	}
+
+	routes := map[string]string{}
+	for i := 0; i < 20; i++ {
+		affinity := fmt.Sprint("entity", i%5)
+		q.PushMessage(&Message{Time: time.Now(), Affinity: affinity})
+		q.Pop(true)
+		select {
+		case <-a.Messages():
+			routes[affinity] += "a"
+		case <-b.Messages():
+			routes[affinity] += "b"
+		case <-time.After(time.Second):
+			t.Fatalf("no Subscriber received a Message")
+		}
+	}
+	for affinity, route := range routes {
+		if route != "aaaa" && route != "bbbb" {
+			t.Errorf("route of %v = %v WANT the same Subscriber", affinity, route)
+		}
+	}
+
+	//a Message pinned to a closed Subscriber moves to the remaining one.
+	pinned := ""
+	for affinity, route := range routes {
+		if route[0] == 'a' {
+			pinned = affinity
+		}
+	}
+	if pinned == "" {
+		t.Fatalf("no Affinity routed to a")
+	}
+	q.PushMessage(&Message{Time: time.Now(), Affinity: pinned})
+	q.Pop(true)
+	a.Close()
+	a.Close()
+	if got := receive(b); got != pinned {
+		t.Errorf("b received %q WANT %q", got, pinned)
+	}
+
+	b.Close()
+	q.Push(time.Now(), "unsubscribed")
+	q.Pop(true)
+	if message := <-q.Messages(); message.Data != "unsubscribed" {
+		t.Errorf("q.Messages() = %v WANT unsubscribed", message)
+	}
+}
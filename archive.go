@@ -0,0 +1,237 @@
+package timequeue
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+//ArchiveEvent identifies what happened to the Message recorded by an ArchiveRecord.
+type ArchiveEvent string
+
+//The ArchiveEvents recorded by a TimeQueue and a Dispatcher.
+const (
+	//ArchiveReleased is recorded when a Message is released by a TimeQueue.
+	ArchiveReleased ArchiveEvent = "released"
+	//ArchiveConsumed is recorded when a Dispatcher's Handler successfully handles
+	//a Message.
+	ArchiveConsumed ArchiveEvent = "consumed"
+	//ArchivePushed is recorded when a Message is pushed to a TimeQueue given
+	//WithArchiveChanges().
+	ArchivePushed ArchiveEvent = "pushed"
+	//ArchiveRemoved is recorded when a Message is removed from a TimeQueue given
+	//WithArchiveChanges() without being released. It is also recorded right before
+	//a Message is released explicitly, e.g. with Pop(true).
+	ArchiveRemoved ArchiveEvent = "removed"
+)
+
+//ArchiveRecord is a record of a single Message that was released or consumed, or
+//pushed or removed.
+type ArchiveRecord struct {
+	//Event is what happened to the Message.
+	Event ArchiveEvent
+	//At is when Event happened.
+	At time.Time
+	//Time is the Message's Time.
+	Time time.Time
+	//Data is the Message's Data, or its redaction if the TimeQueue was given
+	//WithRedactor().
+	Data interface{}
+	//Topic is the Message's Topic.
+	Topic string
+	//ID is the Message's ID.
+	ID string
+	//ParentID is the Message's ParentID.
+	ParentID string
+	//TraceID is the Message's TraceID.
+	TraceID string
+}
+
+//newArchiveRecord creates the ArchiveRecord of event happening to message at at.
+func newArchiveRecord(event ArchiveEvent, at time.Time, message *Message) ArchiveRecord {
+	return ArchiveRecord{
+		Event:    event,
+		At:       at,
+		Time:     message.Time,
+		Data:     message.Data,
+		Topic:    message.Topic,
+		ID:       message.ID,
+		ParentID: message.ParentID,
+		TraceID:  message.TraceID,
+	}
+}
+
+//ArchiveSink receives ArchiveRecords from a TimeQueue. See WithArchive().
+//
+//Archive is called with the TimeQueue locked, in the order Messages are released,
+//and must not call any methods on the TimeQueue. It should return quickly.
+type ArchiveSink interface {
+	Archive(record ArchiveRecord)
+}
+
+//ArchiveFunc is an ArchiveSink that calls itself.
+type ArchiveFunc func(record ArchiveRecord)
+
+//Archive calls f(record).
+func (f ArchiveFunc) Archive(record ArchiveRecord) {
+	f(record)
+}
+
+//WithArchive sets the ArchiveSink that records of all released and consumed
+//Messages are appended to. Messages are not archived by default.
+//WithTopicArchive() overrides sink for individual topics.
+func WithArchive(sink ArchiveSink) Option {
+	return func(c *config) {
+		c.archive = sink
+	}
+}
+
+//WithArchiveChanges causes a TimeQueue to also archive records of every Message
+//that is pushed or removed, so that its archive is a complete stream of changes
+//to its pending Messages. See StateAt().
+func WithArchiveChanges() Option {
+	return func(c *config) {
+		c.archiveChanges = true
+	}
+}
+
+//WithTopicArchive sets the ArchiveSink that records of released and consumed
+//Messages with topic are appended to, in place of the one given to WithArchive().
+//A nil sink disables archival of topic.
+func WithTopicArchive(topic string, sink ArchiveSink) Option {
+	return func(c *config) {
+		topicArchives := make(map[string]ArchiveSink, len(c.topicArchives)+1)
+		for t, s := range c.topicArchives {
+			topicArchives[t] = s
+		}
+		topicArchives[topic] = sink
+		c.topicArchives = topicArchives
+	}
+}
+
+//archiveSink returns the ArchiveSink for message or nil if message should not be
+//archived.
+//It should only be called when q is locked.
+func (q *TimeQueue) archiveSink(message *Message) ArchiveSink {
+	if sink, ok := q.config.topicArchives[message.Topic]; ok {
+		return sink
+	}
+	return q.config.archive
+}
+
+//archive records event happening to message at at.
+//It should only be called when q is locked.
+func (q *TimeQueue) archive(event ArchiveEvent, at time.Time, message *Message) {
+	if sink := q.archiveSink(message); sink != nil {
+		record := newArchiveRecord(event, at, message)
+		record.Data = q.redactData(message)
+		sink.Archive(record)
+	}
+}
+
+//archiveChange records event happening to message now if q was given
+//WithArchiveChanges().
+//It should only be called when q is locked.
+func (q *TimeQueue) archiveChange(event ArchiveEvent, message *Message) {
+	if q.config.archiveChanges && message.fn == nil {
+		q.archive(event, q.now(), message)
+	}
+}
+
+//archiveConsumed records that message was consumed.
+//archiveConsumed acts like an exported method in that it locks q.
+func (q *TimeQueue) archiveConsumed(message *Message) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.archive(ArchiveConsumed, q.now(), message)
+}
+
+//MemoryArchive is an ArchiveSink that keeps ArchiveRecords in memory subject to
+//retention limits.
+//MemoryArchive is safe for use by multiple go-routines.
+type MemoryArchive struct {
+	lock       *sync.Mutex
+	maxRecords int
+	maxAge     time.Duration
+	records    []ArchiveRecord
+}
+
+//NewMemoryArchive creates a MemoryArchive that keeps at most maxRecords records
+//that are no older than maxAge. A maxRecords or maxAge of 0 means no limit.
+func NewMemoryArchive(maxRecords int, maxAge time.Duration) *MemoryArchive {
+	return &MemoryArchive{
+		lock:       &sync.Mutex{},
+		maxRecords: maxRecords,
+		maxAge:     maxAge,
+	}
+}
+
+//Archive appends record to a and discards records outside of the retention limits.
+func (a *MemoryArchive) Archive(record ArchiveRecord) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.records = append(a.records, record)
+	a.trim(record.At)
+}
+
+//trim discards records older than a.maxAge before now and the oldest records
+//over a.maxRecords.
+//It should only be called when a is locked.
+func (a *MemoryArchive) trim(now time.Time) {
+	start := 0
+	if a.maxAge > 0 {
+		for start < len(a.records) && now.Sub(a.records[start].At) > a.maxAge {
+			start++
+		}
+	}
+	if a.maxRecords > 0 && len(a.records)-start > a.maxRecords {
+		start = len(a.records) - a.maxRecords
+	}
+	if start > 0 {
+		a.records = append([]ArchiveRecord{}, a.records[start:]...)
+	}
+}
+
+//Records returns all records in a from oldest to newest.
+func (a *MemoryArchive) Records() []ArchiveRecord {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([]ArchiveRecord{}, a.records...)
+}
+
+//WriterArchive is an ArchiveSink that writes each ArchiveRecord to an io.Writer,
+//e.g. an *os.File, as a line of JSON.
+//Retention of the written records is up to the owner of the io.Writer.
+//WriterArchive is safe for use by multiple go-routines.
+type WriterArchive struct {
+	lock *sync.Mutex
+	enc  *json.Encoder
+	err  error
+}
+
+//NewWriterArchive creates a WriterArchive that writes to w.
+func NewWriterArchive(w io.Writer) *WriterArchive {
+	return &WriterArchive{
+		lock: &sync.Mutex{},
+		enc:  json.NewEncoder(w),
+	}
+}
+
+//Archive writes record to a's io.Writer.
+//After the first error, no more records are written. See Err().
+func (a *WriterArchive) Archive(record ArchiveRecord) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.err != nil {
+		return
+	}
+	a.err = a.enc.Encode(record)
+}
+
+//Err returns the first error that occurred while writing a record, if any.
+func (a *WriterArchive) Err() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.err
+}
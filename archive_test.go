@@ -0,0 +1,110 @@
+package timequeue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_archive(t *testing.T) {
+	all, orders := NewMemoryArchive(0, 0), NewMemoryArchive(0, 0)
+	q := New(WithArchive(all), WithTopicArchive("orders", orders), WithTopicArchive("noisy", nil))
+	now := time.Now()
+	q.PushMessage(&Message{Time: now, Data: 0})
+	q.PushMessage(&Message{Time: now, Data: 1, Topic: "orders"})
+	q.PushMessage(&Message{Time: now, Data: 2, Topic: "noisy"})
+	q.PopAll(true)
+
+	if records := all.Records(); len(records) != 1 || records[0].Data != 0 || records[0].Event != ArchiveReleased {
+		t.Errorf("all.Records() = %v WANT one released record with Data 0", records)
+	}
+	if records := orders.Records(); len(records) != 1 || records[0].Data != 1 || records[0].Topic != "orders" {
+		t.Errorf("orders.Records() = %v WANT one record with Data 1", records)
+	}
+}
+
+func TestDispatcher_archivesConsumed(t *testing.T) {
+	archive := NewMemoryArchive(0, 0)
+	q := New(WithArchive(archive))
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handled := make(chan struct{}, 2)
+	q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		defer func() { handled <- struct{}{} }()
+		if message.Data == "bad" {
+			return errors.New("bad")
+		}
+		return nil
+	})
+	q.Push(time.Now(), "bad")
+	<-handled
+	q.Push(time.Now(), "good")
+	<-handled
+
+	for len(archive.Records()) != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	consumed := archive.Records()[2]
+	if consumed.Event != ArchiveConsumed || consumed.Data != "good" {
+		t.Errorf("consumed record = %+v WANT consumed good", consumed)
+	}
+}
+
+func TestMemoryArchive_retention(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		maxRecords int
+		maxAge     time.Duration
+		result     []interface{}
+	}{
+		{0, 0, []interface{}{0, 1, 2, 3}},
+		{2, 0, []interface{}{2, 3}},
+		{0, 90 * time.Minute, []interface{}{2, 3}},
+		{1, 3 * time.Hour, []interface{}{3}},
+	}
+	for _, test := range tests {
+		a := NewMemoryArchive(test.maxRecords, test.maxAge)
+		for i := 0; i < 4; i++ {
+			a.Archive(ArchiveRecord{At: now.Add(time.Duration(i) * time.Hour), Data: i})
+		}
+		records := a.Records()
+		data := []interface{}{}
+		for _, record := range records {
+			data = append(data, record.Data)
+		}
+		if len(data) != len(test.result) {
+			t.Errorf("NewMemoryArchive(%v, %v) records = %v WANT %v", test.maxRecords, test.maxAge, data, test.result)
+			continue
+		}
+		for i := range data {
+			if data[i] != test.result[i] {
+				t.Errorf("NewMemoryArchive(%v, %v) records = %v WANT %v", test.maxRecords, test.maxAge, data, test.result)
+				break
+			}
+		}
+	}
+}
+
+func TestWriterArchive(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := NewWriterArchive(buf)
+	a.Archive(ArchiveRecord{Event: ArchiveReleased, Data: "a", Topic: "t"})
+	a.Archive(ArchiveRecord{Event: ArchiveConsumed, Data: "b"})
+
+	dec := json.NewDecoder(buf)
+	for _, want := range []string{"a", "b"} {
+		record := ArchiveRecord{}
+		if err := dec.Decode(&record); err != nil || record.Data != want {
+			t.Errorf("decoded record = %+v, %v WANT Data %v", record, err, want)
+		}
+	}
+	if err := a.Err(); err != nil {
+		t.Errorf("a.Err() = %v WANT nil", err)
+	}
+}
@@ -0,0 +1,219 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//DefaultAuditCapacity is the default number of AuditEntries kept by a TimeQueue.
+const DefaultAuditCapacity = 100
+
+//AuditAction identifies the administrative action recorded by an AuditEntry.
+type AuditAction string
+
+//The AuditActions recorded by a TimeQueue.
+const (
+	AuditStart         AuditAction = "start"
+	AuditStartInactive AuditAction = "start_inactive"
+	AuditActivate      AuditAction = "activate"
+	AuditStop          AuditAction = "stop"
+	AuditHold          AuditAction = "hold"
+	AuditRelease       AuditAction = "release"
+	AuditClear         AuditAction = "clear"
+	AuditReconfigure   AuditAction = "reconfigure"
+)
+
+//AuditEntry is a record of a single administrative action performed on a TimeQueue.
+type AuditEntry struct {
+	//Time is when the action was performed.
+	Time time.Time
+	//Actor identifies who performed the action. It is empty unless the action
+	//was performed through an Admin.
+	Actor string
+	//Action is the action that was performed.
+	Action AuditAction
+	//Detail is additional information about the action, e.g. the reason for a hold.
+	Detail string
+}
+
+//auditLog is a fixed size ring of AuditEntries.
+//auditLog is not safe for use by multiple go-routines.
+type auditLog struct {
+	entries []AuditEntry
+	//the index that the next entry will be written to.
+	next int
+	//whether or not entries has been filled and next has wrapped around.
+	full bool
+}
+
+//newAuditLog creates an auditLog that keeps the most recent capacity entries.
+func newAuditLog(capacity int) *auditLog {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &auditLog{
+		entries: make([]AuditEntry, capacity),
+	}
+}
+
+//add adds entry to l, overwriting the oldest entry if l is full.
+func (l *auditLog) add(entry AuditEntry) {
+	if len(l.entries) == 0 {
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+//list returns all entries in l from oldest to newest.
+func (l *auditLog) list() []AuditEntry {
+	if !l.full {
+		return append([]AuditEntry{}, l.entries[:l.next]...)
+	}
+	return append(append([]AuditEntry{}, l.entries[l.next:]...), l.entries[:l.next]...)
+}
+
+//resize changes the capacity of l, keeping as many of the newest entries as fit.
+func (l *auditLog) resize(capacity int) {
+	if capacity == len(l.entries) {
+		return
+	}
+	entries := l.list()
+	*l = *newAuditLog(capacity)
+	if len(entries) > capacity {
+		entries = entries[len(entries)-capacity:]
+	}
+	for _, entry := range entries {
+		l.add(entry)
+	}
+}
+
+//AuditLog returns the most recent administrative actions performed on q from
+//oldest to newest. See WithAuditCapacity() and WithAuditHook().
+func (q *TimeQueue) AuditLog() []AuditEntry {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.audits.list()
+}
+
+//administer locks q, performs action, and records an AuditEntry for actor if
+//action does not return an error.
+//administer acts like an exported method in that it locks q.
+func (q *TimeQueue) administer(actor string, auditAction AuditAction, detail string, action func() error) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := action(); err != nil {
+		return err
+	}
+	entry := AuditEntry{
+		Time:   time.Now(),
+		Actor:  actor,
+		Action: auditAction,
+		Detail: detail,
+	}
+	q.audits.add(entry)
+	if q.config.auditHook != nil {
+		q.config.auditHook(entry)
+	}
+	return nil
+}
+
+//Admin performs administrative actions on a TimeQueue on behalf of an actor.
+//Every action is recorded in the audit log with the actor's identity.
+type Admin struct {
+	q     *TimeQueue
+	actor string
+}
+
+//As returns an Admin that performs actions on q on behalf of actor.
+func (q *TimeQueue) As(actor string) *Admin {
+	return &Admin{
+		q:     q,
+		actor: actor,
+	}
+}
+
+//Start is TimeQueue.Start() performed by a.
+func (a *Admin) Start() {
+	a.q.administer(a.actor, AuditStart, "", func() error {
+		a.q.start(false)
+		return nil
+	})
+}
+
+//StartInactive is TimeQueue.StartInactive() performed by a.
+func (a *Admin) StartInactive() {
+	a.q.administer(a.actor, AuditStartInactive, "", func() error {
+		a.q.start(true)
+		return nil
+	})
+}
+
+//Activate is TimeQueue.Activate() performed by a.
+func (a *Admin) Activate() {
+	a.q.administer(a.actor, AuditActivate, "", func() error {
+		a.q.activate()
+		return nil
+	})
+}
+
+//Stop is TimeQueue.Stop() performed by a.
+func (a *Admin) Stop() {
+	a.q.administer(a.actor, AuditStop, "", func() error {
+		a.q.stop()
+		return nil
+	})
+}
+
+//Hold is TimeQueue.Hold() performed by a.
+func (a *Admin) Hold(reason string) {
+	a.q.administer(a.actor, AuditHold, reason, func() error {
+		a.q.hold(reason)
+		return nil
+	})
+}
+
+//Release is TimeQueue.Release() performed by a.
+func (a *Admin) Release() {
+	a.q.administer(a.actor, AuditRelease, "", func() error {
+		a.q.release()
+		return nil
+	})
+}
+
+//HoldTopic is TimeQueue.HoldTopic() performed by a.
+func (a *Admin) HoldTopic(topic, reason string) {
+	name := HoldTopicName(topic)
+	a.q.administer(a.actor, AuditHold, name+": "+reason, func() error {
+		a.q.holdMatching(name, reason, matchTopic(topic))
+		return nil
+	})
+}
+
+//ReleaseTopic is TimeQueue.ReleaseTopic() performed by a.
+func (a *Admin) ReleaseTopic(topic string) {
+	name := HoldTopicName(topic)
+	a.q.administer(a.actor, AuditRelease, name, func() error {
+		a.q.releaseMatching(name)
+		return nil
+	})
+}
+
+//Clear is TimeQueue.Clear() performed by a.
+func (a *Admin) Clear() int {
+	count := 0
+	a.q.administer(a.actor, AuditClear, "", func() error {
+		count = a.q.clear()
+		return nil
+	})
+	return count
+}
+
+//Reconfigure is TimeQueue.Reconfigure() performed by a.
+func (a *Admin) Reconfigure(opts ...Option) error {
+	return a.q.administer(a.actor, AuditReconfigure, "", func() error {
+		return a.q.reconfigure(opts)
+	})
+}
@@ -0,0 +1,97 @@
+package timequeue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	tests := []struct {
+		capacity int
+		adds     int
+		want     []string
+	}{
+		{0, 2, []string{}},
+		{3, 0, []string{}},
+		{3, 2, []string{"0", "1"}},
+		{3, 3, []string{"0", "1", "2"}},
+		{3, 5, []string{"2", "3", "4"}},
+	}
+	for _, test := range tests {
+		l := newAuditLog(test.capacity)
+		for i := 0; i < test.adds; i++ {
+			l.add(AuditEntry{Detail: string(rune('0' + i))})
+		}
+		if result := auditDetails(l.list()); !reflect.DeepEqual(result, test.want) {
+			t.Errorf("l.list() = %v WANT %v", result, test.want)
+		}
+	}
+}
+
+func TestAuditLog_resize(t *testing.T) {
+	l := newAuditLog(3)
+	for i := 0; i < 3; i++ {
+		l.add(AuditEntry{Detail: string(rune('0' + i))})
+	}
+	l.resize(2)
+	if result, want := auditDetails(l.list()), []string{"1", "2"}; !reflect.DeepEqual(result, want) {
+		t.Errorf("l.list() = %v WANT %v", result, want)
+	}
+	l.resize(4)
+	l.add(AuditEntry{Detail: "3"})
+	if result, want := auditDetails(l.list()), []string{"1", "2", "3"}; !reflect.DeepEqual(result, want) {
+		t.Errorf("l.list() = %v WANT %v", result, want)
+	}
+}
+
+func TestTimeQueue_AuditLog(t *testing.T) {
+	hooked := []AuditEntry{}
+	q := New(WithAuditHook(func(entry AuditEntry) {
+		hooked = append(hooked, entry)
+	}))
+	q.Start()
+	q.As("alice").Hold("incident")
+	q.As("alice").Release()
+	q.Push(time.Now().Add(time.Hour), 0)
+	q.As("bob").Clear()
+	if err := q.Reconfigure(WithCapacity(10)); err == nil {
+		t.Errorf("q.Reconfigure() = nil WANT non-nil")
+	}
+	q.Stop()
+
+	entries := q.AuditLog()
+	want := []AuditEntry{
+		{Action: AuditStart},
+		{Actor: "alice", Action: AuditHold, Detail: "incident"},
+		{Actor: "alice", Action: AuditRelease},
+		{Actor: "bob", Action: AuditClear},
+		{Action: AuditStop},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("len(q.AuditLog()) = %v WANT %v", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Time.IsZero() {
+			t.Errorf("entries[%v].Time is zero", i)
+		}
+		entry.Time = time.Time{}
+		if entry != want[i] {
+			t.Errorf("entries[%v] = %+v WANT %+v", i, entry, want[i])
+		}
+	}
+	if !reflect.DeepEqual(hooked, entries) {
+		t.Errorf("hooked = %v WANT %v", hooked, entries)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func auditDetails(entries []AuditEntry) []string {
+	result := []string{}
+	for _, entry := range entries {
+		result = append(result, entry.Detail)
+	}
+	return result
+}
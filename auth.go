@@ -0,0 +1,79 @@
+package timequeue
+
+import (
+	"errors"
+	"net/http"
+)
+
+//ErrUnauthenticated may be returned, or wrapped, by an Authorizer to respond with
+//401 instead of 403.
+var ErrUnauthenticated = errors.New("timequeue: unauthenticated")
+
+//Endpoint names an endpoint served by Handler() or PushHandler() for an Authorizer.
+type Endpoint string
+
+//Endpoints passed to Authorizers.
+const (
+	EndpointHealthz Endpoint = "healthz"
+	EndpointReadyz  Endpoint = "readyz"
+	EndpointStats   Endpoint = "stats"
+	EndpointHold    Endpoint = "hold"
+	EndpointRelease Endpoint = "release"
+	EndpointAudit   Endpoint = "audit"
+	EndpointPush    Endpoint = "push"
+)
+
+//Authorizer decides whether r may be served by endpoint. It returns nil to allow
+//the request and an error to deny it.
+//Denied requests are responded to with 401 if the error is ErrUnauthenticated, or
+//wraps it, and 403 otherwise.
+//
+//Authorizers may modify r before it is served, e.g. to set the ActorHeader to the
+//authenticated identity so that it, and not the client supplied value, is recorded
+//in the audit log.
+type Authorizer func(r *http.Request, endpoint Endpoint) error
+
+//HandlerOption configures the http.Handlers returned from Handler() and
+//PushHandler().
+type HandlerOption func(*handlerConfig)
+
+//handlerConfig holds all values that may be set by HandlerOptions.
+type handlerConfig struct {
+	authorizer Authorizer
+}
+
+//newHandlerConfig creates a handlerConfig with opts applied.
+func newHandlerConfig(opts []HandlerOption) handlerConfig {
+	c := handlerConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+//WithAuthorizer sets the Authorizer that is called with every request before it is
+//served. By default all requests are served.
+func WithAuthorizer(authorizer Authorizer) HandlerOption {
+	return func(c *handlerConfig) {
+		c.authorizer = authorizer
+	}
+}
+
+//authorize returns an http.Handler that serves requests with handler only if they
+//are allowed by c's Authorizer for endpoint.
+func (c handlerConfig) authorize(endpoint Endpoint, handler http.Handler) http.Handler {
+	if c.authorizer == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := c.authorizer(r, endpoint); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, ErrUnauthenticated) {
+				status = http.StatusUnauthorized
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,59 @@
+package timequeue
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithAuthorizer(t *testing.T) {
+	q := New()
+	authorizer := func(r *http.Request, endpoint Endpoint) error {
+		switch r.Header.Get("Authorization") {
+		case "":
+			return ErrUnauthenticated
+		case "operator":
+			r.Header.Set(ActorHeader, "operator")
+			return nil
+		}
+		if endpoint == EndpointHold || endpoint == EndpointRelease {
+			return errors.New("forbidden")
+		}
+		return nil
+	}
+	handler := q.Handler(WithAuthorizer(authorizer))
+	tests := []struct {
+		method        string
+		path          string
+		authorization string
+		status        int
+	}{
+		{"GET", "/stats", "", http.StatusUnauthorized},
+		{"GET", "/stats", "viewer", http.StatusOK},
+		{"POST", "/hold", "viewer", http.StatusForbidden},
+		{"POST", "/hold", "operator", http.StatusNoContent},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(test.method, test.path, nil)
+		if test.authorization != "" {
+			r.Header.Set("Authorization", test.authorization)
+		}
+		r.Header.Set(ActorHeader, "spoofed")
+		handler.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%v %v as %q = %v WANT %v", test.method, test.path, test.authorization, w.Code, test.status)
+		}
+	}
+	entries := q.AuditLog()
+	if len(entries) != 1 || entries[0].Actor != "operator" {
+		t.Errorf("q.AuditLog() = %v WANT single operator entry", entries)
+	}
+
+	w := httptest.NewRecorder()
+	q.PushHandler(nil, WithAuthorizer(authorizer)).ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("POST push = %v WANT %v", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,143 @@
+package timequeue
+
+import "time"
+
+//Demand describes the work a TimeQueue has waiting and forecast.
+//It is given to an Autoscaler to determine how many workers should be running.
+type Demand struct {
+	//Backlog is the number of released Messages buffered in Messages(), or in
+	//Outputs(), that have yet to be received.
+	Backlog int
+	//Bucket is the duration of each element in Forecast.
+	Bucket time.Duration
+	//Forecast is the result of TimeQueue.Forecast().
+	Forecast []int
+	//Workers is the number of workers currently running.
+	//It is 0 when Demand is returned from TimeQueue.Demand().
+	Workers int
+	//Latency is the average time a Dispatcher's Handler took to handle a Message.
+	//It is 0 when unknown or when Demand is returned from TimeQueue.Demand().
+	Latency time.Duration
+}
+
+//Demand returns the current Demand of q with a Forecast of bucket and horizon.
+//Applications that manage their own worker pools may call Demand periodically
+//to scale them ahead of releases.
+func (q *TimeQueue) Demand(bucket, horizon time.Duration) Demand {
+	return Demand{
+		Backlog:  q.backlog(),
+		Bucket:   bucket,
+		Forecast: q.Forecast(bucket, horizon),
+	}
+}
+
+//Autoscaler returns the number of workers that should be running to meet demand.
+//A negative result leaves the number of workers unchanged.
+type Autoscaler func(demand Demand) int
+
+//ForecastAutoscaler returns an Autoscaler that scales to the number of workers
+//needed to handle the busiest bucket of a Demand, where each worker can handle
+//perWorker Messages per bucket. The Backlog is added to the first bucket.
+//The returned Autoscaler never returns less than 1.
+func ForecastAutoscaler(perWorker int) Autoscaler {
+	if perWorker < 1 {
+		perWorker = 1
+	}
+	return func(demand Demand) int {
+		peak := demand.Backlog
+		for i, count := range demand.Forecast {
+			if i == 0 {
+				count += demand.Backlog
+			}
+			if count > peak {
+				peak = count
+			}
+		}
+		workers := (peak + perWorker - 1) / perWorker
+		if workers < 1 {
+			workers = 1
+		}
+		return workers
+	}
+}
+
+//LatencyAutoscaler returns an Autoscaler that scales to the number of workers
+//needed to handle the Backlog and the first bucket of the Forecast within one
+//Bucket, given the average Latency of each Message.
+//If the Latency is not yet known, then it adds a worker if there is a Backlog and
+//otherwise leaves the workers unchanged.
+func LatencyAutoscaler() Autoscaler {
+	return func(demand Demand) int {
+		pending := demand.Backlog
+		if len(demand.Forecast) > 0 {
+			pending += demand.Forecast[0]
+		}
+		if demand.Latency <= 0 || demand.Bucket <= 0 {
+			if demand.Backlog > 0 {
+				return demand.Workers + 1
+			}
+			return -1
+		}
+		work := time.Duration(pending) * demand.Latency
+		return int((work + demand.Bucket - 1) / demand.Bucket)
+	}
+}
+
+//autoscale holds the values given to WithAutoscaler().
+type autoscale struct {
+	interval   time.Duration
+	bucket     time.Duration
+	horizon    time.Duration
+	autoscaler Autoscaler
+}
+
+//WithAutoscaler sets an Autoscaler that is called every interval with the Demand
+//of the Dispatcher's TimeQueue, using a Forecast of bucket and horizon.
+//The Dispatcher's workers are set to the result with Dispatcher.SetWorkers().
+//The Demand's Workers and Latency fields are set by the Dispatcher.
+//If interval is zero or less, then DefaultScaleInterval is used. If bucket is
+//zero or less, then the interval is used.
+func WithAutoscaler(interval, bucket, horizon time.Duration, autoscaler Autoscaler) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.autoscale = newAutoscale(interval, bucket, horizon, autoscaler)
+	}
+}
+
+//newAutoscale creates an autoscale with the given values, defaulting interval and
+//bucket like WithAutoscaler().
+func newAutoscale(interval, bucket, horizon time.Duration, autoscaler Autoscaler) *autoscale {
+	if interval <= 0 {
+		interval = DefaultScaleInterval
+	}
+	if bucket <= 0 {
+		bucket = interval
+	}
+	return &autoscale{
+		interval:   interval,
+		bucket:     bucket,
+		horizon:    horizon,
+		autoscaler: autoscaler,
+	}
+}
+
+//runAutoscale scales d with a every a.interval until d.ctx is done or d is shut down.
+func (d *Dispatcher) runAutoscale(a *autoscale) {
+	defer d.wg.Done()
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.draining:
+			return
+		case <-ticker.C:
+			demand := d.q.Demand(a.bucket, a.horizon)
+			demand.Workers = d.Workers()
+			demand.Latency = d.takeLatency()
+			if workers := a.autoscaler(demand); workers >= 0 {
+				d.SetWorkers(workers)
+			}
+		}
+	}
+}
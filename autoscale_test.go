@@ -0,0 +1,105 @@
+package timequeue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Demand(t *testing.T) {
+	q := NewCapacity(2)
+	q.Push(time.Now().Add(-time.Hour), 0)
+	q.Pop(true)
+	q.Push(time.Now().Add(30*time.Minute), 1)
+
+	//wait for the released Message to be buffered.
+	for len(q.messageChan) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	demand := q.Demand(time.Hour, 2*time.Hour)
+	if demand.Backlog != 1 || demand.Bucket != time.Hour || len(demand.Forecast) != 2 || demand.Forecast[0] != 1 || demand.Workers != 0 {
+		t.Errorf("q.Demand() = %+v WANT Backlog 1 Bucket 1h Forecast [1 0] Workers 0", demand)
+	}
+}
+
+func TestForecastAutoscaler(t *testing.T) {
+	tests := []struct {
+		perWorker int
+		demand    Demand
+		result    int
+	}{
+		{10, Demand{}, 1},
+		{10, Demand{Forecast: []int{0, 25, 5}}, 3},
+		{10, Demand{Backlog: 15, Forecast: []int{10, 20}}, 3},
+		{10, Demand{Backlog: 31}, 4},
+		{0, Demand{Forecast: []int{3}}, 3},
+	}
+	for _, test := range tests {
+		if result := ForecastAutoscaler(test.perWorker)(test.demand); result != test.result {
+			t.Errorf("ForecastAutoscaler(%v)(%+v) = %v WANT %v", test.perWorker, test.demand, result, test.result)
+		}
+	}
+}
+
+func TestWithAutoscaler(t *testing.T) {
+	q := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	demands := make(chan Demand, 1)
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error { return nil },
+		WithWorkers(1),
+		WithAutoscaler(time.Millisecond, time.Hour, time.Hour, func(demand Demand) int {
+			select {
+			case demands <- demand:
+			default:
+			}
+			return 3
+		}),
+	)
+	demand := <-demands
+	if demand.Workers < 1 || len(demand.Forecast) != 1 {
+		t.Errorf("autoscaler Demand = %+v WANT Workers >= 1 and 1 Forecast bucket", demand)
+	}
+	for d.Workers() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	d.Wait()
+}
+
+func TestNewAutoscale(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		bucket   time.Duration
+		wantI    time.Duration
+		wantB    time.Duration
+	}{
+		{time.Minute, time.Hour, time.Minute, time.Hour},
+		{0, time.Hour, DefaultScaleInterval, time.Hour},
+		{-time.Minute, 0, DefaultScaleInterval, DefaultScaleInterval},
+		{time.Minute, -time.Hour, time.Minute, time.Minute},
+	}
+	for _, test := range tests {
+		a := newAutoscale(test.interval, test.bucket, time.Hour, LatencyAutoscaler())
+		if a.interval != test.wantI || a.bucket != test.wantB {
+			t.Errorf("newAutoscale(%v, %v) = %v, %v WANT %v, %v", test.interval, test.bucket, a.interval, a.bucket, test.wantI, test.wantB)
+		}
+	}
+}
+
+func TestLatencyAutoscaler(t *testing.T) {
+	tests := []struct {
+		demand Demand
+		result int
+	}{
+		{Demand{Workers: 2}, -1},
+		{Demand{Workers: 2, Backlog: 1}, 3},
+		{Demand{Workers: 2, Bucket: time.Second, Latency: 100 * time.Millisecond}, 0},
+		{Demand{Workers: 2, Bucket: time.Second, Latency: 100 * time.Millisecond, Backlog: 15, Forecast: []int{10}}, 3},
+		{Demand{Workers: 2, Bucket: time.Second, Latency: time.Second, Forecast: []int{5, 100}}, 5},
+	}
+	for _, test := range tests {
+		if result := LatencyAutoscaler()(test.demand); result != test.result {
+			t.Errorf("LatencyAutoscaler()(%+v) = %v WANT %v", test.demand, result, test.result)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+//Package bench provides a harness that drives a TimeQueue with a synthetic
+//workload and reports its throughput and release lateness, so that Storages and
+//Options can be compared for a workload before they are deployed.
+//
+//Every run creates a new TimeQueue with Config.New, so the same Config can be run
+//with different backends:
+//	for name, storage := range map[string]func() timequeue.Storage{...} {
+//		result, err := bench.Run(ctx, bench.Config{
+//			New: func() *timequeue.TimeQueue {
+//				return timequeue.New(timequeue.WithStorage(storage()))
+//			},
+//			Messages: 100000,
+//			Rate:     10000,
+//			Offset:   bench.Uniform(0, time.Second),
+//		})
+//		//handle err.
+//		fmt.Println(name, result)
+//	}
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//DefaultMessages is the number of Messages pushed by a run unless Config.Messages
+//is set.
+const DefaultMessages = 10000
+
+//Distribution returns the offset from the time a Message is pushed to its Time.
+//r is only used by the go-routine that pushes Messages.
+type Distribution func(r *rand.Rand) time.Duration
+
+//Fixed returns a Distribution whose offsets are all d.
+func Fixed(d time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return d
+	}
+}
+
+//Uniform returns a Distribution whose offsets are uniformly distributed in
+//[min, max).
+func Uniform(min, max time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+//Exponential returns a Distribution whose offsets are exponentially distributed
+//with mean, i.e. most Messages are due soon and a few are due much later.
+func Exponential(mean time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+//Config describes the workload of a run.
+type Config struct {
+	//New creates the TimeQueue under test, which must not be started.
+	//timequeue.New() is used if New is nil.
+	New func() *timequeue.TimeQueue
+	//Messages is the number of Messages pushed. DefaultMessages is used if it is
+	//less than or equal to zero.
+	Messages int
+	//Rate is the number of Messages pushed per second. Messages are pushed as fast
+	//as possible if it is less than or equal to zero.
+	Rate float64
+	//Offset distributes the Times of Messages after the time they are pushed.
+	//Every Message is due when it is pushed if Offset is nil.
+	Offset Distribution
+	//Consumers is the number of go-routines receiving from Messages().
+	//One is used if it is less than or equal to zero.
+	Consumers int
+	//ConsumerLatency is the time a consumer spends on every Message it receives
+	//before it receives the next.
+	ConsumerLatency time.Duration
+	//Seed seeds the random source given to Offset.
+	Seed int64
+}
+
+//Result is the outcome of a run.
+type Result struct {
+	//Pushed and Released are the number of Messages pushed and received.
+	Pushed   int
+	Released int
+	//Duration is the time from the first push to the last receipt.
+	Duration time.Duration
+	//PushThroughput is the number of Messages pushed per second spent pushing, and
+	//ReleaseThroughput is the number of Messages received per second of Duration.
+	PushThroughput    float64
+	ReleaseThroughput float64
+	//P50, P90, P99, and Max are percentiles of lateness, the time from the Time of a
+	//Message until it is received.
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+//String returns a one line summary of r.
+func (r *Result) String() string {
+	return fmt.Sprintf("pushed=%d released=%d duration=%v push/s=%.0f release/s=%.0f p50=%v p90=%v p99=%v max=%v",
+		r.Pushed, r.Released, r.Duration, r.PushThroughput, r.ReleaseThroughput, r.P50, r.P90, r.P99, r.Max)
+}
+
+//Run pushes the Messages described by c to a new started TimeQueue, receives them
+//from its Messages() channel, and returns the Result once every Message has been
+//received. The TimeQueue is stopped before Run returns.
+//
+//If ctx is done first, then Run returns the Result of the Messages received so far
+//along with the error of ctx.
+func Run(ctx context.Context, c Config) (*Result, error) {
+	c = c.withDefaults()
+	q := c.New()
+	q.Start()
+	defer q.Stop()
+
+	lateness := make([]time.Duration, 0, c.Messages)
+	lock := &sync.Mutex{}
+	received := make(chan struct{}, c.Messages)
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < c.Consumers; i++ {
+		go func() {
+			for {
+				select {
+				case message := <-q.Messages():
+					late := time.Since(message.Time)
+					lock.Lock()
+					lateness = append(lateness, late)
+					lock.Unlock()
+					received <- struct{}{}
+					if c.ConsumerLatency > 0 {
+						time.Sleep(c.ConsumerLatency)
+					}
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	r := rand.New(rand.NewSource(c.Seed))
+	start := time.Now()
+	result := &Result{}
+	var err error
+	for pushed := 0; pushed < c.Messages && err == nil; pushed++ {
+		if c.Rate > 0 {
+			next := start.Add(time.Duration(float64(pushed) / c.Rate * float64(time.Second)))
+			if wait := time.Until(next); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					err = ctx.Err()
+					continue
+				}
+			}
+		}
+		q.Push(time.Now().Add(c.Offset(r)), pushed)
+		result.Pushed++
+	}
+	pushDuration := time.Since(start)
+
+	for released := 0; released < result.Pushed && err == nil; released++ {
+		select {
+		case <-received:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	result.Duration = time.Since(start)
+
+	lock.Lock()
+	defer lock.Unlock()
+	result.summarize(lateness, pushDuration)
+	return result, err
+}
+
+//withDefaults returns c with defaults for all unset values.
+func (c Config) withDefaults() Config {
+	if c.New == nil {
+		c.New = func() *timequeue.TimeQueue {
+			return timequeue.New()
+		}
+	}
+	if c.Messages <= 0 {
+		c.Messages = DefaultMessages
+	}
+	if c.Offset == nil {
+		c.Offset = Fixed(0)
+	}
+	if c.Consumers <= 0 {
+		c.Consumers = 1
+	}
+	return c
+}
+
+//summarize sets the throughputs and lateness percentiles of r.
+func (r *Result) summarize(lateness []time.Duration, pushDuration time.Duration) {
+	r.Released = len(lateness)
+	if seconds := pushDuration.Seconds(); seconds > 0 {
+		r.PushThroughput = float64(r.Pushed) / seconds
+	}
+	if seconds := r.Duration.Seconds(); seconds > 0 {
+		r.ReleaseThroughput = float64(r.Released) / seconds
+	}
+	if len(lateness) == 0 {
+		return
+	}
+	sorted := append([]time.Duration(nil), lateness...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	r.P50 = percentile(sorted, 0.5)
+	r.P90 = percentile(sorted, 0.9)
+	r.P99 = percentile(sorted, 0.99)
+	r.Max = sorted[len(sorted)-1]
+}
+
+//percentile returns the value at p, in [0, 1], of sorted, which is not empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	return sorted[int(p*float64(len(sorted)-1))]
+}
@@ -0,0 +1,94 @@
+package bench
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+func TestRun(t *testing.T) {
+	created := 0
+	result, err := Run(context.Background(), Config{
+		New: func() *timequeue.TimeQueue {
+			created++
+			return timequeue.New(timequeue.WithCapacity(8))
+		},
+		Messages:  200,
+		Rate:      10000,
+		Offset:    Uniform(0, 10*time.Millisecond),
+		Consumers: 2,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v WANT nil", err)
+	}
+	if created != 1 {
+		t.Errorf("created = %v WANT %v", created, 1)
+	}
+	if result.Pushed != 200 || result.Released != 200 {
+		t.Errorf("result.Pushed, result.Released = %v, %v WANT 200, 200", result.Pushed, result.Released)
+	}
+	if result.PushThroughput <= 0 || result.ReleaseThroughput <= 0 {
+		t.Errorf("result throughputs = %v, %v WANT positive", result.PushThroughput, result.ReleaseThroughput)
+	}
+	if !(result.P50 <= result.P90 && result.P90 <= result.P99 && result.P99 <= result.Max) {
+		t.Errorf("result percentiles = %v WANT non-decreasing", result)
+	}
+}
+
+func TestRun_canceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := Run(ctx, Config{
+		Messages: 1000,
+		Rate:     100,
+		Offset:   Fixed(time.Hour),
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Run() error = %v WANT %v", err, context.DeadlineExceeded)
+	}
+	if result.Pushed == 0 || result.Pushed >= 1000 || result.Released != 0 {
+		t.Errorf("result = %v WANT some pushed and none released", result)
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name     string
+		offset   Distribution
+		min, max time.Duration
+	}{
+		{"fixed", Fixed(time.Second), time.Second, time.Second},
+		{"uniform", Uniform(time.Second, 2*time.Second), time.Second, 2*time.Second - 1},
+		{"uniform empty", Uniform(time.Second, time.Second), time.Second, time.Second},
+		{"exponential", Exponential(time.Second), 0, time.Hour},
+	}
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			if offset := test.offset(r); offset < test.min || offset > test.max {
+				t.Errorf("%v: offset = %v WANT in [%v, %v]", test.name, offset, test.min, test.max)
+			}
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1},
+		{0.5, 5},
+		{0.9, 9},
+		{1, 10},
+	}
+	for _, test := range tests {
+		if got := percentile(sorted, test.p); got != test.want {
+			t.Errorf("percentile(%v) = %v WANT %v", test.p, got, test.want)
+		}
+	}
+}
@@ -1,5 +1,5 @@
 #!/bin/sh
 
-go get github.com/axw/gocov/gocov
-go get github.com/ericelsken/goveralls
-go get -t ./...
+go install github.com/axw/gocov/gocov@latest
+go install github.com/ericelsken/goveralls@latest
+go mod download
@@ -0,0 +1,79 @@
+package timequeue
+
+import (
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+//bloomFilter is a bloom filter of strings whose methods are safe for use by
+//multiple go-routines without locking.
+type bloomFilter struct {
+	bits []uint64
+	//the number of bits and hash functions.
+	m, k uint64
+}
+
+//newBloomFilter creates a bloomFilter sized for n keys with a false positive
+//rate of p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Ceil(math.Ln2 * float64(m) / float64(n)))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+//hashes returns the two hashes of key used for double hashing.
+func (b *bloomFilter) hashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+	h2 := h1>>33 | h1<<31
+	return h1, h2 | 1
+}
+
+//add adds key to b.
+func (b *bloomFilter) add(key string) {
+	h1, h2 := b.hashes(key)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		word, mask := &b.bits[bit/64], uint64(1)<<(bit%64)
+		for {
+			old := atomic.LoadUint64(word)
+			if old&mask != 0 || atomic.CompareAndSwapUint64(word, old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+//mayContain returns false if key was definitely never added to b.
+func (b *bloomFilter) mayContain(key string) bool {
+	h1, h2 := b.hashes(key)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if atomic.LoadUint64(&b.bits[bit/64])&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//reset removes all keys from b.
+func (b *bloomFilter) reset() {
+	for i := range b.bits {
+		atomic.StoreUint64(&b.bits[i], 0)
+	}
+}
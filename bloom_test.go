@@ -0,0 +1,32 @@
+package timequeue
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	b := newBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		b.add(strconv.Itoa(i))
+	}
+	for i := 0; i < 1000; i++ {
+		if !b.mayContain(strconv.Itoa(i)) {
+			t.Fatalf("b.mayContain(%v) = false WANT true", i)
+		}
+	}
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if b.mayContain(strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 300 {
+		t.Errorf("false positives = %v WANT about 100", falsePositives)
+	}
+
+	b.reset()
+	if b.mayContain("0") {
+		t.Errorf("b.mayContain(0) after reset = true WANT false")
+	}
+}
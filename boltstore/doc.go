@@ -0,0 +1,15 @@
+//Package boltstore provides a timequeue.Store backed by a bbolt database, so that
+//a single binary gets durable scheduling without any external services:
+//	store, err := boltstore.Open("/var/lib/myservice/timequeue.db", nil)
+//	//handle err.
+//	defer store.Close()
+//	q := timequeue.New(timequeue.WithStore(store))
+//	q.Start()
+//
+//Messages are kept in time order, keyed by their Time and a sequence number, so
+//they are loaded in the order they would be released.
+//
+//Message Data is encoded with the Codec registered for its type with
+//timequeue.RegisterType(), or otherwise with encoding/gob, in which case the
+//concrete types of all Data values must be registered with gob.Register().
+package boltstore
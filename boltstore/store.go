@@ -0,0 +1,190 @@
+package boltstore
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+	"github.com/gogolfing/timequeue/internal/record"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	//messagesBucket holds the encoded Messages keyed by Time and sequence.
+	messagesBucket = []byte("timequeue.messages")
+	//idsBucket holds the key in messagesBucket of every Message keyed by ID.
+	idsBucket = []byte("timequeue.ids")
+	//metaBucket holds the cursor at cursorKey.
+	metaBucket = []byte("timequeue.meta")
+	cursorKey  = []byte("cursor")
+)
+
+//Store is a timequeue.CursorStore and timequeue.LoadStore that keeps pending
+//Messages in a bbolt database. Every Append(), Remove(), and SaveCursor() is
+//committed in its own transaction before it returns.
+type Store struct {
+	db *bolt.DB
+}
+
+//Open opens the bbolt database at path with options, which may be nil, and
+//returns a Store that uses it.
+func Open(path string, options *bolt.Options) (*Store, error) {
+	db, err := bolt.Open(path, 0600, options)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+//New returns a Store that uses db, which may also be used for other buckets.
+func New(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{messagesBucket, idsBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+//DB returns the database used by s.
+func (s *Store) DB() *bolt.DB {
+	return s.db
+}
+
+//Close closes the database used by s.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+//Append stores message, replacing any Message with the same ID.
+func (s *Store) Append(message *timequeue.Message) error {
+	value, err := timequeue.EncodeMessage(message)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := remove(tx, message.ID); err != nil {
+			return err
+		}
+		messages := tx.Bucket(messagesBucket)
+		seq, err := messages.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := newKey(message.Time, seq)
+		if err := messages.Put(key, value); err != nil {
+			return err
+		}
+		return tx.Bucket(idsBucket).Put([]byte(message.ID), key)
+	})
+}
+
+//Remove deletes the Message with id if it is stored.
+func (s *Store) Remove(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return remove(tx, id)
+	})
+}
+
+//LoadAll returns new Messages for every stored Message in order of Time.
+func (s *Store) LoadAll() ([]*timequeue.Message, error) {
+	result := []*timequeue.Message{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(messagesBucket).ForEach(func(_, value []byte) error {
+			message, err := timequeue.DecodeMessage(value)
+			if err != nil {
+				return err
+			}
+			result = append(result, message)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//Load returns a new Message for the stored Message with id, or nil if there is
+//none.
+func (s *Store) Load(id string) (*timequeue.Message, error) {
+	var message *timequeue.Message
+	err := s.db.View(func(tx *bolt.Tx) error {
+		key := tx.Bucket(idsBucket).Get([]byte(id))
+		if key == nil {
+			return nil
+		}
+		value := tx.Bucket(messagesBucket).Get(key)
+		if value == nil {
+			return nil
+		}
+		var err error
+		message, err = timequeue.DecodeMessage(value)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+//SaveCursor stores cursor.
+func (s *Store) SaveCursor(cursor uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, cursor)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(cursorKey, value)
+	})
+}
+
+//LoadCursor returns the stored cursor or 0 if there is none.
+func (s *Store) LoadCursor() (uint64, error) {
+	cursor := uint64(0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(metaBucket).Get(cursorKey); len(value) == 8 {
+			cursor = binary.BigEndian.Uint64(value)
+		}
+		return nil
+	})
+	return cursor, err
+}
+
+//Compact does nothing and returns nil. Removed Messages are deleted immediately
+//and bbolt reuses the pages that they occupied.
+func (s *Store) Compact() error {
+	return nil
+}
+
+//remove deletes the Message with id from both buckets in tx.
+func remove(tx *bolt.Tx, id string) error {
+	ids := tx.Bucket(idsBucket)
+	key := ids.Get([]byte(id))
+	if key == nil {
+		return nil
+	}
+	if err := tx.Bucket(messagesBucket).Delete(key); err != nil {
+		return err
+	}
+	return ids.Delete([]byte(id))
+}
+
+//newKey returns the key of a Message with t and seq, which sorts by t and then
+//seq. Times before the Unix epoch sort first, and Times that cannot be represented
+//in Unix nanoseconds sort with the earliest or latest ones. See record.UnixNano().
+func newKey(t time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(record.UnixNano(t))^(1<<63))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
@@ -0,0 +1,118 @@
+package boltstore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	s, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v WANT nil", err)
+	}
+	return s
+}
+
+func TestStore(t *testing.T) {
+	dir, err := os.MkdirTemp("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "timequeue.db")
+	now := time.Now().Truncate(time.Second)
+
+	s := openTestStore(t, path)
+	q := timequeue.New(timequeue.WithStore(s))
+	q.PushMessage(&timequeue.Message{Time: now.Add(2 * time.Hour), Data: "later", Key: "key"})
+	q.Push(now.Add(time.Hour), "sooner")
+	q.Push(time.Unix(-1, 0), "before epoch")
+	removed := q.Push(now, "removed")
+	q.Remove(removed, false)
+	q.Start()
+	if message := <-q.Messages(); message.Data != "before epoch" {
+		t.Fatalf("q.Messages() = %v WANT %v", message.Data, "before epoch")
+	}
+	q.Stop()
+	if err := q.Healthy(); err != timequeue.ErrNotRunning {
+		t.Fatalf("q.Healthy() = %v WANT %v", err, timequeue.ErrNotRunning)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("s.Close() = %v WANT nil", err)
+	}
+
+	s = openTestStore(t, path)
+	defer s.Close()
+	messages, err := s.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v WANT nil", err)
+	}
+	if len(messages) != 2 || messages[0].Data != "sooner" || messages[1].Data != "later" ||
+		messages[1].Key != "key" || !messages[1].Time.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("LoadAll() = %v WANT sooner, later", messages)
+	}
+	if message, err := s.Load(messages[1].ID); err != nil || message == nil || message.Data != "later" {
+		t.Errorf("Load() = %v, %v WANT later, nil", message, err)
+	}
+	if message, err := s.Load("missing"); message != nil || err != nil {
+		t.Errorf("Load(missing) = %v, %v WANT nil, nil", message, err)
+	}
+	recovered := timequeue.New(timequeue.WithStore(s))
+	if !recovered.Contains("key") || recovered.Size() != 2 {
+		t.Errorf("recovered Contains(key), Size() = %v, %v WANT true, 2", recovered.Contains("key"), recovered.Size())
+	}
+}
+
+func TestStore_cursor(t *testing.T) {
+	dir, err := os.MkdirTemp("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "timequeue.db")
+
+	s := openTestStore(t, path)
+	if cursor, err := s.LoadCursor(); cursor != 0 || err != nil {
+		t.Errorf("LoadCursor() = %v, %v WANT 0, nil", cursor, err)
+	}
+	q := timequeue.New(timequeue.WithStore(s), timequeue.WithAckMode())
+	message := q.Push(time.Now(), 1)
+	q.Pop(true)
+	if messages, _ := s.LoadAll(); len(messages) != 1 {
+		t.Errorf("LoadAll() before Ack() = %v WANT 1 Message", messages)
+	}
+	q.Ack(message.ID)
+	s.Close()
+
+	s = openTestStore(t, path)
+	defer s.Close()
+	if cursor, err := s.LoadCursor(); cursor != 1 || err != nil {
+		t.Errorf("LoadCursor() = %v, %v WANT 1, nil", cursor, err)
+	}
+	if messages, _ := s.LoadAll(); len(messages) != 0 {
+		t.Errorf("LoadAll() after Ack() = %v WANT none", messages)
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	times := []time.Time{
+		time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Unix(-1, 0),
+		time.Unix(0, 0),
+		time.Date(2262, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2263, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	//the last two Times are clamped to the same key prefix and sort by seq.
+	for i := 1; i < len(times); i++ {
+		before, after := newKey(times[i-1], uint64(i-1)), newKey(times[i], uint64(i))
+		if bytes.Compare(before, after) >= 0 {
+			t.Errorf("newKey(%v) >= newKey(%v) WANT <", times[i-1], times[i])
+		}
+	}
+}
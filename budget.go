@@ -0,0 +1,58 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//budget tracks the releases spent in a single budget window.
+type budget struct {
+	//the start of the window that used applies to.
+	window time.Time
+	//the number of releases spent in window.
+	used int
+}
+
+//hasBudget returns whether or not q has a budget configured.
+//It should only be called when q is locked.
+func (q *TimeQueue) hasBudget() bool {
+	return q.config.budgetLimit > 0 && q.config.budgetWindow > 0
+}
+
+//spendBudget spends weight units from the budget window containing now.
+//Returns false, without spending, if the budget for that window does not have
+//weight units remaining.
+//A weight larger than the entire budget is allowed to spend a window that has
+//nothing spent so that it is not deferred forever.
+//It should only be called when q is locked.
+func (q *TimeQueue) spendBudget(now time.Time, weight int) bool {
+	if !q.hasBudget() {
+		return true
+	}
+	window := now.Truncate(q.config.budgetWindow)
+	if !window.Equal(q.budget.window) {
+		q.budget = budget{window: window}
+	}
+	if q.budget.used > 0 && q.budget.used+weight > q.config.budgetLimit {
+		return false
+	}
+	q.budget.used += weight
+	return true
+}
+
+//budgetDeferral returns the start of the next budget window if the budget for the
+//window containing now cannot spend weight, i.e. if spendBudget() would refuse it,
+//and the zero time otherwise.
+//It should only be called when q is locked.
+func (q *TimeQueue) budgetDeferral(now time.Time, weight int) time.Time {
+	if !q.hasBudget() {
+		return time.Time{}
+	}
+	window := now.Truncate(q.config.budgetWindow)
+	if !window.Equal(q.budget.window) || q.budget.used == 0 {
+		return time.Time{}
+	}
+	if q.budget.used+weight <= q.config.budgetLimit {
+		return time.Time{}
+	}
+	return window.Add(q.config.budgetWindow)
+}
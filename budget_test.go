@@ -0,0 +1,104 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_spendBudget(t *testing.T) {
+	q := New(WithBudget(2, time.Hour))
+	now := time.Date(2016, 8, 7, 10, 30, 0, 0, time.UTC)
+	for i, want := range []bool{true, true, false} {
+		if result := q.spendBudget(now, 1); result != want {
+			t.Errorf("spend %v q.spendBudget() = %v WANT %v", i, result, want)
+		}
+	}
+	if result := q.spendBudget(now.Add(time.Hour), 1); !result {
+		t.Errorf("next window q.spendBudget() = %v WANT %v", result, true)
+	}
+}
+
+func TestTimeQueue_spendBudget_disabled(t *testing.T) {
+	q := New()
+	for i := 0; i < 10; i++ {
+		if result := q.spendBudget(time.Now(), 1); !result {
+			t.Errorf("q.spendBudget() = %v WANT %v", result, true)
+		}
+	}
+}
+
+func TestTimeQueue_spendBudget_weight(t *testing.T) {
+	q := New(WithBudget(10, time.Hour))
+	now := time.Date(2016, 8, 7, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		weight int
+		result bool
+		used   int
+	}{
+		{6, true, 6},
+		{5, false, 6},
+		{4, true, 10},
+		{1, false, 10},
+	}
+	for _, test := range tests {
+		if result := q.spendBudget(now, test.weight); result != test.result || q.budget.used != test.used {
+			t.Errorf("q.spendBudget(%v) = %v, %v WANT %v, %v", test.weight, result, q.budget.used, test.result, test.used)
+		}
+	}
+	if result := q.spendBudget(now.Add(time.Hour), 25); !result {
+		t.Errorf("q.spendBudget(25) in empty window = %v WANT %v", result, true)
+	}
+}
+
+func TestTimeQueue_budgetDeferral(t *testing.T) {
+	q := New(WithBudget(1, time.Hour))
+	now := time.Date(2016, 8, 7, 10, 30, 0, 0, time.UTC)
+	if result := q.budgetDeferral(now, 1); !result.IsZero() {
+		t.Errorf("q.budgetDeferral() = %v WANT zero", result)
+	}
+	q.spendBudget(now, 1)
+	want := time.Date(2016, 8, 7, 11, 0, 0, 0, time.UTC)
+	if result := q.budgetDeferral(now, 1); !result.Equal(want) {
+		t.Errorf("q.budgetDeferral() = %v WANT %v", result, want)
+	}
+	if result := q.budgetDeferral(want, 1); !result.IsZero() {
+		t.Errorf("q.budgetDeferral(next window) = %v WANT zero", result)
+	}
+}
+
+func TestTimeQueue_onWake_budget(t *testing.T) {
+	q := New(WithBudget(2, time.Hour))
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		q.Push(now.Add(time.Duration(i)), i)
+	}
+	q.onWake(now.Add(4))
+	for i := 0; i < 2; i++ {
+		if message := <-q.Messages(); message.Data != i {
+			t.Errorf("message.Data = %v WANT %v", message.Data, i)
+		}
+	}
+	if size := q.Size(); size != 2 {
+		t.Errorf("q.Size() = %v WANT %v", size, 2)
+	}
+	if wake := q.wakeTime(now, 1); !wake.After(now) {
+		t.Errorf("q.wakeTime() = %v WANT after %v", wake, now)
+	}
+}
+
+func TestTimeQueue_budget_weightDoesNotSpin(t *testing.T) {
+	q := New(WithBudget(3, time.Hour))
+	now := time.Now()
+	q.PushMessage(&Message{Time: now, Data: 0, Weight: 2})
+	q.PushMessage(&Message{Time: now, Data: 1, Weight: 2})
+	q.Start()
+	defer q.Stop()
+	<-q.Messages()
+	time.Sleep(100 * time.Millisecond)
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+	if wakes := q.counters.wakeGeneration.load(); wakes > 10 {
+		t.Errorf("wakes = %v WANT <= %v", wakes, 10)
+	}
+}
@@ -0,0 +1,81 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//maxBusinessDaySearch is the maximum number of days searched for a business day.
+//This guards against Calendars that black out every day.
+const maxBusinessDaySearch = 3660
+
+//IsBusinessDay returns whether or not the day of t, in t's Location, is a weekday
+//that does not start in a blackout of calendar. calendar may be nil.
+func IsBusinessDay(t time.Time, calendar Calendar) bool {
+	if weekday := t.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	if calendar == nil {
+		return true
+	}
+	_, blackout := calendar.Blackout(startOfDay(t))
+	return !blackout
+}
+
+//NextBusinessDay returns midnight, in t's Location, of the first business day
+//(see IsBusinessDay()) after the day of t.
+//The zero Time is returned if no business day is found within ten years.
+func NextBusinessDay(t time.Time, calendar Calendar) time.Time {
+	day := startOfDay(t)
+	for i := 0; i < maxBusinessDaySearch; i++ {
+		day = day.AddDate(0, 0, 1)
+		if IsBusinessDay(day, calendar) {
+			return day
+		}
+	}
+	return time.Time{}
+}
+
+//NextWeekdayAt returns the first time after t, in t's Location, that is on weekday
+//at hour:min.
+func NextWeekdayAt(t time.Time, weekday time.Weekday, hour, min int) time.Time {
+	result := atClock(t, hour, min)
+	result = result.AddDate(0, 0, (int(weekday)-int(result.Weekday())+7)%7)
+	if !result.After(t) {
+		result = result.AddDate(0, 0, 7)
+	}
+	return result
+}
+
+//BusinessDaysAt returns a Recurrence that occurs at hour:min, in loc, on every
+//business day (see IsBusinessDay()). calendar may be nil.
+func BusinessDaysAt(hour, min int, loc *time.Location, calendar Calendar) Recurrence {
+	return RecurrenceFunc(func(t time.Time) time.Time {
+		t = t.In(loc)
+		if result := atClock(t, hour, min); result.After(t) && IsBusinessDay(result, calendar) {
+			return result
+		}
+		day := NextBusinessDay(t, calendar)
+		if day.IsZero() {
+			return day
+		}
+		return atClock(day, hour, min)
+	})
+}
+
+//WeeklyAt returns a Recurrence that occurs at hour:min, in loc, on every weekday.
+func WeeklyAt(weekday time.Weekday, hour, min int, loc *time.Location) Recurrence {
+	return RecurrenceFunc(func(t time.Time) time.Time {
+		return NextWeekdayAt(t.In(loc), weekday, hour, min)
+	})
+}
+
+//startOfDay returns midnight of the day of t in t's Location.
+func startOfDay(t time.Time) time.Time {
+	return atClock(t, 0, 0)
+}
+
+//atClock returns hour:min on the day of t in t's Location.
+func atClock(t time.Time, hour, min int) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, hour, min, 0, 0, t.Location())
+}
@@ -0,0 +1,89 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsBusinessDay(t *testing.T) {
+	christmas := time.Date(2017, 12, 25, 0, 0, 0, 0, time.UTC)
+	calendar := BlackoutDates(time.UTC, christmas)
+	tests := []struct {
+		t      time.Time
+		result bool
+	}{
+		{time.Date(2017, 12, 22, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2017, 12, 23, 12, 0, 0, 0, time.UTC), false},
+		{time.Date(2017, 12, 24, 12, 0, 0, 0, time.UTC), false},
+		{time.Date(2017, 12, 25, 12, 0, 0, 0, time.UTC), false},
+		{time.Date(2017, 12, 26, 12, 0, 0, 0, time.UTC), true},
+	}
+	for _, test := range tests {
+		if result := IsBusinessDay(test.t, calendar); result != test.result {
+			t.Errorf("IsBusinessDay(%v) = %v WANT %v", test.t, result, test.result)
+		}
+	}
+	if result := IsBusinessDay(christmas, nil); !result {
+		t.Errorf("IsBusinessDay(%v, nil) = %v WANT %v", christmas, result, true)
+	}
+}
+
+func TestNextBusinessDay(t *testing.T) {
+	calendar := BlackoutDates(time.UTC, time.Date(2017, 12, 25, 0, 0, 0, 0, time.UTC))
+	friday := time.Date(2017, 12, 22, 15, 0, 0, 0, time.UTC)
+	want := time.Date(2017, 12, 26, 0, 0, 0, 0, time.UTC)
+	if result := NextBusinessDay(friday, calendar); !result.Equal(want) {
+		t.Errorf("NextBusinessDay() = %v WANT %v", result, want)
+	}
+	always := CalendarFunc(func(t time.Time) (time.Time, bool) {
+		return t.Add(time.Hour), true
+	})
+	if result := NextBusinessDay(friday, always); !result.IsZero() {
+		t.Errorf("NextBusinessDay(always) = %v WANT zero", result)
+	}
+}
+
+func TestNextWeekdayAt(t *testing.T) {
+	wednesday := time.Date(2017, 12, 20, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		weekday time.Weekday
+		hour    int
+		result  time.Time
+	}{
+		{time.Wednesday, 9, time.Date(2017, 12, 27, 9, 0, 0, 0, time.UTC)},
+		{time.Wednesday, 11, time.Date(2017, 12, 20, 11, 0, 0, 0, time.UTC)},
+		{time.Friday, 9, time.Date(2017, 12, 22, 9, 0, 0, 0, time.UTC)},
+		{time.Monday, 9, time.Date(2017, 12, 25, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if result := NextWeekdayAt(wednesday, test.weekday, test.hour, 0); !result.Equal(test.result) {
+			t.Errorf("NextWeekdayAt(%v, %v) = %v WANT %v", test.weekday, test.hour, result, test.result)
+		}
+	}
+}
+
+func TestBusinessDaysAt(t *testing.T) {
+	calendar := BlackoutDates(time.UTC, time.Date(2017, 12, 25, 0, 0, 0, 0, time.UTC))
+	r := BusinessDaysAt(9, 0, time.UTC, calendar)
+	tests := []struct {
+		t      time.Time
+		result time.Time
+	}{
+		{time.Date(2017, 12, 22, 8, 0, 0, 0, time.UTC), time.Date(2017, 12, 22, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2017, 12, 22, 9, 0, 0, 0, time.UTC), time.Date(2017, 12, 26, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2017, 12, 23, 8, 0, 0, 0, time.UTC), time.Date(2017, 12, 26, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if result := r.Next(test.t); !result.Equal(test.result) {
+			t.Errorf("r.Next(%v) = %v WANT %v", test.t, result, test.result)
+		}
+	}
+}
+
+func TestWeeklyAt(t *testing.T) {
+	r := WeeklyAt(time.Monday, 9, 30, time.UTC)
+	want := time.Date(2017, 12, 25, 9, 30, 0, 0, time.UTC)
+	if result := r.Next(time.Date(2017, 12, 20, 10, 0, 0, 0, time.UTC)); !result.Equal(want) {
+		t.Errorf("r.Next() = %v WANT %v", result, want)
+	}
+}
@@ -0,0 +1,100 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//maxBlackoutChain is the maximum number of adjacent blackouts followed when
+//determining the end of a blackout. This guards against Calendars that never
+//report a time outside of a blackout.
+const maxBlackoutChain = 1024
+
+//Calendar determines periods of time, blackouts, during which a TimeQueue does not
+//release Messages. Implementations may be used to plug in holiday calendars for a
+//locale.
+type Calendar interface {
+	//Blackout returns the end of the blackout containing t and true, or false if
+	//t is not in a blackout. The returned end must be after t.
+	Blackout(t time.Time) (end time.Time, ok bool)
+}
+
+//CalendarFunc is a function that implements Calendar.
+type CalendarFunc func(t time.Time) (time.Time, bool)
+
+//Blackout calls f(t).
+func (f CalendarFunc) Blackout(t time.Time) (time.Time, bool) {
+	return f(t)
+}
+
+//Blackout is a single blackout starting at Start (inclusive) and ending at End
+//(exclusive).
+type Blackout struct {
+	Start time.Time
+	End   time.Time
+}
+
+//Contains returns whether or not t is in b.
+func (b Blackout) Contains(t time.Time) bool {
+	return !t.Before(b.Start) && t.Before(b.End)
+}
+
+//Blackouts is a Calendar of explicit blackout ranges.
+type Blackouts []Blackout
+
+//Blackout returns the End of the first element in b that contains t.
+func (b Blackouts) Blackout(t time.Time) (time.Time, bool) {
+	for _, blackout := range b {
+		if blackout.Contains(t) {
+			return blackout.End, true
+		}
+	}
+	return time.Time{}, false
+}
+
+//BlackoutDates returns Blackouts covering the entire days of dates in loc.
+//Only the year, month, and day of each date are used.
+func BlackoutDates(loc *time.Location, dates ...time.Time) Blackouts {
+	result := make(Blackouts, 0, len(dates))
+	for _, date := range dates {
+		year, month, day := date.Date()
+		start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		result = append(result, Blackout{
+			Start: start,
+			End:   start.AddDate(0, 0, 1),
+		})
+	}
+	return result
+}
+
+//Calendars is a Calendar that is in a blackout whenever any of its elements are.
+type Calendars []Calendar
+
+//Blackout returns the latest end of all elements in c that are in a blackout at t.
+func (c Calendars) Blackout(t time.Time) (time.Time, bool) {
+	result, found := time.Time{}, false
+	for _, calendar := range c {
+		if end, ok := calendar.Blackout(t); ok && end.After(result) {
+			result, found = end, true
+		}
+	}
+	return result, found
+}
+
+//blackoutEnd returns the time at which the blackout containing t ends and true,
+//or false if t is not in a blackout or q has no Calendar.
+//Adjacent and overlapping blackouts are followed to the end of the last one.
+//It should only be called when q is locked.
+func (q *TimeQueue) blackoutEnd(t time.Time) (time.Time, bool) {
+	if q.config.calendar == nil {
+		return time.Time{}, false
+	}
+	found := false
+	for i := 0; i < maxBlackoutChain; i++ {
+		end, ok := q.config.calendar.Blackout(t)
+		if !ok || !end.After(t) {
+			break
+		}
+		t, found = end, true
+	}
+	return t, found
+}
@@ -0,0 +1,79 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlackout_Contains(t *testing.T) {
+	start := time.Date(2016, 12, 25, 0, 0, 0, 0, time.UTC)
+	b := Blackout{Start: start, End: start.Add(time.Hour)}
+	tests := []struct {
+		t      time.Time
+		result bool
+	}{
+		{start.Add(-1), false},
+		{start, true},
+		{start.Add(time.Minute), true},
+		{start.Add(time.Hour), false},
+	}
+	for _, test := range tests {
+		if result := b.Contains(test.t); result != test.result {
+			t.Errorf("b.Contains(%v) = %v WANT %v", test.t, result, test.result)
+		}
+	}
+}
+
+func TestBlackoutDates(t *testing.T) {
+	christmas := time.Date(2016, 12, 25, 15, 30, 0, 0, time.UTC)
+	b := BlackoutDates(time.UTC, christmas)
+	end, ok := b.Blackout(christmas)
+	want := time.Date(2016, 12, 26, 0, 0, 0, 0, time.UTC)
+	if !ok || !end.Equal(want) {
+		t.Errorf("b.Blackout() = %v, %v WANT %v, %v", end, ok, want, true)
+	}
+	if _, ok := b.Blackout(want); ok {
+		t.Errorf("b.Blackout(%v) = %v WANT %v", want, ok, false)
+	}
+}
+
+func TestCalendars_Blackout(t *testing.T) {
+	start := time.Date(2016, 12, 25, 0, 0, 0, 0, time.UTC)
+	c := Calendars{
+		Blackouts{{start, start.Add(time.Hour)}},
+		Blackouts{{start, start.Add(2 * time.Hour)}},
+	}
+	if end, ok := c.Blackout(start); !ok || !end.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("c.Blackout() = %v, %v WANT %v, %v", end, ok, start.Add(2*time.Hour), true)
+	}
+	if _, ok := c.Blackout(start.Add(-1)); ok {
+		t.Errorf("c.Blackout() ok = %v WANT %v", ok, false)
+	}
+}
+
+func TestTimeQueue_blackoutEnd(t *testing.T) {
+	start := time.Date(2016, 12, 25, 0, 0, 0, 0, time.UTC)
+	q := New(WithCalendar(Blackouts{
+		{start, start.Add(time.Hour)},
+		{start.Add(time.Hour), start.Add(2 * time.Hour)},
+	}))
+	if end, ok := q.blackoutEnd(start.Add(time.Minute)); !ok || !end.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("q.blackoutEnd() = %v, %v WANT %v, %v", end, ok, start.Add(2*time.Hour), true)
+	}
+	if _, ok := New().blackoutEnd(start); ok {
+		t.Errorf("New().blackoutEnd() ok = %v WANT %v", ok, false)
+	}
+}
+
+func TestTimeQueue_onWake_blackout(t *testing.T) {
+	now := time.Now()
+	q := New(WithCalendar(Blackouts{{now.Add(-time.Hour), now.Add(time.Hour)}}))
+	q.Push(now.Add(-time.Minute), 0)
+	q.onWake(now)
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+	if wake := q.wakeTime(now.Add(-time.Minute), 1); !wake.Equal(now.Add(time.Hour)) {
+		t.Errorf("q.wakeTime() = %v WANT %v", wake, now.Add(time.Hour))
+	}
+}
@@ -0,0 +1,126 @@
+package timequeue
+
+import (
+	"sync"
+	"time"
+)
+
+//CancelFunc cancels a function scheduled with ScheduleFunc().
+//It returns true if the function had not yet been released to run, in which case
+//it never runs.
+type CancelFunc func() bool
+
+//ScheduleFunc pushes a Message at at whose release calls fn with a copy of the
+//Message instead of sending it on Messages(). It is a replacement for
+//time.AfterFunc() that uses no timer or go-routine per function.
+//
+//All functions scheduled on q are called one at a time, in release order, on a
+//single go-routine that only runs while there are functions to call. A function
+//that blocks therefore delays all those released after it; long running work
+//should be started on its own go-routine.
+//
+//The Message is like any other in q, e.g. it may be held or removed, and it
+//is only released while q is running or advanced manually. Removing it without
+//releasing it, e.g. with Clear() or Pop(false), cancels fn.
+func (q *TimeQueue) ScheduleFunc(at time.Time, fn func(Message)) CancelFunc {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	message := &Message{
+		Time: at,
+		fn:   fn,
+	}
+	q.pushStored(message)
+	q.afterHeapUpdate()
+	return func() bool {
+		return q.Remove(message, false)
+	}
+}
+
+//callbacks runs the functions of released Messages pushed with ScheduleFunc().
+type callbacks struct {
+	//protects pending, running, and calls.
+	lock sync.Mutex
+	//the released Messages whose functions have not been called, in release order.
+	pending []*Message
+	//true if a go-routine is calling the pending functions.
+	running bool
+	//counts the functions that have been queued and not yet returned.
+	calls inFlight
+}
+
+//run queues the function of message to be called and starts the go-routine that
+//calls them if it is not running.
+func (c *callbacks) run(message *Message) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pending = append(c.pending, message)
+	c.calls.add()
+	if !c.running {
+		c.running = true
+		go c.drain()
+	}
+}
+
+//drain calls the pending functions in order until there are none.
+func (c *callbacks) drain() {
+	for {
+		c.lock.Lock()
+		if len(c.pending) == 0 {
+			c.running = false
+			c.lock.Unlock()
+			return
+		}
+		message := c.pending[0]
+		c.pending[0] = nil
+		c.pending = c.pending[1:]
+		c.lock.Unlock()
+		message.fn(*message)
+		c.lock.Lock()
+		c.calls.done()
+		c.lock.Unlock()
+	}
+}
+
+//idle returns a channel that is closed once every queued function has returned.
+func (c *callbacks) idle() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.calls.wait()
+}
+
+//inFlight counts the function calls that have been queued and not yet returned,
+//and notifies waiters when there are none. Unlike a sync.WaitGroup, calls may be
+//added while it is waited on. It must be protected by the lock of its owner.
+type inFlight struct {
+	count int
+	//closed when count reaches zero.
+	idle []chan struct{}
+}
+
+//add counts a queued call.
+func (f *inFlight) add() {
+	f.count++
+}
+
+//done counts a call that returned and notifies the waiters if there are none left.
+func (f *inFlight) done() {
+	f.count--
+	if f.count > 0 {
+		return
+	}
+	for _, idle := range f.idle {
+		close(idle)
+	}
+	f.idle = nil
+}
+
+//wait returns a channel that is closed once there are no calls in flight.
+func (f *inFlight) wait() <-chan struct{} {
+	idle := make(chan struct{})
+	if f.count == 0 {
+		close(idle)
+	} else {
+		f.idle = append(f.idle, idle)
+	}
+	return idle
+}
@@ -0,0 +1,63 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_ScheduleFunc(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	now := time.Now()
+	called := make(chan string, 3)
+	record := func(name string) func(Message) {
+		return func(message Message) {
+			called <- name
+		}
+	}
+	q.ScheduleFunc(now.Add(20*time.Millisecond), record("second"))
+	q.ScheduleFunc(now.Add(10*time.Millisecond), record("first"))
+	cancel := q.ScheduleFunc(now.Add(15*time.Millisecond), record("canceled"))
+	if !cancel() {
+		t.Errorf("cancel() = %v WANT %v", false, true)
+	}
+	if cancel() {
+		t.Errorf("cancel() = %v WANT %v", true, false)
+	}
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-called:
+			if got != want {
+				t.Errorf("called = %v WANT %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v was not called", want)
+		}
+	}
+	select {
+	case message := <-q.Messages():
+		t.Errorf("q.Messages() received %v WANT nothing", message)
+	case got := <-called:
+		t.Errorf("called = %v WANT nothing", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestTimeQueue_ScheduleFunc_manual(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start), WithAckMode())
+	called := make(chan time.Time, 1)
+	q.ScheduleFunc(start.Add(time.Minute), func(message Message) {
+		called <- message.Time
+	})
+	if released := q.Advance(start.Add(time.Minute)); len(released) != 1 {
+		t.Fatalf("len(q.Advance()) = %v WANT %v", len(released), 1)
+	}
+	if got := <-called; !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("message.Time = %v WANT %v", got, start.Add(time.Minute))
+	}
+	if unacked := q.Unacked(); unacked != 0 {
+		t.Errorf("q.Unacked() = %v WANT %v", unacked, 0)
+	}
+}
@@ -0,0 +1,53 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//PushAfterRelease creates a Message with Data data that is pushed to q d after
+//parent is actually released, rather than d after parent's Time. This is useful
+//when parent may be postponed, e.g. by a hold, budget, or blackout.
+//
+//The created Message is returned but is not in q until parent is released. Its
+//ParentID is the ID of parent. If parent is removed from q without being
+//released, then the created Message is never pushed.
+//parent must be in q or itself be waiting on a release from PushAfterRelease(),
+//otherwise ErrMessageNotQueued is returned.
+func (q *TimeQueue) PushAfterRelease(parent *Message, d time.Duration, data interface{}) (*Message, error) {
+	if parent == nil {
+		return nil, ErrNilMessage
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if !q.contains(parent) && !parent.awaiting {
+		return nil, ErrMessageNotQueued
+	}
+	child := &Message{
+		Data:       data,
+		ID:         newMessageID(),
+		ParentID:   parent.ID,
+		TraceID:    parent.TraceID,
+		afterDelay: d,
+		awaiting:   true,
+	}
+	parent.children = append(parent.children, child)
+	return child, nil
+}
+
+//contains returns whether or not message is in q.
+//It should only be called when q is locked.
+func (q *TimeQueue) contains(message *Message) bool {
+	return q.isStored(message) || (message.mh != nil && message.mh == q.heldMessages)
+}
+
+//pushChildren pushes all Messages waiting on the release of parent, which was
+//released at releaseTime.
+//It should only be called when q is locked.
+func (q *TimeQueue) pushChildren(parent *Message, releaseTime time.Time) {
+	for _, child := range parent.children {
+		child.Time = releaseTime.Add(child.afterDelay)
+		child.awaiting = false
+		q.pushStored(child)
+	}
+	parent.children = nil
+}
@@ -0,0 +1,65 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_PushAfterRelease(t *testing.T) {
+	q := New()
+	parent := q.Push(time.Now().Add(-time.Hour), "parent")
+	child, err := q.PushAfterRelease(parent, time.Hour, "child")
+	if err != nil {
+		t.Fatalf("q.PushAfterRelease() = %v WANT nil", err)
+	}
+	grandchild, err := q.PushAfterRelease(child, time.Minute, "grandchild")
+	if err != nil {
+		t.Fatalf("q.PushAfterRelease(child) = %v WANT nil", err)
+	}
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+	if child.ParentID != parent.ID || grandchild.ParentID != child.ID || child.ID == "" {
+		t.Errorf("child.ParentID, grandchild.ParentID = %q, %q WANT %q, %q", child.ParentID, grandchild.ParentID, parent.ID, child.ID)
+	}
+
+	before := time.Now()
+	q.Pop(true)
+	<-q.Messages()
+	if !q.contains(child) {
+		t.Fatalf("child not in q after parent release")
+	}
+	if child.Time.Before(before.Add(time.Hour)) {
+		t.Errorf("child.Time = %v WANT at least %v", child.Time, before.Add(time.Hour))
+	}
+	if q.contains(grandchild) {
+		t.Errorf("grandchild in q before child release")
+	}
+
+	q.Pop(true)
+	<-q.Messages()
+	if !q.contains(grandchild) {
+		t.Errorf("grandchild not in q after child release")
+	}
+}
+
+func TestTimeQueue_PushAfterRelease_removedParent(t *testing.T) {
+	q := New()
+	parent := q.Push(time.Now(), "parent")
+	q.PushAfterRelease(parent, 0, "child")
+	q.Remove(parent, false)
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_PushAfterRelease_errors(t *testing.T) {
+	q := New()
+	if _, err := q.PushAfterRelease(nil, 0, nil); err != ErrNilMessage {
+		t.Errorf("q.PushAfterRelease(nil) = %v WANT %v", err, ErrNilMessage)
+	}
+	other := New().Push(time.Now(), nil)
+	if _, err := q.PushAfterRelease(other, 0, nil); err != ErrMessageNotQueued {
+		t.Errorf("q.PushAfterRelease(other) = %v WANT %v", err, ErrMessageNotQueued)
+	}
+}
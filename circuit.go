@@ -0,0 +1,174 @@
+package timequeue
+
+import (
+	"sync"
+	"time"
+)
+
+//CircuitHoldName is the name of the selective hold placed on a TimeQueue by a
+//Dispatcher while its circuit breaker is open.
+const CircuitHoldName = "circuit-breaker"
+
+//CircuitActor is the actor recorded in the AuditLog() for holds placed and
+//released by a circuit breaker.
+const CircuitActor = "circuit-breaker"
+
+//CircuitBreaker configures a circuit breaker around a Dispatcher's Handler.
+//See WithCircuitBreaker().
+type CircuitBreaker struct {
+	//Window is the number of most recent Handler results used to calculate the
+	//failure rate. The breaker does not open until Window results are known.
+	Window int
+	//FailureRate is the fraction, in (0, 1], of failed results in the Window at
+	//which the breaker opens.
+	FailureRate float64
+	//CoolOff is the duration the breaker stays open before letting Messages
+	//through again.
+	CoolOff time.Duration
+}
+
+//WithCircuitBreaker wraps a Dispatcher's Handler with a circuit breaker.
+//
+//When the failure rate of the most recent results reaches cb.FailureRate, the
+//breaker opens: all Messages in the TimeQueue are held with a selective hold named
+//CircuitHoldName, and workers stop receiving Messages. After cb.CoolOff, the hold
+//is released and the breaker is half-open. The first result in the half-open
+//state closes the breaker if it succeeded or opens it again if it failed.
+//
+//Errors classified with Fatal() are caused by the Message rather than by the
+//Handler's downstream and are not counted as failures.
+func WithCircuitBreaker(cb CircuitBreaker) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.circuitBreaker = &cb
+	}
+}
+
+//circuitState is the state of a breaker.
+type circuitState int
+
+//Possible circuitStates.
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+//breaker is the running state of a CircuitBreaker.
+type breaker struct {
+	config CircuitBreaker
+	q      *TimeQueue
+
+	lock *sync.Mutex
+	//ring of the most recent results. true means failed.
+	results []bool
+	//the index in results to write next and the number of results written.
+	next, count int
+	//the number of true values in results.
+	failures int
+	state    circuitState
+	//closed when the breaker is not open. replaced when the breaker opens.
+	ready chan struct{}
+}
+
+//newBreaker creates a closed breaker for q with config.
+func newBreaker(q *TimeQueue, config CircuitBreaker) *breaker {
+	if config.Window < 1 {
+		config.Window = 1
+	}
+	ready := make(chan struct{})
+	close(ready)
+	return &breaker{
+		config:  config,
+		q:       q,
+		lock:    &sync.Mutex{},
+		results: make([]bool, config.Window),
+		state:   circuitClosed,
+		ready:   ready,
+	}
+}
+
+//isOpen returns true if b is open.
+func (b *breaker) isOpen() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state == circuitOpen
+}
+
+//readyChan returns a channel that is closed when b is not open.
+func (b *breaker) readyChan() <-chan struct{} {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.ready
+}
+
+//record adds the result of a Handler call with err to b, opening or closing b as
+//needed.
+func (b *breaker) record(err error) {
+	failed := err != nil && !IsFatal(err)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		return
+	case circuitHalfOpen:
+		if failed {
+			b.open()
+		} else {
+			b.state = circuitClosed
+		}
+		return
+	}
+
+	if b.count == len(b.results) && b.results[b.next] {
+		b.failures--
+	}
+	b.results[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.results)
+	if b.count < len(b.results) {
+		b.count++
+	}
+	if b.count == len(b.results) && float64(b.failures)/float64(b.count) >= b.config.FailureRate {
+		b.open()
+	}
+}
+
+//open opens b, holds all Messages in b.q, and schedules b to become half-open.
+//It should only be called when b is locked.
+func (b *breaker) open() {
+	b.state = circuitOpen
+	b.ready = make(chan struct{})
+	b.next, b.count, b.failures = 0, 0, 0
+	for i := range b.results {
+		b.results[i] = false
+	}
+	b.q.administer(CircuitActor, AuditHold, CircuitHoldName, func() error {
+		b.q.holdMatching(CircuitHoldName, "circuit breaker open", func(message *Message) bool {
+			return true
+		})
+		return nil
+	})
+	time.AfterFunc(b.config.CoolOff, b.halfOpen)
+}
+
+//halfOpen releases the hold placed by open() and lets Messages through to test
+//the Handler's downstream.
+func (b *breaker) halfOpen() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.state = circuitHalfOpen
+	close(b.ready)
+	b.q.administer(CircuitActor, AuditRelease, CircuitHoldName, func() error {
+		b.q.releaseMatching(CircuitHoldName)
+		return nil
+	})
+}
+
+//CircuitOpen returns true if d was given WithCircuitBreaker() and the breaker is
+//currently open.
+func (d *Dispatcher) CircuitOpen() bool {
+	return d.breaker != nil && d.breaker.isOpen()
+}
@@ -0,0 +1,92 @@
+package timequeue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker_record(t *testing.T) {
+	q := New()
+	b := newBreaker(q, CircuitBreaker{Window: 4, FailureRate: 0.5, CoolOff: time.Hour})
+	failure := errors.New("failure")
+
+	results := []error{nil, Fatal(failure), nil, failure}
+	for _, err := range results {
+		b.record(err)
+		if b.isOpen() {
+			t.Fatalf("b.isOpen() after %v = true WANT false", err)
+		}
+	}
+	b.record(failure)
+	if !b.isOpen() {
+		t.Fatalf("b.isOpen() = false WANT true")
+	}
+	if _, ok := q.Stats().Holds[CircuitHoldName]; !ok {
+		t.Errorf("q.Stats().Holds missing %q", CircuitHoldName)
+	}
+	select {
+	case <-b.readyChan():
+		t.Errorf("b.readyChan() closed WANT open")
+	default:
+	}
+	entries := q.AuditLog()
+	if last := entries[len(entries)-1]; last.Actor != CircuitActor || last.Action != AuditHold {
+		t.Errorf("last audit entry = %+v WANT actor %v action %v", last, CircuitActor, AuditHold)
+	}
+}
+
+func TestBreaker_halfOpen(t *testing.T) {
+	failure := errors.New("failure")
+	tests := []struct {
+		err  error
+		open bool
+	}{
+		{nil, false},
+		{failure, true},
+	}
+	for _, test := range tests {
+		q := New()
+		b := newBreaker(q, CircuitBreaker{Window: 1, FailureRate: 1, CoolOff: time.Millisecond})
+		b.record(failure)
+		<-b.readyChan()
+		if _, ok := q.Stats().Holds[CircuitHoldName]; ok {
+			t.Errorf("q.Stats().Holds has %q after cool off", CircuitHoldName)
+		}
+		b.record(test.err)
+		if open := b.isOpen(); open != test.open {
+			t.Errorf("b.isOpen() after half-open %v = %v WANT %v", test.err, open, test.open)
+		}
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	handled := make(chan interface{}, 4)
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		handled <- message.Data
+		return errors.New("downstream")
+	}, WithCircuitBreaker(CircuitBreaker{Window: 1, FailureRate: 1, CoolOff: time.Hour}))
+
+	q.Push(time.Now(), 0)
+	<-handled
+	for !d.CircuitOpen() {
+		time.Sleep(time.Millisecond)
+	}
+	q.Push(time.Now(), 1)
+	select {
+	case data := <-handled:
+		t.Errorf("handled %v while circuit open", data)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+	cancel()
+	d.Wait()
+}
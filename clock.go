@@ -0,0 +1,105 @@
+package timequeue
+
+import (
+	"sort"
+	"time"
+)
+
+//clockDomain is a virtual clock and the Messages scheduled against it.
+type clockDomain struct {
+	now     time.Time
+	storage *heapStorage
+}
+
+//AdvanceClock sets the virtual clock named name to now and releases every Message
+//whose Clock is name and whose Time is before or equal to now. The released
+//Messages are sent on Messages(), or to their Subscribers or functions, like any
+//other, and are also returned in release order.
+//
+//A virtual clock starts at the zero Time and only moves when advanced, so Messages
+//scheduled against it are never released by the passing of real time. This lets
+//replayed events advanced by a simulation share a TimeQueue, and its consumers,
+//with live events scheduled against the TimeQueue's own clock.
+//Virtual clocks never move backwards: a now before the current time of the clock
+//releases nothing.
+//
+//Messages on virtual clocks are released whether or not q is running or held, and
+//are not subject to WithBudget() or WithCalendar(). They are not written to a Store
+//or included in snapshots or handoffs, but otherwise behave like other Messages,
+//e.g. they count towards Size() and may be removed with Remove() and PopAll().
+func (q *TimeQueue) AdvanceClock(name string, now time.Time) []*Message {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	domain := q.clockDomain(name)
+	domain.now = laterOf(domain.now, now)
+	//Messages at exactly now are due.
+	until := domain.now.Add(time.Nanosecond)
+	result := []*Message{}
+	for message := domain.storage.PopDue(until); message != nil; message = domain.storage.PopDue(until) {
+		message.storage = nil
+		q.markRemoved(message)
+		result = append(result, message)
+	}
+	q.releaseCopyToChan(result)
+	q.afterHeapUpdate()
+	return result
+}
+
+//ClockNow returns the current time of the virtual clock named name, which is the
+//zero Time until it is advanced with AdvanceClock().
+func (q *TimeQueue) ClockNow(name string) time.Time {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.clockDomain(name).now
+}
+
+//clockDomain returns the clockDomain named name, creating it if it does not exist.
+//It should only be called when q is locked.
+func (q *TimeQueue) clockDomain(name string) *clockDomain {
+	domain, ok := q.clocks[name]
+	if !ok {
+		domain = &clockDomain{storage: newHeapStorage()}
+		q.clocks[name] = domain
+	}
+	return domain
+}
+
+//storageFor returns the Storage that message is kept in while it is pending, which
+//is q.storage unless message has a Clock.
+//It should only be called when q is locked.
+func (q *TimeQueue) storageFor(message *Message) Storage {
+	if message.Clock == "" {
+		return q.storage
+	}
+	return q.clockDomain(message.Clock).storage
+}
+
+//clockedLen returns the number of Messages on virtual clocks.
+//It should only be called when q is locked.
+func (q *TimeQueue) clockedLen() int {
+	result := 0
+	for _, domain := range q.clocks {
+		result += domain.storage.Len()
+	}
+	return result
+}
+
+//popClocked removes and returns every Message on a virtual clock, ordered by the
+//name of their Clocks and then by Time.
+//It should only be called when q is locked.
+func (q *TimeQueue) popClocked() []*Message {
+	names := make([]string, 0, len(q.clocks))
+	for name := range q.clocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []*Message{}
+	for _, name := range names {
+		storage := q.clocks[name].storage
+		for message := storage.Peek(); message != nil; message = storage.Peek() {
+			q.removeStored(message)
+			result = append(result, message)
+		}
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_AdvanceClock(t *testing.T) {
+	q := New(WithCapacity(4))
+	q.Start()
+	defer q.Stop()
+	replayed := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	first := &Message{Time: replayed, Data: "first", Clock: "replay"}
+	second := &Message{Time: replayed.Add(time.Minute), Data: "second", Clock: "replay"}
+	removed := &Message{Time: replayed, Data: "removed", Clock: "replay"}
+	for _, message := range []*Message{second, first, removed} {
+		if err := q.PushMessage(message); err != nil {
+			t.Fatalf("q.PushMessage() = %v WANT nil", err)
+		}
+	}
+	live := q.Push(time.Now(), "live")
+	if message := <-q.Messages(); message != live {
+		t.Errorf("q.Messages() = %v WANT %v", message, live)
+	}
+	if q.Size() != 3 || !q.ClockNow("replay").IsZero() {
+		t.Errorf("q.Size(), q.ClockNow() = %v, %v WANT 3, zero", q.Size(), q.ClockNow("replay"))
+	}
+	if !q.Remove(removed, false) || q.Remove(removed, false) {
+		t.Errorf("q.Remove(removed) WANT true then false")
+	}
+
+	released := q.AdvanceClock("replay", replayed)
+	if len(released) != 1 || released[0] != first || <-q.Messages() != first {
+		t.Errorf("q.AdvanceClock() = %v WANT %v", released, first)
+	}
+	if stats := q.Stats(); stats.MaxLag > time.Second {
+		t.Errorf("stats.MaxLag = %v WANT the lag of its Clock", stats.MaxLag)
+	}
+	if released := q.AdvanceClock("replay", replayed.Add(-time.Hour)); len(released) != 0 || !q.ClockNow("replay").Equal(replayed) {
+		t.Errorf("q.AdvanceClock(backwards) = %v WANT none", released)
+	}
+	if released := q.AdvanceClock("other", replayed.Add(time.Hour)); len(released) != 0 {
+		t.Errorf("q.AdvanceClock(other) = %v WANT none", released)
+	}
+	if messages := q.PopAll(false); len(messages) != 1 || messages[0] != second || q.Size() != 0 {
+		t.Errorf("q.PopAll() = %v WANT %v", messages, second)
+	}
+	if err := q.ConsistencyCheck(); err != nil {
+		t.Errorf("q.ConsistencyCheck() = %v WANT nil", err)
+	}
+}
@@ -0,0 +1,171 @@
+package timequeue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gogolfing/timequeue/internal/record"
+)
+
+//ErrUnregisteredType is returned by MarshalData() and UnmarshalData() for types
+//and names that were not registered with RegisterType().
+var ErrUnregisteredType = errors.New("timequeue: unregistered data type")
+
+//Codec encodes and decodes the Data of Messages for persistence. See
+//RegisterType().
+type Codec interface {
+	//Encode returns the encoding of value.
+	Encode(value interface{}) ([]byte, error)
+	//Decode decodes data, which was returned from Encode(), into a new value of
+	//type t.
+	Decode(data []byte, t reflect.Type) (interface{}, error)
+}
+
+//The Codecs provided by this package.
+var (
+	//GobCodec encodes values with encoding/gob.
+	GobCodec Codec = gobCodec{}
+	//JSONCodec encodes values with encoding/json.
+	JSONCodec Codec = jsonCodec{}
+)
+
+//gobCodec is the type of GobCodec.
+type gobCodec struct{}
+
+//Encode encodes value with encoding/gob.
+func (gobCodec) Encode(value interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//Decode decodes data into a new value of type t with encoding/gob.
+func (gobCodec) Decode(data []byte, t reflect.Type) (interface{}, error) {
+	value := reflect.New(t)
+	if err := gob.NewDecoder(bytes.NewReader(data)).DecodeValue(value); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+//jsonCodec is the type of JSONCodec.
+type jsonCodec struct{}
+
+//Encode encodes value with encoding/json.
+func (jsonCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+//Decode decodes data into a new value of type t with encoding/json.
+func (jsonCodec) Decode(data []byte, t reflect.Type) (interface{}, error) {
+	value := reflect.New(t)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+//registeredType is a type registered with RegisterType().
+type registeredType struct {
+	name  string
+	t     reflect.Type
+	codec Codec
+}
+
+//codecs holds every type registered with RegisterType() by name and by type.
+var codecs = struct {
+	lock  sync.RWMutex
+	names map[string]*registeredType
+	types map[reflect.Type]*registeredType
+}{
+	names: map[string]*registeredType{},
+	types: map[reflect.Type]*registeredType{},
+}
+
+//RegisterType registers the concrete type of value under name with codec, so that
+//Data of that type is encoded with codec wherever Messages are persisted or
+//transferred: snapshots, handoffs, WALs, MmapStorage, and the Stores in the
+//subpackages of this package. name is persisted with the encoded Data, so it must
+//stay the same for as long as any encoded Data may be read, even if the type is
+//renamed.
+//
+//Data of types that are not registered is encoded with encoding/gob as an
+//interface value and must be registered with gob.Register() instead.
+//
+//RegisterType is usually called from init functions. It panics if name or the
+//type of value is already registered differently.
+//	type Email struct {
+//		To string
+//	}
+//
+//	func init() {
+//		timequeue.RegisterType("email.v1", Email{}, timequeue.JSONCodec)
+//	}
+func RegisterType(name string, value interface{}, codec Codec) {
+	t := reflect.TypeOf(value)
+	codecs.lock.Lock()
+	defer codecs.lock.Unlock()
+	if existing, ok := codecs.names[name]; ok && existing.t != t {
+		panic(fmt.Sprintf("timequeue: type name %q registered for %v and %v", name, existing.t, t))
+	}
+	if existing, ok := codecs.types[t]; ok && existing.name != name {
+		panic(fmt.Sprintf("timequeue: type %v registered as %q and %q", t, existing.name, name))
+	}
+	registered := &registeredType{name: name, t: t, codec: codec}
+	codecs.names[name] = registered
+	codecs.types[t] = registered
+}
+
+//MarshalData encodes data with the Codec of its type and returns the name its
+//type is registered under. ErrUnregisteredType is returned if the type of data
+//was not registered with RegisterType().
+func MarshalData(data interface{}) (string, []byte, error) {
+	codecs.lock.RLock()
+	registered, ok := codecs.types[reflect.TypeOf(data)]
+	codecs.lock.RUnlock()
+	if !ok {
+		return "", nil, ErrUnregisteredType
+	}
+	encoded, err := registered.codec.Encode(data)
+	return registered.name, encoded, err
+}
+
+//UnmarshalData decodes data returned from MarshalData() with the type and Codec
+//registered under name. ErrUnregisteredType is returned if name was not
+//registered with RegisterType().
+func UnmarshalData(name string, data []byte) (interface{}, error) {
+	codecs.lock.RLock()
+	registered, ok := codecs.names[name]
+	codecs.lock.RUnlock()
+	if !ok {
+		return nil, ErrUnregisteredType
+	}
+	return registered.codec.Decode(data, registered.t)
+}
+
+//EncodeMessage encodes message with encoding/gob in the form that is shared by
+//the Stores in the subpackages of timequeue. Its fields and Data are encoded as
+//in a handoff. See HandoffTo().
+func EncodeMessage(message *Message) ([]byte, error) {
+	r, err := newRecord(message)
+	if err != nil {
+		return nil, err
+	}
+	return r.Encode()
+}
+
+//DecodeMessage decodes a new Message from value returned by EncodeMessage().
+func DecodeMessage(value []byte) (*Message, error) {
+	r, err := record.Decode(value)
+	if err != nil {
+		return nil, err
+	}
+	return recordMessage(r)
+}
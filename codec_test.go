@@ -0,0 +1,90 @@
+package timequeue
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type codecTestEmail struct {
+	To      string
+	private string
+}
+
+type codecTestCount int
+
+func init() {
+	RegisterType("codec_test.email", codecTestEmail{}, JSONCodec)
+	RegisterType("codec_test.count", codecTestCount(0), GobCodec)
+}
+
+func TestMarshalData(t *testing.T) {
+	tests := []struct {
+		data interface{}
+		name string
+		err  error
+	}{
+		{codecTestEmail{To: "gopher"}, "codec_test.email", nil},
+		{codecTestCount(3), "codec_test.count", nil},
+		{"unregistered", "", ErrUnregisteredType},
+		{nil, "", ErrUnregisteredType},
+	}
+	for i, test := range tests {
+		name, data, err := MarshalData(test.data)
+		if name != test.name || err != test.err {
+			t.Errorf("%v: MarshalData() = %v, %v WANT %v, %v", i, name, err, test.name, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if decoded, err := UnmarshalData(name, data); !reflect.DeepEqual(decoded, test.data) || err != nil {
+			t.Errorf("%v: UnmarshalData() = %v, %v WANT %v, nil", i, decoded, err, test.data)
+		}
+	}
+	if _, err := UnmarshalData("unknown", nil); err != ErrUnregisteredType {
+		t.Errorf("UnmarshalData(unknown) = %v WANT %v", err, ErrUnregisteredType)
+	}
+}
+
+func TestRegisterType_conflict(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		panic bool
+	}{
+		{"codec_test.email", codecTestEmail{}, false},
+		{"codec_test.email", codecTestCount(0), true},
+		{"codec_test.other", codecTestEmail{}, true},
+	}
+	for i, test := range tests {
+		func() {
+			defer func() {
+				if recovered := recover(); (recovered != nil) != test.panic {
+					t.Errorf("%v: RegisterType() panic = %v WANT %v", i, recovered, test.panic)
+				}
+			}()
+			RegisterType(test.name, test.value, JSONCodec)
+		}()
+	}
+}
+
+func TestRegisterType_snapshot(t *testing.T) {
+	now := time.Now()
+	q := New()
+	q.Push(now, codecTestEmail{To: "gopher", private: "dropped by JSON"})
+	q.Push(now.Add(time.Second), codecTestCount(7))
+	buf := &bytes.Buffer{}
+	if err := q.Snapshot(buf); err != nil {
+		t.Fatalf("Snapshot() = %v WANT nil", err)
+	}
+	restored, err := NewFromSnapshot(buf)
+	if err != nil {
+		t.Fatalf("NewFromSnapshot() = %v WANT nil", err)
+	}
+	messages := restored.PopAll(false)
+	if len(messages) != 2 || messages[0].Data != (codecTestEmail{To: "gopher"}) || messages[1].Data != codecTestCount(7) {
+		t.Errorf("restored = %v WANT email, 7", messages)
+	}
+}
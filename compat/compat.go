@@ -0,0 +1,125 @@
+package compat
+
+import (
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//DefaultCapacity is the default capacity used for Messages() channels in New().
+const DefaultCapacity = timequeue.DefaultCapacity
+
+//Message is the Message of a TimeQueue.
+//The v1 fields, Time and Data, are unchanged, and the fields added since v1 may be
+//ignored.
+type Message = timequeue.Message
+
+//TimeQueue is a queue of Messages that releases its Messages when their Time
+//fields pass. It has the API of a v1 TimeQueue.
+//
+//One of New(), NewCapacity(), or Wrap() should be used to create a TimeQueue. A
+//zero-value TimeQueue is not in a valid or working state.
+type TimeQueue struct {
+	q *timequeue.TimeQueue
+}
+
+//New creates a new *TimeQueue with a call to NewCapacity(DefaultCapacity).
+func New() *TimeQueue {
+	return NewCapacity(DefaultCapacity)
+}
+
+//NewCapacity creates a new *TimeQueue where the channel returned from Messages()
+//has the capacity given by capacity.
+//The new TimeQueue is in the stopped state and has no Messages in it.
+func NewCapacity(capacity int) *TimeQueue {
+	return Wrap(timequeue.New(timequeue.WithCapacity(capacity)))
+}
+
+//Wrap creates a *TimeQueue that uses q. Calls to the returned TimeQueue and q may be
+//mixed freely.
+func Wrap(q *timequeue.TimeQueue) *TimeQueue {
+	return &TimeQueue{q: q}
+}
+
+//Queue returns the timequeue.TimeQueue that q uses.
+func (q *TimeQueue) Queue() *timequeue.TimeQueue {
+	return q.q
+}
+
+//Push creates and adds a Message to q with t and data. The created Message is returned.
+func (q *TimeQueue) Push(t time.Time, data interface{}) *Message {
+	return q.q.Push(t, data)
+}
+
+//Peek returns (without removing) the Time and Data fields from the earliest
+//Message in q.
+//If q is empty, then the zero Time and nil are returned.
+func (q *TimeQueue) Peek() (time.Time, interface{}) {
+	return q.q.Peek()
+}
+
+//PeekMessage returns (without removing) the earliest Message in q or nil if q
+//is empty.
+func (q *TimeQueue) PeekMessage() *Message {
+	return q.q.PeekMessage()
+}
+
+//Pop removes and returns the earliest Message in q or nil if q is empty.
+//If release is true, then the Message is also sent on the channel returned from
+//Messages().
+func (q *TimeQueue) Pop(release bool) *Message {
+	return q.q.Pop(release)
+}
+
+//PopAll removes and returns a slice of all Messages in q.
+//The returned slice is non-nil but empty if q is empty. If release is true, then
+//the Messages are also sent on the channel returned from Messages().
+func (q *TimeQueue) PopAll(release bool) []*Message {
+	return q.q.PopAll(release)
+}
+
+//PopAllUntil removes and returns a slice of Messages in q with Time fields before,
+//but not equal to, until.
+//The returned slice is non-nil but empty if q is empty. If release is true, then
+//the Messages are also sent on the channel returned from Messages().
+func (q *TimeQueue) PopAllUntil(until time.Time, release bool) []*Message {
+	return q.q.PopAllUntil(until, release)
+}
+
+//Remove removes message from q and returns whether or not it was in q.
+//If release is true and message was removed, then it is also sent on the channel
+//returned from Messages().
+func (q *TimeQueue) Remove(message *Message, release bool) bool {
+	return q.q.Remove(message, release)
+}
+
+//Messages returns the receive only channel that all Messages are released on.
+//The returned channel is the same instance on every call.
+func (q *TimeQueue) Messages() <-chan *Message {
+	return q.q.Messages()
+}
+
+//Size returns the number of Messages in q.
+func (q *TimeQueue) Size() int {
+	return q.q.Size()
+}
+
+//Start spawns a new go-routine to listen for wake times of Messages and release
+//them when their times pass.
+//If q is already running, then Start is a nop.
+func (q *TimeQueue) Start() {
+	q.q.Start()
+}
+
+//IsRunning returns whether or not q is running, i.e. between calls to Start()
+//and Stop().
+func (q *TimeQueue) IsRunning() bool {
+	return q.q.IsRunning()
+}
+
+//Stop tells the running go-routine to stop running, so that no more Messages are
+//released.
+//If q is not running, then Stop is a nop.
+func (q *TimeQueue) Stop() {
+	q.q.Stop()
+}
@@ -0,0 +1,60 @@
+package compat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+func TestTimeQueue(t *testing.T) {
+	q := NewCapacity(2)
+	if q.Queue() == nil || q.IsRunning() || q.Size() != 0 {
+		t.Fatalf("NewCapacity() = %v WANT a stopped, empty TimeQueue", q)
+	}
+	now := time.Now()
+	later := q.Push(now.Add(time.Hour), "later")
+	q.Push(now.Add(2*time.Hour), "latest")
+	first := q.Push(now.Add(-time.Hour), "first")
+	if peekTime, data := q.Peek(); !peekTime.Equal(first.Time) || data != "first" || q.PeekMessage() != first {
+		t.Errorf("q.Peek() = %v, %v WANT %v, first", peekTime, data, first.Time)
+	}
+	if message := q.Pop(false); message != first || q.Size() != 2 {
+		t.Errorf("q.Pop() = %v WANT %v", message, first)
+	}
+	if !q.Remove(later, false) || q.Remove(later, false) {
+		t.Errorf("q.Remove(later) WANT true then false")
+	}
+	if messages := q.PopAllUntil(now, false); len(messages) != 0 {
+		t.Errorf("q.PopAllUntil() = %v WANT none", messages)
+	}
+	if messages := q.PopAll(true); len(messages) != 1 || messages[0].Data != "latest" || <-q.Messages() != messages[0] {
+		t.Errorf("q.PopAll() = %v WANT latest released", messages)
+	}
+}
+
+func TestTimeQueue_Start(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	if !q.IsRunning() {
+		t.Errorf("q.IsRunning() = false WANT true")
+	}
+	message := q.Push(time.Now(), 1)
+	if released := <-q.Messages(); released != message {
+		t.Errorf("q.Messages() = %v WANT %v", released, message)
+	}
+	q.Stop()
+	if q.IsRunning() {
+		t.Errorf("q.IsRunning() = true WANT false")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	engine := timequeue.New()
+	q := Wrap(engine)
+	message := q.Push(time.Now(), 1)
+	if engine.PeekMessage() != message || q.Queue() != engine {
+		t.Errorf("engine.PeekMessage() = %v WANT %v", engine.PeekMessage(), message)
+	}
+}
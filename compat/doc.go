@@ -0,0 +1,14 @@
+//Package compat provides the v1 API of package timequeue on top of the current
+//TimeQueue, so that code written against v1 can migrate incrementally.
+//
+//A compat.TimeQueue has only the methods of a v1 TimeQueue, and they behave as they
+//did in v1. Existing call sites only need their import path changed:
+//	q := compat.New()
+//	q.Start()
+//	q.Push(time.Now().Add(time.Minute), "data")
+//	message := <-q.Messages()
+//
+//The wrapped timequeue.TimeQueue is returned from Queue(), so call sites can be
+//moved to the current API one at a time, and Wrap() creates a compat.TimeQueue for
+//a timequeue.TimeQueue created with Options. Both share the same Messages.
+package compat
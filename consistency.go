@@ -0,0 +1,112 @@
+package timequeue
+
+import (
+	"fmt"
+	"strings"
+)
+
+//maxInconsistencies is the number of inconsistencies described by an
+//InconsistencyError. All are counted.
+const maxInconsistencies = 10
+
+//InconsistencyError is returned by ConsistencyCheck() when a TimeQueue has lost
+//track of a Message.
+type InconsistencyError struct {
+	//Count is the number of inconsistencies found.
+	Count int
+	//Problems describes the first inconsistencies found.
+	Problems []string
+}
+
+//Error implements the error interface.
+func (e *InconsistencyError) Error() string {
+	return fmt.Sprintf("timequeue: %d inconsistencies: %s", e.Count, strings.Join(e.Problems, "; "))
+}
+
+//ConsistencyCheck returns nil if every Message pushed to q has been removed or
+//released at most once per push and every pending Message is accounted for.
+//Otherwise it returns an *InconsistencyError.
+//
+//Every method that removes Messages, e.g. Pop(), PopAll(), PopAllUntil(),
+//Remove(), and Clear(), and every release by the running go-routine takes
+//Messages from q while it is locked. They are therefore linearizable with respect
+//to each other and to Push() and Stop(), however calls to them interleave: a
+//Message is either removed by a call or released, and never both or neither.
+//q validates this with the sequence number of every push as Messages are removed
+//and released, and ConsistencyCheck reports any violation since q was created,
+//e.g. a Message that was drained by PopAll(false) and also released.
+//
+//ConsistencyCheck locks q while it visits every pending Message and is intended
+//for tests and occasional audits.
+func (q *TimeQueue) ConsistencyCheck() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	count, problems := q.inconsistencies, append([]string{}, q.problems...)
+	inconsistent := func(format string, args ...interface{}) {
+		count++
+		if len(problems) < maxInconsistencies {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	pending := func(where string) func(message *Message) {
+		return func(message *Message) {
+			if message.removedFor == message.pushSeq {
+				inconsistent("message %v is %v after it was removed", message.ID, where)
+			}
+		}
+	}
+	q.storage.Each(func(message *Message) {
+		if message.storage != q.storage {
+			inconsistent("message %v is stored without a reference to the storage", message.ID)
+		}
+		pending("stored")(message)
+	})
+	for _, message := range q.heldMessages.messages {
+		pending("held")(message)
+	}
+	for _, message := range q.spilled {
+		pending("spilled")(message)
+	}
+	for key, message := range q.keys {
+		if !q.isStored(message) && message.mh != q.heldMessages {
+			inconsistent("key %q indexes message %v that is not pending", key, message.ID)
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return &InconsistencyError{
+		Count:    count,
+		Problems: problems,
+	}
+}
+
+//markRemoved records that message was removed from the pending Messages of q.
+//It should only be called when q is locked.
+func (q *TimeQueue) markRemoved(message *Message) {
+	if message.removedFor == message.pushSeq {
+		q.inconsistent("message %v removed twice", message.ID)
+	}
+	message.removedFor = message.pushSeq
+}
+
+//markReleased records that message was released from q.
+//It should only be called when q is locked.
+func (q *TimeQueue) markReleased(message *Message) {
+	if message.releasedFor == message.pushSeq {
+		q.inconsistent("message %v released twice", message.ID)
+	}
+	if message.removedFor != message.pushSeq {
+		q.inconsistent("message %v released while pending", message.ID)
+	}
+	message.releasedFor = message.pushSeq
+}
+
+//inconsistent records an inconsistency for ConsistencyCheck().
+//It should only be called when q is locked.
+func (q *TimeQueue) inconsistent(format string, args ...interface{}) {
+	q.inconsistencies++
+	if len(q.problems) < maxInconsistencies {
+		q.problems = append(q.problems, fmt.Sprintf(format, args...))
+	}
+}
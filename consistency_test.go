@@ -0,0 +1,106 @@
+package timequeue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_ConsistencyCheck_interleaved(t *testing.T) {
+	q := New(WithCapacity(1000))
+	q.Start()
+	const pushes = 2000
+	lock := &sync.Mutex{}
+	seen := map[*Message]int{}
+	see := func(messages ...*Message) {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, message := range messages {
+			seen[message]++
+		}
+	}
+	done := make(chan struct{})
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		for {
+			select {
+			case message := <-q.Messages():
+				see(message)
+			case <-done:
+				return
+			}
+		}
+	}()
+	wg := &sync.WaitGroup{}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		now := time.Now()
+		for i := 0; i < pushes; i++ {
+			message := q.Push(now.Add(time.Duration(i%10)*time.Millisecond), i)
+			if i%7 == 0 && q.Remove(message, false) {
+				see(message)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			see(q.PopAll(false)...)
+			see(q.PopAllUntil(time.Now(), false)...)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			q.Stop()
+			q.Start()
+		}
+	}()
+	wg.Wait()
+	see(q.PopAll(false)...)
+	q.Stop()
+	//Messages already released may still be on their way to Messages().
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		lock.Lock()
+		n := len(seen)
+		lock.Unlock()
+		if n == pushes {
+			break
+		}
+	}
+	close(done)
+	<-received
+
+	if err := q.ConsistencyCheck(); err != nil {
+		t.Errorf("q.ConsistencyCheck() = %v WANT nil", err)
+	}
+	if len(seen) != pushes {
+		t.Errorf("seen = %v Messages WANT %v", len(seen), pushes)
+	}
+	for message, count := range seen {
+		if count != 1 {
+			t.Errorf("Message %v seen %v times WANT 1", message.Data, count)
+		}
+	}
+}
+
+func TestTimeQueue_ConsistencyCheck(t *testing.T) {
+	q := New()
+	message := q.Push(time.Now(), 0)
+	q.PushMessage(&Message{Time: time.Now(), Key: "key"})
+	if err := q.ConsistencyCheck(); err != nil {
+		t.Errorf("q.ConsistencyCheck() = %v WANT nil", err)
+	}
+
+	q.lock.Lock()
+	q.markRemoved(message)
+	q.afterRelease(message)
+	q.afterRelease(message)
+	q.lock.Unlock()
+	err, ok := q.ConsistencyCheck().(*InconsistencyError)
+	if !ok || err.Count != 2 || len(err.Problems) != 2 {
+		t.Errorf("q.ConsistencyCheck() = %v WANT pending after removal and released twice", err)
+	}
+}
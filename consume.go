@@ -0,0 +1,429 @@
+package timequeue
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+//DefaultWorkers is the number of workers a Dispatcher starts with unless
+//WithWorkers() is given.
+const DefaultWorkers = 1
+
+//DefaultScaleInterval is the interval at which a Dispatcher given WithWorkerBounds()
+//and no Autoscaler scales its workers. It is also used by WithAutoscaler() when
+//its interval is zero or less.
+const DefaultScaleInterval = time.Second
+
+//Handler processes a Message received from a TimeQueue by a Dispatcher.
+type Handler func(ctx context.Context, message *Message) error
+
+//DispatcherOption configures a Dispatcher created with TimeQueue.Consume().
+type DispatcherOption func(*dispatcherConfig)
+
+//dispatcherConfig holds all values that may be set by DispatcherOptions.
+type dispatcherConfig struct {
+	workers    int
+	minWorkers int
+	maxWorkers int
+	errorHook  func(message *Message, err error)
+	autoscale  *autoscale
+	retry      retry
+	deadLetter func(message *Message, err error)
+	//the circuit breaker given to WithCircuitBreaker(). nil if none.
+	circuitBreaker *CircuitBreaker
+	//the processing budget of every Handler call. see WithHandlerTimeout().
+	handlerTimeout time.Duration
+}
+
+//newDispatcherConfig creates a dispatcherConfig with all default values.
+func newDispatcherConfig() dispatcherConfig {
+	return dispatcherConfig{
+		workers: DefaultWorkers,
+	}
+}
+
+//WithWorkers sets the number of workers a Dispatcher starts with.
+//The default is DefaultWorkers.
+func WithWorkers(workers int) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.workers = workers
+	}
+}
+
+//WithWorkerBounds sets the minimum and maximum number of workers a Dispatcher
+//may run. A max of 0 means there is no maximum.
+//All values given to Dispatcher.SetWorkers(), including those returned from an
+//Autoscaler, are clamped to the bounds.
+//
+//If WithAutoscaler() is not also given, then the Dispatcher scales itself every
+//DefaultScaleInterval with LatencyAutoscaler().
+func WithWorkerBounds(min, max int) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.minWorkers = min
+		c.maxWorkers = max
+		if c.autoscale == nil {
+			c.autoscale = newAutoscale(DefaultScaleInterval, DefaultScaleInterval, DefaultScaleInterval, LatencyAutoscaler())
+		}
+	}
+}
+
+//clamp returns workers limited to the bounds in c.
+func (c *dispatcherConfig) clamp(workers int) int {
+	if c.maxWorkers > 0 && workers > c.maxWorkers {
+		workers = c.maxWorkers
+	}
+	if workers < c.minWorkers {
+		workers = c.minWorkers
+	}
+	if workers < 0 {
+		workers = 0
+	}
+	return workers
+}
+
+//WithErrorHook sets a function that is called with every Message for which the
+//Handler returned a non-nil error or panicked.
+//hook is called from the worker go-routine that handled the Message.
+func WithErrorHook(hook func(message *Message, err error)) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.errorHook = hook
+	}
+}
+
+//Dispatcher is a pool of workers that receive Messages released from a TimeQueue
+//and pass them to a Handler.
+//A Dispatcher is created with TimeQueue.Consume().
+type Dispatcher struct {
+	q       *TimeQueue
+	ctx     context.Context
+	handler Handler
+	config  dispatcherConfig
+	//the running circuit breaker. nil if WithCircuitBreaker() was not given.
+	breaker *breaker
+
+	//the total time spent in handler, in nanoseconds, and number of calls since the
+	//last scaling. updated atomically so that handling Messages never contends on lock.
+	latencies *latencyCounters
+
+	//protects stops, latency, and shutdown.
+	lock *sync.Mutex
+	//one channel per running worker. closing a channel stops its worker.
+	stops []chan struct{}
+	//true after Shutdown() is called.
+	shutdown bool
+	//closed by Shutdown() to make workers drain and return.
+	draining chan struct{}
+	//the average handler latency as of the last scaling with any handled Messages.
+	latency time.Duration
+	//tracks all go-routines spawned by the Dispatcher.
+	wg *sync.WaitGroup
+}
+
+//Consume creates a Dispatcher that receives Messages from q.Messages() and calls
+//handler with each of them until ctx is done.
+//If q uses WithOutputs(), then each worker receives from a single output channel,
+//worker i from output i modulo the number of outputs, and there should be at least
+//as many workers as outputs.
+//Consume does not start q. q must be started for Messages to be released to the
+//Dispatcher.
+//	q := timequeue.New()
+//	q.Start()
+//	d := q.Consume(ctx, handler, timequeue.WithWorkers(4))
+//	//push Messages to q.
+//	cancel()
+//	d.Wait()
+func (q *TimeQueue) Consume(ctx context.Context, handler Handler, opts ...DispatcherOption) *Dispatcher {
+	c := newDispatcherConfig()
+	for _, opt := range opts {
+		opt(&c)
+	}
+	d := &Dispatcher{
+		q:         q,
+		ctx:       ctx,
+		handler:   handler,
+		config:    c,
+		latencies: &latencyCounters{},
+		lock:      &sync.Mutex{},
+		draining:  make(chan struct{}),
+		wg:        &sync.WaitGroup{},
+	}
+	if c.circuitBreaker != nil {
+		d.breaker = newBreaker(q, *c.circuitBreaker)
+	}
+	d.SetWorkers(c.workers)
+	if c.autoscale != nil {
+		d.wg.Add(1)
+		go d.runAutoscale(c.autoscale)
+	}
+	return d
+}
+
+//Workers returns the number of workers currently running in d.
+func (d *Dispatcher) Workers() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return len(d.stops)
+}
+
+//SetWorkers starts or stops workers so that workers are running in d.
+//workers is clamped to the bounds given with WithWorkerBounds(), and negative
+//values are treated as 0.
+//Stopped workers finish handling their current Message before returning.
+//SetWorkers is a nop after the context given to Consume() is done or Shutdown() is
+//called.
+func (d *Dispatcher) SetWorkers(workers int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.ctx.Err() != nil || d.shutdown {
+		return
+	}
+	workers = d.config.clamp(workers)
+	for len(d.stops) < workers {
+		stop := make(chan struct{})
+		d.stops = append(d.stops, stop)
+		d.wg.Add(1)
+		go d.runWorker(stop, d.q.output(len(d.stops)-1))
+	}
+	for len(d.stops) > workers {
+		last := len(d.stops) - 1
+		close(d.stops[last])
+		d.stops = d.stops[:last]
+	}
+}
+
+//Wait blocks until all go-routines spawned by d have returned.
+//This happens after the context given to Consume() is done or Shutdown() is called.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+//Shutdown gracefully stops d. Workers stop receiving once every Message already
+//released to them has been handled, including Messages that are still being sent
+//to them, and Shutdown waits for them and all other go-routines spawned by d to
+//return.
+//If ctx is done first, then Shutdown returns ctx.Err() and the workers continue to
+//drain in the background. Cancel the context given to Consume() to stop them
+//without handling the remaining Messages.
+//
+//Shutdown does not stop d's TimeQueue, so Messages released after the workers
+//have drained remain unhandled. Stop the TimeQueue first to drain everything that
+//has been released:
+//	q.Stop()
+//	err := d.Shutdown(ctx)
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.lock.Lock()
+	if !d.shutdown {
+		d.shutdown = true
+		d.stops = nil
+		close(d.draining)
+	}
+	d.lock.Unlock()
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//runWorker receives Messages from messages and handles them until stop is closed
+//or d.ctx is done, or drains them when d is shut down.
+//Messages are not received while d's circuit breaker is open.
+func (d *Dispatcher) runWorker(stop chan struct{}, messages <-chan *Message) {
+	defer d.wg.Done()
+	var retries <-chan *Message
+	if d.config.retry.queue != nil {
+		retries = d.config.retry.queue.Messages()
+	}
+	for {
+		if d.breaker != nil {
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-d.draining:
+				return
+			case <-d.breaker.readyChan():
+			}
+		}
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-d.draining:
+			d.drain(messages, retries)
+			return
+		case message := <-messages:
+			d.handle(message)
+		case message := <-retries:
+			d.handle(message)
+		}
+	}
+}
+
+//drain handles Messages from messages and retries until neither has a Message
+//ready to receive and no released Messages are still being sent to them by d's
+//TimeQueue or its retry queue, or until d.ctx is done.
+func (d *Dispatcher) drain(messages, retries <-chan *Message) {
+	idle, retryIdle := sendsIdle(d.q), sendsIdle(d.config.retry.queue)
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case message := <-messages:
+			d.handle(message)
+		case message := <-retries:
+			d.handle(message)
+		case <-idle:
+			idle = nil
+		case <-retryIdle:
+			retryIdle = nil
+		}
+		if idle != nil || retryIdle != nil {
+			continue
+		}
+		select {
+		case message := <-messages:
+			d.handle(message)
+		case message := <-retries:
+			d.handle(message)
+		default:
+			return
+		}
+		idle, retryIdle = sendsIdle(d.q), sendsIdle(d.config.retry.queue)
+	}
+}
+
+//sendsIdle returns q.sendsIdle(), or a closed channel if q is nil.
+func sendsIdle(q *TimeQueue) <-chan struct{} {
+	if q == nil {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	return q.sendsIdle()
+}
+
+//PanicError is the error reported for a Message whose Handler panicked.
+type PanicError struct {
+	//Value is the value recovered from the panic.
+	Value interface{}
+	//Stack is the stack trace of the panicking go-routine.
+	Stack []byte
+}
+
+//Error returns a description of the panic value.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("timequeue: handler panic: %v", e.Value)
+}
+
+//handle calls d.handler with message, records its latency, and routes any error
+//through the failure path. Successfully handled Messages are archived.
+func (d *Dispatcher) handle(message *Message) {
+	message.attempts++
+	start := time.Now()
+	err := d.call(message)
+	d.recordLatency(time.Since(start))
+	if d.breaker != nil {
+		d.breaker.record(err)
+	}
+	d.q.Ack(message.ID)
+	if err != nil {
+		d.fail(message, err)
+		return
+	}
+	d.q.archiveConsumed(message)
+}
+
+//call calls d.handler with message and returns its error.
+//The context given to d.handler is canceled at the Deadline() of message or when
+//the handler timeout passes. See WithHandlerTimeout().
+//A panic in d.handler is recovered and returned as a *PanicError so that a single
+//bad Message does not stop the worker.
+func (d *Dispatcher) call(message *Message) (err error) {
+	ctx, cancel := d.handlerContext(message)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return d.handler(ctx, message)
+}
+
+//fail reports err with the error hook and either retries or dead-letters message
+//according to the classification of err.
+func (d *Dispatcher) fail(message *Message, err error) {
+	if d.config.errorHook != nil {
+		d.config.errorHook(message, err)
+	}
+	if rateLimited, ok := AsRateLimited(err); ok {
+		message.attempts--
+		d.redeliver(message, rateLimited.RetryAfter)
+		return
+	}
+	if !IsFatal(err) && d.config.retry.shouldRetry(message) {
+		d.redeliver(message, d.config.retry.delay(message))
+		return
+	}
+	d.deadLetter(message, err)
+}
+
+//redeliver pushes message to the retry TimeQueue, or back to d.q if there is
+//none, to be released delay from now. message is dead-lettered with the error
+//from PushMessage() if it cannot be pushed.
+func (d *Dispatcher) redeliver(message *Message, delay time.Duration) {
+	message.Time = d.q.Now().Add(delay)
+	q := d.q
+	if d.config.retry.queue != nil {
+		q = d.config.retry.queue
+	}
+	if err := q.PushMessage(message); err != nil {
+		d.deadLetter(message, err)
+	}
+}
+
+//deadLetter calls the dead letter hook, if any, with message and err.
+func (d *Dispatcher) deadLetter(message *Message, err error) {
+	if d.config.deadLetter != nil {
+		d.config.deadLetter(message, err)
+	}
+}
+
+//recordLatency adds latency to the handler latency since the last scaling.
+func (d *Dispatcher) recordLatency(latency time.Duration) {
+	d.latencies.total.add(uint64(latency))
+	d.latencies.count.add(1)
+}
+
+//takeLatency returns the average handler latency and resets the totals.
+//The previous average is returned if no Messages were handled since the last
+//call.
+//A call recorded concurrently may have its latency and count split across two
+//averages.
+func (d *Dispatcher) takeLatency() time.Duration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	count := d.latencies.count.swap(0)
+	total := d.latencies.total.swap(0)
+	if count > 0 {
+		d.latency = time.Duration(total / count)
+	}
+	return d.latency
+}
+
+//latencyCounters holds the atomic latency totals of a Dispatcher.
+type latencyCounters struct {
+	total counter
+	count counter
+}
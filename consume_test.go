@@ -0,0 +1,257 @@
+package timequeue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Consume(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	handled := make(chan interface{}, 4)
+	errs := make(chan error, 4)
+	handlerErr := errors.New("handler")
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		handled <- message.Data
+		if message.Data == 3 {
+			return handlerErr
+		}
+		return nil
+	}, WithWorkers(2), WithErrorHook(func(message *Message, err error) {
+		errs <- err
+	}))
+	if workers := d.Workers(); workers != 2 {
+		t.Errorf("d.Workers() = %v WANT %v", workers, 2)
+	}
+
+	for i := 0; i < 4; i++ {
+		q.Push(time.Now(), i)
+	}
+	seen := map[interface{}]bool{}
+	for i := 0; i < 4; i++ {
+		seen[<-handled] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("handled = %v WANT 4 distinct values", seen)
+	}
+	if err := <-errs; err != handlerErr {
+		t.Errorf("error hook err = %v WANT %v", err, handlerErr)
+	}
+
+	cancel()
+	d.Wait()
+}
+
+func TestDispatcher_SetWorkers(t *testing.T) {
+	q := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error { return nil })
+
+	tests := []struct {
+		workers int
+		result  int
+	}{
+		{3, 3},
+		{1, 1},
+		{-1, 0},
+		{2, 2},
+	}
+	for _, test := range tests {
+		d.SetWorkers(test.workers)
+		if workers := d.Workers(); workers != test.result {
+			t.Errorf("d.SetWorkers(%v); d.Workers() = %v WANT %v", test.workers, workers, test.result)
+		}
+	}
+
+	cancel()
+	d.Wait()
+	d.SetWorkers(5)
+	if workers := d.Workers(); workers != 2 {
+		t.Errorf("d.Workers() after cancel = %v WANT %v", workers, 2)
+	}
+}
+
+func TestDispatcher_SetWorkers_stoppedWorkerFinishesMessage(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	var finished sync.WaitGroup
+	finished.Add(1)
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		close(started)
+		<-finish
+		finished.Done()
+		return nil
+	})
+	q.Push(time.Now(), 0)
+	<-started
+	d.SetWorkers(0)
+	close(finish)
+	finished.Wait()
+}
+
+func TestDispatcher_Shutdown(t *testing.T) {
+	q := New(WithCapacity(3))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started, finish := make(chan struct{}, 3), make(chan struct{})
+	handled := make(chan int, 3)
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		started <- struct{}{}
+		<-finish
+		handled <- message.Data.(int)
+		return nil
+	})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		q.Push(now.Add(time.Duration(i)), i)
+	}
+	q.PopAll(true)
+	<-started
+	for len(q.Messages()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+	if err := d.Shutdown(timeout); err != context.DeadlineExceeded {
+		t.Errorf("d.Shutdown() = %v WANT %v", err, context.DeadlineExceeded)
+	}
+	close(finish)
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Errorf("d.Shutdown() = %v WANT %v", err, nil)
+	}
+	if len(handled) != 3 {
+		t.Errorf("handled %v Messages WANT %v", len(handled), 3)
+	}
+	if workers := d.Workers(); workers != 0 {
+		t.Errorf("d.Workers() = %v WANT %v", workers, 0)
+	}
+}
+
+func TestDispatcher_Shutdown_sendsInFlight(t *testing.T) {
+	q := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started, finish := make(chan struct{}, 5), make(chan struct{})
+	handled := make(chan int, 5)
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		started <- struct{}{}
+		<-finish
+		handled <- message.Data.(int)
+		return nil
+	})
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		q.Push(now.Add(time.Duration(i)), i)
+	}
+	//releases all 5 while only 1 fits in q.Messages(), so the rest are in flight.
+	q.PopAll(true)
+	<-started
+
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+	d.Shutdown(timeout)
+	close(finish)
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Errorf("d.Shutdown() = %v WANT %v", err, nil)
+	}
+	if len(handled) != 5 {
+		t.Errorf("handled %v Messages WANT %v", len(handled), 5)
+	}
+}
+
+func TestWithWorkerBounds(t *testing.T) {
+	q := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error { return nil },
+		WithWorkers(10),
+		WithWorkerBounds(2, 4),
+	)
+	if d.config.autoscale == nil {
+		t.Errorf("d.config.autoscale = nil WANT non-nil")
+	}
+
+	tests := []struct {
+		workers int
+		result  int
+	}{
+		{0, 2},
+		{3, 3},
+		{100, 4},
+	}
+	if workers := d.Workers(); workers != 4 {
+		t.Errorf("d.Workers() = %v WANT %v", workers, 4)
+	}
+	for _, test := range tests {
+		d.SetWorkers(test.workers)
+		if workers := d.Workers(); workers != test.result {
+			t.Errorf("d.SetWorkers(%v); d.Workers() = %v WANT %v", test.workers, workers, test.result)
+		}
+	}
+	cancel()
+	d.Wait()
+}
+
+func TestDispatcher_takeLatency(t *testing.T) {
+	d := New().Consume(context.Background(), nil, WithWorkers(0))
+	d.recordLatency(time.Second)
+	d.recordLatency(3 * time.Second)
+	if latency := d.takeLatency(); latency != 2*time.Second {
+		t.Errorf("d.takeLatency() = %v WANT %v", latency, 2*time.Second)
+	}
+	if latency := d.takeLatency(); latency != 2*time.Second {
+		t.Errorf("d.takeLatency() = %v WANT %v", latency, 2*time.Second)
+	}
+}
+
+func TestDispatcher_handle_panic(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errs := make(chan error, 1)
+	deadLetters := make(chan *Message, 1)
+	handled := make(chan interface{}, 1)
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		if message.Data == "bad" {
+			panic("bad payload")
+		}
+		handled <- message.Data
+		return nil
+	}, WithErrorHook(func(message *Message, err error) {
+		errs <- err
+	}), WithDeadLetter(func(message *Message, err error) {
+		deadLetters <- message
+	}))
+
+	q.Push(time.Now(), "bad")
+	err := <-errs
+	if pe, ok := err.(*PanicError); !ok || pe.Value != "bad payload" || len(pe.Stack) == 0 {
+		t.Errorf("error hook err = %#v WANT *PanicError with Value %q", err, "bad payload")
+	}
+	if message := <-deadLetters; message.Data != "bad" {
+		t.Errorf("dead letter = %v WANT Data %v", message, "bad")
+	}
+
+	//the worker should survive the panic.
+	q.Push(time.Now(), "good")
+	if data := <-handled; data != "good" {
+		t.Errorf("handled = %v WANT %v", data, "good")
+	}
+	cancel()
+	d.Wait()
+}
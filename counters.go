@@ -0,0 +1,73 @@
+package timequeue
+
+import (
+	"sync/atomic"
+)
+
+//Counters are running totals of the activity of a TimeQueue.
+type Counters struct {
+	//Pushed is the number of Messages pushed to the TimeQueue, including those
+	//pushed by the TimeQueue itself, e.g. recurring occurrences and retries.
+	Pushed uint64
+	//Released is the number of Messages released from the TimeQueue.
+	Released uint64
+	//Size is the number of Messages in the TimeQueue as of its last update.
+	Size int
+}
+
+//Counters returns the Counters of q.
+//Counters does not lock q and so may be called as often as desired, e.g. by metrics
+//collection, without slowing pushes and releases. Each counter is read individually
+//and so they may be from slightly different moments in time.
+func (q *TimeQueue) Counters() Counters {
+	return Counters{
+		Pushed:   q.counters.pushed.load(),
+		Released: q.counters.released.load(),
+		Size:     int(q.counters.size.load()),
+	}
+}
+
+//storeSize updates the size counter of q.
+//It should only be called when q is locked.
+func (q *TimeQueue) storeSize() {
+	q.counters.size.store(uint64(q.size()))
+}
+
+//counters holds the atomic counters of a TimeQueue.
+//A counters must be allocated on its own so that its counters are 64-bit aligned.
+type counters struct {
+	pushed   counter
+	released counter
+	size     counter
+
+	//the generation of the most recent wakeSignal. wakes from others are stale.
+	//it is only incremented when q is locked, but may be read at any time.
+	wakeGeneration counter
+}
+
+//counter is an atomic uint64 that fills a cache line so that incrementing it does
+//not slow access to other counters.
+type counter struct {
+	n uint64
+	_ [cacheLineSize - 8]byte
+}
+
+//add adds delta to c and returns the new value.
+func (c *counter) add(delta uint64) uint64 {
+	return atomic.AddUint64(&c.n, delta)
+}
+
+//load returns the value of c.
+func (c *counter) load() uint64 {
+	return atomic.LoadUint64(&c.n)
+}
+
+//store sets the value of c to n.
+func (c *counter) store(n uint64) {
+	atomic.StoreUint64(&c.n, n)
+}
+
+//swap sets the value of c to n and returns its previous value.
+func (c *counter) swap(n uint64) uint64 {
+	return atomic.SwapUint64(&c.n, n)
+}
@@ -0,0 +1,79 @@
+package timequeue
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestTimeQueue_Counters(t *testing.T) {
+	q := New(WithCapacity(3))
+	now := time.Now()
+	q.Push(now, 0)
+	q.Push(now, 1)
+	removed := q.Push(now.Add(time.Hour), 2)
+	if counters := q.Counters(); counters != (Counters{Pushed: 3, Released: 0, Size: 3}) {
+		t.Errorf("q.Counters() = %+v WANT %+v", counters, Counters{Pushed: 3, Released: 0, Size: 3})
+	}
+
+	q.Remove(removed, false)
+	q.Start()
+	defer q.Stop()
+	<-q.Messages()
+	<-q.Messages()
+	q.Size() //waits for the release to finish updating q.
+	if counters := q.Counters(); counters != (Counters{Pushed: 3, Released: 2, Size: 0}) {
+		t.Errorf("q.Counters() = %+v WANT %+v", counters, Counters{Pushed: 3, Released: 2, Size: 0})
+	}
+}
+
+func TestCounter(t *testing.T) {
+	if size := unsafe.Sizeof(counter{}); size != cacheLineSize {
+		t.Errorf("unsafe.Sizeof(counter{}) = %v WANT %v", size, cacheLineSize)
+	}
+	c := &counter{}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.swap(5); n != 1000 {
+		t.Errorf("c.swap() = %v WANT %v", n, 1000)
+	}
+	if n := c.load(); n != 5 {
+		t.Errorf("c.load() = %v WANT %v", n, 5)
+	}
+}
+
+func BenchmarkTimeQueue_Counters(b *testing.B) {
+	q := New()
+	t := time.Now().Add(time.Hour)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Push(t, nil)
+			q.Counters()
+		}
+	})
+}
+
+func TestTimeQueue_Counters_hold(t *testing.T) {
+	q := New()
+	q.HoldMatching("all", "", func(message *Message) bool { return true })
+	q.Start()
+	defer q.Stop()
+	q.Push(time.Now(), 0)
+	for q.Stats().HeldMessages == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	q.PopAll(false)
+	if pushed := q.Counters().Pushed; pushed != 1 {
+		t.Errorf("q.Counters().Pushed = %v WANT %v", pushed, 1)
+	}
+}
@@ -0,0 +1,211 @@
+package timequeue
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ErrInvalidCron is returned, possibly wrapped, when parsing an invalid cron
+//expression.
+var ErrInvalidCron = errors.New("timequeue: invalid cron expression")
+
+//cronSearchYears is the number of years Cron.Next() searches for a matching time
+//before giving up, e.g. for "0 0 30 2 *" which never matches.
+const cronSearchYears = 5
+
+//Cron is a Recurrence given by a standard five field cron expression:
+//	minute hour day-of-month month day-of-week
+//Each field is "*", a value, a range "a-b", or a comma separated list of them, and
+//"*" and ranges may have a step, e.g. "*/15" or "1-5/2". Months and days of the week
+//may also be given by their three letter English names, e.g. "JAN" or "mon", and
+//Sunday is either 0 or 7.
+//If both the day-of-month and day-of-week fields are restricted, i.e. not "*", then
+//a day matches if either of them does, as in most cron implementations.
+//
+//The descriptors @yearly (or @annually), @monthly, @weekly, @daily (or @midnight),
+//and @hourly may be used in place of the five fields.
+//
+//Times are matched in the Location of the time given to Next().
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	//true if the day-of-month or day-of-week fields are "*".
+	domStar, dowStar bool
+}
+
+//cronDescriptors maps cron descriptors to their equivalent expressions.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//cronField describes the allowed values of a single field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day-of-month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: []string{
+		"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec",
+	}}
+	cronDow = cronField{name: "day-of-week", min: 0, max: 7, names: []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat",
+	}}
+)
+
+//ParseCron parses spec as a cron expression. See Cron for the accepted syntax.
+//The returned error wraps ErrInvalidCron.
+func ParseCron(spec string) (*Cron, error) {
+	expression := strings.TrimSpace(spec)
+	if descriptor, ok := cronDescriptors[strings.ToLower(expression)]; ok {
+		expression = descriptor
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w %q: want 5 fields, got %v", ErrInvalidCron, spec, len(fields))
+	}
+	c := &Cron{
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}
+	for i, target := range []struct {
+		field cronField
+		bits  *uint64
+	}{
+		{cronMinute, &c.minute},
+		{cronHour, &c.hour},
+		{cronDom, &c.dom},
+		{cronMonth, &c.month},
+		{cronDow, &c.dow},
+	} {
+		bits, err := target.field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidCron, spec, err)
+		}
+		*target.bits = bits
+	}
+	//Sunday may be given as 7.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+//parse returns the values matched by expression as a bit set.
+func (f cronField) parse(expression string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expression, ",") {
+		rangeExpression, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangeExpression = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %v step %q", f.name, part)
+			}
+		}
+		low, high := f.min, f.max
+		if rangeExpression != "*" {
+			bounds := strings.SplitN(rangeExpression, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = f.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				//"a/n" means from a through the maximum.
+				high = f.max
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid %v range %q", f.name, rangeExpression)
+			}
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+//value parses a single value of f.
+func (f cronField) value(expression string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(expression, name) {
+			return i, nil
+		}
+	}
+	value, err := strconv.Atoi(expression)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %v %q", f.name, expression)
+	}
+	return value, nil
+}
+
+//Next returns the first time after t that matches c, or the zero Time if there is
+//none within the following five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case !has(c.month, int(month)):
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchDay(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		case !has(c.hour, t.Hour()):
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, loc)
+		case !has(c.minute, t.Minute()):
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+//matchDay returns whether or not the day of t matches c.
+func (c *Cron) matchDay(t time.Time) bool {
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+//has returns whether or not value is in bits.
+func has(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
+
+//PushCron pushes a recurring Message with Data data at the times given by the cron
+//expression spec, evaluated in the Location of Now(), i.e. the local time zone
+//unless q is advanced manually. See Cron and PushRecurring().
+//Since only a single occurrence of the Schedule is ever in q, calling PushCron with
+//the same spec when a process starts is enough to restore the Schedule.
+//The returned error wraps ErrInvalidCron if spec is invalid, in which case nothing
+//is pushed.
+func (q *TimeQueue) PushCron(spec string, data interface{}) (*Schedule, error) {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	return q.PushRecurring(c, data), nil
+}
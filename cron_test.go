@@ -0,0 +1,65 @@
+package timequeue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	//a Saturday.
+	base := time.Date(2022, 1, 1, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		t    time.Time
+		want time.Time
+	}{
+		{"* * * * *", base, time.Date(2022, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", base, time.Date(2022, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", base, time.Date(2022, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * MON-FRI", base, time.Date(2022, 1, 3, 9, 0, 0, 0, time.UTC)},
+		{"30 10 1 * *", base, time.Date(2022, 2, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", base, time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", base, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", base, time.Date(2022, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", base, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", base, time.Date(2022, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", base, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", base, time.Time{}},
+	}
+	for _, test := range tests {
+		c, err := ParseCron(test.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) = %v WANT nil", test.spec, err)
+		}
+		if result := c.Next(test.t); !result.Equal(test.want) {
+			t.Errorf("ParseCron(%q).Next() = %v WANT %v", test.spec, result, test.want)
+		}
+	}
+}
+
+func TestParseCron_invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@often"} {
+		if _, err := ParseCron(spec); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("ParseCron(%q) = %v WANT %v", spec, err, ErrInvalidCron)
+		}
+	}
+}
+
+func TestTimeQueue_PushCron(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start))
+	if _, err := q.PushCron("bad", nil); !errors.Is(err, ErrInvalidCron) || q.Size() != 0 {
+		t.Errorf("q.PushCron() = %v, size %v WANT %v, 0", err, q.Size(), ErrInvalidCron)
+	}
+	s, err := q.PushCron("0 */6 * * *", "report")
+	if err != nil {
+		t.Fatalf("q.PushCron() = %v WANT nil", err)
+	}
+	if released := q.Advance(start.Add(24 * time.Hour)); len(released) != 4 {
+		t.Errorf("len(q.Advance()) = %v WANT %v", len(released), 4)
+	}
+	if next := s.Message().Time; !next.Equal(start.Add(30 * time.Hour)) {
+		t.Errorf("s.Message().Time = %v WANT %v", next, start.Add(30*time.Hour))
+	}
+}
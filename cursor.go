@@ -0,0 +1,87 @@
+package timequeue
+
+//CursorStore is a Store that also persists the cursor of a TimeQueue in ack mode,
+//i.e. the greatest Sequence() of an acknowledged Message. See Cursor().
+type CursorStore interface {
+	Store
+	//SaveCursor records cursor, replacing any previous cursor.
+	SaveCursor(cursor uint64) error
+	//LoadCursor returns the last cursor given to SaveCursor(), or 0 if there is
+	//none.
+	LoadCursor() (uint64, error)
+}
+
+//Cursor returns the greatest Sequence() of a Message acknowledged by a consumer
+//of q in ack mode, or 0 if none has been acknowledged.
+//
+//If q was created with WithStore() and WithAckMode(), then released Messages are
+//only removed from the Store when they are acknowledged, so Messages that were
+//released but not acknowledged when q's process crashed are released again after
+//it restarts. If the Store is a CursorStore, then the cursor is also saved to it
+//and Sequence() numbers continue after the cursor, so that a restarted consumer
+//can tell the Messages it already acknowledged from redeliveries.
+func (q *TimeQueue) Cursor() uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.cursor
+}
+
+//ResumeFrom redelivers every unacknowledged Message with a Sequence() greater
+//than cursor by pushing it back to q to be released immediately, and returns the
+//number of Messages redelivered.
+//A consumer that crashes and restarts while q keeps running, e.g. in another
+//process, calls ResumeFrom with the Sequence() of the last Message that it
+//processed to receive the Messages that were released to it in the meantime.
+//Messages that have already been acknowledged cannot be redelivered.
+//Redeliveries count towards WithMaxDeliveries() like Nack().
+func (q *TimeQueue) ResumeFrom(cursor uint64) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	count := 0
+	now := q.now()
+	for id, message := range q.unacked {
+		if message.seq > cursor {
+			delete(q.unacked, id)
+			q.requeue(message, now, nil)
+			count++
+		}
+	}
+	q.afterHeapUpdate()
+	return count
+}
+
+//ack acknowledges the released message by removing it from q.unacked and
+//q.store and advancing q.cursor. The cursor is saved by saveCursor().
+//It should only be called when q is locked.
+func (q *TimeQueue) ack(message *Message) {
+	delete(q.unacked, message.ID)
+	q.storeRemove(message)
+	if message.seq > q.cursor {
+		q.cursor = message.seq
+	}
+}
+
+//saveCursor saves q.cursor to q.store if it is a CursorStore and the cursor has
+//changed since it was last saved.
+//It should only be called when q is locked.
+func (q *TimeQueue) saveCursor() {
+	cs, ok := q.store.(CursorStore)
+	if !ok || q.cursor == q.savedCursor {
+		return
+	}
+	q.storeFailed(cs.SaveCursor(q.cursor))
+	q.savedCursor = q.cursor
+}
+
+//loadCursor loads q.cursor from store if it is a CursorStore and continues
+//Sequence() numbers after it.
+//It should only be called when q is locked.
+func (q *TimeQueue) loadCursor(store Store) {
+	cs, ok := store.(CursorStore)
+	if !ok {
+		return
+	}
+	cursor, err := cs.LoadCursor()
+	q.storeFailed(err)
+	q.cursor, q.savedCursor, q.releaseSeq = cursor, cursor, cursor
+}
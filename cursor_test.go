@@ -0,0 +1,42 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Cursor(t *testing.T) {
+	dir := newTestWALDir(t)
+	q := New(WithStore(openTestWAL(t, dir)), WithAckMode(), WithCapacity(3))
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		q.Push(now.Add(time.Duration(i)*time.Millisecond), i)
+	}
+	released := q.PopAll(true)
+	q.Ack(released[1].ID)
+	if cursor := q.Cursor(); cursor != 2 {
+		t.Errorf("q.Cursor() = %v WANT %v", cursor, 2)
+	}
+	if count := q.ResumeFrom(2); count != 1 || q.Size() != 1 || q.Unacked() != 1 {
+		t.Errorf("q.ResumeFrom() = %v, Size() = %v, Unacked() = %v WANT 1, 1, 1", count, q.Size(), q.Unacked())
+	}
+
+	//the process crashes with 0 unacknowledged and 2 redelivered but not released.
+	recovered := New(WithStore(openTestWAL(t, dir)), WithAckMode(), WithCapacity(3))
+	if cursor := recovered.Cursor(); cursor != 2 {
+		t.Errorf("recovered.Cursor() = %v WANT %v", cursor, 2)
+	}
+	released = recovered.PopAll(true)
+	if len(released) != 2 || released[0].Data != 0 || released[1].Data != 2 {
+		t.Fatalf("recovered.PopAll() = %v WANT 0, 2", released)
+	}
+	for i, message := range released {
+		if seq := message.Sequence(); seq != uint64(i+3) {
+			t.Errorf("message.Sequence() = %v WANT %v", seq, i+3)
+		}
+	}
+	recovered.AckUpTo(4)
+	if again := New(WithStore(openTestWAL(t, dir))); again.Size() != 0 || again.Cursor() != 4 {
+		t.Errorf("again Size(), Cursor() = %v, %v WANT 0, 4", again.Size(), again.Cursor())
+	}
+}
@@ -0,0 +1,57 @@
+package timequeue
+
+import "errors"
+
+//ErrVisibilityTimeout is the Err of a DeadLetter whose last delivery was not
+//acknowledged within the visibility timeout, see WithVisibilityTimeout().
+var ErrVisibilityTimeout = errors.New("timequeue: visibility timeout expired")
+
+//DeadLetter is a Message that was delivered the maximum number of times in ack
+//mode without being acknowledged. See WithMaxDeliveries().
+type DeadLetter struct {
+	//Message is the dead-lettered Message.
+	Message *Message
+	//Deliveries is the number of times Message was released.
+	Deliveries int
+	//Err is the error given to NackError() for the last delivery, or
+	//ErrVisibilityTimeout if it timed out. It is nil if Nack() was called.
+	Err error
+}
+
+//WithMaxDeliveries limits the number of times a Message is released in ack mode.
+//A Message that has been released max times and is then negatively acknowledged,
+//or whose visibility timeout expires, is sent on DeadLetters() instead of being
+//redelivered.
+//A max less than or equal to zero redelivers Messages forever, which is the
+//default.
+func WithMaxDeliveries(max int) Option {
+	return func(c *config) {
+		c.maxDeliveries = max
+	}
+}
+
+//DeadLetters returns the channel that dead-lettered Messages are sent on.
+//It has the same capacity as Messages() and dead letters are sent in order
+//without waiting. If the channel is full, then the dead letter is discarded and
+//its Message is finalized with DropDeadLettersFull, so DeadLetters() should be
+//received from whenever WithMaxDeliveries() is given.
+func (q *TimeQueue) DeadLetters() <-chan *DeadLetter {
+	return q.deadLetters
+}
+
+//deadLetter sends message on q.deadLetters with err without waiting, or finalizes
+//it if q.deadLetters is full.
+//It should only be called when q is locked.
+func (q *TimeQueue) deadLetter(message *Message, err error) {
+	dl := &DeadLetter{
+		Message:    message,
+		Deliveries: message.deliveries,
+		Err:        err,
+	}
+	select {
+	case q.deadLetters <- dl:
+	default:
+		q.logMessage("timequeue: dead letter dropped", message)
+		q.finalize(message, DropDeadLettersFull)
+	}
+}
@@ -0,0 +1,68 @@
+package timequeue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithMaxDeliveries(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	failed := errors.New("failed")
+	tests := []struct {
+		//fail fails the delivery of message and returns any Messages released.
+		fail func(q *TimeQueue, message *Message) []*Message
+		err  error
+	}{
+		{func(q *TimeQueue, message *Message) []*Message {
+			q.NackError(message.ID, 0, failed)
+			return nil
+		}, failed},
+		{func(q *TimeQueue, message *Message) []*Message {
+			q.Nack(message.ID, 0)
+			return nil
+		}, nil},
+		{func(q *TimeQueue, message *Message) []*Message {
+			return q.Advance(q.Now().Add(time.Minute))
+		}, ErrVisibilityTimeout},
+	}
+	for i, test := range tests {
+		q := New(WithManualAdvance(start), WithAckMode(), WithVisibilityTimeout(time.Minute), WithMaxDeliveries(2))
+		pushed := q.Push(start, i)
+		released := q.Advance(q.Now())
+		for delivery := 1; delivery <= 2; delivery++ {
+			if len(released) != 1 || released[0] != pushed {
+				t.Fatalf("%v: delivery %v = %v WANT %v", i, delivery, released, pushed)
+			}
+			released = append(test.fail(q, pushed), q.Advance(q.Now())...)
+		}
+		select {
+		case dl := <-q.DeadLetters():
+			if dl.Message != pushed || dl.Deliveries != 2 || dl.Err != test.err {
+				t.Errorf("%v: DeadLetter = %+v WANT %v after 2 deliveries with %v", i, dl, pushed, test.err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v: Message was not dead-lettered", i)
+		}
+		if size, unacked := q.Size(), q.Unacked(); size != 0 || unacked != 0 {
+			t.Errorf("%v: q.Size(), q.Unacked() = %v, %v WANT 0, 0", i, size, unacked)
+		}
+	}
+}
+
+func TestTimeQueue_deadLetter_full(t *testing.T) {
+	dropped := []DropReason{}
+	q := New(WithCapacity(1), WithFinalizer(func(message Message, reason DropReason) {
+		dropped = append(dropped, reason)
+	}))
+	q.lock.Lock()
+	q.deadLetter(&Message{Data: 0}, nil)
+	q.deadLetter(&Message{Data: 1}, nil)
+	q.lock.Unlock()
+	if dl := <-q.DeadLetters(); dl.Message.Data != 0 {
+		t.Errorf("DeadLetter.Message.Data = %v WANT %v", dl.Message.Data, 0)
+	}
+	if len(dropped) != 1 || dropped[0] != DropDeadLettersFull {
+		t.Errorf("dropped = %v WANT [%v]", dropped, DropDeadLettersFull)
+	}
+}
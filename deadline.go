@@ -0,0 +1,78 @@
+package timequeue
+
+import (
+	"context"
+	"time"
+)
+
+//Deadline returns the time after which m should no longer be processed, i.e. its
+//Time plus its TTL, and true, or the zero time and false if m has no TTL.
+//A Message retried by a Dispatcher is pushed again with a later Time, so each
+//attempt has the full TTL.
+//
+//A Message whose Deadline has passed by the time that it would be released, e.g.
+//because its TimeQueue was stopped or held, is discarded instead and finalized
+//with DropExpired.
+func (m *Message) Deadline() (time.Time, bool) {
+	if m.TTL <= 0 {
+		return time.Time{}, false
+	}
+	return m.Time.Add(m.TTL), true
+}
+
+//expired returns whether or not the Deadline() of m is before now.
+func (m *Message) expired(now time.Time) bool {
+	deadline, ok := m.Deadline()
+	return ok && deadline.Before(now)
+}
+
+//expire discards message, which was removed from q.storage when it became due,
+//instead of releasing it because its Deadline() passed.
+//It should only be called when q is locked.
+func (q *TimeQueue) expire(message *Message) {
+	q.logMessage("timequeue: expire", message)
+	q.storeRemove(message)
+	q.unindexKey(message)
+	q.untrackTenant(message)
+	q.archiveChange(ArchiveRemoved, message)
+	q.finalize(message, DropExpired)
+}
+
+//Context returns a copy of parent that is canceled at the Deadline() of m, if it
+//has one. It allows functions given to ScheduleFunc() and receivers of Messages()
+//to stop processing m at the same time as a Dispatcher's Handler would.
+//The returned CancelFunc should be called once processing m is done.
+func (m *Message) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := m.Deadline(); ok {
+		return context.WithDeadline(parent, deadline)
+	}
+	return context.WithCancel(parent)
+}
+
+//WithHandlerTimeout gives every call to a Dispatcher's Handler a processing budget
+//of timeout. The context given to the Handler is canceled once timeout passes, or
+//at the Deadline() of the Message if that is earlier. A Handler that returns an
+//error because its context was canceled is retried like any other failure.
+//
+//A timeout less than or equal to zero only limits Handlers by the Deadline() of
+//their Messages, which is the default.
+func WithHandlerTimeout(timeout time.Duration) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.handlerTimeout = timeout
+	}
+}
+
+//handlerContext returns the context for calling d.handler with message, which is
+//canceled at the earlier of the Deadline() of message and the handler timeout.
+func (d *Dispatcher) handlerContext(message *Message) (context.Context, context.CancelFunc) {
+	deadline, ok := message.Deadline()
+	if timeout := d.config.handlerTimeout; timeout > 0 {
+		if budget := time.Now().Add(timeout); !ok || budget.Before(deadline) {
+			deadline, ok = budget, true
+		}
+	}
+	if !ok {
+		return context.WithCancel(d.ctx)
+	}
+	return context.WithDeadline(d.ctx, deadline)
+}
@@ -0,0 +1,134 @@
+package timequeue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMessage_Deadline(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		message *Message
+		want    time.Time
+		ok      bool
+	}{
+		{&Message{Time: now}, time.Time{}, false},
+		{&Message{Time: now, TTL: -time.Second}, time.Time{}, false},
+		{&Message{Time: now, TTL: time.Second}, now.Add(time.Second), true},
+	}
+	for _, test := range tests {
+		deadline, ok := test.message.Deadline()
+		if !deadline.Equal(test.want) || ok != test.ok {
+			t.Errorf("%v.Deadline() = %v, %v WANT %v, %v", test.message, deadline, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestMessage_Context(t *testing.T) {
+	message := &Message{Time: time.Now(), TTL: time.Minute}
+	ctx, cancel := message.Context(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(message.Time.Add(time.Minute)) {
+		t.Errorf("ctx.Deadline() = %v, %v WANT %v, true", deadline, ok, message.Time.Add(time.Minute))
+	}
+
+	ctx, cancel = (&Message{}).Context(context.Background())
+	cancel()
+	if _, ok := ctx.Deadline(); ok || ctx.Err() != context.Canceled {
+		t.Errorf("ctx.Deadline() ok, ctx.Err() = %v, %v WANT false, %v", ok, ctx.Err(), context.Canceled)
+	}
+}
+
+func TestWithHandlerTimeout(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ttl     time.Duration
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"ttl", 0, time.Minute, time.Minute, time.Minute},
+		{"timeout", time.Second, 0, time.Second, 2 * time.Second},
+		{"earlier ttl", time.Hour, time.Minute, time.Minute, time.Minute},
+		{"earlier timeout", time.Second, time.Hour, time.Second, 2 * time.Second},
+	}
+	for _, test := range tests {
+		q := New()
+		q.Start()
+		deadlines := make(chan time.Time, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		q.Consume(ctx, func(ctx context.Context, message *Message) error {
+			deadline, _ := ctx.Deadline()
+			deadlines <- deadline
+			return nil
+		}, WithHandlerTimeout(test.timeout))
+		q.PushMessage(&Message{Time: now, TTL: test.ttl})
+
+		deadline := <-deadlines
+		if d := deadline.Sub(now); d < test.min || d > test.max {
+			t.Errorf("%v: deadline = now + %v WANT in [%v, %v]", test.name, d, test.min, test.max)
+		}
+		cancel()
+		q.Stop()
+	}
+}
+
+func TestWithHandlerTimeout_cancels(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithHandlerTimeout(10*time.Millisecond), WithErrorHook(func(message *Message, err error) {
+		errs <- err
+	}))
+	q.Push(time.Now(), 0)
+	select {
+	case err := <-errs:
+		if err != context.DeadlineExceeded {
+			t.Errorf("err = %v WANT %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler was not canceled")
+	}
+}
+
+func TestTimeQueue_popDue_expired(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"fairness", []Option{WithFairness(RoundRobin())}},
+	}
+	for _, test := range tests {
+		start := time.Now()
+		dropped := map[interface{}]DropReason{}
+		opts := append([]Option{WithManualAdvance(start), WithFinalizer(func(message Message, reason DropReason) {
+			dropped[message.Data] = reason
+		})}, test.opts...)
+		q := New(opts...)
+		q.PushMessage(&Message{Time: start, Data: "expired", TTL: time.Minute, Key: "key"})
+		q.PushMessage(&Message{Time: start.Add(time.Minute), Data: "live", TTL: time.Hour})
+		q.Push(start, "no ttl")
+		released := q.Advance(start.Add(2 * time.Minute))
+		if len(released) != 2 || released[0].Data != "no ttl" || released[1].Data != "live" {
+			t.Errorf("%v: q.Advance() = %v WANT no ttl, live", test.name, released)
+		}
+		if len(dropped) != 1 || dropped["expired"] != DropExpired {
+			t.Errorf("%v: dropped = %v WANT expired: %v", test.name, dropped, DropExpired)
+		}
+		if q.Contains("key") || q.Size() != 0 {
+			t.Errorf("%v: q.Contains(key), q.Size() = %v, %v WANT false, 0", test.name, q.Contains("key"), q.Size())
+		}
+		if err := q.ConsistencyCheck(); err != nil {
+			t.Errorf("%v: q.ConsistencyCheck() = %v WANT nil", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package timequeue
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"reflect"
+)
+
+//ErrDecrypt is returned by the Codecs created with EncryptCodec() for data that
+//was not encrypted with the same key or was modified since.
+var ErrDecrypt = errors.New("timequeue: cannot decrypt data")
+
+//encryptCodec is a Codec created with EncryptCodec().
+type encryptCodec struct {
+	codec Codec
+	aead  cipher.AEAD
+}
+
+//EncryptCodec returns a Codec that encrypts the output of codec with AES-GCM using
+//key, which must be 16, 24, or 32 bytes long to select AES-128, AES-192, or
+//AES-256. Registering a type with the returned Codec keeps its Data confidential
+//in snapshots, handoffs, WALs, and Stores. The other fields of Messages, e.g. IDs
+//and Keys, are not encrypted.
+//
+//Codecs compose, so Data may be compressed before it is encrypted, e.g. with the
+//zstdcodec subpackage:
+//	codec, err := timequeue.EncryptCodec(zstdcodec.Wrap(timequeue.JSONCodec), key)
+//	//handle err.
+//	timequeue.RegisterType("payment.v1", Payment{}, codec)
+func EncryptCodec(codec Codec, key []byte) (Codec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptCodec{codec: codec, aead: aead}, nil
+}
+
+//Encode encodes value with c.codec and encrypts the result with a random nonce,
+//which is prepended to it.
+func (c *encryptCodec) Encode(value interface{}) ([]byte, error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+//Decode decrypts data and decodes the result with c.codec.
+func (c *encryptCodec) Decode(data []byte, t reflect.Type) (interface{}, error) {
+	if len(data) < c.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce := data[:c.aead.NonceSize()]
+	plain, err := c.aead.Open(nil, nonce, data[len(nonce):], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return c.codec.Decode(plain, t)
+}
@@ -0,0 +1,43 @@
+package timequeue
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncryptCodec(t *testing.T) {
+	codec, err := EncryptCodec(JSONCodec, bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("EncryptCodec() = %v WANT nil", err)
+	}
+	other, _ := EncryptCodec(JSONCodec, bytes.Repeat([]byte{2}, 16))
+	value := codecTestEmail{To: "gopher"}
+	data, err := codec.Encode(value)
+	if err != nil || bytes.Contains(data, []byte("gopher")) {
+		t.Fatalf("Encode() = %q, %v WANT ciphertext, nil", data, err)
+	}
+	if again, _ := codec.Encode(value); bytes.Equal(again, data) {
+		t.Errorf("Encode() twice = same ciphertext WANT different nonces")
+	}
+
+	typ := reflect.TypeOf(value)
+	if decoded, err := codec.Decode(data, typ); decoded != value || err != nil {
+		t.Errorf("Decode() = %v, %v WANT %v, nil", decoded, err, value)
+	}
+	tests := [][]byte{
+		data[:4],
+		append(append([]byte{}, data[:len(data)-1]...), data[len(data)-1]^1),
+	}
+	for i, test := range tests {
+		if _, err := codec.Decode(test, typ); err != ErrDecrypt {
+			t.Errorf("%v: Decode() = %v WANT %v", i, err, ErrDecrypt)
+		}
+	}
+	if _, err := other.Decode(data, typ); err != ErrDecrypt {
+		t.Errorf("Decode() with other key = %v WANT %v", err, ErrDecrypt)
+	}
+	if _, err := EncryptCodec(JSONCodec, []byte("short")); err == nil {
+		t.Errorf("EncryptCodec() with invalid key = nil WANT non-nil")
+	}
+}
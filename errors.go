@@ -0,0 +1,106 @@
+package timequeue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//Handler errors may be classified to control how a Dispatcher redelivers the
+//failed Message:
+//
+//Errors wrapped with Retryable() and unclassified errors are retried according
+//to WithRetry() and then given to the WithDeadLetter() hook.
+//
+//Errors wrapped with Fatal() are never retried and are given to the
+//WithDeadLetter() hook immediately.
+//
+//*RateLimited errors are always retried after their RetryAfter duration and do
+//not count towards the maximum attempts given to WithRetry().
+//
+//Classified errors may be wrapped again with fmt.Errorf("%w") and are still
+//recognized.
+
+//retryableError is the error returned from Retryable().
+type retryableError struct {
+	err error
+}
+
+//Retryable wraps err to classify it as a failure that should be retried.
+func Retryable(err error) error {
+	return &retryableError{err: err}
+}
+
+//Error returns the error message of the wrapped error.
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+//Unwrap returns the wrapped error.
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+//IsRetryable returns true if err was wrapped with Retryable().
+func IsRetryable(err error) bool {
+	var r *retryableError
+	return errors.As(err, &r)
+}
+
+//fatalError is the error returned from Fatal().
+type fatalError struct {
+	err error
+}
+
+//Fatal wraps err to classify it as a failure that should never be retried.
+func Fatal(err error) error {
+	return &fatalError{err: err}
+}
+
+//Error returns the error message of the wrapped error.
+func (e *fatalError) Error() string {
+	return e.err.Error()
+}
+
+//Unwrap returns the wrapped error.
+func (e *fatalError) Unwrap() error {
+	return e.err
+}
+
+//IsFatal returns true if err was wrapped with Fatal().
+func IsFatal(err error) bool {
+	var f *fatalError
+	return errors.As(err, &f)
+}
+
+//RateLimited is an error that classifies a failure as caused by a rate limit.
+//The failed Message is retried after RetryAfter.
+type RateLimited struct {
+	//Err is the underlying error. It may be nil.
+	Err error
+	//RetryAfter is the duration after the failure at which to retry.
+	RetryAfter time.Duration
+}
+
+//Error returns a description of e.
+func (e *RateLimited) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("timequeue: rate limited, retry after %v", e.RetryAfter)
+	}
+	return fmt.Sprintf("timequeue: rate limited, retry after %v: %v", e.RetryAfter, e.Err)
+}
+
+//Unwrap returns e.Err.
+func (e *RateLimited) Unwrap() error {
+	return e.Err
+}
+
+//AsRateLimited returns the *RateLimited in err's chain and true, or nil and
+//false if there is none.
+func AsRateLimited(err error) (*RateLimited, bool) {
+	var r *RateLimited
+	if errors.As(err, &r) {
+		return r, true
+	}
+	return nil, false
+}
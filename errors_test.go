@@ -0,0 +1,55 @@
+package timequeue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorClassification(t *testing.T) {
+	base := errors.New("base")
+	rateLimited := &RateLimited{Err: base, RetryAfter: time.Second}
+	tests := []struct {
+		err         error
+		retryable   bool
+		fatal       bool
+		rateLimited bool
+	}{
+		{base, false, false, false},
+		{Retryable(base), true, false, false},
+		{Fatal(base), false, true, false},
+		{fmt.Errorf("wrapped: %w", Fatal(base)), false, true, false},
+		{rateLimited, false, false, true},
+		{fmt.Errorf("wrapped: %w", rateLimited), false, false, true},
+	}
+	for _, test := range tests {
+		if result := IsRetryable(test.err); result != test.retryable {
+			t.Errorf("IsRetryable(%v) = %v WANT %v", test.err, result, test.retryable)
+		}
+		if result := IsFatal(test.err); result != test.fatal {
+			t.Errorf("IsFatal(%v) = %v WANT %v", test.err, result, test.fatal)
+		}
+		if r, ok := AsRateLimited(test.err); ok != test.rateLimited || (ok && r != rateLimited) {
+			t.Errorf("AsRateLimited(%v) = %v, %v WANT %v", test.err, r, ok, test.rateLimited)
+		}
+		if !errors.Is(test.err, base) {
+			t.Errorf("errors.Is(%v, base) = false WANT true", test.err)
+		}
+	}
+}
+
+func TestRateLimited_Error(t *testing.T) {
+	tests := []struct {
+		err    *RateLimited
+		result string
+	}{
+		{&RateLimited{RetryAfter: time.Second}, "timequeue: rate limited, retry after 1s"},
+		{&RateLimited{Err: errors.New("429"), RetryAfter: time.Second}, "timequeue: rate limited, retry after 1s: 429"},
+	}
+	for _, test := range tests {
+		if result := test.err.Error(); result != test.result {
+			t.Errorf("err.Error() = %q WANT %q", result, test.result)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//Rung is a single step of an Escalation.
+type Rung struct {
+	//After is the duration after the start of the Escalation at which the rung
+	//is released.
+	After time.Duration
+	//Data is the Data of the rung's Message.
+	Data interface{}
+}
+
+//Escalation is a ladder of linked Messages, e.g. warn at +5m, page at +15m, and
+//auto-cancel at +1h, where acknowledging the Escalation removes all rungs that
+//have yet to be released.
+type Escalation struct {
+	q     *TimeQueue
+	rungs []*Message
+	acked bool
+}
+
+//PushEscalation pushes a Message for every rung at start plus the rung's After
+//duration. The returned Escalation may be acknowledged with Ack() to cancel all
+//rungs that have not been released.
+//
+//Consumers receiving a rung may find its Escalation with EscalationOf().
+//Every rung after the first has a ParentID of the first rung's ID.
+func (q *TimeQueue) PushEscalation(start time.Time, rungs ...Rung) *Escalation {
+	e := &Escalation{
+		q:     q,
+		rungs: make([]*Message, 0, len(rungs)),
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, rung := range rungs {
+		message := &Message{
+			Time:       start.Add(rung.After),
+			Data:       rung.Data,
+			escalation: e,
+		}
+		if len(e.rungs) > 0 {
+			message.ParentID = e.rungs[0].ID
+			message.TraceID = e.rungs[0].TraceID
+		}
+		q.pushStored(message)
+		e.rungs = append(e.rungs, message)
+	}
+	q.afterHeapUpdate()
+	return e
+}
+
+//EscalationOf returns the Escalation that message is a rung of, or nil if message
+//was not pushed by PushEscalation().
+func EscalationOf(message *Message) *Escalation {
+	if message == nil {
+		return nil
+	}
+	return message.escalation
+}
+
+//Ack acknowledges e and removes all of its rungs that are still in its TimeQueue.
+//Returns the number of rungs removed.
+//Calling Ack more than once is a nop that returns zero.
+func (e *Escalation) Ack() int {
+	e.q.lock.Lock()
+	defer e.q.lock.Unlock()
+	if e.acked {
+		return 0
+	}
+	e.acked = true
+	count := 0
+	for _, message := range e.rungs {
+		if e.q.removeStored(message) || e.q.removeHeld(message) {
+			e.q.finalize(message, DropCanceled)
+			count++
+		}
+	}
+	e.q.afterHeapUpdate()
+	return count
+}
+
+//IsAcked returns whether or not Ack() has been called on e.
+func (e *Escalation) IsAcked() bool {
+	e.q.lock.Lock()
+	defer e.q.lock.Unlock()
+	return e.acked
+}
+
+//Pending returns the rungs of e that are still in its TimeQueue.
+func (e *Escalation) Pending() []*Message {
+	e.q.lock.Lock()
+	defer e.q.lock.Unlock()
+	result := []*Message{}
+	for _, message := range e.rungs {
+		if e.q.contains(message) {
+			result = append(result, message)
+		}
+	}
+	return result
+}
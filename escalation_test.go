@@ -0,0 +1,56 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_PushEscalation(t *testing.T) {
+	q := New()
+	start := time.Now()
+	e := q.PushEscalation(start,
+		Rung{5 * time.Minute, "warn"},
+		Rung{15 * time.Minute, "page"},
+		Rung{time.Hour, "cancel"},
+	)
+	if size := q.Size(); size != 3 {
+		t.Errorf("q.Size() = %v WANT %v", size, 3)
+	}
+	warn := q.Pop(false)
+	if warn.Data != "warn" || !warn.Time.Equal(start.Add(5*time.Minute)) {
+		t.Errorf("q.Pop() = %v WANT warn at %v", warn, start.Add(5*time.Minute))
+	}
+	if escalation := EscalationOf(warn); escalation != e {
+		t.Errorf("EscalationOf(warn) = %v WANT %v", escalation, e)
+	}
+	pending := e.Pending()
+	if len(pending) != 2 {
+		t.Fatalf("len(e.Pending()) = %v WANT %v", len(pending), 2)
+	}
+	for _, rung := range pending {
+		if rung.ParentID != warn.ID {
+			t.Errorf("rung.ParentID = %q WANT %q", rung.ParentID, warn.ID)
+		}
+	}
+	if count := EscalationOf(warn).Ack(); count != 2 {
+		t.Errorf("e.Ack() = %v WANT %v", count, 2)
+	}
+	if count := e.Ack(); count != 0 {
+		t.Errorf("e.Ack() = %v WANT %v", count, 0)
+	}
+	if !e.IsAcked() {
+		t.Errorf("e.IsAcked() = %v WANT %v", false, true)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestEscalationOf_nil(t *testing.T) {
+	if e := EscalationOf(nil); e != nil {
+		t.Errorf("EscalationOf(nil) = %v WANT nil", e)
+	}
+	if e := EscalationOf(&Message{}); e != nil {
+		t.Errorf("EscalationOf(&Message{}) = %v WANT nil", e)
+	}
+}
@@ -0,0 +1,111 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//EventsCapacity is the capacity of the channel returned from Events().
+const EventsCapacity = 64
+
+//EventType is the kind of an Event.
+type EventType int
+
+//EventTypes of Events.
+const (
+	//EventStarted is sent when a TimeQueue is started.
+	EventStarted EventType = iota
+	//EventStopped is sent when a TimeQueue is stopped.
+	EventStopped
+	//EventPaused is sent when a TimeQueue is held with Hold().
+	EventPaused
+	//EventResumed is sent when a hold is lifted with Release().
+	EventResumed
+	//EventDrained is sent when a TimeQueue becomes empty.
+	EventDrained
+	//EventOverflowed is sent when a Message is released to an output channel that
+	//is full, i.e. when receivers stop keeping up. It is not sent again until a
+	//Message is released to an output channel with room.
+	EventOverflowed
+	//EventTimerReset is sent when a TimeQueue sets the timer that wakes it to
+	//release its earliest Message.
+	EventTimerReset
+)
+
+//String returns the name of t.
+func (t EventType) String() string {
+	switch t {
+	case EventStarted:
+		return "started"
+	case EventStopped:
+		return "stopped"
+	case EventPaused:
+		return "paused"
+	case EventResumed:
+		return "resumed"
+	case EventDrained:
+		return "drained"
+	case EventOverflowed:
+		return "overflowed"
+	case EventTimerReset:
+		return "timer reset"
+	}
+	return "unknown"
+}
+
+//Event is a state transition of a TimeQueue. See Events().
+type Event struct {
+	Type EventType
+	//Time is when the Event occurred, according to Now().
+	Time time.Time
+	//Size is the number of Messages in the TimeQueue after the Event.
+	Size int
+	//Wake is the time the timer wakes the TimeQueue for EventTimerReset.
+	Wake time.Time
+}
+
+//Events returns the channel that the state transitions of q are sent on, so that
+//supervisors can observe them. The returned channel will be the same instance on
+//every call, and it is never closed.
+//
+//Events are sent without waiting, in the order that they occur. An Event that
+//occurs while the channel holds EventsCapacity Events is dropped, so the channel
+//should be received from by a dedicated go-routine.
+func (q *TimeQueue) Events() <-chan Event {
+	return q.events
+}
+
+//emit sends an Event with t on q.events, or drops it if q.events is full.
+//It should only be called when q is locked.
+func (q *TimeQueue) emit(t EventType, wake time.Time) {
+	event := Event{
+		Type: t,
+		Time: q.now(),
+		Size: q.size(),
+		Wake: wake,
+	}
+	select {
+	case q.events <- event:
+	default:
+	}
+}
+
+//emitDrained sends EventDrained if q became empty since it was last called.
+//It should only be called when q is locked.
+func (q *TimeQueue) emitDrained() {
+	empty := q.size() == 0
+	if empty && !q.drained {
+		q.emit(EventDrained, time.Time{})
+	}
+	q.drained = empty
+}
+
+//emitOverflowed sends EventOverflowed if out is full and the last output channel
+//that a Message was released to was not.
+//It should only be called when q is locked.
+func (q *TimeQueue) emitOverflowed(out chan *Message) {
+	full := len(out) >= cap(out)
+	if full && !q.overflowed {
+		q.emit(EventOverflowed, time.Time{})
+	}
+	q.overflowed = full
+}
@@ -0,0 +1,96 @@
+package timequeue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+//receiveEventTypes returns the types of all Events buffered on q.Events().
+func receiveEventTypes(q *TimeQueue) []EventType {
+	result := []EventType{}
+	for {
+		select {
+		case event := <-q.Events():
+			result = append(result, event.Type)
+		default:
+			return result
+		}
+	}
+}
+
+func TestTimeQueue_Events(t *testing.T) {
+	now := time.Now()
+	q := New(WithCapacity(1))
+	q.Start()
+	q.Push(now.Add(time.Hour), 0)
+	q.Hold("maintenance")
+	q.Release()
+	q.Pop(true)
+	for len(q.Messages()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	q.Push(now.Add(time.Hour), 1)
+	q.Pop(true)
+	q.Stop()
+
+	want := []EventType{
+		EventStarted,
+		EventTimerReset,
+		EventPaused,
+		EventResumed,
+		EventTimerReset,
+		EventDrained,
+		EventTimerReset,
+		EventOverflowed,
+		EventDrained,
+		EventStopped,
+	}
+	if got := receiveEventTypes(q); !reflect.DeepEqual(got, want) {
+		t.Errorf("event types = %v WANT %v", got, want)
+	}
+}
+
+func TestTimeQueue_Events_timerReset(t *testing.T) {
+	now := time.Now()
+	q := New()
+	q.Start()
+	defer q.Stop()
+	<-q.Events()
+	q.Push(now.Add(time.Hour), 0)
+	event := <-q.Events()
+	if event.Type != EventTimerReset || !event.Wake.Equal(now.Add(time.Hour)) || event.Size != 1 {
+		t.Errorf("event = %+v WANT %v at %v with size 1", event, EventTimerReset, now.Add(time.Hour))
+	}
+}
+
+func TestTimeQueue_Events_dropped(t *testing.T) {
+	q := New()
+	for i := 0; i < EventsCapacity+1; i++ {
+		q.Hold("")
+	}
+	if got := len(receiveEventTypes(q)); got != EventsCapacity {
+		t.Errorf("len(events) = %v WANT %v", got, EventsCapacity)
+	}
+}
+
+func TestEventType_String(t *testing.T) {
+	tests := []struct {
+		t    EventType
+		want string
+	}{
+		{EventStarted, "started"},
+		{EventStopped, "stopped"},
+		{EventPaused, "paused"},
+		{EventResumed, "resumed"},
+		{EventDrained, "drained"},
+		{EventOverflowed, "overflowed"},
+		{EventTimerReset, "timer reset"},
+		{EventType(-1), "unknown"},
+	}
+	for _, test := range tests {
+		if got := test.t.String(); got != test.want {
+			t.Errorf("%d.String() = %v WANT %v", test.t, got, test.want)
+		}
+	}
+}
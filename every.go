@@ -0,0 +1,69 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//every is the Recurrence of a Schedule created by PushEvery().
+type every struct {
+	interval time.Duration
+	//true if occurrences are relative to the release of the previous occurrence.
+	fromRelease bool
+}
+
+//Next returns t plus the interval of e.
+func (e *every) Next(t time.Time) time.Time {
+	return t.Add(e.interval)
+}
+
+//EveryOption configures a Schedule created by PushEvery().
+type EveryOption func(e *every)
+
+//EveryFromRelease causes each occurrence of a Schedule created by PushEvery() to
+//be one interval after the previous occurrence is actually released, rather than
+//one interval after the previous occurrence's Time.
+//
+//By default occurrences are anchored to the first occurrence and never drift, and
+//occurrences that are late, e.g. because of a hold, are released in quick
+//succession until the Schedule has caught up. With EveryFromRelease, late
+//occurrences delay all later occurrences and none are released in quick succession.
+func EveryFromRelease() EveryOption {
+	return func(e *every) {
+		e.fromRelease = true
+	}
+}
+
+//PushEvery pushes a Message with Data data that recurs every interval, starting
+//one interval from now. See EveryFromRelease() for how drift is controlled.
+//PushEvery panics if interval is not positive.
+func (q *TimeQueue) PushEvery(interval time.Duration, data interface{}, opts ...EveryOption) *Schedule {
+	if interval <= 0 {
+		panic("timequeue: non-positive interval for PushEvery")
+	}
+	e := &every{interval: interval}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return q.PushRecurring(e, data)
+}
+
+//Reset changes the interval of s, which must have been created by PushEvery(), to
+//interval and reschedules its pending occurrence to one interval from now.
+//Returns false, without changing s, if s was not created by PushEvery(), has
+//ended, or interval is not positive.
+func (s *Schedule) Reset(interval time.Duration) bool {
+	s.q.lock.Lock()
+	defer s.q.lock.Unlock()
+	e, ok := s.recurrence.(*every)
+	message := s.pending()
+	if !ok || message == nil || interval <= 0 {
+		return false
+	}
+	e.interval = interval
+	if !s.q.removeStored(message) {
+		s.q.removeHeld(message)
+	}
+	s.push(s.q.now().Add(interval))
+	s.q.afterHeapUpdate()
+	return true
+}
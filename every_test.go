@@ -0,0 +1,67 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_PushEvery(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		opts     []EveryOption
+		released int
+		next     time.Time
+	}{
+		//anchored occurrences catch up on those missed and stay on the minute.
+		{nil, 3, start.Add(4 * time.Minute)},
+		//occurrences from release are a minute after the late release.
+		{[]EveryOption{EveryFromRelease()}, 1, start.Add(3*time.Minute + 30*time.Second + time.Minute)},
+	}
+	for i, test := range tests {
+		q := New(WithManualAdvance(start))
+		s := q.PushEvery(time.Minute, i, test.opts...)
+		released := q.Advance(start.Add(3*time.Minute + 30*time.Second))
+		if len(released) != test.released {
+			t.Errorf("%v: len(q.Advance()) = %v WANT %v", i, len(released), test.released)
+		}
+		if next := s.Message().Time; !next.Equal(test.next) {
+			t.Errorf("%v: s.Message().Time = %v WANT %v", i, next, test.next)
+		}
+	}
+}
+
+func TestSchedule_Reset(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start))
+	s := q.PushEvery(time.Hour, "tick")
+	q.Advance(start.Add(30 * time.Minute))
+	if !s.Reset(time.Minute) {
+		t.Fatalf("s.Reset() = false WANT true")
+	}
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+	if upcoming := s.Upcoming(2); !upcoming[0].Equal(start.Add(31*time.Minute)) || !upcoming[1].Equal(start.Add(32*time.Minute)) {
+		t.Errorf("s.Upcoming() = %v WANT 31 and 32 minutes after start", upcoming)
+	}
+	if s.Reset(0) {
+		t.Errorf("s.Reset(0) = true WANT false")
+	}
+	s.Stop()
+	if s.Reset(time.Minute) {
+		t.Errorf("s.Reset() after Stop() = true WANT false")
+	}
+	cron, _ := q.PushCron("@daily", nil)
+	if cron.Reset(time.Minute) {
+		t.Errorf("cron.Reset() = true WANT false")
+	}
+}
+
+func TestTimeQueue_PushEvery_panic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("q.PushEvery(0) recover() = nil WANT non-nil")
+		}
+	}()
+	New().PushEvery(0, nil)
+}
@@ -0,0 +1,96 @@
+package timequeue
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//Vars are the live counters of a TimeQueue that are published with expvar.
+type Vars struct {
+	//Size is the number of Messages in the TimeQueue.
+	Size int
+	//Head is the Time of the earliest Message, or nil if the TimeQueue is empty.
+	Head *time.Time
+	//LagSeconds is how long the earliest Message is overdue, or 0 if it is not.
+	LagSeconds float64
+	//Pushed and Released are the Counters of the TimeQueue.
+	Pushed   uint64
+	Released uint64
+	//ReleasesPerSecond is the rate at which Messages were released since Vars were
+	//previously measured, at least one second ago.
+	ReleasesPerSecond float64
+}
+
+//Vars returns the current Vars of q.
+func (q *TimeQueue) Vars() Vars {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	counters := q.Counters()
+	result := Vars{
+		Size:              q.size(),
+		Pushed:            counters.Pushed,
+		Released:          counters.Released,
+		ReleasesPerSecond: q.releaseRate.measure(counters.Released, time.Now()),
+	}
+	if message := q.peekMessage(); message != nil {
+		head := message.Time
+		result.Head = &head
+		if lag := q.now().Sub(head); lag > 0 {
+			result.LagSeconds = lag.Seconds()
+		}
+	}
+	return result
+}
+
+//Expvar returns an expvar.Var whose value is the JSON encoding of Vars().
+func (q *TimeQueue) Expvar() expvar.Var {
+	return expvar.Func(func() interface{} {
+		return q.Vars()
+	})
+}
+
+//Publish publishes Expvar() with expvar.Publish() as name, so that q is included in
+//the /debug/vars endpoint served by package expvar, and existing dashboards that
+//read it pick up q without other metrics libraries.
+//Like expvar.Publish(), Publish panics if name is already published.
+func (q *TimeQueue) Publish(name string) {
+	expvar.Publish(name, q.Expvar())
+}
+
+//ExpvarHandler returns an http.Handler that responds with the JSON encoding of
+//Vars(), for serving q's counters without publishing them globally.
+func (q *TimeQueue) ExpvarHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, q.Vars())
+	})
+}
+
+//rateMeter measures the rate at which a counter increases.
+type rateMeter struct {
+	//protects all fields.
+	lock sync.Mutex
+	//the time and value of the previous measurement.
+	at    time.Time
+	value uint64
+	//the most recently measured rate.
+	rate float64
+}
+
+//measure returns the rate per second at which the counter increased to value
+//since the previous measurement. Measurements less than a second apart return
+//the previous rate.
+func (m *rateMeter) measure(value uint64, now time.Time) float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.at.IsZero() {
+		m.at, m.value = now, value
+		return 0
+	}
+	if elapsed := now.Sub(m.at); elapsed >= time.Second {
+		m.rate = float64(value-m.value) / elapsed.Seconds()
+		m.at, m.value = now, value
+	}
+	return m.rate
+}
@@ -0,0 +1,51 @@
+package timequeue
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Vars(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start))
+	if vars := q.Vars(); vars.Size != 0 || vars.Head != nil || vars.ReleasesPerSecond != 0 {
+		t.Errorf("q.Vars() = %+v WANT empty", vars)
+	}
+	for i := 0; i < 4; i++ {
+		q.Push(start.Add(time.Duration(i)*time.Minute), i)
+	}
+	q.Advance(start.Add(90 * time.Second))
+	q.Advance(start.Add(3 * time.Minute))
+
+	//the previous measurement was a second ago.
+	q.releaseRate.at = q.releaseRate.at.Add(-time.Second)
+	vars := q.Vars()
+	if vars.Size != 0 || vars.Pushed != 4 || vars.Released != 4 || vars.ReleasesPerSecond < 3 || vars.ReleasesPerSecond > 4 {
+		t.Errorf("q.Vars() = %+v WANT 4 pushed and released at about 4/s", vars)
+	}
+
+	q.Push(start.Add(time.Minute), "overdue")
+	if vars := q.Vars(); vars.Head == nil || !vars.Head.Equal(start.Add(time.Minute)) || vars.LagSeconds != 120 {
+		t.Errorf("q.Vars() = %+v WANT Head at 1m and 120s lag", vars)
+	}
+}
+
+func TestTimeQueue_Publish(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(time.Hour), 0)
+	q.Publish("timequeue_test")
+	vars := Vars{}
+	if err := json.Unmarshal([]byte(expvar.Get("timequeue_test").String()), &vars); err != nil || vars.Size != 1 {
+		t.Errorf("expvar.Get() = %+v, %v WANT Size 1", vars, err)
+	}
+
+	w := httptest.NewRecorder()
+	q.ExpvarHandler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	vars = Vars{}
+	if err := json.Unmarshal(w.Body.Bytes(), &vars); err != nil || vars.Pushed != 1 || vars.Head == nil {
+		t.Errorf("ExpvarHandler() = %v, %v WANT Pushed 1", w.Body.String(), err)
+	}
+}
@@ -0,0 +1,187 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//FairnessPolicy decides how Messages of different Topics that are due in the same
+//wake are interleaved, so that a Topic with many due Messages cannot monopolize a
+//burst of releases. Messages of the same Topic are always released in Time order.
+//
+//A FairnessPolicy is called with its TimeQueue locked and must not call any of its
+//methods. It may keep state between calls, so it should not be given to more than
+//one TimeQueue.
+type FairnessPolicy interface {
+	//Pick returns the index in topics of the Topic whose earliest due Message is
+	//released next. topics contains every Topic that has due Messages remaining,
+	//in order of their earliest due Message. An index out of range picks the first
+	//Topic.
+	Pick(topics []string) int
+}
+
+//WithFairness sets the FairnessPolicy that interleaves the Topics of Messages due
+//in the same wake. It matters most when the Messages released by a wake are limited
+//by WithWakeBatch() or WithBudget(), in which case it decides which Messages are
+//released first and which wait for the next wake.
+//
+//Every Message that is due is removed from the Storage of the TimeQueue to be
+//interleaved, and those that are not released are pushed back, so a wake costs time
+//proportional to the number of due Messages.
+//A nil policy releases due Messages in Time order, which is the default.
+func WithFairness(policy FairnessPolicy) Option {
+	return func(c *config) {
+		c.fairness = policy
+	}
+}
+
+//roundRobin is the FairnessPolicy returned from RoundRobin().
+type roundRobin struct {
+	//the sequence number of the last pick of every Topic.
+	picked map[string]uint64
+	seq    uint64
+}
+
+//RoundRobin returns a FairnessPolicy that releases one Message of every Topic with
+//due Messages in turn. The Topic picked least recently is always picked next.
+func RoundRobin() FairnessPolicy {
+	return &roundRobin{
+		picked: map[string]uint64{},
+	}
+}
+
+//Pick implements FairnessPolicy.
+func (r *roundRobin) Pick(topics []string) int {
+	result := 0
+	for i, topic := range topics {
+		if r.picked[topic] < r.picked[topics[result]] {
+			result = i
+		}
+	}
+	r.seq++
+	r.picked[topics[result]] = r.seq
+	return result
+}
+
+//weighted is the FairnessPolicy returned from Weighted().
+type weighted struct {
+	weights map[string]int
+	//the current weight of every Topic in smooth weighted round-robin.
+	current map[string]int
+}
+
+//Weighted returns a FairnessPolicy that releases Messages of every Topic in
+//proportion to its weight in weights, e.g. 3 Messages of a Topic with weight 3 for
+//every Message of a Topic with weight 1. Picks are spread out rather than made in
+//runs. Topics that are not in weights, or whose weight is less than 1, have a weight
+//of 1.
+func Weighted(weights map[string]int) FairnessPolicy {
+	return &weighted{
+		weights: weights,
+		current: map[string]int{},
+	}
+}
+
+//Pick implements FairnessPolicy.
+func (w *weighted) Pick(topics []string) int {
+	result, total := 0, 0
+	for i, topic := range topics {
+		weight := w.weights[topic]
+		if weight < 1 {
+			weight = 1
+		}
+		total += weight
+		w.current[topic] += weight
+		if w.current[topic] > w.current[topics[result]] {
+			result = i
+		}
+	}
+	w.current[topics[result]] -= total
+	return result
+}
+
+//strictPriority is the FairnessPolicy returned from StrictPriority().
+type strictPriority struct {
+	ranks map[string]int
+}
+
+//StrictPriority returns a FairnessPolicy that always releases the Messages of the
+//earliest Topic in topics that has due Messages. Topics that are not in topics are
+//released after all those that are, in Time order.
+func StrictPriority(topics ...string) FairnessPolicy {
+	ranks := make(map[string]int, len(topics))
+	for i, topic := range topics {
+		if _, ok := ranks[topic]; !ok {
+			ranks[topic] = i
+		}
+	}
+	return &strictPriority{
+		ranks: ranks,
+	}
+}
+
+//Pick implements FairnessPolicy.
+func (s *strictPriority) Pick(topics []string) int {
+	result, best := 0, len(s.ranks)
+	for i, topic := range topics {
+		if rank, ok := s.ranks[topic]; ok && rank < best {
+			result, best = i, rank
+		}
+	}
+	return result
+}
+
+//popDueFair is popDue() for a TimeQueue with a FairnessPolicy. It removes every
+//due Message from q.storage, releases them in the order picked by the policy until
+//a limit is reached, and pushes the rest back.
+//It should only be called when q is locked.
+func (q *TimeQueue) popDueFair(now, until time.Time) []*Message {
+	due := map[string][]*Message{}
+	topics := []string{}
+	for message := q.peekStored(); message != nil && message.Before(until.Add(q.lead(message))); message = q.peekStored() {
+		message = q.popStoredDue(until.Add(q.lead(message)))
+		if q.isHeldMessage(message) {
+			q.heldMessages.pushMessage(message)
+			continue
+		}
+		if message.expired(now) {
+			q.markRemoved(message)
+			q.expire(message)
+			continue
+		}
+		if _, ok := due[message.Topic]; !ok {
+			topics = append(topics, message.Topic)
+		}
+		due[message.Topic] = append(due[message.Topic], message)
+	}
+
+	result := make([]*Message, 0)
+	for len(topics) > 0 {
+		if q.config.wakeBatch > 0 && len(result) >= q.config.wakeBatch {
+			break
+		}
+		i := q.config.fairness.Pick(topics)
+		if i < 0 || i >= len(topics) {
+			i = 0
+		}
+		topic := topics[i]
+		message := due[topic][0]
+		if !q.spendBudget(now, message.weight()) {
+			break
+		}
+		if due[topic] = due[topic][1:]; len(due[topic]) == 0 {
+			delete(due, topic)
+			topics = append(topics[:i], topics[i+1:]...)
+		}
+		q.markRemoved(message)
+		if q.claim(message) {
+			result = append(result, message)
+		}
+	}
+
+	for _, topic := range topics {
+		for _, message := range due[topic] {
+			q.restoreStored(message)
+		}
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package timequeue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithFairness(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy FairnessPolicy
+		want   string
+	}{
+		{"default", nil, "aaaabbc"},
+		{"round robin", RoundRobin(), "abcabaa"},
+		{"weighted", Weighted(map[string]int{"b": 2}), "bacbaaa"},
+		{"strict priority", StrictPriority("c", "b"), "cbbaaaa"},
+	}
+	for _, test := range tests {
+		now := time.Now()
+		q := New(WithManualAdvance(now), WithFairness(test.policy))
+		for i, topic := range "aaaabbc" {
+			q.PushMessage(&Message{Time: now.Add(time.Duration(i)), Topic: string(topic)})
+		}
+		result := ""
+		for _, message := range q.Advance(now.Add(time.Second)) {
+			result += message.Topic
+		}
+		if result != test.want {
+			t.Errorf("%v: topics = %v WANT %v", test.name, result, test.want)
+		}
+	}
+}
+
+func TestWithFairness_wakeBatch(t *testing.T) {
+	now := time.Now()
+	q := New(WithManualAdvance(now), WithFairness(RoundRobin()), WithWakeBatch(2))
+	for i, topic := range []string{"a", "a", "a", "b"} {
+		q.PushMessage(&Message{Time: now.Add(time.Duration(i)), Topic: topic})
+	}
+	q.lock.Lock()
+	released := q.popDue(now.Add(time.Second))
+	q.lock.Unlock()
+	topics := []string{}
+	for _, message := range released {
+		topics = append(topics, message.Topic)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(topics, want) {
+		t.Errorf("topics = %v WANT %v", topics, want)
+	}
+	if size := q.Size(); size != 2 {
+		t.Errorf("q.Size() = %v WANT %v", size, 2)
+	}
+	if message := q.PeekMessage(); message == nil || message.Time != now.Add(1) {
+		t.Errorf("q.PeekMessage() = %v WANT the second Message", message)
+	}
+}
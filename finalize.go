@@ -0,0 +1,83 @@
+package timequeue
+
+//DropReason is why a TimeQueue discarded a Message without releasing it.
+type DropReason int
+
+const (
+	//DropCleared is the reason for Messages removed by Clear().
+	DropCleared DropReason = iota
+	//DropClaimed is the reason for Messages claimed from a shared ClaimStore by
+	//another TimeQueue.
+	DropClaimed
+	//DropCanceled is the reason for Messages whose Schedule was stopped or whose
+	//Escalation was acknowledged.
+	DropCanceled
+	//DropRestoreFailed is the reason for Messages that were decoded by
+	//NewFromSnapshot(), ImportJSON(), or ReceiveHandoff() before a later error
+	//stopped them from being pushed.
+	DropRestoreFailed
+	//DropDeadLettersFull is the reason for dead letters that were discarded because
+	//the channel returned from DeadLetters() was full.
+	DropDeadLettersFull
+	//DropExpired is the reason for Messages whose Deadline() passed before they were
+	//released.
+	DropExpired
+)
+
+//String returns the name of r.
+func (r DropReason) String() string {
+	switch r {
+	case DropCleared:
+		return "cleared"
+	case DropClaimed:
+		return "claimed"
+	case DropCanceled:
+		return "canceled"
+	case DropRestoreFailed:
+		return "restore failed"
+	case DropDeadLettersFull:
+		return "dead letters full"
+	case DropExpired:
+		return "expired"
+	}
+	return "unknown"
+}
+
+//Finalizer may be implemented by the Data of Messages that reference resources,
+//e.g. open files or reservations, that must be cleaned up if the Message is never
+//released. Finalize is called once when a TimeQueue discards the Message.
+//
+//Messages returned to the caller, e.g. by Pop(false) or PopAll(false), and
+//Messages removed with Remove() are not discarded, since the caller may still
+//release their resources.
+type Finalizer interface {
+	Finalize(reason DropReason)
+}
+
+//WithFinalizer sets a function that is called with every Message that a TimeQueue
+//discards without releasing it, after the Finalize method of its Data if it is a
+//Finalizer. finalize is called while the TimeQueue is locked and must not call any
+//of its methods.
+func WithFinalizer(finalize func(message Message, reason DropReason)) Option {
+	return func(c *config) {
+		c.finalizer = finalize
+	}
+}
+
+//finalize calls the finalizers of every one of messages for reason.
+func (c *config) finalize(messages []*Message, reason DropReason) {
+	for _, message := range messages {
+		if finalizer, ok := message.Data.(Finalizer); ok {
+			finalizer.Finalize(reason)
+		}
+		if c.finalizer != nil {
+			c.finalizer(*message, reason)
+		}
+	}
+}
+
+//finalize calls the finalizers of message for reason.
+//It should only be called when q is locked.
+func (q *TimeQueue) finalize(message *Message, reason DropReason) {
+	q.config.finalize([]*Message{message}, reason)
+}
@@ -0,0 +1,81 @@
+package timequeue
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//reservation is a Finalizer that records why it was finalized.
+type reservation struct {
+	reasons *[]DropReason
+}
+
+func (r reservation) Finalize(reason DropReason) {
+	*r.reasons = append(*r.reasons, reason)
+}
+
+func TestWithFinalizer(t *testing.T) {
+	reasons := []DropReason{}
+	finalized := []interface{}{}
+	q := New(WithFinalizer(func(message Message, reason DropReason) {
+		finalized = append(finalized, message.Data)
+	}))
+	now := time.Now()
+	q.Push(now, reservation{reasons: &reasons})
+	q.Push(now.Add(time.Hour), 1)
+	popped := q.Push(now.Add(2*time.Hour), 2)
+	q.Remove(popped, false)
+	if count := q.Clear(); count != 2 {
+		t.Errorf("q.Clear() = %v WANT %v", count, 2)
+	}
+	if !reflect.DeepEqual(reasons, []DropReason{DropCleared}) || len(finalized) != 2 || finalized[1] != 1 {
+		t.Errorf("finalized = %v, %v WANT cleared reservation and 1", reasons, finalized)
+	}
+
+	schedule := q.PushEvery(time.Hour, reservation{reasons: &reasons})
+	schedule.Stop()
+	if !reflect.DeepEqual(reasons, []DropReason{DropCleared, DropCanceled}) {
+		t.Errorf("reasons = %v WANT cleared, canceled", reasons)
+	}
+}
+
+func TestWithFinalizer_restoreFailed(t *testing.T) {
+	finalized := map[string]DropReason{}
+	q := New(WithFinalizer(func(message Message, reason DropReason) {
+		finalized[message.ID] = reason
+	}))
+	document := `{"version": 1, "messages": [
+		{"time": "2017-03-04T02:00:00Z", "id": "a", "data": 1},
+		{"time": "2017-03-04T02:00:00Z", "id": "b", "data": "bad"}
+	]}`
+	_, err := q.ImportJSON(strings.NewReader(document), func(topic string, data json.RawMessage) (interface{}, error) {
+		if string(data) == `"bad"` {
+			return nil, errors.New("bad")
+		}
+		return data, nil
+	})
+	if err == nil || q.Size() != 0 || !reflect.DeepEqual(finalized, map[string]DropReason{"a": DropRestoreFailed}) {
+		t.Errorf("q.ImportJSON() = %v, finalized %v WANT error and a finalized", err, finalized)
+	}
+}
+
+func TestDropReason_String(t *testing.T) {
+	tests := map[DropReason]string{
+		DropCleared:         "cleared",
+		DropClaimed:         "claimed",
+		DropCanceled:        "canceled",
+		DropRestoreFailed:   "restore failed",
+		DropDeadLettersFull: "dead letters full",
+		DropExpired:         "expired",
+		DropReason(-1):      "unknown",
+	}
+	for reason, want := range tests {
+		if result := reason.String(); result != want {
+			t.Errorf("%d.String() = %v WANT %v", reason, result, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/gogolfing/timequeue
+
+go 1.25.0
+
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/klauspost/compress v1.18.0
+	github.com/redis/go-redis/v9 v9.9.0
+	go.etcd.io/bbolt v1.5.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/sys v0.45.0 // indirect
+)
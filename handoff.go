@@ -0,0 +1,257 @@
+package timequeue
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gogolfing/timequeue/internal/record"
+)
+
+//HandoffVersion is the version of the handoff protocol spoken by HandoffTo()
+//and ReceiveHandoff().
+//
+//The protocol is encoded with encoding/gob, which ignores fields that are unknown
+//to the decoder and leaves fields missing from the encoder at their zero values.
+//This allows processes running different versions of this package to hand off
+//Messages to each other. The version is only incremented when a change cannot be
+//understood by an older receiver, in which case the receiver rejects the handoff
+//and the sender keeps all of its Messages.
+const HandoffVersion = 1
+
+var (
+	//ErrHandoffVersion is returned when the sending side of a handoff speaks a
+	//newer protocol version than the receiving side.
+	ErrHandoffVersion = errors.New("timequeue: unsupported handoff version")
+
+	//ErrHandoffCount is returned when the number of Messages acknowledged by a
+	//receiver does not equal the number sent, or when the sending side of a
+	//handoff announces a negative number of Messages.
+	ErrHandoffCount = errors.New("timequeue: handoff count mismatch")
+)
+
+//maxHandoffPrealloc is the greatest number of Messages that ReceiveHandoff() and
+//NewFromSnapshot() allocate room for before they are read, so that a header with
+//a huge Count cannot allocate unbounded memory.
+const maxHandoffPrealloc = 1024
+
+//handoffHeader is the first value sent by the sending side of a handoff.
+type handoffHeader struct {
+	Version int
+	Count   int
+}
+
+//handoffAck is sent by the receiving side of a handoff once after accepting the
+//header and once after all Messages have been pushed.
+//Err is non-empty if the receiver rejected the handoff.
+type handoffAck struct {
+	Version int
+	Count   int
+	Err     string
+}
+
+//HandoffTo streams all Messages in q to the receiving side of rw, which should
+//be another process calling ReceiveHandoff().
+//Messages are only removed from q after the receiver has acknowledged every one
+//of them. If any error occurs, then q keeps all of its Messages.
+//Returns the number of Messages handed off.
+//Messages pushed with ScheduleFunc() or PushQueue() cannot be sent to another
+//process and stay in q.
+//
+//q is locked for the entire duration of the handoff so that no Messages are
+//released or modified while they are in transit. Usually q should be stopped
+//before calling HandoffTo, e.g. during a graceful restart:
+//	conn, err := net.Dial("unix", "/run/myservice/handoff.sock")
+//	//handle err.
+//	q.Stop()
+//	n, err := q.HandoffTo(conn)
+//
+//Message Data is encoded with the Codec registered for its type with
+//RegisterType(), or otherwise with encoding/gob, in which case the concrete types
+//of all Data values must be registered with gob.Register() in both processes.
+func (q *TimeQueue) HandoffTo(rw io.ReadWriter) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.unholdMessages(true)
+	messages := q.snapshotMessages()
+	enc := gob.NewEncoder(rw)
+	dec := gob.NewDecoder(rw)
+	if err := enc.Encode(&handoffHeader{Version: HandoffVersion, Count: len(messages)}); err != nil {
+		return 0, err
+	}
+	if _, err := decodeHandoffAck(dec); err != nil {
+		return 0, err
+	}
+	for _, message := range messages {
+		r, err := newRecord(message)
+		if err != nil {
+			return 0, err
+		}
+		if err := enc.Encode(r); err != nil {
+			return 0, err
+		}
+	}
+
+	ack, err := decodeHandoffAck(dec)
+	if err != nil {
+		return 0, err
+	}
+	if ack.Count != len(messages) {
+		return 0, ErrHandoffCount
+	}
+
+	for _, message := range messages {
+		q.removeStored(message)
+	}
+	q.afterHeapUpdate()
+	return ack.Count, nil
+}
+
+//ReceiveHandoff reads Messages sent by another process calling HandoffTo() on
+//the other side of rw, pushes them to q, and acknowledges their receipt.
+//Returns the number of Messages received.
+//
+//All received Messages are pushed to q before the acknowledgement is sent.
+//If sending the acknowledgement fails, then the sender keeps its Messages and
+//they may exist in both processes, but no Messages are lost.
+//
+//Received Messages are admitted like they are by PushMessage(). If any Message
+//is rejected, e.g. with ErrDuplicateKey or a *QuotaError, then none of them are
+//kept in q, the error is returned, and the sender keeps all of its Messages.
+func (q *TimeQueue) ReceiveHandoff(rw io.ReadWriter) (int, error) {
+	dec := gob.NewDecoder(rw)
+	enc := gob.NewEncoder(rw)
+
+	header := &handoffHeader{}
+	if err := dec.Decode(header); err != nil {
+		return 0, err
+	}
+	if header.Version > HandoffVersion {
+		enc.Encode(&handoffAck{Version: HandoffVersion, Err: ErrHandoffVersion.Error()})
+		return 0, ErrHandoffVersion
+	}
+	if header.Count < 0 {
+		enc.Encode(&handoffAck{Version: HandoffVersion, Err: ErrHandoffCount.Error()})
+		return 0, ErrHandoffCount
+	}
+	if err := enc.Encode(&handoffAck{Version: HandoffVersion}); err != nil {
+		return 0, err
+	}
+
+	prealloc := header.Count
+	if prealloc > maxHandoffPrealloc {
+		prealloc = maxHandoffPrealloc
+	}
+	messages := make([]*Message, 0, prealloc)
+	for i := 0; i < header.Count; i++ {
+		message, err := decodeRecord(dec)
+		if err != nil {
+			q.lock.Lock()
+			q.config.finalize(messages, DropRestoreFailed)
+			q.lock.Unlock()
+			enc.Encode(&handoffAck{Version: HandoffVersion, Err: err.Error()})
+			return 0, err
+		}
+		messages = append(messages, message)
+	}
+
+	q.lock.Lock()
+	for i, message := range messages {
+		if err := q.admitMessage(message); err != nil {
+			for _, pushed := range messages[:i] {
+				q.removeStored(pushed)
+			}
+			q.config.finalize(messages, DropRestoreFailed)
+			q.afterHeapUpdate()
+			q.lock.Unlock()
+			enc.Encode(&handoffAck{Version: HandoffVersion, Err: err.Error()})
+			return 0, err
+		}
+		q.pushStored(message)
+	}
+	q.afterHeapUpdate()
+	q.lock.Unlock()
+
+	if err := enc.Encode(&handoffAck{Version: HandoffVersion, Count: len(messages)}); err != nil {
+		return len(messages), err
+	}
+	return len(messages), nil
+}
+
+//decodeHandoffAck decodes a handoffAck from dec and returns an error if the ack
+//could not be decoded or the receiver rejected the handoff.
+func decodeHandoffAck(dec *gob.Decoder) (*handoffAck, error) {
+	ack := &handoffAck{}
+	if err := dec.Decode(ack); err != nil {
+		return nil, err
+	}
+	if ack.Err != "" {
+		return nil, fmt.Errorf("timequeue: handoff rejected: %v", ack.Err)
+	}
+	return ack, nil
+}
+
+//decodeRecord decodes a record.Record from dec and returns its Message.
+func decodeRecord(dec *gob.Decoder) (*Message, error) {
+	r := &record.Record{}
+	if err := dec.Decode(r); err != nil {
+		return nil, err
+	}
+	return recordMessage(r)
+}
+
+//newRecord creates the record.Record for message, the encoded form of message
+//that is shared by handoffs, snapshots, WALs, MmapStorage, and Stores.
+func newRecord(message *Message) (*record.Record, error) {
+	r := &record.Record{
+		Time:     message.Time,
+		Topic:    message.Topic,
+		Weight:   message.Weight,
+		ID:       message.ID,
+		ParentID: message.ParentID,
+		TraceID:  message.TraceID,
+		Key:      message.Key,
+		Tenant:   message.Tenant,
+		Affinity: message.Affinity,
+		Priority: message.Priority,
+		TTL:      message.TTL,
+	}
+	name, data, err := MarshalData(message.Data)
+	switch err {
+	case nil:
+		r.DataType, r.DataBytes = name, data
+	case ErrUnregisteredType:
+		r.Data = message.Data
+	default:
+		return nil, err
+	}
+	return r, nil
+}
+
+//recordMessage creates a new Message from the values in r.
+func recordMessage(r *record.Record) (*Message, error) {
+	data := r.Data
+	if r.DataType != "" {
+		decoded, err := UnmarshalData(r.DataType, r.DataBytes)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+	return &Message{
+		Time:     r.Time,
+		Data:     data,
+		Topic:    r.Topic,
+		Weight:   r.Weight,
+		ID:       r.ID,
+		ParentID: r.ParentID,
+		TraceID:  r.TraceID,
+		Key:      r.Key,
+		Tenant:   r.Tenant,
+		Affinity: r.Affinity,
+		Priority: r.Priority,
+		TTL:      r.TTL,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package timequeue
+
+import (
+	"encoding/gob"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_HandoffTo(t *testing.T) {
+	old, young := New(), New()
+	now := time.Now()
+	ids := []string{}
+	for i := 0; i < 4; i++ {
+		ids = append(ids, old.Push(now.Add(time.Duration(i)*time.Hour), i).ID)
+	}
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	received := make(chan int)
+	go func() {
+		n, err := young.ReceiveHandoff(b)
+		if err != nil {
+			t.Errorf("young.ReceiveHandoff() error = %v WANT nil", err)
+		}
+		received <- n
+	}()
+
+	n, err := old.HandoffTo(a)
+	if err != nil {
+		t.Fatalf("old.HandoffTo() error = %v WANT nil", err)
+	}
+	if n != 4 {
+		t.Errorf("old.HandoffTo() = %v WANT %v", n, 4)
+	}
+	if n := <-received; n != 4 {
+		t.Errorf("young.ReceiveHandoff() = %v WANT %v", n, 4)
+	}
+	if size := old.Size(); size != 0 {
+		t.Errorf("old.Size() = %v WANT %v", size, 0)
+	}
+	for i := 0; i < 4; i++ {
+		message := young.Pop(false)
+		if !message.Time.Equal(now.Add(time.Duration(i)*time.Hour)) || message.Data != i {
+			t.Errorf("young.Pop() = %v WANT %v %v", message, now.Add(time.Duration(i)*time.Hour), i)
+		}
+		if message.ID != ids[i] {
+			t.Errorf("young.Pop().ID = %q WANT %q", message.ID, ids[i])
+		}
+	}
+}
+
+func TestTimeQueue_HandoffTo_skipsFuncs(t *testing.T) {
+	old, young := New(), New()
+	now := time.Now()
+	old.Push(now, "sent")
+	old.ScheduleFunc(now, func(Message) {})
+	old.PushQueue(now, New(), QueueStart)
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	go young.ReceiveHandoff(b)
+	if n, err := old.HandoffTo(a); n != 1 || err != nil {
+		t.Fatalf("old.HandoffTo() = %v, %v WANT 1, nil", n, err)
+	}
+	if size := old.Size(); size != 2 {
+		t.Errorf("old.Size() = %v WANT %v", size, 2)
+	}
+}
+
+func TestTimeQueue_HandoffTo_versionRejected(t *testing.T) {
+	old := New()
+	old.Push(time.Now(), 0)
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	go func() {
+		//pretend to be a receiver running an older protocol version.
+		header := &handoffHeader{}
+		gob.NewDecoder(b).Decode(header)
+		gob.NewEncoder(b).Encode(&handoffAck{Version: 0, Err: ErrHandoffVersion.Error()})
+	}()
+
+	if _, err := old.HandoffTo(a); err == nil {
+		t.Errorf("old.HandoffTo() error = nil WANT non-nil")
+	}
+	if size := old.Size(); size != 1 {
+		t.Errorf("old.Size() = %v WANT %v", size, 1)
+	}
+}
+
+func TestTimeQueue_ReceiveHandoff_newerVersion(t *testing.T) {
+	q := New()
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	go func() {
+		gob.NewEncoder(a).Encode(&handoffHeader{Version: HandoffVersion + 1, Count: 1})
+		gob.NewDecoder(a).Decode(&handoffAck{})
+	}()
+
+	if _, err := q.ReceiveHandoff(b); err != ErrHandoffVersion {
+		t.Errorf("q.ReceiveHandoff() error = %v WANT %v", err, ErrHandoffVersion)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_ReceiveHandoff_negativeCount(t *testing.T) {
+	q := New()
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	acks := make(chan *handoffAck, 1)
+	go func() {
+		gob.NewEncoder(a).Encode(&handoffHeader{Version: HandoffVersion, Count: -1})
+		ack := &handoffAck{}
+		gob.NewDecoder(a).Decode(ack)
+		acks <- ack
+	}()
+
+	if _, err := q.ReceiveHandoff(b); err != ErrHandoffCount {
+		t.Errorf("q.ReceiveHandoff() error = %v WANT %v", err, ErrHandoffCount)
+	}
+	if ack := <-acks; ack.Err != ErrHandoffCount.Error() {
+		t.Errorf("ack.Err = %q WANT %q", ack.Err, ErrHandoffCount.Error())
+	}
+}
+
+func TestTimeQueue_ReceiveHandoff_rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		young   *TimeQueue
+		message *Message
+	}{
+		{"duplicate key", New(), &Message{Key: "k"}},
+		{"tenant quota", New(WithTenantQuota(TenantQuota{MaxPending: 1})), &Message{Tenant: "t"}},
+	}
+	for _, test := range tests {
+		old := New()
+		now := time.Now()
+		old.PushMessage(&Message{Time: now, Data: 0})
+		test.message.Time = now.Add(time.Hour)
+		test.young.PushMessage(test.message)
+		old.PushMessage(&Message{Time: now, Key: test.message.Key, Tenant: test.message.Tenant})
+		a, b := net.Pipe()
+
+		received := make(chan error, 1)
+		go func() {
+			_, err := test.young.ReceiveHandoff(b)
+			received <- err
+		}()
+
+		if _, err := old.HandoffTo(a); err == nil {
+			t.Errorf("%v: old.HandoffTo() error = nil WANT non-nil", test.name)
+		}
+		if err := <-received; err == nil {
+			t.Errorf("%v: young.ReceiveHandoff() error = nil WANT non-nil", test.name)
+		}
+		if size := old.Size(); size != 2 {
+			t.Errorf("%v: old.Size() = %v WANT %v", test.name, size, 2)
+		}
+		if size := test.young.Size(); size != 1 {
+			t.Errorf("%v: young.Size() = %v WANT %v", test.name, size, 1)
+		}
+		a.Close()
+		b.Close()
+	}
+}
@@ -0,0 +1,97 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//TimeUntil returns the duration until message is due to be released from q and
+//true, or false if message is not waiting to be released from q.
+//The returned duration is zero if message is overdue. Messages held by a selective
+//hold are not waiting to be released and return false.
+func (q *TimeQueue) TimeUntil(message *Message) (time.Duration, bool) {
+	if message == nil {
+		return 0, false
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if !q.isStored(message) {
+		return 0, false
+	}
+	d := message.Time.Sub(q.now())
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+//WatchHead returns a channel that receives the Time of the earliest Message in q
+//whenever it changes, and a function that stops the watch.
+//The zero Time is sent when q becomes empty.
+//
+//The channel has a buffer of one and only ever holds the most recent Time, so a
+//slow receiver never blocks q and always receives the current head. The channel is
+//not closed by the stop function.
+func (q *TimeQueue) WatchHead() (<-chan time.Time, func()) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	watcher := make(chan time.Time, 1)
+	q.headWatchers[watcher] = struct{}{}
+	return watcher, func() {
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		delete(q.headWatchers, watcher)
+	}
+}
+
+//HeadChanges returns a channel that receives the Time of the earliest Message in q
+//whenever it changes, like the channel returned from WatchHead(), for the life of
+//q. Every call returns the same channel, so it should have a single receiver, e.g.
+//a loop that mirrors q's next release time in a UI or another scheduler. Use
+//WatchHead() for independent watchers that may stop watching.
+//
+//Only changes are sent. To mirror the head from the start, call HeadChanges()
+//before reading the current head with PeekMessage().
+func (q *TimeQueue) HeadChanges() <-chan time.Time {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.headChanges == nil {
+		q.headChanges = make(chan time.Time, 1)
+		q.headWatchers[q.headChanges] = struct{}{}
+	}
+	return q.headChanges
+}
+
+//headTime returns the Time of the earliest Message in q or the zero Time if q is
+//empty.
+//It should only be called when q is locked.
+func (q *TimeQueue) headTime() time.Time {
+	if message := q.peekMessage(); message != nil {
+		return message.Time
+	}
+	return time.Time{}
+}
+
+//notifyHead sends the earliest time in q to all head watchers if it has changed
+//since they were last notified.
+//It should only be called when q is locked.
+func (q *TimeQueue) notifyHead() {
+	head := q.headTime()
+	if head.Equal(q.lastHead) {
+		return
+	}
+	q.lastHead = head
+	for watcher := range q.headWatchers {
+		sendLatest(watcher, head)
+	}
+}
+
+//sendLatest sends t on c, which must have a buffer of one, replacing any value
+//that has yet to be received.
+//sendLatest must only be called by a single go-routine at a time for c.
+func sendLatest(c chan time.Time, t time.Time) {
+	select {
+	case <-c:
+	default:
+	}
+	c <- t
+}
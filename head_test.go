@@ -0,0 +1,88 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_TimeUntil(t *testing.T) {
+	q := New()
+	future := q.Push(time.Now().Add(time.Hour), 0)
+	past := q.Push(time.Now().Add(-time.Hour), 0)
+	if d, ok := q.TimeUntil(future); !ok || d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("q.TimeUntil(future) = %v, %v WANT ~%v, %v", d, ok, time.Hour, true)
+	}
+	if d, ok := q.TimeUntil(past); !ok || d != 0 {
+		t.Errorf("q.TimeUntil(past) = %v, %v WANT %v, %v", d, ok, 0, true)
+	}
+	q.Remove(past, false)
+	if _, ok := q.TimeUntil(past); ok {
+		t.Errorf("q.TimeUntil(removed) ok = %v WANT %v", ok, false)
+	}
+	if _, ok := q.TimeUntil(nil); ok {
+		t.Errorf("q.TimeUntil(nil) ok = %v WANT %v", ok, false)
+	}
+}
+
+func TestTimeQueue_WatchHead(t *testing.T) {
+	q := New()
+	heads, stop := q.WatchHead()
+	now := time.Now()
+	later := q.Push(now.Add(time.Hour), 0)
+	if head := <-heads; !head.Equal(later.Time) {
+		t.Errorf("<-heads = %v WANT %v", head, later.Time)
+	}
+	q.Push(now.Add(2*time.Hour), 0)
+	select {
+	case head := <-heads:
+		t.Errorf("<-heads = %v WANT no change", head)
+	default:
+	}
+	earlier := q.Push(now, 0)
+	q.Remove(earlier, false)
+	if head := <-heads; !head.Equal(later.Time) {
+		t.Errorf("<-heads latest = %v WANT %v", head, later.Time)
+	}
+	q.Clear()
+	if head := <-heads; !head.IsZero() {
+		t.Errorf("<-heads = %v WANT zero", head)
+	}
+	stop()
+	q.Push(now, 0)
+	select {
+	case head := <-heads:
+		t.Errorf("<-heads after stop = %v WANT nothing", head)
+	default:
+	}
+}
+
+func TestTimeQueue_HeadChanges(t *testing.T) {
+	q := New()
+	heads := q.HeadChanges()
+	if again := q.HeadChanges(); again != heads {
+		t.Errorf("q.HeadChanges() = %v WANT same channel %v", again, heads)
+	}
+	now := time.Now()
+	first := q.Push(now.Add(time.Hour), 0)
+	if head := <-heads; !head.Equal(first.Time) {
+		t.Errorf("<-heads = %v WANT %v", head, first.Time)
+	}
+	second := q.Push(now.Add(time.Minute), 0)
+	if head := <-heads; !head.Equal(second.Time) {
+		t.Errorf("<-heads = %v WANT %v", head, second.Time)
+	}
+	q.Remove(second, false)
+	if head := <-heads; !head.Equal(first.Time) {
+		t.Errorf("<-heads = %v WANT %v", head, first.Time)
+	}
+}
+
+func TestSendLatest(t *testing.T) {
+	c := make(chan time.Time, 1)
+	now := time.Now()
+	sendLatest(c, now)
+	sendLatest(c, now.Add(1))
+	if result := <-c; !result.Equal(now.Add(1)) {
+		t.Errorf("<-c = %v WANT %v", result, now.Add(1))
+	}
+}
@@ -0,0 +1,84 @@
+package timequeue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	//ErrNotRunning is returned by Healthy() and Ready() when a TimeQueue is not running.
+	ErrNotRunning = errors.New("timequeue: not running")
+
+	//ErrNotActive is returned by Ready() when a TimeQueue is running but has not
+	//been activated.
+	ErrNotActive = errors.New("timequeue: not active")
+)
+
+//WedgedError is returned by Healthy() when the earliest Message in a releasing
+//TimeQueue has not been released long after its Time has passed.
+type WedgedError struct {
+	//Overdue is the amount of time the earliest Message is past its Time.
+	Overdue time.Duration
+}
+
+//Error implements the error interface.
+func (e *WedgedError) Error() string {
+	return fmt.Sprintf("timequeue: earliest message overdue by %v", e.Overdue)
+}
+
+//Healthy returns nil if q is running and able to release Messages.
+//
+//The first error returned by the Store given WithStore() is returned if there was
+//one, since q may no longer recover its Messages.
+//ErrNotRunning is returned if q is not running.
+//A *WedgedError is returned if q is releasing Messages and the earliest Message
+//is overdue by more than the wedge threshold (see WithWedgeThreshold()), which
+//indicates that the running go-routine or its wake signal is stuck.
+//
+//Healthy only locks q briefly and is cheap enough to call from liveness probes.
+func (q *TimeQueue) Healthy() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.healthy()
+}
+
+//healthy is the unexported version of Healthy().
+//It should only be called when q is locked.
+func (q *TimeQueue) healthy() error {
+	if q.storeErr != nil {
+		return q.storeErr
+	}
+	if !q.isRunning() {
+		return ErrNotRunning
+	}
+	if !q.isReleasing() {
+		return nil
+	}
+	message := q.peekMessage()
+	if message == nil {
+		return nil
+	}
+	if overdue := q.now().Sub(message.Time); overdue > q.config.wedgeThreshold {
+		return &WedgedError{Overdue: overdue}
+	}
+	return nil
+}
+
+//Ready returns nil if q is running and has been activated.
+//ErrNotRunning is returned if q is not running, and ErrNotActive is returned if
+//q was started with StartInactive() and has yet to be activated.
+//A held TimeQueue is still ready because it continues to accept Messages.
+//
+//Ready only locks q briefly and is cheap enough to call from readiness probes.
+func (q *TimeQueue) Ready() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if !q.isRunning() {
+		return ErrNotRunning
+	}
+	if !q.isActive() {
+		return ErrNotActive
+	}
+	return nil
+}
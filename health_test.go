@@ -0,0 +1,68 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Healthy_notRunning(t *testing.T) {
+	q := New()
+	if err := q.Healthy(); err != ErrNotRunning {
+		t.Errorf("q.Healthy() = %v WANT %v", err, ErrNotRunning)
+	}
+}
+
+func TestTimeQueue_Healthy_running(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(time.Hour), 0)
+	q.Start()
+	defer q.Stop()
+	if err := q.Healthy(); err != nil {
+		t.Errorf("q.Healthy() = %v WANT %v", err, nil)
+	}
+}
+
+func TestTimeQueue_Healthy_wedged(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(-time.Hour), 0)
+	//set running without spawning the run loop to simulate a stuck go-routine.
+	q.setRunning(true)
+	err := q.Healthy()
+	if _, ok := err.(*WedgedError); !ok {
+		t.Errorf("q.Healthy() = %v WANT *WedgedError", err)
+	}
+}
+
+func TestTimeQueue_Healthy_inactiveOverdue(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(-time.Hour), 0)
+	q.StartInactive()
+	defer q.Stop()
+	if err := q.Healthy(); err != nil {
+		t.Errorf("q.Healthy() = %v WANT %v", err, nil)
+	}
+}
+
+func TestTimeQueue_Ready(t *testing.T) {
+	q := New()
+	if err := q.Ready(); err != ErrNotRunning {
+		t.Errorf("q.Ready() = %v WANT %v", err, ErrNotRunning)
+	}
+	q.StartInactive()
+	defer q.Stop()
+	if err := q.Ready(); err != ErrNotActive {
+		t.Errorf("q.Ready() = %v WANT %v", err, ErrNotActive)
+	}
+	q.Activate()
+	if err := q.Ready(); err != nil {
+		t.Errorf("q.Ready() = %v WANT %v", err, nil)
+	}
+}
+
+func TestWedgedError_Error(t *testing.T) {
+	err := &WedgedError{Overdue: time.Second}
+	want := "timequeue: earliest message overdue by 1s"
+	if result := err.Error(); result != want {
+		t.Errorf("err.Error() = %v WANT %v", result, want)
+	}
+}
@@ -0,0 +1,81 @@
+package timequeue
+
+import (
+	"errors"
+	"time"
+)
+
+//ErrQueueCycle is returned from PushQueue() when a TimeQueue is given itself.
+var ErrQueueCycle = errors.New("timequeue: a queue cannot schedule itself")
+
+//QueueAction is what is done to a TimeQueue when a Message pushed with
+//PushQueue() is released.
+type QueueAction int
+
+//QueueActions that may be given to PushQueue().
+const (
+	//QueueStart calls Start() on the TimeQueue.
+	QueueStart QueueAction = iota
+	//QueueActivate calls Activate() on the TimeQueue, e.g. one that was started
+	//with StartInactive().
+	QueueActivate
+	//QueueDrain calls PopAll(true) on the TimeQueue, which releases all of its
+	//Messages at once whether or not it is running.
+	QueueDrain
+)
+
+//QueueRef is the Data of a Message pushed with PushQueue().
+type QueueRef struct {
+	//Queue is the TimeQueue that Action is performed on.
+	Queue *TimeQueue
+	//Action is what is done to Queue.
+	Action QueueAction
+}
+
+//PushQueue pushes a Message at t whose release performs action on child instead
+//of sending the Message on Messages(). The Data of the Message is a *QueueRef.
+//Since child may itself have Messages pushed with PushQueue(), this expresses
+//hierarchical plans, e.g. a staged rollout in which each stage is a TimeQueue
+//of jobs that starts an hour after the previous one:
+//	plan := timequeue.New()
+//	for i, stage := range stages {
+//		plan.PushQueue(start.Add(time.Duration(i)*time.Hour), stage, timequeue.QueueStart)
+//	}
+//	plan.Start()
+//
+//Actions are performed like functions given to ScheduleFunc(), one at a time in
+//release order. The Message may be removed from q to cancel the action.
+//ErrNilMessage is returned if child is nil and ErrQueueCycle if child is q.
+func (q *TimeQueue) PushQueue(t time.Time, child *TimeQueue, action QueueAction) (*Message, error) {
+	if child == nil {
+		return nil, ErrNilMessage
+	}
+	if child == q {
+		return nil, ErrQueueCycle
+	}
+	ref := &QueueRef{Queue: child, Action: action}
+	message := &Message{
+		Time: t,
+		Data: ref,
+		fn: func(Message) {
+			ref.perform()
+		},
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.pushStored(message)
+	q.afterHeapUpdate()
+	return message, nil
+}
+
+//perform performs r.Action on r.Queue.
+func (r *QueueRef) perform() {
+	switch r.Action {
+	case QueueStart:
+		r.Queue.Start()
+	case QueueActivate:
+		r.Queue.Activate()
+	case QueueDrain:
+		r.Queue.PopAll(true)
+	}
+}
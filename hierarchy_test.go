@@ -0,0 +1,54 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_PushQueue(t *testing.T) {
+	parent := New()
+	started, drained := New(), New(WithCapacity(2))
+	defer started.Stop()
+	now := time.Now()
+	started.Push(now, "started")
+	drained.Push(now.Add(time.Hour), "drained")
+	drained.Push(now.Add(2*time.Hour), "drained")
+
+	message, err := parent.PushQueue(now.Add(10*time.Millisecond), started, QueueStart)
+	if err != nil || message.Data.(*QueueRef).Queue != started {
+		t.Fatalf("parent.PushQueue() = %v, %v WANT QueueRef to started, nil", message, err)
+	}
+	parent.PushQueue(now.Add(20*time.Millisecond), drained, QueueDrain)
+	if _, err := parent.PushQueue(now, parent, QueueStart); err != ErrQueueCycle {
+		t.Errorf("parent.PushQueue(parent) = %v WANT %v", err, ErrQueueCycle)
+	}
+	if started.IsRunning() {
+		t.Errorf("started.IsRunning() = %v WANT %v", true, false)
+	}
+	parent.Start()
+	defer parent.Stop()
+
+	for _, test := range []struct {
+		q     *TimeQueue
+		count int
+	}{
+		{started, 1},
+		{drained, 2},
+	} {
+		for i := 0; i < test.count; i++ {
+			select {
+			case <-test.q.Messages():
+			case <-time.After(time.Second):
+				t.Fatalf("child queue did not release")
+			}
+		}
+	}
+	if !started.IsRunning() || drained.IsRunning() {
+		t.Errorf("IsRunning() = %v, %v WANT %v, %v", started.IsRunning(), drained.IsRunning(), true, false)
+	}
+	select {
+	case message := <-parent.Messages():
+		t.Errorf("parent.Messages() = %v WANT nothing", message)
+	default:
+	}
+}
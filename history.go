@@ -0,0 +1,156 @@
+package timequeue
+
+import (
+	"sort"
+	"time"
+)
+
+//ReleasedRecord is a record of a single Message that was released.
+type ReleasedRecord struct {
+	//ReleasedAt is when the Message was released.
+	ReleasedAt time.Time
+	//Time is the Message's Time.
+	Time time.Time
+	//Data is the Message's Data.
+	Data interface{}
+	//Topic is the Message's Topic.
+	Topic string
+	//ID is the Message's ID.
+	ID string
+	//ParentID is the Message's ParentID.
+	ParentID string
+	//TraceID is the Message's TraceID.
+	TraceID string
+}
+
+//ArchiveQuerier is implemented by ArchiveSinks that can be queried for the
+//records they have received, e.g. *MemoryArchive.
+type ArchiveQuerier interface {
+	//Between returns all records with At in [from, to) from oldest to newest.
+	Between(from, to time.Time) []ArchiveRecord
+}
+
+//Between returns all records in a with At in [from, to) from oldest to newest.
+func (a *MemoryArchive) Between(from, to time.Time) []ArchiveRecord {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	result := []ArchiveRecord{}
+	for _, record := range a.records {
+		if !record.At.Before(from) && record.At.Before(to) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+//ReleasedBetween returns records of all Messages released by q in [from, to),
+//ordered by the time they were released.
+//
+//Records are read from the ArchiveSinks given to WithArchive() and
+//WithTopicArchive() that implement ArchiveQuerier. Messages that were not
+//archived, or whose records were discarded by retention limits, are not returned.
+//	//what fired between 02:00 and 02:05?
+//	records := q.ReleasedBetween(
+//		time.Date(2017, 3, 4, 2, 0, 0, 0, time.Local),
+//		time.Date(2017, 3, 4, 2, 5, 0, 0, time.Local),
+//	)
+func (q *TimeQueue) ReleasedBetween(from, to time.Time) []ReleasedRecord {
+	return releasedBetween(q.archiveQueriers(), from, to)
+}
+
+//releasedBetween returns records of all Messages released in [from, to) from the
+//records of queriers, ordered by the time they were released.
+func releasedBetween(queriers []ArchiveQuerier, from, to time.Time) []ReleasedRecord {
+	result := []ReleasedRecord{}
+	for _, querier := range queriers {
+		for _, record := range querier.Between(from, to) {
+			if record.Event != ArchiveReleased {
+				continue
+			}
+			result = append(result, ReleasedRecord{
+				ReleasedAt: record.At,
+				Time:       record.Time,
+				Data:       record.Data,
+				Topic:      record.Topic,
+				ID:         record.ID,
+				ParentID:   record.ParentID,
+				TraceID:    record.TraceID,
+			})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ReleasedAt.Before(result[j].ReleasedAt)
+	})
+	return result
+}
+
+//StateAt reconstructs the Messages that were pending in q at t by replaying the
+//records of the ArchiveSinks given to WithArchive() and WithTopicArchive() that
+//implement ArchiveQuerier. It returns the Messages ordered by Time, with only the
+//fields kept by an ArchiveRecord.
+//
+//q must have been given WithArchiveChanges() for its entire lifetime up to t, and
+//the reconstruction is incomplete if records were discarded by retention limits.
+//	//what was waiting when the report fired late yesterday?
+//	for _, message := range q.StateAt(time.Date(2017, 3, 4, 2, 0, 0, 0, time.Local)) {
+//		fmt.Println(message.Time, message.ID, message.Data)
+//	}
+func (q *TimeQueue) StateAt(t time.Time) []Message {
+	pending := map[string]Message{}
+	for _, querier := range q.archiveQueriers() {
+		//every record of a Message is in the same querier since its Topic does not
+		//change.
+		for _, record := range querier.Between(time.Time{}, t.Add(time.Nanosecond)) {
+			switch record.Event {
+			case ArchivePushed:
+				pending[record.ID] = Message{
+					Time:     record.Time,
+					Data:     record.Data,
+					ID:       record.ID,
+					ParentID: record.ParentID,
+					TraceID:  record.TraceID,
+					Topic:    record.Topic,
+				}
+			case ArchiveReleased, ArchiveRemoved:
+				delete(pending, record.ID)
+			}
+		}
+	}
+	result := make([]Message, 0, len(pending))
+	for _, message := range pending {
+		result = append(result, message)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Time.Equal(result[j].Time) {
+			return result[i].Time.Before(result[j].Time)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+//archiveQueriers returns every distinct ArchiveSink configured on q that is an
+//ArchiveQuerier.
+func (q *TimeQueue) archiveQueriers() []ArchiveQuerier {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.queriers()
+}
+
+//queriers is the unexported version of archiveQueriers().
+//It should only be called when q is locked.
+func (q *TimeQueue) queriers() []ArchiveQuerier {
+	sinks := []ArchiveSink{q.config.archive}
+	for _, sink := range q.config.topicArchives {
+		sinks = append(sinks, sink)
+	}
+	result := []ArchiveQuerier{}
+	seen := map[ArchiveQuerier]bool{}
+	for _, sink := range sinks {
+		if querier, ok := sink.(ArchiveQuerier); ok && !seen[querier] {
+			seen[querier] = true
+			result = append(result, querier)
+		}
+	}
+	return result
+}
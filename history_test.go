@@ -0,0 +1,75 @@
+package timequeue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_ReleasedBetween(t *testing.T) {
+	all, orders := NewMemoryArchive(0, 0), NewMemoryArchive(0, 0)
+	q := New(WithArchive(all), WithTopicArchive("orders", orders), WithTopicArchive("other", orders))
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+
+	records := []ArchiveRecord{
+		{Event: ArchiveReleased, At: start.Add(-time.Minute), Data: "before"},
+		{Event: ArchiveReleased, At: start.Add(3 * time.Minute), Data: "b"},
+		{Event: ArchiveConsumed, At: start.Add(3 * time.Minute), Data: "b"},
+		{Event: ArchiveReleased, At: start.Add(5 * time.Minute), Data: "after"},
+	}
+	for _, record := range records {
+		all.Archive(record)
+	}
+	orders.Archive(ArchiveRecord{Event: ArchiveReleased, At: start, Data: "a", Topic: "orders"})
+
+	result := q.ReleasedBetween(start, start.Add(5*time.Minute))
+	if len(result) != 2 || result[0].Data != "a" || result[0].Topic != "orders" || result[1].Data != "b" || !result[1].ReleasedAt.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("q.ReleasedBetween() = %+v WANT records a then b", result)
+	}
+}
+
+func TestTimeQueue_ReleasedBetween_noArchive(t *testing.T) {
+	q := New()
+	q.Push(time.Now(), 0)
+	q.PopAll(true)
+	if result := q.ReleasedBetween(time.Time{}, time.Now().Add(time.Hour)); result == nil || len(result) != 0 {
+		t.Errorf("q.ReleasedBetween() = %v WANT empty", result)
+	}
+}
+
+func TestTimeQueue_StateAt(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	all, orders := NewMemoryArchive(0, 0), NewMemoryArchive(0, 0)
+	q := New(WithManualAdvance(start), WithArchive(all), WithTopicArchive("orders", orders), WithArchiveChanges())
+	a := q.Push(start.Add(time.Minute), "a")
+	q.PushMessage(&Message{Time: start.Add(2 * time.Minute), Data: "b", Topic: "orders"})
+	c := q.Push(start.Add(3*time.Minute), "c")
+	q.Advance(start.Add(90 * time.Second))
+	q.Remove(c, false)
+	q.Advance(start.Add(4 * time.Minute))
+	q.Push(start.Add(5*time.Minute), "d")
+
+	tests := []struct {
+		at   time.Time
+		data []interface{}
+	}{
+		{start.Add(-time.Second), []interface{}{}},
+		{start, []interface{}{"a", "b", "c"}},
+		{start.Add(90 * time.Second), []interface{}{"b"}},
+		{start.Add(3 * time.Minute), []interface{}{"b"}},
+		{start.Add(4 * time.Minute), []interface{}{"d"}},
+	}
+	for i, test := range tests {
+		result := q.StateAt(test.at)
+		data := []interface{}{}
+		for _, message := range result {
+			data = append(data, message.Data)
+		}
+		if len(data) != len(test.data) || (len(data) > 0 && fmt.Sprint(data) != fmt.Sprint(test.data)) {
+			t.Errorf("%v: q.StateAt() = %v WANT %v", i, data, test.data)
+		}
+	}
+	if result := q.StateAt(start); result[0].ID != a.ID {
+		t.Errorf("q.StateAt()[0].ID = %v WANT %v", result[0].ID, a.ID)
+	}
+}
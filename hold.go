@@ -0,0 +1,175 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//Hold stops q from releasing Messages until a call to Release().
+//Messages may still be pushed to and removed from q while it is held.
+//reason is reported by Stats() and the Handler() stats endpoint.
+//
+//Calling Hold while q is already held replaces the reason.
+//Hold is independent of Start() and Stop(), and a held TimeQueue stays held
+//across calls to them.
+func (q *TimeQueue) Hold(reason string) {
+	q.administer("", AuditHold, reason, func() error {
+		q.hold(reason)
+		return nil
+	})
+}
+
+//hold is the unexported version of Hold().
+//It should only be called when q is locked.
+func (q *TimeQueue) hold(reason string) {
+	q.held = true
+	q.holdReason = reason
+	q.killWakeSignal()
+	q.emit(EventPaused, time.Time{})
+}
+
+//Release lifts a hold placed by Hold() and resumes releasing Messages if q is
+//running. Messages whose times passed during the hold are released immediately.
+//If q is not held, then Release is a nop.
+func (q *TimeQueue) Release() {
+	q.administer("", AuditRelease, "", func() error {
+		q.release()
+		return nil
+	})
+}
+
+//release is the unexported version of Release().
+//It should only be called when q is locked.
+func (q *TimeQueue) release() {
+	if !q.held {
+		return
+	}
+	q.held = false
+	q.holdReason = ""
+	q.emit(EventResumed, time.Time{})
+	q.afterHeapUpdate()
+}
+
+//IsHeld returns whether or not q is held and the reason given to Hold().
+func (q *TimeQueue) IsHeld() (bool, string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.held, q.holdReason
+}
+
+//hold is a selective hold on all Messages that match.
+type hold struct {
+	reason string
+	match  func(message *Message) bool
+}
+
+//HoldMatching places a selective hold, identified by name, on all Messages for
+//which match returns true. Held Messages are not released when their times pass
+//while all other Messages continue to be released normally.
+//Held Messages still count towards Size() and may be removed with Remove() or
+//PopAll().
+//
+//Calling HoldMatching with the name of an existing hold replaces it.
+//match is called with q locked and must not call any methods on q.
+func (q *TimeQueue) HoldMatching(name, reason string, match func(message *Message) bool) {
+	q.administer("", AuditHold, name+": "+reason, func() error {
+		q.holdMatching(name, reason, match)
+		return nil
+	})
+}
+
+//holdMatching is the unexported version of HoldMatching().
+//It should only be called when q is locked.
+func (q *TimeQueue) holdMatching(name, reason string, match func(message *Message) bool) {
+	q.holds[name] = &hold{
+		reason: reason,
+		match:  match,
+	}
+	q.unholdMessages(false)
+	q.afterHeapUpdate()
+}
+
+//HoldTopic places a selective hold on all Messages with a Topic of topic.
+//It is equivalent to HoldMatching(HoldTopicName(topic), reason, ...).
+func (q *TimeQueue) HoldTopic(topic, reason string) {
+	q.HoldMatching(HoldTopicName(topic), reason, matchTopic(topic))
+}
+
+//matchTopic returns a selective hold match function for Messages with topic.
+func matchTopic(topic string) func(message *Message) bool {
+	return func(message *Message) bool {
+		return message.Topic == topic
+	}
+}
+
+//HoldTopicName returns the name of the selective hold placed by HoldTopic().
+func HoldTopicName(topic string) string {
+	return "topic:" + topic
+}
+
+//ReleaseMatching lifts the selective hold identified by name.
+//Held Messages that no longer match any selective hold are released immediately
+//if q is releasing.
+//If there is no hold identified by name, then ReleaseMatching is a nop.
+func (q *TimeQueue) ReleaseMatching(name string) {
+	q.administer("", AuditRelease, name, func() error {
+		q.releaseMatching(name)
+		return nil
+	})
+}
+
+//releaseMatching is the unexported version of ReleaseMatching().
+//It should only be called when q is locked.
+func (q *TimeQueue) releaseMatching(name string) {
+	if _, ok := q.holds[name]; !ok {
+		return
+	}
+	delete(q.holds, name)
+	q.unholdMessages(false)
+	q.afterHeapUpdate()
+}
+
+//ReleaseTopic lifts the selective hold placed by HoldTopic(topic).
+func (q *TimeQueue) ReleaseTopic(topic string) {
+	q.ReleaseMatching(HoldTopicName(topic))
+}
+
+//isHeldMessage returns whether or not message matches any selective hold.
+//It should only be called when q is locked.
+func (q *TimeQueue) isHeldMessage(message *Message) bool {
+	for _, h := range q.holds {
+		if h.match(message) {
+			return true
+		}
+	}
+	return false
+}
+
+//removeHeld removes message from q.heldMessages and returns whether or not it was
+//there.
+//It should only be called when q is locked.
+func (q *TimeQueue) removeHeld(message *Message) bool {
+	if !q.heldMessages.removeMessage(message) {
+		return false
+	}
+	q.markRemoved(message)
+	q.storeRemove(message)
+	q.archiveChange(ArchiveRemoved, message)
+	return true
+}
+
+//unholdMessages moves Messages from q.heldMessages back to q.storage.
+//If all is false, then only Messages that no longer match a selective hold are moved.
+//It should only be called when q is locked.
+func (q *TimeQueue) unholdMessages(all bool) {
+	held := make([]*Message, 0, q.heldMessages.Len())
+	for message := q.heldMessages.popMessage(); message != nil; message = q.heldMessages.popMessage() {
+		held = append(held, message)
+	}
+	for _, message := range held {
+		if !all && q.isHeldMessage(message) {
+			q.heldMessages.pushMessage(message)
+		} else {
+			q.restoreStored(message)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Hold(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(time.Hour), 0)
+	q.Start()
+	defer q.Stop()
+	q.Hold("incident")
+	if held, reason := q.IsHeld(); !held || reason != "incident" {
+		t.Errorf("q.IsHeld() = %v, %v WANT %v, %v", held, reason, true, "incident")
+	}
+	if q.wakeSignal != nil {
+		t.Errorf("q.wakeSignal = non-nil WANT nil")
+	}
+	q.Push(time.Now(), 1)
+	if q.wakeSignal != nil {
+		t.Errorf("q.wakeSignal = non-nil WANT nil")
+	}
+	select {
+	case message := <-q.Messages():
+		t.Errorf("<-q.Messages() = %v WANT no release", message)
+	case <-time.After(time.Duration(50) * time.Millisecond):
+	}
+	if size := q.Size(); size != 2 {
+		t.Errorf("q.Size() = %v WANT %v", size, 2)
+	}
+}
+
+func TestTimeQueue_Release(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	q.Hold("incident")
+	want := q.Push(time.Now(), 0)
+	q.Release()
+	if held, reason := q.IsHeld(); held || reason != "" {
+		t.Errorf("q.IsHeld() = %v, %v WANT %v, %v", held, reason, false, "")
+	}
+	if message := <-q.Messages(); message != want {
+		t.Errorf("<-q.Messages() = %v WANT %v", message, want)
+	}
+}
+
+func TestTimeQueue_Release_notHeld(t *testing.T) {
+	q := New()
+	q.Release()
+	if held, _ := q.IsHeld(); held {
+		t.Errorf("q.IsHeld() = %v WANT %v", held, false)
+	}
+}
+
+func TestTimeQueue_HoldTopic(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	q.HoldTopic("email", "provider outage")
+	held := &Message{Time: time.Now(), Data: 0, Topic: "email"}
+	q.PushMessage(held)
+	want := q.Push(time.Now(), 1)
+	if message := <-q.Messages(); message != want {
+		t.Errorf("<-q.Messages() = %v WANT %v", message, want)
+	}
+	select {
+	case message := <-q.Messages():
+		t.Errorf("<-q.Messages() = %v WANT no release", message)
+	case <-time.After(time.Duration(50) * time.Millisecond):
+	}
+	if stats := q.Stats(); stats.Size != 1 || stats.HeldMessages != 1 {
+		t.Errorf("q.Stats() Size, HeldMessages = %v, %v WANT %v, %v", stats.Size, stats.HeldMessages, 1, 1)
+	}
+	q.ReleaseTopic("email")
+	if message := <-q.Messages(); message != held {
+		t.Errorf("<-q.Messages() = %v WANT %v", message, held)
+	}
+}
+
+func TestTimeQueue_HoldMatching_remove(t *testing.T) {
+	q := New()
+	q.HoldMatching("odd", "testing", func(message *Message) bool {
+		return message.Data.(int)%2 == 1
+	})
+	message := q.Push(time.Now(), 1)
+	q.onWake(time.Now().Add(1))
+	if message.mh != q.heldMessages {
+		t.Fatalf("message.mh = %v WANT %v", message.mh, q.heldMessages)
+	}
+	if removed := q.Remove(message, false); !removed {
+		t.Errorf("q.Remove() = %v WANT %v", removed, true)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_ReleaseMatching_unknown(t *testing.T) {
+	q := New()
+	q.ReleaseMatching("unknown")
+	if size := len(q.holds); size != 0 {
+		t.Errorf("len(q.holds) = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_unholdMessages(t *testing.T) {
+	q := New()
+	q.HoldTopic("a", "")
+	a := &Message{Time: time.Now(), Topic: "a"}
+	b := &Message{Time: time.Now(), Topic: "b"}
+	q.heldMessages.pushMessage(a)
+	q.heldMessages.pushMessage(b)
+	q.unholdMessages(false)
+	if a.mh != q.heldMessages || !q.isStored(b) {
+		t.Errorf("unholdMessages(false) moved the wrong messages")
+	}
+	q.unholdMessages(true)
+	if !q.isStored(a) || q.heldMessages.Len() != 0 {
+		t.Errorf("unholdMessages(true) did not move all messages")
+	}
+}
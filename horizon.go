@@ -0,0 +1,124 @@
+package timequeue
+
+import (
+	"sort"
+	"time"
+)
+
+//Horizon summarizes how far in the future the Messages in a TimeQueue are due.
+//All durations are measured from the time Horizon was computed, and overdue
+//Messages count as zero.
+type Horizon struct {
+	//Count is the number of Messages summarized.
+	Count int
+	//P50 is the median duration until release.
+	P50 time.Duration
+	//P95 is the 95th percentile duration until release.
+	P95 time.Duration
+	//Max is the duration until the last Message is released.
+	Max time.Duration
+}
+
+//Horizon computes a Horizon of all Messages in q, including those held by a
+//selective hold and those spilled to the Store by WithMemoryPressure().
+//Messages on virtual clocks are not included, since they are not released by the
+//passing of time. See AdvanceClock().
+//q is locked while the Horizon is computed, which takes time proportional to
+//n log(n) for n Messages in q.
+func (q *TimeQueue) Horizon() Horizon {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	now := q.now()
+	durations := make([]time.Duration, 0, q.size())
+	add := func(message *Message) {
+		d := message.Time.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		durations = append(durations, d)
+	}
+	q.eachTimed(add)
+	for _, message := range q.heldMessages.messages {
+		add(message)
+	}
+	sortDurations(durations)
+	return Horizon{
+		Count: len(durations),
+		P50:   percentile(durations, 50),
+		P95:   percentile(durations, 95),
+		Max:   percentile(durations, 100),
+	}
+}
+
+//eachTimed calls fn with every Message in q.storage and every spilled Message,
+//i.e. every Message that is released by the passing of time and is not held.
+//It should only be called when q is locked.
+func (q *TimeQueue) eachTimed(fn func(message *Message)) {
+	q.storage.Each(fn)
+	for _, message := range q.spilled {
+		fn(message)
+	}
+}
+
+//sortDurations sorts durations in increasing order.
+func sortDurations(durations []time.Duration) {
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+}
+
+//percentile returns the nearest-rank pth percentile of sorted, which must be in
+//increasing order. Zero is returned if sorted is empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+//MaxForecastBuckets is the most buckets returned from Forecast().
+const MaxForecastBuckets = 1 << 16
+
+//Forecast returns the number of Messages in q projected to be released in each
+//bucket of time from now until horizon.
+//The result has one element per bucket, i.e. horizon divided by bucket rounded up,
+//where element i counts the Messages due in [now + i*bucket, now + (i+1)*bucket).
+//Overdue Messages are counted in the first bucket. Messages spilled to the Store
+//by WithMemoryPressure() are counted, but Messages held by a selective hold and
+//Messages on virtual clocks are not.
+//An empty slice is returned if bucket or horizon are not positive. At most
+//MaxForecastBuckets are returned, so a horizon of more buckets is shortened.
+func (q *TimeQueue) Forecast(bucket, horizon time.Duration) []int {
+	if bucket <= 0 || horizon <= 0 {
+		return []int{}
+	}
+	//horizon+bucket-1 could overflow.
+	buckets := horizon / bucket
+	if horizon%bucket != 0 {
+		buckets++
+	}
+	if buckets > MaxForecastBuckets {
+		buckets = MaxForecastBuckets
+	}
+	result := make([]int, buckets)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	now := q.now()
+	q.eachTimed(func(message *Message) {
+		d := message.Time.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		if i := int(d / bucket); d < horizon && i < len(result) {
+			result[i]++
+		}
+	})
+	return result
+}
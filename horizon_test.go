@@ -0,0 +1,115 @@
+package timequeue
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Horizon(t *testing.T) {
+	q := New()
+	if h := q.Horizon(); h != (Horizon{}) {
+		t.Errorf("q.Horizon() = %+v WANT %+v", h, Horizon{})
+	}
+	now := time.Now()
+	q.Push(now.Add(-time.Hour), 0)
+	for i := 1; i <= 19; i++ {
+		q.Push(now.Add(time.Duration(i)*time.Hour), i)
+	}
+	h := q.Horizon()
+	if h.Count != 20 {
+		t.Errorf("h.Count = %v WANT %v", h.Count, 20)
+	}
+	tests := []struct {
+		name   string
+		result time.Duration
+		want   time.Duration
+	}{
+		{"P50", h.P50, 9 * time.Hour},
+		{"P95", h.P95, 18 * time.Hour},
+		{"Max", h.Max, 19 * time.Hour},
+	}
+	for _, test := range tests {
+		if test.result > test.want || test.result <= test.want-time.Minute {
+			t.Errorf("h.%v = %v WANT ~%v", test.name, test.result, test.want)
+		}
+	}
+}
+
+func TestTimeQueue_Horizon_spilledAndClocks(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	now := time.Now()
+	q := New(WithStore(wal), WithManualAdvance(now), WithMemoryPressure(func() bool {
+		return true
+	}, time.Hour))
+	q.Push(now.Add(30*time.Minute), "kept")
+	q.Push(now.Add(2*time.Hour), "spilled")
+	q.PushMessage(&Message{Time: now.Add(time.Minute), Clock: "sim", Data: "clocked"})
+	q.Start()
+	defer q.Stop()
+	q.checkPressure()
+	if _, spilled := q.UnderPressure(); spilled != 1 {
+		t.Fatalf("spilled = %v WANT %v", spilled, 1)
+	}
+
+	want := Horizon{Count: 2, P50: 30 * time.Minute, P95: 2 * time.Hour, Max: 2 * time.Hour}
+	if h := q.Horizon(); h != want {
+		t.Errorf("q.Horizon() = %+v WANT %+v", h, want)
+	}
+	if result := q.Forecast(time.Hour, 3*time.Hour); !reflect.DeepEqual(result, []int{1, 0, 1}) {
+		t.Errorf("q.Forecast() = %v WANT %v", result, []int{1, 0, 1})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p      float64
+		result time.Duration
+	}{
+		{0, 1},
+		{10, 1},
+		{50, 5},
+		{95, 10},
+		{100, 10},
+	}
+	for _, test := range tests {
+		if result := percentile(sorted, test.p); result != test.result {
+			t.Errorf("percentile(%v) = %v WANT %v", test.p, result, test.result)
+		}
+	}
+	if result := percentile(nil, 50); result != 0 {
+		t.Errorf("percentile(nil) = %v WANT %v", result, 0)
+	}
+}
+
+func TestTimeQueue_Forecast(t *testing.T) {
+	q := New()
+	now := time.Now()
+	q.Push(now.Add(-time.Hour), 0)
+	q.Push(now.Add(30*time.Minute), 0)
+	q.Push(now.Add(90*time.Minute), 0)
+	q.Push(now.Add(100*time.Minute), 0)
+	q.Push(now.Add(5*time.Hour), 0)
+	want := []int{2, 2, 0}
+	if result := q.Forecast(time.Hour, 150*time.Minute); !reflect.DeepEqual(result, want) {
+		t.Errorf("q.Forecast() = %v WANT %v", result, want)
+	}
+	if result := q.Forecast(0, time.Hour); len(result) != 0 {
+		t.Errorf("q.Forecast(0) = %v WANT empty", result)
+	}
+	if result := q.Forecast(-time.Hour, time.Hour); len(result) != 0 {
+		t.Errorf("q.Forecast(-time.Hour) = %v WANT empty", result)
+	}
+	if result := q.Forecast(time.Hour, math.MaxInt64); len(result) != MaxForecastBuckets || result[0] != 2 {
+		t.Errorf("q.Forecast(time.Hour, MaxInt64) = %v buckets, %v WANT %v buckets, 2", len(result), result[0], MaxForecastBuckets)
+	}
+	if result := q.Forecast(1, time.Hour); len(result) != MaxForecastBuckets {
+		t.Errorf("q.Forecast(1, time.Hour) = %v buckets WANT %v", len(result), MaxForecastBuckets)
+	}
+}
@@ -0,0 +1,90 @@
+package timequeue
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+//ActorHeader is the request header used by Handler() to identify the actor
+//performing an administrative action.
+const ActorHeader = "X-Timequeue-Actor"
+
+//Handler returns an http.Handler that serves administrative endpoints for q.
+//
+//The following paths are served:
+//	/healthz responds with 200 if Healthy() returns nil and 503 otherwise.
+//	/readyz responds with 200 if Ready() returns nil and 503 otherwise.
+//	/stats responds with the JSON encoding of Stats().
+//	/hold calls Hold() with the "reason" form value. It must be a POST.
+//	/release calls Release(). It must be a POST.
+//	/audit responds with the JSON encoding of AuditLog().
+//
+//Actions performed through the Handler are recorded in the audit log with the
+//actor given by the ActorHeader request header.
+//
+//Every endpoint is served to anyone who can reach it unless WithAuthorizer() is
+//given, e.g. to check credentials per Endpoint:
+//	handler := q.Handler(timequeue.WithAuthorizer(func(r *http.Request, endpoint timequeue.Endpoint) error {
+//		user, err := authenticate(r)
+//		if err != nil {
+//			return timequeue.ErrUnauthenticated
+//		}
+//		if endpoint == timequeue.EndpointHold || endpoint == timequeue.EndpointRelease {
+//			return user.RequireRole("operator")
+//		}
+//		return nil
+//	}))
+//
+//The Handler may be mounted under a prefix with http.StripPrefix().
+func (q *TimeQueue) Handler(opts ...HandlerOption) http.Handler {
+	c := newHandlerConfig(opts)
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", c.authorize(EndpointHealthz, probeHandler(q.Healthy)))
+	mux.Handle("/readyz", c.authorize(EndpointReadyz, probeHandler(q.Ready)))
+	mux.Handle("/stats", c.authorize(EndpointStats, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, q.Stats())
+	})))
+	mux.Handle("/hold", c.authorize(EndpointHold, postHandler(func(r *http.Request) {
+		q.As(r.Header.Get(ActorHeader)).Hold(r.FormValue("reason"))
+	})))
+	mux.Handle("/release", c.authorize(EndpointRelease, postHandler(func(r *http.Request) {
+		q.As(r.Header.Get(ActorHeader)).Release()
+	})))
+	mux.Handle("/audit", c.authorize(EndpointAudit, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, q.AuditLog())
+	})))
+	return mux
+}
+
+//probeHandler returns an http.Handler that responds with the result of probe.
+func probeHandler(probe func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := probe(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(err.Error() + "\n"))
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+}
+
+//postHandler returns an http.Handler that calls action for POST requests and
+//responds with 405 for all other methods.
+func postHandler(action func(r *http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		action(r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+//writeJSON writes the JSON encoding of value to w.
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,85 @@
+package timequeue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTimeQueue_Handler_probes(t *testing.T) {
+	q := New()
+	handler := q.Handler()
+	tests := []struct {
+		path   string
+		start  func()
+		status int
+	}{
+		{"/healthz", func() {}, http.StatusServiceUnavailable},
+		{"/readyz", func() {}, http.StatusServiceUnavailable},
+		{"/healthz", q.StartInactive, http.StatusOK},
+		{"/readyz", q.StartInactive, http.StatusServiceUnavailable},
+		{"/readyz", q.Activate, http.StatusOK},
+	}
+	defer q.Stop()
+	for _, test := range tests {
+		test.start()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != test.status {
+			t.Errorf("GET %v = %v WANT %v", test.path, w.Code, test.status)
+		}
+	}
+}
+
+func TestTimeQueue_Handler_holdAndRelease(t *testing.T) {
+	q := New()
+	handler := q.Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/hold", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /hold = %v WANT %v", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/hold", strings.NewReader(url.Values{"reason": {"incident"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set(ActorHeader, "alice")
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("POST /hold = %v WANT %v", w.Code, http.StatusNoContent)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/stats", nil))
+	stats := Stats{}
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if !stats.Held || stats.HoldReason != "incident" {
+		t.Errorf("GET /stats Held, HoldReason = %v, %v WANT %v, %v", stats.Held, stats.HoldReason, true, "incident")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/release", nil))
+	if held, _ := q.IsHeld(); held {
+		t.Errorf("POST /release q.IsHeld() = %v WANT %v", held, false)
+	}
+}
+
+func TestTimeQueue_Handler_audit(t *testing.T) {
+	q := New()
+	q.As("alice").Hold("incident")
+	w := httptest.NewRecorder()
+	q.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/audit", nil))
+	entries := []AuditEntry{}
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "alice" || entries[0].Action != AuditHold {
+		t.Errorf("GET /audit = %v WANT alice hold entry", entries)
+	}
+}
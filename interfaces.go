@@ -0,0 +1,42 @@
+package timequeue
+
+import (
+	"context"
+	"time"
+)
+
+//Scheduler schedules Messages to be released at a later time.
+//It is implemented by *TimeQueue and allows application code to depend on the
+//ability to schedule work rather than on a TimeQueue itself.
+type Scheduler interface {
+	//Push creates and schedules a Message with t and data.
+	Push(t time.Time, data interface{}) *Message
+	//Remove unschedules message, see TimeQueue.Remove().
+	Remove(message *Message, release bool) bool
+}
+
+//Receiver receives released Messages.
+//It is implemented by *TimeQueue.
+type Receiver interface {
+	//Messages returns the channel that released Messages are sent on.
+	Messages() <-chan *Message
+	//Next blocks until a Message is released or ctx is done.
+	Next(ctx context.Context) (*Message, error)
+}
+
+//compile time checks that TimeQueue implements the interfaces in this file.
+var (
+	_ Scheduler = (*TimeQueue)(nil)
+	_ Receiver  = (*TimeQueue)(nil)
+)
+
+//Next receives the next Message released on q.Messages().
+//It returns ctx.Err() if ctx is done before a Message is released.
+func (q *TimeQueue) Next(ctx context.Context) (*Message, error) {
+	select {
+	case message := <-q.messageChan:
+		return message, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
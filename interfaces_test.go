@@ -0,0 +1,22 @@
+package timequeue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Next(t *testing.T) {
+	q := New()
+	message := q.Push(time.Now(), 0)
+	q.Pop(true)
+	if result, err := q.Next(context.Background()); result != message || err != nil {
+		t.Errorf("q.Next() = %v, %v WANT %v, nil", result, err, message)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if result, err := q.Next(ctx); result != nil || err != context.DeadlineExceeded {
+		t.Errorf("q.Next() = %v, %v WANT nil, %v", result, err, context.DeadlineExceeded)
+	}
+}
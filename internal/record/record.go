@@ -0,0 +1,70 @@
+//Package record defines the encoded form of a Message that is shared by the
+//handoff, write-ahead log, and MmapStorage of timequeue and by the Stores in its
+//subpackages.
+package record
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"time"
+)
+
+//Record is the encoded form of a Message. Data whose type is registered with
+//timequeue.RegisterType() is encoded in DataBytes with its name in DataType
+//instead of in Data.
+type Record struct {
+	Time      time.Time
+	Data      interface{}
+	DataType  string
+	DataBytes []byte
+	Topic     string
+	Weight    int
+	ID        string
+	ParentID  string
+	TraceID   string
+	Key       string
+	Tenant    string
+	Affinity  string
+	Priority  int
+	TTL       time.Duration
+}
+
+//Encode encodes r with encoding/gob. The concrete type of r.Data, if any, must
+//be registered with gob.Register().
+func (r *Record) Encode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//Decode decodes a new Record from value returned by Encode().
+func Decode(value []byte) (*Record, error) {
+	r := &Record{}
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//UnixNano returns t as Unix nanoseconds for ordering Messages in a Store.
+//Unlike t.UnixNano(), Times outside of the years 1678 to 2262 that cannot be
+//represented are clamped to the least or greatest int64, so they still sort
+//before or after all other Times.
+func UnixNano(t time.Time) int64 {
+	if t.Before(minUnixNano) {
+		return math.MinInt64
+	}
+	if t.After(maxUnixNano) {
+		return math.MaxInt64
+	}
+	return t.UnixNano()
+}
+
+//the earliest and latest Times that can be represented in Unix nanoseconds.
+var (
+	minUnixNano = time.Unix(0, math.MinInt64)
+	maxUnixNano = time.Unix(0, math.MaxInt64)
+)
@@ -0,0 +1,131 @@
+package timequeue
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+//JSONDecoder decodes data, the JSON encoding of the Data of a Message with topic,
+//for ImportJSON().
+type JSONDecoder func(topic string, data json.RawMessage) (interface{}, error)
+
+//jsonSnapshot is the JSON document written by ExportJSON().
+type jsonSnapshot struct {
+	Version  int            `json:"version"`
+	Messages []*jsonMessage `json:"messages"`
+}
+
+//jsonMessage is the JSON encoding of a single Message in a jsonSnapshot.
+type jsonMessage struct {
+	ID       string          `json:"id"`
+	ParentID string          `json:"parent_id,omitempty"`
+	TraceID  string          `json:"trace_id,omitempty"`
+	Time     time.Time       `json:"time"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Topic    string          `json:"topic,omitempty"`
+	Key      string          `json:"key,omitempty"`
+	Tenant   string          `json:"tenant,omitempty"`
+	Affinity string          `json:"affinity,omitempty"`
+	Weight   int             `json:"weight,omitempty"`
+	Priority int             `json:"priority,omitempty"`
+	TTL      time.Duration   `json:"ttl,omitempty"`
+}
+
+//ExportJSON writes every Message in q, including those held by a selective hold,
+//to w as a JSON document ordered by Time, e.g. for debugging, migration, or
+//backups. Times are written in RFC 3339 format and Data is written with
+//encoding/json. q is not modified, and it is locked while the document is written.
+//
+//Like Snapshot(), Schedules and Messages pushed with ScheduleFunc() or PushQueue()
+//are not included.
+func (q *TimeQueue) ExportJSON(w io.Writer) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	messages := q.snapshotMessages()
+	sort.Slice(messages, func(i, j int) bool {
+		if !messages[i].Time.Equal(messages[j].Time) {
+			return messages[i].Time.Before(messages[j].Time)
+		}
+		return messages[i].pushSeq < messages[j].pushSeq
+	})
+	snapshot := &jsonSnapshot{
+		Version:  SnapshotVersion,
+		Messages: make([]*jsonMessage, 0, len(messages)),
+	}
+	for _, message := range messages {
+		data, err := json.Marshal(message.Data)
+		if err != nil {
+			return err
+		}
+		snapshot.Messages = append(snapshot.Messages, &jsonMessage{
+			ID:       message.ID,
+			ParentID: message.ParentID,
+			TraceID:  message.TraceID,
+			Time:     message.Time,
+			Data:     data,
+			Topic:    message.Topic,
+			Key:      message.Key,
+			Tenant:   message.Tenant,
+			Affinity: message.Affinity,
+			Weight:   message.Weight,
+			Priority: message.Priority,
+			TTL:      message.TTL,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(snapshot)
+}
+
+//ImportJSON reads a JSON document written by ExportJSON() from r and pushes its
+//Messages to q, keeping their IDs. Returns the number of Messages pushed.
+//
+//The Data of every Message is decoded with decode, or left as a json.RawMessage
+//if decode is nil. If the document cannot be read or any Data cannot be decoded,
+//then the error is returned and no Messages are pushed.
+func (q *TimeQueue) ImportJSON(r io.Reader, decode JSONDecoder) (int, error) {
+	snapshot := &jsonSnapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return 0, err
+	}
+	if snapshot.Version > SnapshotVersion {
+		return 0, ErrSnapshotVersion
+	}
+	messages := make([]*Message, 0, len(snapshot.Messages))
+	for _, m := range snapshot.Messages {
+		var data interface{} = m.Data
+		if decode != nil {
+			decoded, err := decode(m.Topic, m.Data)
+			if err != nil {
+				q.lock.Lock()
+				q.config.finalize(messages, DropRestoreFailed)
+				q.lock.Unlock()
+				return 0, err
+			}
+			data = decoded
+		}
+		messages = append(messages, &Message{
+			Time:     m.Time,
+			Data:     data,
+			ID:       m.ID,
+			ParentID: m.ParentID,
+			TraceID:  m.TraceID,
+			Key:      m.Key,
+			Topic:    m.Topic,
+			Tenant:   m.Tenant,
+			Affinity: m.Affinity,
+			Weight:   m.Weight,
+			Priority: m.Priority,
+			TTL:      m.TTL,
+		})
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, message := range messages {
+		q.pushStored(message)
+	}
+	q.afterHeapUpdate()
+	return len(messages), nil
+}
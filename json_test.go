@@ -0,0 +1,56 @@
+package timequeue
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_ExportJSON(t *testing.T) {
+	type email struct {
+		To string
+	}
+	at := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	q := New()
+	q.PushMessage(&Message{Time: at.Add(time.Hour), Data: 2, Key: "k", Affinity: "user"})
+	q.PushMessage(&Message{Time: at, Data: email{"gopher"}, Topic: "email"})
+	q.ScheduleFunc(at, func(Message) {})
+	buf := &bytes.Buffer{}
+	if err := q.ExportJSON(buf); err != nil {
+		t.Fatalf("ExportJSON() = %v WANT nil", err)
+	}
+	if !strings.Contains(buf.String(), `"time": "2017-03-04T02:00:00Z"`) {
+		t.Errorf("ExportJSON() = %s WANT RFC 3339 times", buf)
+	}
+
+	decode := func(topic string, data json.RawMessage) (interface{}, error) {
+		if topic == "email" {
+			e := email{}
+			err := json.Unmarshal(data, &e)
+			return e, err
+		}
+		var n int
+		err := json.Unmarshal(data, &n)
+		return n, err
+	}
+	imported := New()
+	if n, err := imported.ImportJSON(bytes.NewReader(buf.Bytes()), decode); n != 2 || err != nil {
+		t.Fatalf("ImportJSON() = %v, %v WANT 2, nil", n, err)
+	}
+	messages := imported.PopAll(false)
+	if len(messages) != 2 || messages[0].Data != (email{"gopher"}) || messages[0].Topic != "email" ||
+		messages[1].Data != 2 || messages[1].Key != "k" || messages[1].Affinity != "user" || !messages[1].Time.Equal(at.Add(time.Hour)) {
+		t.Errorf("imported = %v WANT email, 2", messages)
+	}
+
+	raw := New()
+	raw.ImportJSON(bytes.NewReader(buf.Bytes()), nil)
+	if data, ok := raw.PeekMessage().Data.(json.RawMessage); !ok || !strings.Contains(string(data), `"gopher"`) {
+		t.Errorf("raw Data = %v WANT json.RawMessage", raw.PeekMessage().Data)
+	}
+	if _, err := raw.ImportJSON(strings.NewReader(`{"version": 2}`), nil); err != ErrSnapshotVersion {
+		t.Errorf("ImportJSON(version 2) = %v WANT %v", err, ErrSnapshotVersion)
+	}
+}
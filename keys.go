@@ -0,0 +1,80 @@
+package timequeue
+
+import "errors"
+
+//ErrDuplicateKey is returned by PushMessage() when a Message with the same Key is
+//already in the TimeQueue.
+var ErrDuplicateKey = errors.New("timequeue: duplicate message key")
+
+//WithKeyBloomFilter places a bloom filter sized for expectedKeys with a false
+//positive rate of falsePositiveRate in front of the index of Message Keys.
+//Calls to Contains() for keys that were never pushed then return without locking
+//the TimeQueue, which helps queues with millions of keyed Messages that perform
+//many negative dedup checks.
+//
+//Keys cannot be removed from a bloom filter, so the false positive rate rises as
+//keyed Messages are released and new keys are pushed. The filter is emptied by
+//Clear().
+//WithKeyBloomFilter may not be given to Reconfigure().
+func WithKeyBloomFilter(expectedKeys int, falsePositiveRate float64) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.bloomKeys = expectedKeys
+		c.bloomRate = falsePositiveRate
+	}
+}
+
+//Contains returns whether or not a Message with Key key is in q.
+//An empty key is never contained.
+func (q *TimeQueue) Contains(key string) bool {
+	if key == "" || (q.bloom != nil && !q.bloom.mayContain(key)) {
+		return false
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.keyed(key) != nil
+}
+
+//keyed returns the Message in q with Key key or nil if there is none.
+//Stale index entries for Messages that have left q are removed.
+//It should only be called when q is locked.
+func (q *TimeQueue) keyed(key string) *Message {
+	message, ok := q.keys[key]
+	if !ok {
+		return nil
+	}
+	if !q.contains(message) {
+		delete(q.keys, key)
+		return nil
+	}
+	return message
+}
+
+//indexKey adds message to the index of Keys if it has one.
+//It should only be called when q is locked.
+func (q *TimeQueue) indexKey(message *Message) {
+	if message.Key == "" {
+		return
+	}
+	q.keys[message.Key] = message
+	if q.bloom != nil {
+		q.bloom.add(message.Key)
+	}
+}
+
+//unindexKey removes message from the index of Keys.
+//It should only be called when q is locked.
+func (q *TimeQueue) unindexKey(message *Message) {
+	if message.Key != "" && q.keys[message.Key] == message {
+		delete(q.keys, message.Key)
+	}
+}
+
+//resetKeys empties the index of Keys.
+//It should only be called when q is locked.
+func (q *TimeQueue) resetKeys() {
+	q.keys = map[string]*Message{}
+	if q.bloom != nil {
+		q.bloom.reset()
+	}
+}
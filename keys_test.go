@@ -0,0 +1,56 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Contains(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"map only", nil},
+		{"bloom filter", []Option{WithKeyBloomFilter(100, 0.01)}},
+	}
+	for _, test := range tests {
+		q := New(test.opts...)
+		a := &Message{Time: time.Now(), Key: "a"}
+		if err := q.PushMessage(a); err != nil {
+			t.Fatalf("%v: q.PushMessage(a) = %v WANT nil", test.name, err)
+		}
+		if err := q.PushMessage(&Message{Time: time.Now(), Key: "a"}); err != ErrDuplicateKey {
+			t.Errorf("%v: q.PushMessage(duplicate) = %v WANT %v", test.name, err, ErrDuplicateKey)
+		}
+		if !q.Contains("a") || q.Contains("b") || q.Contains("") {
+			t.Errorf("%v: q.Contains() WANT a only", test.name)
+		}
+
+		q.Remove(a, false)
+		if q.Contains("a") {
+			t.Errorf("%v: q.Contains(a) after Remove = true WANT false", test.name)
+		}
+		if err := q.PushMessage(&Message{Time: time.Now(), Key: "a"}); err != nil {
+			t.Errorf("%v: q.PushMessage(a) after Remove = %v WANT nil", test.name, err)
+		}
+
+		q.Pop(true)
+		<-q.Messages()
+		if _, ok := q.keys["a"]; ok {
+			t.Errorf("%v: q.keys has a after release", test.name)
+		}
+
+		q.PushMessage(&Message{Time: time.Now(), Key: "c"})
+		q.Clear()
+		if q.Contains("c") {
+			t.Errorf("%v: q.Contains(c) after Clear = true WANT false", test.name)
+		}
+	}
+}
+
+func TestTimeQueue_Reconfigure_bloomFilter(t *testing.T) {
+	q := New()
+	if err := q.Reconfigure(WithKeyBloomFilter(10, 0.1)); err != ErrNotReconfigurable {
+		t.Errorf("q.Reconfigure(WithKeyBloomFilter()) = %v WANT %v", err, ErrNotReconfigurable)
+	}
+}
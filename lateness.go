@@ -0,0 +1,106 @@
+package timequeue
+
+import (
+	"math"
+	"time"
+)
+
+//latenessBuckets is the number of buckets in a LatenessHistogram. The bounds of
+//the buckets double from a microsecond to about 39 hours, and the last bucket is
+//unbounded.
+const latenessBuckets = 39
+
+//LatenessHistogram counts released Messages by how late they were released
+//relative to their Times.
+type LatenessHistogram struct {
+	//Bounds are the inclusive upper bounds of the buckets in increasing order. The
+	//last bound is the maximum Duration.
+	Bounds []time.Duration
+	//Counts are the numbers of Messages in each bucket, i.e. that were released
+	//more than the previous bound and at most the bound of the bucket late.
+	Counts []uint64
+}
+
+//WithLateCallback sets a function that is called with every Message that is
+//released more than threshold after its Time and how late it was released, e.g.
+//to alert on scheduler lag. Messages released early by Pop() or WithLeadTime() are
+//never late.
+//fn is called while the TimeQueue is locked and must not call any of its methods.
+//A nil fn, which is the default, disables the callback.
+func WithLateCallback(threshold time.Duration, fn func(message Message, lateness time.Duration)) Option {
+	return func(c *config) {
+		c.lateThreshold = threshold
+		c.lateCallback = fn
+	}
+}
+
+//latenessBound returns the upper bound of bucket i.
+func latenessBound(i int) time.Duration {
+	if i >= latenessBuckets-1 {
+		return math.MaxInt64
+	}
+	return time.Microsecond << uint(i)
+}
+
+//latenessBucket returns the bucket that lateness is counted in.
+func latenessBucket(lateness time.Duration) int {
+	i := 0
+	for i < latenessBuckets-1 && lateness > latenessBound(i) {
+		i++
+	}
+	return i
+}
+
+//histogram returns a LatenessHistogram of counts, or the zero LatenessHistogram if
+//nothing was counted.
+func (s *lagStats) histogram() LatenessHistogram {
+	if s.count == 0 {
+		return LatenessHistogram{}
+	}
+	result := LatenessHistogram{
+		Bounds: make([]time.Duration, latenessBuckets),
+		Counts: make([]uint64, latenessBuckets),
+	}
+	for i := range result.Bounds {
+		result.Bounds[i] = latenessBound(i)
+	}
+	copy(result.Counts, s.buckets[:])
+	return result
+}
+
+//LatenessQuantiles returns estimates of how late the given quantiles, between 0
+//and 1, of released Messages were released, e.g. LatenessQuantiles(0.5, 0.99)
+//returns the median and 99th percentile lateness.
+//Each estimate is the upper bound of the bucket of Lateness that the quantile
+//falls in, so it is at most twice the actual lateness or a microsecond, and never
+//more than MaxLag.
+//Every estimate is 0 if no Messages were released.
+func (s Stats) LatenessQuantiles(quantiles ...float64) []time.Duration {
+	result := make([]time.Duration, len(quantiles))
+	var total uint64
+	for _, count := range s.Lateness.Counts {
+		total += count
+	}
+	if total == 0 {
+		return result
+	}
+	for i, quantile := range quantiles {
+		rank := uint64(math.Ceil(quantile * float64(total)))
+		if rank < 1 {
+			rank = 1
+		} else if rank > total {
+			rank = total
+		}
+		var seen uint64
+		for bucket, count := range s.Lateness.Counts {
+			if seen += count; seen >= rank {
+				result[i] = s.Lateness.Bounds[bucket]
+				break
+			}
+		}
+		if result[i] > s.MaxLag {
+			result[i] = s.MaxLag
+		}
+	}
+	return result
+}
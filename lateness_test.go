@@ -0,0 +1,65 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatenessBucket(t *testing.T) {
+	tests := []struct {
+		lateness time.Duration
+		bucket   int
+	}{
+		{0, 0},
+		{time.Microsecond, 0},
+		{time.Microsecond + 1, 1},
+		{time.Millisecond, 10},
+		{time.Second, 20},
+		{1000 * time.Hour, latenessBuckets - 1},
+	}
+	for _, test := range tests {
+		if bucket := latenessBucket(test.lateness); bucket != test.bucket {
+			t.Errorf("latenessBucket(%v) = %v WANT %v", test.lateness, bucket, test.bucket)
+		}
+	}
+}
+
+func TestStats_LatenessQuantiles(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start))
+	if quantiles := q.Stats().LatenessQuantiles(0.5); quantiles[0] != 0 {
+		t.Errorf("LatenessQuantiles() = %v WANT 0", quantiles)
+	}
+	for i := 0; i < 98; i++ {
+		q.Push(start, i)
+	}
+	q.Push(start.Add(-time.Millisecond), "late")
+	q.Push(start.Add(-time.Second), "later")
+	q.Advance(start)
+	stats := q.Stats()
+	if stats.Lateness.Counts[0] != 98 || stats.Lateness.Bounds[10] != 1024*time.Microsecond {
+		t.Errorf("stats.Lateness = %+v WANT 98 on time", stats.Lateness)
+	}
+	quantiles := stats.LatenessQuantiles(0, 0.5, 0.99, 1)
+	want := []time.Duration{time.Microsecond, time.Microsecond, 1024 * time.Microsecond, time.Second}
+	for i := range want {
+		if quantiles[i] != want[i] {
+			t.Errorf("LatenessQuantiles()[%v] = %v WANT %v", i, quantiles[i], want[i])
+		}
+	}
+}
+
+func TestWithLateCallback(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	late := map[interface{}]time.Duration{}
+	q := New(WithManualAdvance(start), WithLateCallback(time.Minute, func(message Message, lateness time.Duration) {
+		late[message.Data] = lateness
+	}))
+	q.Push(start.Add(-time.Minute), "threshold")
+	q.Push(start.Add(-2*time.Minute), "late")
+	q.Push(start, "on time")
+	q.Advance(start)
+	if len(late) != 1 || late["late"] != 2*time.Minute {
+		t.Errorf("late = %v WANT late by %v", late, 2*time.Minute)
+	}
+}
@@ -0,0 +1,52 @@
+package timequeue
+
+import (
+	"context"
+	"time"
+)
+
+//WithLeadTime causes a TimeQueue to release every Message lead before its Time,
+//except for those pushed with ScheduleFunc(), whose functions are never called
+//early.
+//The Time of a released Message is unchanged, so consumers that need time to
+//prepare, e.g. to buffer media or warm a connection, receive the Message early
+//and then act on it exactly at its Time with WaitDue().
+//A lead less than or equal to zero releases Messages at their Times, which is the
+//default.
+func WithLeadTime(lead time.Duration) Option {
+	return func(c *config) {
+		c.leadTime = lead
+	}
+}
+
+//WaitDue blocks until m's Time, or until ctx is done, in which case ctx.Err() is
+//returned. It returns nil immediately if m's Time has already passed.
+//WaitDue waits on the wall clock, even for Messages from a TimeQueue that is
+//advanced manually.
+func (m *Message) WaitDue(ctx context.Context) error {
+	timer := time.NewTimer(time.Until(m.Time))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//lead returns how long before its Time message should be released.
+//It should only be called when q is locked.
+func (q *TimeQueue) lead(message *Message) time.Duration {
+	if message.fn != nil {
+		//functions are never called early, but precise callbacks are handed to
+		//their workers early so that they can wait for the exact Time.
+		if q.config.preciseWorkers > 0 && !q.config.manual {
+			return preciseLead
+		}
+		return 0
+	}
+	if q.config.leadTime <= 0 {
+		return 0
+	}
+	return q.config.leadTime
+}
@@ -0,0 +1,50 @@
+package timequeue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithLeadTime(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start), WithLeadTime(2*time.Second))
+	message := q.Push(start.Add(5*time.Second), 0)
+	if released := q.Advance(start.Add(2 * time.Second)); len(released) != 0 {
+		t.Errorf("Advance(2s) = %v WANT none", released)
+	}
+	if released := q.Advance(start.Add(3 * time.Second)); len(released) != 1 || released[0] != message || !message.Time.Equal(start.Add(5*time.Second)) {
+		t.Errorf("Advance(3s) = %v WANT %v at 5s", released, message)
+	}
+	called := false
+	q.ScheduleFunc(start.Add(10*time.Second), func(Message) { called = true })
+	if q.Advance(start.Add(9 * time.Second)); called || q.Size() != 1 {
+		t.Errorf("ScheduleFunc() called = %v before its Time WANT %v", called, false)
+	}
+
+	q = New(WithLeadTime(time.Hour))
+	q.Start()
+	defer q.Stop()
+	message = q.Push(time.Now().Add(30*time.Minute), 0)
+	select {
+	case released := <-q.Messages():
+		if released != message {
+			t.Errorf("q.Messages() = %v WANT %v", released, message)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Message not released within its lead time")
+	}
+}
+
+func TestMessage_WaitDue(t *testing.T) {
+	message := &Message{Time: time.Now().Add(20 * time.Millisecond)}
+	if err := message.WaitDue(context.Background()); err != nil || time.Now().Before(message.Time) {
+		t.Errorf("WaitDue() = %v before Time WANT nil after", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	message.Time = time.Now().Add(time.Hour)
+	if err := message.WaitDue(ctx); err != context.Canceled {
+		t.Errorf("WaitDue() = %v WANT %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,35 @@
+package timequeue
+
+import (
+	"context"
+	"log/slog"
+)
+
+//logMessage logs a debug event for message, with its Data redacted by Redact().
+//Data is only redacted if q.config.logger is enabled for debug events.
+//It should only be called when q is locked.
+func (q *TimeQueue) logMessage(event string, message *Message) {
+	if !q.debugEnabled() {
+		return
+	}
+	q.config.logger.Debug(event,
+		"id", message.ID,
+		"trace_id", message.TraceID,
+		"topic", message.Topic,
+		"time", message.Time,
+		"data", q.redact(message),
+	)
+}
+
+//logEvent logs a debug event with args.
+//It should only be called when q is locked.
+func (q *TimeQueue) logEvent(event string, args ...interface{}) {
+	if q.debugEnabled() {
+		q.config.logger.Debug(event, args...)
+	}
+}
+
+//debugEnabled returns whether or not q.config.logger logs debug events.
+func (q *TimeQueue) debugEnabled() bool {
+	return q.config.logger != nil && q.config.logger.Enabled(context.Background(), slog.LevelDebug)
+}
@@ -0,0 +1,86 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//WithManualAdvance causes a TimeQueue to never release Messages on its own.
+//Instead, the current time of the TimeQueue starts at start and is only changed by
+//calls to Advance(), which return the Messages that are released.
+//This is useful for discrete-event simulations and game loops where wall-clock
+//time is wrong.
+//
+//A TimeQueue that is advanced manually does not spawn any go-routines, and Start()
+//and Stop() only change the state reported by IsRunning() and Stats().
+//The current time, as returned from Now(), is used everywhere that a TimeQueue
+//otherwise uses time.Now(), e.g. to push the Messages given to PushAfterRelease()
+//and to determine budget windows.
+//WithManualAdvance may not be given to Reconfigure().
+func WithManualAdvance(start time.Time) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.manual = true
+		c.manualStart = start
+	}
+}
+
+//Now returns the current time of q. This is time.Now() unless q is advanced
+//manually, see WithManualAdvance().
+func (q *TimeQueue) Now() time.Time {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.now()
+}
+
+//now is the unexported version of Now().
+//It should only be called when q is locked.
+func (q *TimeQueue) now() time.Time {
+	if q.config.manual {
+		return q.manualNow
+	}
+	return time.Now()
+}
+
+//Advance sets the current time of q to now and releases all Messages in q with
+//Time fields before or equal to now. The released Messages are returned in the
+//order that they are released, and are not sent on Messages(). They pass through
+//the Middleware given to Use(), so Messages that it drops are not returned.
+//Messages that become due because of the releases, e.g. later occurrences of a
+//recurring Message, are released by the same call.
+//
+//The current time of q never goes backwards, so a now before the current time
+//only releases the Messages due at the current time. Holds, budgets, and Calendar
+//blackouts apply as they do to a TimeQueue that is not advanced manually, except
+//that Advance releases Messages whether or not q is running.
+//
+//If q is not advanced manually (see WithManualAdvance()), then Advance releases
+//nothing and returns an empty slice.
+func (q *TimeQueue) Advance(now time.Time) []*Message {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	result := []*Message{}
+	if !q.config.manual {
+		return result
+	}
+	q.manualNow = laterOf(q.manualNow, now)
+	q.expireUnacked(q.manualNow)
+	if q.held {
+		return result
+	}
+	//Messages at exactly now are due.
+	until := q.manualNow.Add(time.Nanosecond)
+	release := q.chain(func(message *Message) {
+		if message.fn != nil {
+			q.dispatched(message)
+		}
+		result = append(result, message)
+	})
+	for due := q.popDue(until); len(due) > 0; due = q.popDue(until) {
+		for _, message := range due {
+			q.afterRelease(message)
+			release(message)
+		}
+	}
+	q.afterHeapUpdate()
+	return result
+}
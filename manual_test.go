@@ -0,0 +1,79 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Advance(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start))
+	q.Start()
+	defer q.Stop()
+	for i := 3; i > 0; i-- {
+		q.Push(start.Add(time.Duration(i)*time.Second), i)
+	}
+	if now := q.Now(); !now.Equal(start) {
+		t.Errorf("q.Now() = %v WANT %v", now, start)
+	}
+
+	released := q.Advance(start.Add(2 * time.Second))
+	if len(released) != 2 || released[0].Data != 1 || released[1].Data != 2 {
+		t.Errorf("q.Advance() = %v WANT Data [1 2]", released)
+	}
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+	if released := q.Advance(start); len(released) != 0 {
+		t.Errorf("q.Advance() = %v WANT empty", released)
+	}
+	if now := q.Now(); !now.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("q.Now() = %v WANT %v", now, start.Add(2*time.Second))
+	}
+	select {
+	case message := <-q.Messages():
+		t.Errorf("<-q.Messages() = %v WANT nothing", message)
+	default:
+	}
+}
+
+func TestTimeQueue_Advance_derived(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start))
+	s := q.PushRecurring(RecurrenceFunc(func(t time.Time) time.Time {
+		return t.Add(time.Second)
+	}), "tick")
+	parent := q.Push(start.Add(time.Second), "parent")
+	if _, err := q.PushAfterRelease(parent, time.Second, "child"); err != nil {
+		t.Fatalf("q.PushAfterRelease() = %v WANT nil", err)
+	}
+
+	released := q.Advance(start.Add(3 * time.Second))
+	counts := map[interface{}]int{}
+	for _, message := range released {
+		counts[message.Data]++
+	}
+	if counts["tick"] != 3 || counts["parent"] != 1 {
+		t.Errorf("q.Advance() released %v WANT 3 ticks and 1 parent", counts)
+	}
+	if pending := s.Message(); pending == nil || !pending.Time.Equal(start.Add(4*time.Second)) {
+		t.Errorf("s.Message() = %v WANT Time %v", pending, start.Add(4*time.Second))
+	}
+
+	//the child is pushed a second after the current time when parent is released.
+	released = q.Advance(start.Add(4 * time.Second))
+	if len(released) != 2 {
+		t.Errorf("len(q.Advance()) = %v WANT %v", len(released), 2)
+	}
+}
+
+func TestTimeQueue_Advance_notManual(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(-time.Second), 0)
+	if released := q.Advance(time.Now()); len(released) != 0 {
+		t.Errorf("q.Advance() = %v WANT empty", released)
+	}
+	if err := q.Reconfigure(WithManualAdvance(time.Now())); err != ErrNotReconfigurable {
+		t.Errorf("q.Reconfigure() = %v WANT %v", err, ErrNotReconfigurable)
+	}
+}
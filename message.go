@@ -2,6 +2,8 @@ package timequeue
 
 import (
 	"container/heap"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 )
@@ -20,16 +22,144 @@ const notInIndex = -1
 //
 //It is up to client code to ensure that Data is always of the same underlying
 //type if that is desired.
+//
+//Topic is an optional classification of the Message that may be used to hold
+//(see TimeQueue.HoldTopic()) all Messages of the same kind.
+//
+//ID and ParentID record the lineage of a Message. Every Message is given a unique
+//ID when it is pushed, unless it already has one. Messages created by a TimeQueue
+//from another Message, e.g. the next occurrence of a recurring Message or a
+//Message pushed by PushAfterRelease(), have a ParentID of that Message's ID.
+//A Message retried by a Dispatcher is pushed again and keeps its ID.
+//
+//TraceID correlates a scheduled item across every subsystem that it passes
+//through, e.g. debug logs, ArchiveRecords, history, Stores, and dead letters. Every
+//Message is given a TraceID when it is pushed, unless it already has one, and
+//Messages created by a TimeQueue from another Message share that Message's TraceID.
 type Message struct {
 	time.Time
 	Data interface{}
 
+	//ID uniquely identifies the Message.
+	ID string
+	//ParentID is the ID of the Message that caused this Message to be created.
+	//It is empty for Messages that are pushed directly.
+	ParentID string
+	//TraceID is shared by all Messages created from the same pushed Message.
+	TraceID string
+	//Key optionally identifies the Message for deduplication. A TimeQueue holds
+	//at most one Message with a given non-empty Key. See TimeQueue.Contains().
+	Key string
+
+	//Topic is an optional classification of the Message.
+	Topic string
+	//Tenant optionally identifies who the Message belongs to for quotas.
+	//See WithTenantQuota().
+	Tenant string
+	//Affinity optionally pins the Message to a Subscriber. Messages with the same
+	//non-empty Affinity are released to the same Subscriber. See Subscribe().
+	Affinity string
+	//Weight is the cost of releasing the Message in units of a TimeQueue's budget
+	//(see WithBudget()). A Weight less than or equal to zero is treated as 1.
+	Weight int
+	//Clock optionally names the virtual clock that the Message is scheduled against.
+	//Messages with an empty Clock are scheduled against the TimeQueue's clock. See
+	//TimeQueue.AdvanceClock().
+	Clock string
+	//Priority orders Messages with equal Times. Messages with greater Priorities
+	//are released first, and Messages with equal Priorities are ordered by their
+	//Data if it is a Tiebreaker.
+	Priority int
+	//TTL optionally limits how long after its Time the Message may be processed.
+	//A TTL less than or equal to zero is no limit. The Message is discarded instead
+	//of released if its TTL has passed when it would be released. See Deadline().
+	TTL time.Duration
+
+	//the Schedule that this Message is an occurrence of. nil if not recurring.
+	schedule *Schedule
+	//Messages to push when this Message is released. see PushAfterRelease().
+	children []*Message
+	//the duration after its parent's release at which this Message is pushed.
+	afterDelay time.Duration
+	//true if this Message is waiting for its parent to be released.
+	awaiting bool
+	//the Escalation that this Message is a rung of. nil if not escalating.
+	escalation *Escalation
+	//the function to call instead of sending this Message on an output channel.
+	//see ScheduleFunc().
+	fn func(Message)
+	//the number of times this Message has been given to a Dispatcher's Handler.
+	attempts int
+	//the number of times this Message has been requeued with RequeueWithBackoff().
+	requeues int
+	//the release sequence number of this Message in ack mode.
+	seq uint64
+	//the sequence numbers of the most recent push and release of this Message.
+	//see PushSequence() and ReleaseSequence().
+	pushSeq    uint64
+	releaseSeq uint64
+	//the pushSeq of the push that this Message was most recently removed from the
+	//pending Messages and released for. see ConsistencyCheck().
+	removedFor  uint64
+	releasedFor uint64
+	//the number of times this Message has been released in ack mode.
+	deliveries int
+	//the time at which this Message is redelivered unless acknowledged.
+	//see WithVisibilityTimeout().
+	visibleAt time.Time
+
+	//the Storage of the TimeQueue that this Message is in. nil if not in a Storage.
+	storage Storage
+	//one more than the offset of this Message's payload in an MmapStorage. 0 if none.
+	mmapOffset int64
+
 	//reference to the messageHeap that this Message is in. used for removal safety.
 	mh *messageHeap
 	//the index of this Message in mh. used to remove a Message from a messageHeap.
 	index int
 }
 
+//weight returns m.Weight or 1 if m.Weight is not positive.
+func (m *Message) weight() int {
+	if m.Weight <= 0 {
+		return 1
+	}
+	return m.Weight
+}
+
+//Attempts returns the number of times m has been given to a Dispatcher's Handler,
+//including the current call when called from within a Handler.
+//Attempts that failed with a *RateLimited error are not counted.
+func (m *Message) Attempts() int {
+	return m.attempts
+}
+
+//PushSequence returns the number of Messages pushed to m's TimeQueue up to and
+//including the most recent push of m, or 0 if m has not been pushed.
+//PushSequences strictly increase with every push to a TimeQueue, so they order
+//Messages pushed to it even when their Times are equal or were computed from
+//different wall clocks. Messages pushed more than once, e.g. retries, are given a
+//new PushSequence each time.
+func (m *Message) PushSequence() uint64 {
+	return m.pushSeq
+}
+
+//ReleaseSequence returns the number of Messages released by m's TimeQueue up to
+//and including the most recent release of m, or 0 if m has not been released.
+//ReleaseSequences strictly increase with every release from a TimeQueue, so they
+//totally order the Messages it releases regardless of their Times. Unlike
+//Sequence(), it is assigned whether or not the TimeQueue is in ack mode.
+func (m *Message) ReleaseSequence() uint64 {
+	return m.releaseSeq
+}
+
+//newMessageID returns a new random Message ID.
+func newMessageID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 //String returns the standard string representation of a struct.
 func (m *Message) String() string {
 	return fmt.Sprintf("&timequeue.Message{%v %v}", m.Time, m.Data)
@@ -62,9 +192,9 @@ func (mh *messageHeap) Len() int {
 
 //Less determines whether or not the Message at index i is less than that at index
 //j.
-//This is determined by the (message at i.Time).Before(message at j.Time).
+//This is determined by messageBefore(message at i, message at j).
 func (mh *messageHeap) Less(i, j int) bool {
-	return mh.messages[i].Time.Before(mh.messages[j].Time)
+	return messageBefore(mh.messages[i], mh.messages[j])
 }
 
 //Swap swaps the messages at indices i and j.
@@ -102,15 +232,21 @@ func (mh *messageHeap) peekMessage() *Message {
 //The created message is returned.
 func (mh *messageHeap) pushMessageValues(t time.Time, data interface{}) *Message {
 	message := &Message{
-		Time:  t,
-		Data:  data,
-		index: mh.Len(),
-		mh:    mh,
+		Time: t,
+		Data: data,
 	}
-	heap.Push(mh, message)
+	mh.pushMessage(message)
 	return message
 }
 
+//pushMessage adds message in the appropriate index to mh.
+//message must not be nil and must not be in another messageHeap.
+func (mh *messageHeap) pushMessage(message *Message) {
+	message.index = mh.Len()
+	message.mh = mh
+	heap.Push(mh, message)
+}
+
 //popMessage returns the "smallest" Message in the heap (after removal) or nil
 //if the heap is empty.
 func (mh *messageHeap) popMessage() *Message {
@@ -8,7 +8,7 @@ import (
 
 func TestMessage_String(t *testing.T) {
 	now := time.Now()
-	message := &Message{now, "test_data", nil, notInIndex}
+	message := &Message{Time: now, Data: "test_data", mh: nil, index: notInIndex}
 	want := "&timequeue.Message{" + now.String() + " test_data}"
 	if result := message.String(); result != want {
 		t.Errorf("message.String() = %v WANT %v", result, want)
@@ -25,6 +25,23 @@ func TestNewMessageHeap(t *testing.T) {
 	}
 }
 
+func TestMessage_weight(t *testing.T) {
+	tests := []struct {
+		weight int
+		result int
+	}{
+		{-1, 1},
+		{0, 1},
+		{1, 1},
+		{5, 5},
+	}
+	for _, test := range tests {
+		if result := (&Message{Weight: test.weight}).weight(); result != test.result {
+			t.Errorf("message.weight() = %v WANT %v", result, test.result)
+		}
+	}
+}
+
 func TestMessageHeap_Len(t *testing.T) {
 	tests := []struct {
 		messages []*Message
@@ -32,7 +49,7 @@ func TestMessageHeap_Len(t *testing.T) {
 	}{
 		{nil, 0},
 		{[]*Message{}, 0},
-		{[]*Message{{time.Now(), 0, nil, notInIndex}, {time.Now(), 1, nil, notInIndex}}, 2},
+		{[]*Message{{Time: time.Now(), Data: 0, mh: nil, index: notInIndex}, {Time: time.Now(), Data: 1, mh: nil, index: notInIndex}}, 2},
 	}
 	for _, test := range tests {
 		if result := (&messageHeap{test.messages}).Len(); result != test.result {
@@ -48,9 +65,9 @@ func TestMessageHeap_Less(t *testing.T) {
 		b      *Message
 		result bool
 	}{
-		{&Message{now.Add(-1), 0, nil, notInIndex}, &Message{now, 0, nil, notInIndex}, true},
-		{&Message{now, 0, nil, notInIndex}, &Message{now, 0, nil, notInIndex}, false},
-		{&Message{now.Add(1), 0, nil, notInIndex}, &Message{now, 0, nil, notInIndex}, false},
+		{&Message{Time: now.Add(-1), Data: 0, mh: nil, index: notInIndex}, &Message{Time: now, Data: 0, mh: nil, index: notInIndex}, true},
+		{&Message{Time: now, Data: 0, mh: nil, index: notInIndex}, &Message{Time: now, Data: 0, mh: nil, index: notInIndex}, false},
+		{&Message{Time: now.Add(1), Data: 0, mh: nil, index: notInIndex}, &Message{Time: now, Data: 0, mh: nil, index: notInIndex}, false},
 	}
 	for _, test := range tests {
 		//do this so the heap.Init() is not called and messes with the ordering we want.
@@ -81,7 +98,7 @@ func TestMessageHeap_Swap(t *testing.T) {
 
 func TestMessageHeap_Push(t *testing.T) {
 	mh := newMessageHeap()
-	message := &Message{time.Now(), 0, nil, notInIndex}
+	message := &Message{Time: time.Now(), Data: 0, mh: nil, index: notInIndex}
 	mh.Push(message)
 	if mh.Len() != 1 || mh.messages[0] != message {
 		t.Errorf("mh.Len(), mh[0] = %v, %v WANT %v, %v", mh.Len(), 1, mh.messages[0], message)
@@ -139,6 +156,18 @@ func TestMessageHeap_pushMessageValues(t *testing.T) {
 	}
 }
 
+func TestMessageHeap_pushMessage(t *testing.T) {
+	mh := newMessageHeap()
+	message := &Message{Time: time.Now(), Data: 0, Topic: "test_topic"}
+	mh.pushMessage(message)
+	if message.mh != mh || message.index != 0 {
+		t.Errorf("message.mh, message.index = %v, %v WANT %v, %v", message.mh, message.index, mh, 0)
+	}
+	if peek := mh.peekMessage(); peek != message {
+		t.Errorf("mh.peekMessage() = %v WANT %v", peek, message)
+	}
+}
+
 func TestMessageHeap_popMessage_empty(t *testing.T) {
 	mh := newMessageHeap()
 	if message := mh.popMessage(); message != nil {
@@ -211,7 +240,7 @@ func TestMessageHeap_removeMessage_success(t *testing.T) {
 
 func TestBeforeRemoval(t *testing.T) {
 	mh := newMessageHeap()
-	message := &Message{time.Now(), nil, mh, 1}
+	message := &Message{Time: time.Now(), Data: nil, mh: mh, index: 1}
 	beforeRemoval(message)
 	if message.mh != nil {
 		t.Errorf("message.mh = non-nil WANT nil")
@@ -220,3 +249,72 @@ func TestBeforeRemoval(t *testing.T) {
 		t.Errorf("message.index = %v WANT %v", message.index, notInIndex)
 	}
 }
+
+func TestMessage_PushSequence_ReleaseSequence(t *testing.T) {
+	now := time.Now()
+	q := New()
+	b := q.Push(now, "b")
+	a := q.Push(now, "a")
+	if a.PushSequence() != 2 || b.PushSequence() != 1 {
+		t.Errorf("PushSequence() = %v, %v WANT %v, %v", a.PushSequence(), b.PushSequence(), 2, 1)
+	}
+	if a.ReleaseSequence() != 0 {
+		t.Errorf("a.ReleaseSequence() = %v WANT %v", a.ReleaseSequence(), 0)
+	}
+	first := q.Pop(true)
+	second := q.Pop(true)
+	if first.ReleaseSequence() != 1 || second.ReleaseSequence() != 2 {
+		t.Errorf("ReleaseSequence() = %v, %v WANT %v, %v", first.ReleaseSequence(), second.ReleaseSequence(), 1, 2)
+	}
+	q.PushMessage(first)
+	if first.PushSequence() != 3 {
+		t.Errorf("first.PushSequence() after push = %v WANT %v", first.PushSequence(), 3)
+	}
+}
+
+func TestMessage_TraceID(t *testing.T) {
+	start := time.Now()
+	archive := NewMemoryArchive(0, 0)
+	q := New(WithManualAdvance(start), WithArchive(archive))
+
+	traced := &Message{Time: start, TraceID: "producer"}
+	q.PushMessage(traced)
+	if traced.TraceID != "producer" {
+		t.Errorf("traced.TraceID = %q WANT %q", traced.TraceID, "producer")
+	}
+
+	parent := q.Push(start, "parent")
+	child, _ := q.PushAfterRelease(parent, time.Minute, "child")
+	schedule := q.PushEvery(time.Hour, "every")
+	first := schedule.Message()
+	escalation := q.PushEscalation(start, Rung{Data: "warn"}, Rung{After: time.Minute, Data: "page"})
+	rungs := escalation.Pending()
+
+	if parent.TraceID == "" || parent.TraceID == parent.ID || first.TraceID == parent.TraceID {
+		t.Errorf("parent.TraceID, first.TraceID = %q, %q WANT new and distinct", parent.TraceID, first.TraceID)
+	}
+	if child.TraceID != parent.TraceID {
+		t.Errorf("child.TraceID = %q WANT %q", child.TraceID, parent.TraceID)
+	}
+	if rungs[1].TraceID != rungs[0].TraceID {
+		t.Errorf("rungs[1].TraceID = %q WANT %q", rungs[1].TraceID, rungs[0].TraceID)
+	}
+
+	q.Advance(start.Add(time.Hour))
+	if next := schedule.Message(); next == first || next.TraceID != first.TraceID {
+		t.Errorf("next occurrence TraceID = %q WANT %q", next.TraceID, first.TraceID)
+	}
+	q.Advance(start.Add(2 * time.Hour))
+	found := false
+	for _, record := range archive.Records() {
+		if record.ID == child.ID {
+			found = true
+			if record.TraceID != parent.TraceID {
+				t.Errorf("record.TraceID = %q WANT %q", record.TraceID, parent.TraceID)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("archive.Records() has no record of child")
+	}
+}
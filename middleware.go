@@ -0,0 +1,34 @@
+package timequeue
+
+//ReleaseFunc dispatches a released Message to where it is received, i.e. the
+//function given to ScheduleFunc(), a Subscriber, or the channel returned from
+//Messages().
+type ReleaseFunc func(message *Message)
+
+//Middleware wraps the ReleaseFunc that dispatches released Messages, e.g. to log,
+//trace, filter, or transform them, like HTTP middleware wraps a handler.
+//
+//A Middleware that does not call next drops the Message, and one that calls next
+//with a different Message dispatches that Message instead. A dropped Message is
+//still released, i.e. it is counted, archived, and acknowledged like any other.
+type Middleware func(next ReleaseFunc) ReleaseFunc
+
+//Use appends middleware to the chain that every released Message passes through
+//before it is dispatched. The first Middleware given to Use is the outermost, so
+//it sees every Message before those given after it.
+//
+//Middleware is called with q locked and must not call any of its methods.
+func (q *TimeQueue) Use(middleware ...Middleware) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.middleware = append(q.middleware, middleware...)
+}
+
+//chain returns release wrapped by every Middleware given to Use().
+//It should only be called when q is locked.
+func (q *TimeQueue) chain(release ReleaseFunc) ReleaseFunc {
+	for i := len(q.middleware) - 1; i >= 0; i-- {
+		release = q.middleware[i](release)
+	}
+	return release
+}
@@ -0,0 +1,77 @@
+package timequeue
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Use(t *testing.T) {
+	q := New(WithCapacity(4))
+	calls := []string{}
+	q.Use(func(next ReleaseFunc) ReleaseFunc {
+		return func(message *Message) {
+			calls = append(calls, fmt.Sprint("outer ", message.Data))
+			next(message)
+		}
+	}, func(next ReleaseFunc) ReleaseFunc {
+		return func(message *Message) {
+			calls = append(calls, fmt.Sprint("inner ", message.Data))
+			if message.Data == "drop" {
+				return
+			}
+			copied := *message
+			copied.Data = fmt.Sprint(message.Data, "!")
+			next(&copied)
+		}
+	})
+	now := time.Now()
+	q.Push(now, "drop")
+	q.Push(now.Add(time.Second), "keep")
+	q.PopAll(true)
+
+	want := []string{"outer drop", "inner drop", "outer keep", "inner keep"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v WANT %v", calls, want)
+	}
+	select {
+	case message := <-q.Messages():
+		if message.Data != "keep!" {
+			t.Errorf("message.Data = %v WANT %v", message.Data, "keep!")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("q.Messages() received nothing")
+	}
+	if q.Size() != 0 || len(q.Messages()) != 0 {
+		t.Errorf("q.Size(), len(q.Messages()) = %v, %v WANT 0, 0", q.Size(), len(q.Messages()))
+	}
+}
+
+func TestTimeQueue_Use_advance(t *testing.T) {
+	now := time.Now()
+	q := New(WithManualAdvance(now))
+	q.Use(func(next ReleaseFunc) ReleaseFunc {
+		return func(message *Message) {
+			if message.Data != "drop" {
+				next(message)
+			}
+		}
+	})
+	called := make(chan Message, 1)
+	q.ScheduleFunc(now, func(message Message) {
+		called <- message
+	})
+	q.Push(now, "drop")
+	q.Push(now, "keep")
+
+	released := q.Advance(now)
+	if len(released) != 2 || released[0].fn == nil || released[1].Data != "keep" {
+		t.Errorf("q.Advance() = %v WANT the function and keep", released)
+	}
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("ScheduleFunc() function not called")
+	}
+}
@@ -0,0 +1,303 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package timequeue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/gogolfing/timequeue/internal/record"
+)
+
+const (
+	//the size in bytes of a single index entry: the Message's Time in Unix
+	//nanoseconds and the offset of its payload in the data file.
+	mmapEntrySize = 16
+	//the minimum size in bytes of the memory-mapped index file.
+	mmapMinSize = 4096
+)
+
+//MmapStorage is an experimental Storage for schedules far larger than RAM.
+//
+//MmapStorage keeps a binary heap of small fixed-size index entries in a
+//memory-mapped file, so the operating system pages the heap in and out as needed.
+//Each entry references the Message's payload by its offset in a separate
+//append-only data file. Only the earliest Message is kept decoded in memory.
+//
+//Messages are encoded like a handoff, and therefore the concrete types of all Data
+//values must be registered with RegisterType() or gob.Register().
+//The Messages returned by Peek() and PopDue(), and therefore released by a
+//TimeQueue, may be decoded copies of the Messages that were pushed. The pushed
+//*Message values should not be used with TimeQueue methods, e.g. Remove(), after
+//being pushed, and Message Keys are not supported.
+//
+//Removing a Message other than the earliest takes time proportional to the number
+//of Messages. Space in the data file is not reclaimed until the MmapStorage is
+//closed and recreated. MmapStorage does not persist Messages across restarts.
+type MmapStorage struct {
+	index *os.File
+	data  *os.File
+	//the memory-mapped contents of index.
+	mem []byte
+	//the number of entries in the heap.
+	n int
+	//the size of data.
+	dataEnd int64
+	//the decoded Message of the first entry. nil if not yet decoded.
+	head *Message
+}
+
+//NewMmapStorage creates an empty MmapStorage using the files at indexPath and
+//dataPath, which are created or truncated.
+func NewMmapStorage(indexPath, dataPath string) (*MmapStorage, error) {
+	index, err := os.OpenFile(indexPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(dataPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	s := &MmapStorage{
+		index: index,
+		data:  data,
+	}
+	if err := s.remap(mmapMinSize); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+//Close unmaps and closes the files of s. s must not be used after Close.
+func (s *MmapStorage) Close() error {
+	var result error
+	if s.mem != nil {
+		result = syscall.Munmap(s.mem)
+		s.mem = nil
+	}
+	if err := s.index.Close(); err != nil && result == nil {
+		result = err
+	}
+	if err := s.data.Close(); err != nil && result == nil {
+		result = err
+	}
+	return result
+}
+
+//remap resizes the index file to size bytes and maps it into memory.
+func (s *MmapStorage) remap(size int) error {
+	if s.mem != nil {
+		if err := syscall.Munmap(s.mem); err != nil {
+			return err
+		}
+		s.mem = nil
+	}
+	if err := s.index.Truncate(int64(size)); err != nil {
+		return err
+	}
+	mem, err := syscall.Mmap(int(s.index.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	s.mem = mem
+	return nil
+}
+
+//entry returns the time and offset of the entry at i.
+//The time is in Unix nanoseconds and clamped by record.UnixNano() so that Times
+//after 2262 and before 1678 still order correctly.
+func (s *MmapStorage) entry(i int) (int64, int64) {
+	b := s.mem[i*mmapEntrySize:]
+	return int64(binary.LittleEndian.Uint64(b)), int64(binary.LittleEndian.Uint64(b[8:]))
+}
+
+//setEntry sets the time and offset of the entry at i.
+func (s *MmapStorage) setEntry(i int, t, offset int64) {
+	b := s.mem[i*mmapEntrySize:]
+	binary.LittleEndian.PutUint64(b, uint64(t))
+	binary.LittleEndian.PutUint64(b[8:], uint64(offset))
+}
+
+//less returns whether or not the entry at i is ordered before the entry at j.
+//Entries with equal times are ordered by offset, i.e. by push order, since
+//entries do not include Priorities.
+func (s *MmapStorage) less(i, j int) bool {
+	ti, oi := s.entry(i)
+	tj, oj := s.entry(j)
+	return ti < tj || (ti == tj && oi < oj)
+}
+
+//swap swaps the entries at i and j.
+func (s *MmapStorage) swap(i, j int) {
+	ti, oi := s.entry(i)
+	tj, oj := s.entry(j)
+	s.setEntry(i, tj, oj)
+	s.setEntry(j, ti, oi)
+}
+
+//up moves the entry at i towards the root until the heap is ordered.
+func (s *MmapStorage) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !s.less(i, parent) {
+			return
+		}
+		s.swap(i, parent)
+		i = parent
+	}
+}
+
+//down moves the entry at i towards the leaves until the heap is ordered.
+func (s *MmapStorage) down(i int) {
+	for {
+		smallest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < s.n && s.less(child, smallest) {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return
+		}
+		s.swap(i, smallest)
+		i = smallest
+	}
+}
+
+//removeAt removes the entry at i from the heap.
+func (s *MmapStorage) removeAt(i int) {
+	s.n--
+	if i != s.n {
+		s.swap(i, s.n)
+		s.down(i)
+		s.up(i)
+	}
+	s.head = nil
+}
+
+//write appends the encoded payload of message to the data file and returns its
+//offset.
+func (s *MmapStorage) write(message *Message) (int64, error) {
+	buf := &bytes.Buffer{}
+	buf.Write(make([]byte, 4))
+	value, err := EncodeMessage(message)
+	if err != nil {
+		return 0, err
+	}
+	buf.Write(value)
+	b := buf.Bytes()
+	binary.LittleEndian.PutUint32(b, uint32(len(b)-4))
+	offset := s.dataEnd
+	if _, err := s.data.WriteAt(b, offset); err != nil {
+		return 0, err
+	}
+	s.dataEnd += int64(len(b))
+	return offset, nil
+}
+
+//read decodes the Message whose payload is at offset in the data file.
+func (s *MmapStorage) read(offset int64) (*Message, error) {
+	size := make([]byte, 4)
+	if _, err := s.data.ReadAt(size, offset); err != nil {
+		return nil, err
+	}
+	b := make([]byte, binary.LittleEndian.Uint32(size))
+	if _, err := s.data.ReadAt(b, offset+4); err != nil {
+		return nil, err
+	}
+	message, err := DecodeMessage(b)
+	if err != nil {
+		return nil, err
+	}
+	message.mmapOffset = offset + 1
+	return message, nil
+}
+
+//Push adds message to s.
+//Push panics if message cannot be encoded or written, since the Storage interface
+//cannot return errors.
+func (s *MmapStorage) Push(message *Message) {
+	offset, err := s.write(message)
+	if err != nil {
+		panic(err)
+	}
+	if (s.n+1)*mmapEntrySize > len(s.mem) {
+		if err := s.remap(2 * len(s.mem)); err != nil {
+			panic(err)
+		}
+	}
+	s.setEntry(s.n, record.UnixNano(message.Time), offset)
+	s.n++
+	s.up(s.n - 1)
+	message.mmapOffset = offset + 1
+	if _, first := s.entry(0); first == offset {
+		s.head = message
+	} else if s.head != nil && s.head.mmapOffset-1 != first {
+		s.head = nil
+	}
+}
+
+//Peek returns the earliest Message in s.
+//Peek panics if the Message cannot be read or decoded.
+func (s *MmapStorage) Peek() *Message {
+	if s.n == 0 {
+		return nil
+	}
+	if s.head == nil {
+		_, offset := s.entry(0)
+		message, err := s.read(offset)
+		if err != nil {
+			panic(err)
+		}
+		s.head = message
+	}
+	return s.head
+}
+
+//PopDue removes and returns the earliest Message in s if it is before until.
+func (s *MmapStorage) PopDue(until time.Time) *Message {
+	message := s.Peek()
+	if message == nil || !message.Before(until) {
+		return nil
+	}
+	s.removeAt(0)
+	return message
+}
+
+//Remove removes message from s.
+func (s *MmapStorage) Remove(message *Message) bool {
+	if message.mmapOffset == 0 {
+		return false
+	}
+	for i := 0; i < s.n; i++ {
+		if _, offset := s.entry(i); offset == message.mmapOffset-1 {
+			s.removeAt(i)
+			return true
+		}
+	}
+	return false
+}
+
+//Len returns the number of Messages in s.
+func (s *MmapStorage) Len() int {
+	return s.n
+}
+
+//Each calls fn with a decoded copy of every Message in s.
+//Each panics if a Message cannot be read or decoded.
+func (s *MmapStorage) Each(fn func(message *Message)) {
+	for i := 0; i < s.n; i++ {
+		_, offset := s.entry(i)
+		message, err := s.read(offset)
+		if err != nil {
+			panic(err)
+		}
+		fn(message)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package timequeue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestMmapStorage(t *testing.T) *MmapStorage {
+	dir, err := os.MkdirTemp("", "timequeue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	s, err := NewMmapStorage(filepath.Join(dir, "index"), filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatalf("NewMmapStorage() error = %v WANT nil", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestMmapStorage(t *testing.T) {
+	s := newTestMmapStorage(t)
+	now := time.Now()
+	count := mmapMinSize/mmapEntrySize + 10
+	var removed *Message
+	for i := 0; i < count; i++ {
+		j := (i * 7919) % count
+		message := &Message{Time: now.Add(time.Duration(j) * time.Millisecond), Data: j, Topic: "t"}
+		s.Push(message)
+		if j == 100 {
+			removed = message
+		}
+	}
+	if length := s.Len(); length != count {
+		t.Errorf("s.Len() = %v WANT %v", length, count)
+	}
+	if !s.Remove(removed) {
+		t.Errorf("s.Remove() = false WANT true")
+	}
+	if s.PopDue(now) != nil {
+		t.Errorf("s.PopDue(now) = non-nil WANT nil")
+	}
+
+	eachCount := 0
+	s.Each(func(message *Message) { eachCount++ })
+	if eachCount != count-1 {
+		t.Errorf("s.Each() count = %v WANT %v", eachCount, count-1)
+	}
+
+	for want := 0; want < count; want++ {
+		if want == 100 {
+			continue
+		}
+		message := s.PopDue(now.Add(time.Hour))
+		if message == nil || message.Data != want || message.Topic != "t" {
+			t.Fatalf("s.PopDue() = %v WANT Data %v", message, want)
+		}
+	}
+	if peek := s.Peek(); peek != nil {
+		t.Errorf("s.Peek() = %v WANT nil", peek)
+	}
+}
+
+func TestMmapStorage_farTime(t *testing.T) {
+	s := newTestMmapStorage(t)
+	now := time.Now()
+	far := time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC)
+	past := time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Push(&Message{Time: far, Data: "far"})
+	s.Push(&Message{Time: now.Add(time.Hour), Data: "hour"})
+	s.Push(&Message{Time: past, Data: "past"})
+
+	for _, want := range []string{"past", "hour", "far"} {
+		message := s.PopDue(far.Add(time.Hour))
+		if message == nil || message.Data != want {
+			t.Fatalf("s.PopDue() = %v WANT Data %v", message, want)
+		}
+	}
+}
+
+func TestMmapStorage_timeQueue(t *testing.T) {
+	q := New(WithStorage(newTestMmapStorage(t)))
+	q.Start()
+	defer q.Stop()
+	now := time.Now()
+	for i := 3; i > 0; i-- {
+		q.Push(now.Add(time.Duration(i)*10*time.Millisecond), i)
+	}
+	for i := 1; i <= 3; i++ {
+		if message := <-q.Messages(); message.Data != i {
+			t.Errorf("<-q.Messages() = %v WANT Data %v", message, i)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package timequeue
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+//ErrNotReconfigurable is returned by Reconfigure() when given an Option that can
+//only be used when creating a TimeQueue.
+var ErrNotReconfigurable = errors.New("timequeue: option cannot be reconfigured")
+
+//Option configures a TimeQueue.
+//Options are given to New() and Reconfigure().
+//All Options may be given to Reconfigure() unless their documentation says otherwise.
+type Option func(*config)
+
+//config holds all values that may be set by Options.
+type config struct {
+	capacity          int
+	wedgeThreshold    time.Duration
+	auditCapacity     int
+	auditHook         func(entry AuditEntry)
+	budgetLimit       int
+	budgetWindow      time.Duration
+	calendar          Calendar
+	archive           ArchiveSink
+	archiveChanges    bool
+	redactor          Redactor
+	finalizer         func(message Message, reason DropReason)
+	lateThreshold     time.Duration
+	lateCallback      func(message Message, lateness time.Duration)
+	replayWindow      time.Duration
+	logger            *slog.Logger
+	reorderHold       time.Duration
+	fairness          FairnessPolicy
+	pressure          func() bool
+	pressureHorizon   time.Duration
+	leadTime          time.Duration
+	preciseWorkers    int
+	topicArchives     map[string]ArchiveSink
+	storage           Storage
+	bloomKeys         int
+	bloomRate         float64
+	ackMode           bool
+	visibilityTimeout time.Duration
+	maxDeliveries     int
+	scheduler         *SharedScheduler
+	store             Store
+	outputs           int
+	wakeBatch         int
+	manual            bool
+	manualStart       time.Time
+	tenantQuota       TenantQuota
+	tenantQuotas      map[string]TenantQuota
+	//true once an Option that may not be given to Reconfigure() is applied.
+	fixed bool
+}
+
+//newConfig creates a config with all default values.
+func newConfig() config {
+	return config{
+		capacity:       DefaultCapacity,
+		wedgeThreshold: DefaultWedgeThreshold,
+		auditCapacity:  DefaultAuditCapacity,
+	}
+}
+
+//apply calls every Option in opts on c.
+func (c *config) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+//WithCapacity sets the capacity of the channel returned from Messages().
+//WithCapacity may not be given to Reconfigure().
+func WithCapacity(capacity int) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.capacity = capacity
+	}
+}
+
+//WithWedgeThreshold sets the amount of time the earliest Message may be overdue
+//before Healthy() reports an error. The default is DefaultWedgeThreshold.
+func WithWedgeThreshold(threshold time.Duration) Option {
+	return func(c *config) {
+		c.wedgeThreshold = threshold
+	}
+}
+
+//WithAuditCapacity sets the number of AuditEntries kept in memory by a TimeQueue.
+//The default is DefaultAuditCapacity.
+func WithAuditCapacity(capacity int) Option {
+	return func(c *config) {
+		c.auditCapacity = capacity
+	}
+}
+
+//WithAuditHook sets a function that is called with every AuditEntry as it is
+//recorded. hook is called with the TimeQueue locked and must not call any of its
+//methods.
+func WithAuditHook(hook func(entry AuditEntry)) Option {
+	return func(c *config) {
+		c.auditHook = hook
+	}
+}
+
+//WithBudget limits the total Weight of Messages released as their times pass to
+//limit per window of wall-clock time. Messages without a Weight count as 1, so
+//limit is the number of Messages released if no Messages have a Weight.
+//Windows are aligned to the zero time, e.g. a window of time.Hour starts at the
+//top of every hour.
+//Due Messages that exceed the budget stay in the TimeQueue and are released
+//in the next window.
+//A limit or window less than or equal to zero disables the budget, which is the
+//default.
+//
+//Messages released explicitly with Pop(), PopAll(), PopAllUntil(), or Remove()
+//do not count against the budget.
+func WithBudget(limit int, window time.Duration) Option {
+	return func(c *config) {
+		c.budgetLimit = limit
+		c.budgetWindow = window
+	}
+}
+
+//WithCalendar sets the Calendar whose blackouts determine when a TimeQueue does
+//not release Messages. Messages that are due during a blackout are released when
+//it ends.
+//Messages released explicitly with Pop(), PopAll(), PopAllUntil(), or Remove()
+//are not affected by blackouts.
+func WithCalendar(calendar Calendar) Option {
+	return func(c *config) {
+		c.calendar = calendar
+	}
+}
+
+//WithWakeBatch limits the number of Messages released each time a TimeQueue wakes
+//to max. Every Message that is due when a TimeQueue wakes is released at once, so
+//a large backlog of due Messages otherwise keeps the TimeQueue locked until all of
+//it is released. Messages over the limit are released by an immediately following
+//wake, which allows pushes and other calls to proceed in between.
+//A max less than or equal to zero does not limit releases, which is the default.
+func WithWakeBatch(max int) Option {
+	return func(c *config) {
+		c.wakeBatch = max
+	}
+}
+
+//Reconfigure applies opts to q at runtime without losing any Messages.
+//q is paused while the Options are applied, i.e. no Messages are released, and
+//then resumes releasing with the new configuration.
+//
+//If any of opts may not be reconfigured, then ErrNotReconfigurable is returned and
+//none of opts are applied.
+func (q *TimeQueue) Reconfigure(opts ...Option) error {
+	return q.administer("", AuditReconfigure, "", func() error {
+		return q.reconfigure(opts)
+	})
+}
+
+//reconfigure is the unexported version of Reconfigure().
+//It should only be called when q is locked.
+func (q *TimeQueue) reconfigure(opts []Option) error {
+	c := q.config
+	c.fixed = false
+	c.apply(opts)
+	if c.fixed || (c.pressure == nil) != (q.config.pressure == nil) {
+		return ErrNotReconfigurable
+	}
+	q.killWakeSignal()
+	q.config = c
+	q.audits.resize(c.auditCapacity)
+	q.afterHeapUpdate()
+	return nil
+}
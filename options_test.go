@@ -0,0 +1,66 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew_options(t *testing.T) {
+	q := New(WithCapacity(3), WithWedgeThreshold(time.Minute))
+	if cap(q.messageChan) != 3 {
+		t.Errorf("cap(q.messageChan) = %v WANT %v", cap(q.messageChan), 3)
+	}
+	if q.config.wedgeThreshold != time.Minute {
+		t.Errorf("q.config.wedgeThreshold = %v WANT %v", q.config.wedgeThreshold, time.Minute)
+	}
+}
+
+func TestTimeQueue_Reconfigure(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(time.Hour), 0)
+	q.Start()
+	defer q.Stop()
+	if err := q.Reconfigure(WithWedgeThreshold(time.Minute)); err != nil {
+		t.Errorf("q.Reconfigure() = %v WANT %v", err, nil)
+	}
+	if q.config.wedgeThreshold != time.Minute {
+		t.Errorf("q.config.wedgeThreshold = %v WANT %v", q.config.wedgeThreshold, time.Minute)
+	}
+	if q.wakeSignal == nil {
+		t.Errorf("q.wakeSignal = nil WANT non-nil")
+	}
+}
+
+func TestTimeQueue_Reconfigure_notReconfigurable(t *testing.T) {
+	q := New()
+	err := q.Reconfigure(WithWedgeThreshold(time.Minute), WithCapacity(10))
+	if err != ErrNotReconfigurable {
+		t.Errorf("q.Reconfigure() = %v WANT %v", err, ErrNotReconfigurable)
+	}
+	if q.config.wedgeThreshold != DefaultWedgeThreshold {
+		t.Errorf("q.config.wedgeThreshold = %v WANT %v", q.config.wedgeThreshold, DefaultWedgeThreshold)
+	}
+}
+
+//uncomparableStore is a Store that panics if it is compared with ==.
+type uncomparableStore struct {
+	Store
+	_ []int
+}
+
+func TestTimeQueue_Reconfigure_uncomparable(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	q := New(WithStore(uncomparableStore{Store: wal}))
+	if err := q.Reconfigure(WithWedgeThreshold(time.Minute)); err != nil {
+		t.Errorf("q.Reconfigure() = %v WANT %v", err, nil)
+	}
+	for _, opt := range []Option{WithAckMode(), WithStore(uncomparableStore{Store: wal})} {
+		if err := q.Reconfigure(opt); err != ErrNotReconfigurable {
+			t.Errorf("q.Reconfigure() = %v WANT %v", err, ErrNotReconfigurable)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package timequeue
+
+//WithOutputs causes a TimeQueue to release Messages round-robin across n output
+//channels, returned from Outputs(), instead of on Messages(). Each output channel
+//has the capacity given to WithCapacity().
+//
+//With one consumer go-routine per output channel, consumers no longer contend on
+//a single channel, which becomes a bottleneck at very high release rates.
+//Messages() and Next() receive nothing while outputs are in use. A value of n less
+//than 1 disables outputs.
+//WithOutputs may not be given to Reconfigure().
+func WithOutputs(n int) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.outputs = n
+	}
+}
+
+//newOutputs creates n output channels with capacity, or nil if n is less than 1.
+func newOutputs(n, capacity int) []chan *Message {
+	if n < 1 {
+		return nil
+	}
+	outputs := make([]chan *Message, n)
+	for i := range outputs {
+		outputs[i] = make(chan *Message, capacity)
+	}
+	return outputs
+}
+
+//Outputs returns the output channels given by WithOutputs(), or nil if q does not
+//use outputs. The returned channels are the same instances on every call and are
+//never closed.
+func (q *TimeQueue) Outputs() []<-chan *Message {
+	if q.outputs == nil {
+		return nil
+	}
+	result := make([]<-chan *Message, len(q.outputs))
+	for i, output := range q.outputs {
+		result[i] = output
+	}
+	return result
+}
+
+//output returns the channel that the ith consumer should receive from.
+func (q *TimeQueue) output(i int) <-chan *Message {
+	if q.outputs == nil {
+		return q.messageChan
+	}
+	return q.outputs[i%len(q.outputs)]
+}
+
+//nextOutput returns the channel to release the next Message on.
+//It should only be called when q is locked.
+func (q *TimeQueue) nextOutput() chan *Message {
+	if q.outputs == nil {
+		return q.messageChan
+	}
+	out := q.outputs[q.outputIndex]
+	q.outputIndex = (q.outputIndex + 1) % len(q.outputs)
+	return out
+}
+
+//backlog returns the number of released Messages buffered in q's channels.
+func (q *TimeQueue) backlog() int {
+	result := len(q.messageChan)
+	for _, output := range q.outputs {
+		result += len(output)
+	}
+	return result
+}
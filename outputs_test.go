@@ -0,0 +1,68 @@
+package timequeue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Outputs(t *testing.T) {
+	q := New(WithOutputs(3), WithCapacity(6))
+	outputs := q.Outputs()
+	if len(outputs) != 3 {
+		t.Fatalf("len(q.Outputs()) = %v WANT %v", len(outputs), 3)
+	}
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		q.Push(now.Add(time.Duration(i)*time.Millisecond), i)
+	}
+	q.PopAll(true)
+	for i := 0; i < 6; i++ {
+		message := <-outputs[i%3]
+		if message.Data != i {
+			t.Errorf("message.Data = %v WANT %v", message.Data, i)
+		}
+	}
+	q.Push(now, 6)
+	q.PopAll(true)
+	if message := <-outputs[0]; message.Data != 6 {
+		t.Errorf("message.Data = %v WANT %v", message.Data, 6)
+	}
+	if backlog := len(q.Messages()); backlog != 0 {
+		t.Errorf("len(q.Messages()) = %v WANT %v", backlog, 0)
+	}
+}
+
+func TestTimeQueue_Outputs_none(t *testing.T) {
+	if outputs := New().Outputs(); outputs != nil {
+		t.Errorf("q.Outputs() = %v WANT nil", outputs)
+	}
+	if outputs := New(WithOutputs(0)).Outputs(); outputs != nil {
+		t.Errorf("q.Outputs() = %v WANT nil", outputs)
+	}
+	q := New()
+	if err := q.Reconfigure(WithOutputs(2)); err != ErrNotReconfigurable {
+		t.Errorf("q.Reconfigure() = %v WANT %v", err, ErrNotReconfigurable)
+	}
+}
+
+func TestTimeQueue_Consume_outputs(t *testing.T) {
+	q := New(WithOutputs(2), WithCapacity(4))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handled := make(chan interface{}, 4)
+	d := q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		handled <- message.Data
+		return nil
+	}, WithWorkers(2))
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		q.Push(now, i)
+	}
+	q.PopAll(true)
+	for i := 0; i < 4; i++ {
+		<-handled
+	}
+	cancel()
+	d.Wait()
+}
@@ -0,0 +1,28 @@
+package timequeue
+
+import (
+	"sync"
+	"unsafe"
+)
+
+//cacheLineSize is the assumed size of a CPU cache line in bytes.
+//It is the size on amd64 and most arm64 processors, and too large is only wasteful
+//while too small allows false sharing.
+const cacheLineSize = 64
+
+//cacheLinePad separates the fields before it in a struct from those after it so
+//that they are never on the same cache line.
+type cacheLinePad [cacheLineSize]byte
+
+//paddedMutex is a sync.Mutex that fills an entire cache line.
+//Allocated on its own, it is aligned to a cache line and so never shares one with
+//other values, which would otherwise be invalidated every time the Mutex is locked.
+type paddedMutex struct {
+	sync.Mutex
+	_ [cacheLineSize - unsafe.Sizeof(sync.Mutex{})]byte
+}
+
+//newPaddedMutex returns a *sync.Mutex that does not share its cache line.
+func newPaddedMutex() *sync.Mutex {
+	return &(&paddedMutex{}).Mutex
+}
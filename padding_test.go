@@ -0,0 +1,90 @@
+package timequeue
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestPaddedMutex_size(t *testing.T) {
+	if size := unsafe.Sizeof(paddedMutex{}); size != cacheLineSize {
+		t.Errorf("unsafe.Sizeof(paddedMutex{}) = %v WANT %v", size, cacheLineSize)
+	}
+}
+
+func TestTimeQueue_padding(t *testing.T) {
+	q := TimeQueue{}
+	readOnly := unsafe.Offsetof(q.stopChan) + unsafe.Sizeof(q.stopChan)
+	if locked := unsafe.Offsetof(q.heldMessages); locked-readOnly < cacheLineSize {
+		t.Errorf("locked fields offset = %v WANT at least %v", locked, readOnly+cacheLineSize)
+	}
+}
+
+//lockedCounter is a Mutex protected count that does not fill a cache line.
+type lockedCounter struct {
+	lock  sync.Mutex
+	count int
+}
+
+//paddedCounter is a lockedCounter that fills a cache line.
+type paddedCounter struct {
+	lockedCounter
+	_ [cacheLineSize - unsafe.Sizeof(lockedCounter{})]byte
+}
+
+//benchmarkCounters has each parallel go-routine increment its own counter from
+//the same array, where the counters are lock and count.
+func benchmarkCounters(b *testing.B, n int, lock func(i int) (*sync.Mutex, *int)) {
+	var next int
+	var nextLock sync.Mutex
+	b.RunParallel(func(pb *testing.PB) {
+		nextLock.Lock()
+		l, count := lock(next % n)
+		next++
+		nextLock.Unlock()
+		for pb.Next() {
+			l.Lock()
+			*count++
+			l.Unlock()
+		}
+	})
+}
+
+func BenchmarkFalseSharing(b *testing.B) {
+	const n = 64
+	b.Run("unpadded", func(b *testing.B) {
+		counters := make([]lockedCounter, n)
+		benchmarkCounters(b, n, func(i int) (*sync.Mutex, *int) {
+			return &counters[i].lock, &counters[i].count
+		})
+	})
+	b.Run("padded", func(b *testing.B) {
+		counters := make([]paddedCounter, n)
+		benchmarkCounters(b, n, func(i int) (*sync.Mutex, *int) {
+			return &counters[i].lock, &counters[i].count
+		})
+	})
+}
+
+func BenchmarkTimeQueue_Push_parallel(b *testing.B) {
+	q := New(WithCapacity(1024))
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-q.Messages():
+			}
+		}
+	}()
+	t := time.Now().Add(time.Hour)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Push(t, nil)
+		}
+	})
+}
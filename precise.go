@@ -0,0 +1,155 @@
+package timequeue
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	//preciseLead is how long before their Times the functions of Messages are
+	//handed to precise workers.
+	preciseLead = 2 * time.Millisecond
+
+	//preciseSpin is how long before their Times precise workers stop sleeping and
+	//spin, since sleeping may oversleep by more than the desired jitter.
+	preciseSpin = 500 * time.Microsecond
+)
+
+//WithPreciseCallbacks causes a TimeQueue to call the functions of Messages pushed
+//with ScheduleFunc() as close to their Times as possible, for using a TimeQueue as
+//a precision timer service.
+//Functions are handed to a pool of up to workers go-routines shortly before their
+//Times, and each go-routine is locked to an OS thread and sleeps and then spins
+//until the Time of the function it calls. Unlike the default, functions may
+//therefore be called concurrently and out of release order, and a function that
+//blocks only delays those on its own worker.
+//How late functions are called is reported by CallbackLateness().
+//
+//Functions of a TimeQueue that is advanced manually are called without waiting.
+//A workers less than or equal to zero calls functions one at a time as they are
+//released, which is the default.
+func WithPreciseCallbacks(workers int) Option {
+	return func(c *config) {
+		c.preciseWorkers = workers
+	}
+}
+
+//Lateness summarizes how late the functions of Messages pushed with ScheduleFunc()
+//were called relative to their Times.
+type Lateness struct {
+	//the number of functions called.
+	Count uint64
+	//the mean lateness of all calls.
+	Mean time.Duration
+	//the greatest lateness of any call.
+	Max time.Duration
+}
+
+//CallbackLateness returns the Lateness of the functions called by q since it was
+//created. Only functions called WithPreciseCallbacks() by a TimeQueue that is not
+//advanced manually are counted.
+func (q *TimeQueue) CallbackLateness() Lateness {
+	return q.precise.lateness()
+}
+
+//precisePool calls the functions of released Messages on up to a given number of
+//go-routines at their Times.
+type precisePool struct {
+	//protects all fields.
+	lock sync.Mutex
+	//the released Messages whose functions have not been handed to a worker.
+	pending []*Message
+	//the number of running workers.
+	workers int
+	//the number of functions called.
+	count uint64
+	//the total and max lateness of all calls.
+	total time.Duration
+	max   time.Duration
+	//counts the functions that have been queued and not yet returned.
+	calls inFlight
+}
+
+//run queues the function of message to be called at its Time and starts another
+//worker if fewer than workers are running.
+func (p *precisePool) run(message *Message, workers int, wait bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.pending = append(p.pending, message)
+	p.calls.add()
+	if p.workers < workers {
+		p.workers++
+		go p.work(wait)
+	}
+}
+
+//work calls pending functions until there are none.
+func (p *precisePool) work(wait bool) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	for {
+		p.lock.Lock()
+		if len(p.pending) == 0 {
+			p.workers--
+			p.lock.Unlock()
+			return
+		}
+		message := p.pending[0]
+		p.pending[0] = nil
+		p.pending = p.pending[1:]
+		p.lock.Unlock()
+		if wait {
+			waitUntil(message.Time)
+			p.record(time.Since(message.Time))
+		}
+		message.fn(*message)
+		p.lock.Lock()
+		p.calls.done()
+		p.lock.Unlock()
+	}
+}
+
+//idle returns a channel that is closed once every queued function has returned.
+func (p *precisePool) idle() <-chan struct{} {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.calls.wait()
+}
+
+//waitUntil sleeps until shortly before t and then spins until t.
+func waitUntil(t time.Time) {
+	if d := time.Until(t) - preciseSpin; d > 0 {
+		time.Sleep(d)
+	}
+	for time.Now().Before(t) {
+	}
+}
+
+//record adds a call late by late to the Lateness of p.
+func (p *precisePool) record(late time.Duration) {
+	if late < 0 {
+		late = 0
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.count++
+	p.total += late
+	if late > p.max {
+		p.max = late
+	}
+}
+
+//lateness returns the Lateness of all calls so far.
+func (p *precisePool) lateness() Lateness {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	result := Lateness{
+		Count: p.count,
+		Max:   p.max,
+	}
+	if p.count > 0 {
+		result.Mean = p.total / time.Duration(p.count)
+	}
+	return result
+}
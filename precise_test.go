@@ -0,0 +1,54 @@
+package timequeue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithPreciseCallbacks(t *testing.T) {
+	q := New(WithPreciseCallbacks(2))
+	q.Start()
+	defer q.Stop()
+	now := time.Now()
+	wg := &sync.WaitGroup{}
+	lock := &sync.Mutex{}
+	early := 0
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		q.ScheduleFunc(now.Add(time.Duration(i)*10*time.Millisecond), func(message Message) {
+			defer wg.Done()
+			if time.Now().Before(message.Time) {
+				lock.Lock()
+				early++
+				lock.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if early != 0 {
+		t.Errorf("functions called early = %v WANT %v", early, 0)
+	}
+	if lateness := q.CallbackLateness(); lateness.Count != 4 || lateness.Mean > lateness.Max {
+		t.Errorf("CallbackLateness() = %+v WANT Count 4", lateness)
+	}
+}
+
+func TestWithPreciseCallbacks_manual(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start), WithPreciseCallbacks(1))
+	called := make(chan Message, 1)
+	q.ScheduleFunc(start.Add(time.Second), func(message Message) { called <- message })
+	if released := q.Advance(start.Add(time.Second - time.Millisecond)); len(released) != 0 || len(called) != 0 {
+		t.Errorf("Advance() released = %v WANT none", released)
+	}
+	q.Advance(start.Add(time.Second))
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Errorf("function not called after Advance()")
+	}
+	if lateness := q.CallbackLateness(); lateness != (Lateness{}) {
+		t.Errorf("CallbackLateness() = %+v WANT zero", lateness)
+	}
+}
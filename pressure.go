@@ -0,0 +1,196 @@
+package timequeue
+
+import (
+	"runtime"
+	"time"
+)
+
+//PressureInterval is how often a running TimeQueue given WithMemoryPressure()
+//checks for memory pressure.
+const PressureInterval = time.Second
+
+//WithMemoryPressure makes a TimeQueue given WithStore() degrade gracefully under
+//memory pressure. While it is running, it calls pressure every PressureInterval,
+//and while pressure returns true, it spills every Message due more than horizon
+//from now out of memory and shrinks its buffers. Spilled Messages stay in the Store,
+//and their Data is loaded back from it when pressure clears or their Times come
+//within horizon, so horizon should be much longer than PressureInterval. They are
+//loaded one at a time if the Store is a LoadStore, and with LoadAll() otherwise.
+//
+//Spilled Messages still count towards Size() and are still pending, so they may be
+//removed, acknowledged, or exported, e.g. with Remove(), Snapshot(), or HandoffTo(),
+//which loads them first. They are not seen by methods that read the earliest
+//Messages, e.g. Peek(), PopAll(), or Horizon(), until they are loaded. They are all
+//loaded when the TimeQueue is stopped or cleared. Messages that are not written to
+//the Store, e.g. those pushed with ScheduleFunc() or PushEvery(), are never
+//spilled.
+//
+//HeapLimit() creates a pressure function from the runtime's memory statistics.
+//WithMemoryPressure has no effect without WithStore(). Its pressure function may be
+//replaced with Reconfigure(), but it may not be added or removed.
+func WithMemoryPressure(pressure func() bool, horizon time.Duration) Option {
+	return func(c *config) {
+		c.pressure = pressure
+		c.pressureHorizon = horizon
+	}
+}
+
+//HeapLimit returns a pressure function for WithMemoryPressure() that reports
+//pressure while the bytes of allocated heap objects exceed limit.
+func HeapLimit(limit uint64) func() bool {
+	return func() bool {
+		stats := &runtime.MemStats{}
+		runtime.ReadMemStats(stats)
+		return stats.HeapAlloc > limit
+	}
+}
+
+//UnderPressure returns whether or not q is under memory pressure and the number of
+//Messages that are spilled out of memory. See WithMemoryPressure().
+func (q *TimeQueue) UnderPressure() (bool, int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.pressured, len(q.spilled)
+}
+
+//watchPressure checks for memory pressure every PressureInterval until done is
+//closed.
+func (q *TimeQueue) watchPressure(done <-chan struct{}) {
+	ticker := time.NewTicker(PressureInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.checkPressure()
+		case <-done:
+			return
+		}
+	}
+}
+
+//checkPressure spills Messages if q is under memory pressure, and loads them back
+//if it is not or they are due within the horizon.
+func (q *TimeQueue) checkPressure() {
+	q.lock.Lock()
+	pressure := q.config.pressure
+	q.lock.Unlock()
+	//pressure is called without q locked since it may be slow.
+	pressured := pressure != nil && pressure()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.store == nil || q.pressureDone == nil {
+		return
+	}
+	if pressured != q.pressured {
+		q.logEvent("timequeue: pressure", "pressured", pressured, "spilled", len(q.spilled))
+	}
+	q.pressured = pressured
+	cutoff := q.now().Add(q.config.pressureHorizon)
+	if pressured {
+		q.loadSpilled(cutoff)
+		q.spill(cutoff)
+	} else {
+		q.loadSpilled(time.Time{})
+	}
+	q.afterHeapUpdate()
+}
+
+//isSpilled returns whether or not message is spilled out of q.storage.
+//It should only be called when q is locked.
+func (q *TimeQueue) isSpilled(message *Message) bool {
+	return message != nil && message.ID != "" && q.spilled[message.ID] == message
+}
+
+//spill removes the Data of every Message in q.storage that is due after cutoff and
+//moves the Message from q.storage to q.spilled. It then shrinks q.storage.
+//It should only be called when q is locked.
+func (q *TimeQueue) spill(cutoff time.Time) {
+	spilled := []*Message{}
+	q.storage.Each(func(message *Message) {
+		if message.Time.After(cutoff) && message.fn == nil && message.Clock == "" && message.schedule == nil {
+			spilled = append(spilled, message)
+		}
+	})
+	for _, message := range spilled {
+		if !q.storage.Remove(message) {
+			continue
+		}
+		message.storage = nil
+		message.Data = nil
+		q.spilled[message.ID] = message
+	}
+	if s, ok := q.storage.(*heapStorage); ok && len(spilled) > 0 {
+		s.mh.shrink()
+	}
+}
+
+//loadSpilled loads every spilled Message that is due before until, or all of them
+//if until is zero. See unspill().
+//It should only be called when q is locked.
+func (q *TimeQueue) loadSpilled(until time.Time) {
+	due := []*Message{}
+	for _, message := range q.spilled {
+		if until.IsZero() || message.Before(until) {
+			due = append(due, message)
+		}
+	}
+	q.unspill(due)
+}
+
+//unspill loads the Data of messages, which are spilled, from q.store and adds them
+//back to q.storage. The Messages are loaded one at a time if q.store is a
+//LoadStore, and with a single call to LoadAll() otherwise.
+//Messages stay spilled if loading fails.
+//It should only be called when q is locked.
+func (q *TimeQueue) unspill(messages []*Message) {
+	if len(messages) == 0 {
+		return
+	}
+	loaded, err := q.loadSpilledData(messages)
+	if err != nil {
+		q.storeFailed(err)
+		return
+	}
+	for _, message := range messages {
+		l, ok := loaded[message.ID]
+		if !ok {
+			continue
+		}
+		delete(q.spilled, message.ID)
+		message.Data = l.Data
+		q.restoreStored(message)
+	}
+}
+
+//loadSpilledData returns the Messages loaded from q.store for messages keyed by ID.
+//It should only be called when q is locked.
+func (q *TimeQueue) loadSpilledData(messages []*Message) (map[string]*Message, error) {
+	result := map[string]*Message{}
+	if ls, ok := q.store.(LoadStore); ok {
+		for _, message := range messages {
+			loaded, err := ls.Load(message.ID)
+			if err != nil {
+				return nil, err
+			}
+			if loaded != nil {
+				result[loaded.ID] = loaded
+			}
+		}
+		return result, nil
+	}
+	loaded, err := q.store.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range loaded {
+		result[l.ID] = l
+	}
+	return result, nil
+}
+
+//shrink reallocates the messages of mh so that they use no more memory than needed.
+func (mh *messageHeap) shrink() {
+	messages := make([]*Message, len(mh.messages))
+	copy(messages, mh.messages)
+	mh.messages = messages
+}
@@ -0,0 +1,196 @@
+package timequeue
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWithMemoryPressure(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	pressured := true
+	q := New(WithStore(wal), WithMemoryPressure(func() bool {
+		return pressured
+	}, time.Hour))
+	now := time.Now()
+	near := q.Push(now.Add(time.Minute), "near")
+	far := q.Push(now.Add(2*time.Hour), "far")
+	q.Start()
+	defer q.Stop()
+
+	q.checkPressure()
+	if ok, spilled := q.UnderPressure(); !ok || spilled != 1 {
+		t.Errorf("q.UnderPressure() = %v, %v WANT true, 1", ok, spilled)
+	}
+	if far.Data != nil || near.Data != "near" {
+		t.Errorf("far.Data, near.Data = %v, %v WANT nil, near", far.Data, near.Data)
+	}
+	if size := q.Size(); size != 2 {
+		t.Errorf("q.Size() = %v WANT %v", size, 2)
+	}
+
+	pressured = false
+	q.checkPressure()
+	if ok, spilled := q.UnderPressure(); ok || spilled != 0 {
+		t.Errorf("q.UnderPressure() = %v, %v WANT false, 0", ok, spilled)
+	}
+	if far.Data != "far" {
+		t.Errorf("far.Data = %v WANT %v", far.Data, "far")
+	}
+	if !q.Remove(far, false) {
+		t.Errorf("q.Remove(far) = false WANT true")
+	}
+}
+
+func TestTimeQueue_loadSpilled_horizon(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	start := time.Now()
+	q := New(WithStore(wal), WithManualAdvance(start), WithMemoryPressure(func() bool {
+		return true
+	}, time.Hour))
+	message := q.Push(start.Add(90*time.Minute), 0)
+	q.Start()
+
+	q.checkPressure()
+	if _, spilled := q.UnderPressure(); spilled != 1 {
+		t.Fatalf("spilled = %v WANT %v", spilled, 1)
+	}
+	q.Advance(start.Add(time.Hour))
+	q.checkPressure()
+	if _, spilled := q.UnderPressure(); spilled != 0 || message.Data != 0 {
+		t.Errorf("spilled, message.Data = %v, %v WANT 0, 0", spilled, message.Data)
+	}
+
+	q.Advance(start.Add(2 * time.Hour))
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_stop_loadsSpilled(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	q := New(WithStore(wal), WithMemoryPressure(func() bool {
+		return true
+	}, time.Hour))
+	message := q.Push(time.Now().Add(2*time.Hour), "far")
+	q.Start()
+	q.checkPressure()
+	q.Stop()
+	if _, spilled := q.UnderPressure(); spilled != 0 || message.Data != "far" {
+		t.Errorf("spilled, message.Data = %v, %v WANT 0, far", spilled, message.Data)
+	}
+}
+
+func TestHeapLimit(t *testing.T) {
+	if !HeapLimit(0)() {
+		t.Errorf("HeapLimit(0)() = false WANT true")
+	}
+	if HeapLimit(1 << 62)() {
+		t.Errorf("HeapLimit(1 << 62)() = true WANT false")
+	}
+}
+
+//loadCountingStore counts the calls to LoadAll() of the WAL that it embeds.
+type loadCountingStore struct {
+	*WAL
+	loadAll int
+}
+
+func (s *loadCountingStore) LoadAll() ([]*Message, error) {
+	s.loadAll++
+	return s.WAL.LoadAll()
+}
+
+func TestTimeQueue_loadSpilled_LoadStore(t *testing.T) {
+	tests := []struct {
+		loadStore bool
+		loadAll   int
+	}{
+		{true, 0},
+		{false, 1},
+	}
+	for _, test := range tests {
+		wal, err := OpenWAL(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		counting := &loadCountingStore{WAL: wal}
+		var store Store = counting
+		if !test.loadStore {
+			//hides Load() so that store is not a LoadStore.
+			store = struct{ Store }{counting}
+		}
+		q := New(WithStore(store), WithManualAdvance(time.Now()), WithMemoryPressure(func() bool {
+			return true
+		}, time.Hour))
+		message := q.Push(time.Now().Add(2*time.Hour), "far")
+		q.Start()
+		q.checkPressure()
+		counting.loadAll = 0
+		q.Stop()
+		if message.Data != "far" || counting.loadAll != test.loadAll {
+			t.Errorf("%v: message.Data, loadAll = %v, %v WANT far, %v", test.loadStore, message.Data, counting.loadAll, test.loadAll)
+		}
+		wal.Close()
+	}
+}
+
+func TestTimeQueue_spilled_pending(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	q := New(WithStore(wal), WithManualAdvance(time.Now()), WithMemoryPressure(func() bool {
+		return true
+	}, time.Hour))
+	now := time.Now()
+	removed := q.Push(now.Add(2*time.Hour), "removed")
+	repushed := &Message{Time: now.Add(3 * time.Hour), Data: "repushed", Key: "key"}
+	q.PushMessage(repushed)
+	exported := q.Push(now.Add(4*time.Hour), "exported")
+	q.Start()
+	defer q.Stop()
+	q.checkPressure()
+	if _, spilled := q.UnderPressure(); spilled != 3 {
+		t.Fatalf("spilled = %v WANT %v", spilled, 3)
+	}
+
+	if !q.Remove(removed, false) || removed.Data != "removed" {
+		t.Errorf("q.Remove() = false, %v WANT true, removed", removed.Data)
+	}
+	if message, _ := wal.Load(removed.ID); message != nil {
+		t.Errorf("wal.Load(removed) = %v WANT nil", message)
+	}
+	if !q.Contains("key") {
+		t.Errorf("q.Contains(key) = false WANT true")
+	}
+	if err := q.PushMessage(repushed); err != ErrMessageQueued {
+		t.Errorf("q.PushMessage(spilled) = %v WANT %v", err, ErrMessageQueued)
+	}
+	if size := q.Size(); size != 2 {
+		t.Errorf("q.Size() = %v WANT %v", size, 2)
+	}
+
+	if err := q.Snapshot(io.Discard); err != nil {
+		t.Fatalf("q.Snapshot() = %v WANT nil", err)
+	}
+	if exported.Data != "exported" {
+		t.Errorf("exported.Data = %v WANT %v", exported.Data, "exported")
+	}
+	if err := q.ConsistencyCheck(); err != nil {
+		t.Errorf("q.ConsistencyCheck() = %v WANT nil", err)
+	}
+}
@@ -0,0 +1,26 @@
+package timequeue
+
+//Tiebreaker may be implemented by the Data of Messages to order Messages whose
+//Times and Priorities are equal, e.g. by a deadline or a fairness class, without
+//encoding every criterion into the Priority of a Message.
+type Tiebreaker interface {
+	//Precedes returns whether or not a Message with this Data is released before
+	//one with other Data. other may be of any type, and Precedes should return
+	//false if it cannot compare them.
+	Precedes(other interface{}) bool
+}
+
+//messageBefore returns whether or not a is released before b. Messages are ordered
+//by Time, then by descending Priority, then by their Data if it is a Tiebreaker.
+func messageBefore(a, b *Message) bool {
+	if !a.Time.Equal(b.Time) {
+		return a.Time.Before(b.Time)
+	}
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if tiebreaker, ok := a.Data.(Tiebreaker); ok {
+		return tiebreaker.Precedes(b.Data)
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+//deadline is a Tiebreaker that orders earlier deadlines first.
+type deadline int
+
+func (d deadline) Precedes(other interface{}) bool {
+	o, ok := other.(deadline)
+	return ok && d < o
+}
+
+func TestMessageBefore(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		a, b   *Message
+		result bool
+	}{
+		{&Message{Time: now}, &Message{Time: now.Add(1)}, true},
+		{&Message{Time: now.Add(1), Priority: 10}, &Message{Time: now}, false},
+		{&Message{Time: now, Priority: 1}, &Message{Time: now}, true},
+		{&Message{Time: now}, &Message{Time: now, Priority: 1}, false},
+		{&Message{Time: now, Data: deadline(1)}, &Message{Time: now, Data: deadline(2)}, true},
+		{&Message{Time: now, Data: deadline(2)}, &Message{Time: now, Data: deadline(1)}, false},
+		{&Message{Time: now, Data: deadline(1)}, &Message{Time: now, Data: 0}, false},
+		{&Message{Time: now, Data: deadline(2), Priority: 1}, &Message{Time: now, Data: deadline(1)}, true},
+		{&Message{Time: now}, &Message{Time: now}, false},
+	}
+	for i, test := range tests {
+		if result := messageBefore(test.a, test.b); result != test.result {
+			t.Errorf("%v: messageBefore() = %v WANT %v", i, result, test.result)
+		}
+	}
+}
+
+func TestTimeQueue_Priority(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	for _, storage := range []Storage{nil, NewSkiplistStorage()} {
+		opts := []Option{WithManualAdvance(start)}
+		if storage != nil {
+			opts = append(opts, WithStorage(storage))
+		}
+		q := New(opts...)
+		q.PushMessage(&Message{Time: start, Data: deadline(3)})
+		q.PushMessage(&Message{Time: start, Data: deadline(2), Priority: -1})
+		q.PushMessage(&Message{Time: start, Data: deadline(1)})
+		q.PushMessage(&Message{Time: start, Data: deadline(4), Priority: 1})
+		q.PushMessage(&Message{Time: start.Add(-time.Second), Data: deadline(5), Priority: -5})
+		released := q.Advance(start)
+		want := []deadline{5, 4, 1, 3, 2}
+		if len(released) != len(want) {
+			t.Fatalf("q.Advance() = %v WANT %v", released, want)
+		}
+		for i, message := range released {
+			if message.Data != want[i] {
+				t.Errorf("%T: q.Advance()[%v] = %v WANT %v", storage, i, message.Data, want[i])
+			}
+		}
+	}
+}
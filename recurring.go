@@ -0,0 +1,146 @@
+package timequeue
+
+import (
+	"time"
+)
+
+//Recurrence determines the times at which a recurring Message is released.
+type Recurrence interface {
+	//Next returns the first time after t at which the Message should be released,
+	//or the zero Time if the Message should not recur.
+	Next(t time.Time) time.Time
+}
+
+//RecurrenceFunc is a function that implements Recurrence.
+type RecurrenceFunc func(t time.Time) time.Time
+
+//Next calls f(t).
+func (f RecurrenceFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+//Schedule is a handle to a recurring Message pushed with PushRecurring().
+//
+//Only a single occurrence of a Schedule is ever in a TimeQueue. When that occurrence
+//is released (automatically or explicitly), the next occurrence is computed from
+//the Time of the released occurrence and pushed. Therefore even unbounded
+//Schedules, e.g. every minute forever, occupy a single Message in a TimeQueue.
+//Use Upcoming() to preview later occurrences without pushing them. Occurrences
+//that are removed without being released, e.g. by Clear() or
+//Remove(message, false), end the Schedule.
+//
+//Schedules are not included in a handoff; only their pending occurrences are.
+type Schedule struct {
+	q          *TimeQueue
+	recurrence Recurrence
+	data       interface{}
+
+	//the pending occurrence. nil if the Schedule has ended.
+	message *Message
+	//the TraceID of the first occurrence, which all occurrences share.
+	traceID string
+}
+
+//PushRecurring pushes a Message with Data data that recurs at the times given by
+//recurrence. The first occurrence is at recurrence.Next(q.Now()).
+//If recurrence returns the zero Time, then nothing is pushed and the returned
+//Schedule has already ended.
+func (q *TimeQueue) PushRecurring(recurrence Recurrence, data interface{}) *Schedule {
+	s := &Schedule{
+		q:          q,
+		recurrence: recurrence,
+		data:       data,
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	s.push(recurrence.Next(q.now()))
+	q.afterHeapUpdate()
+	return s
+}
+
+//Message returns the pending occurrence of s or nil if s has ended.
+func (s *Schedule) Message() *Message {
+	s.q.lock.Lock()
+	defer s.q.lock.Unlock()
+	return s.pending()
+}
+
+//Upcoming returns the times of at most the next k occurrences of s, starting with
+//the pending occurrence. Fewer than k times are returned if s ends, and none are
+//returned if s has already ended.
+//The occurrences after the pending one are computed with the Schedule's
+//Recurrence, which should therefore return the same results for the same times,
+//but are not pushed.
+func (s *Schedule) Upcoming(k int) []time.Time {
+	s.q.lock.Lock()
+	defer s.q.lock.Unlock()
+	result := []time.Time{}
+	message := s.pending()
+	if message == nil {
+		return result
+	}
+	for t := message.Time; !t.IsZero() && len(result) < k; t = s.recurrence.Next(t) {
+		result = append(result, t)
+	}
+	return result
+}
+
+//Stop ends s by removing its pending occurrence from its TimeQueue.
+//Returns true if s had not already ended.
+func (s *Schedule) Stop() bool {
+	s.q.lock.Lock()
+	defer s.q.lock.Unlock()
+	message := s.pending()
+	if message == nil {
+		return false
+	}
+	s.message = nil
+	s.q.removeStored(message)
+	s.q.removeHeld(message)
+	s.q.finalize(message, DropCanceled)
+	s.q.afterHeapUpdate()
+	return true
+}
+
+//pending returns the pending occurrence of s or nil if s has ended, including
+//if the occurrence was removed without being released.
+//It should only be called when s.q is locked.
+func (s *Schedule) pending() *Message {
+	if s.message == nil || !s.q.contains(s.message) {
+		return nil
+	}
+	return s.message
+}
+
+//recur pushes the occurrence of s that follows released.
+//It should only be called when s.q is locked.
+func (s *Schedule) recur(released *Message) {
+	if s.message != released {
+		return
+	}
+	from := released.Time
+	if e, ok := s.recurrence.(*every); ok && e.fromRelease {
+		from = s.q.now()
+	}
+	s.push(s.recurrence.Next(from))
+	if s.message != nil {
+		s.message.ParentID = released.ID
+	}
+}
+
+//push pushes an occurrence of s at t, or ends s if t is zero.
+//It should only be called when s.q is locked.
+func (s *Schedule) push(t time.Time) {
+	if t.IsZero() {
+		s.message = nil
+		return
+	}
+	s.message = &Message{
+		Time:     t,
+		Data:     s.data,
+		TraceID:  s.traceID,
+		schedule: s,
+	}
+	s.q.pushStored(s.message)
+	s.traceID = s.message.TraceID
+}
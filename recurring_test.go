@@ -0,0 +1,128 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_PushRecurring(t *testing.T) {
+	q := New()
+	start := time.Now()
+	count := 0
+	s := q.PushRecurring(RecurrenceFunc(func(t time.Time) time.Time {
+		if count == 3 {
+			return time.Time{}
+		}
+		count++
+		return start.Add(time.Duration(count) * time.Millisecond)
+	}), "test_data")
+	parentID := ""
+	for i := 1; i <= 3; i++ {
+		message := s.Message()
+		if message == nil {
+			t.Fatalf("occurrence %v s.Message() = nil WANT non-nil", i)
+		}
+		if message.ParentID != parentID {
+			t.Errorf("occurrence %v message.ParentID = %q WANT %q", i, message.ParentID, parentID)
+		}
+		parentID = message.ID
+		if want := start.Add(time.Duration(i) * time.Millisecond); !message.Time.Equal(want) || message.Data != "test_data" {
+			t.Errorf("s.Message() = %v WANT %v %v", message, want, "test_data")
+		}
+		if popped := q.Pop(true); popped != message {
+			t.Errorf("q.Pop() = %v WANT %v", popped, message)
+		}
+		<-q.Messages()
+	}
+	if message := s.Message(); message != nil {
+		t.Errorf("s.Message() = %v WANT nil", message)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_PushRecurring_running(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	s := q.PushRecurring(RecurrenceFunc(func(t time.Time) time.Time {
+		return t.Add(time.Millisecond)
+	}), 0)
+	defer s.Stop()
+	for i := 0; i < 3; i++ {
+		<-q.Messages()
+	}
+}
+
+func TestSchedule_Stop(t *testing.T) {
+	q := New()
+	s := q.PushRecurring(RecurrenceFunc(func(t time.Time) time.Time {
+		return t.Add(time.Hour)
+	}), 0)
+	if stopped := s.Stop(); !stopped {
+		t.Errorf("s.Stop() = %v WANT %v", stopped, true)
+	}
+	if stopped := s.Stop(); stopped {
+		t.Errorf("s.Stop() = %v WANT %v", stopped, false)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestSchedule_removedWithoutRelease(t *testing.T) {
+	q := New()
+	s := q.PushRecurring(RecurrenceFunc(func(t time.Time) time.Time {
+		return t.Add(time.Hour)
+	}), 0)
+	q.Clear()
+	if message := s.Message(); message != nil {
+		t.Errorf("s.Message() = %v WANT nil", message)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestSchedule_Upcoming(t *testing.T) {
+	q := New()
+	start := time.Now().Truncate(time.Minute)
+	end := start.Add(3 * time.Minute)
+	s := q.PushRecurring(RecurrenceFunc(func(t time.Time) time.Time {
+		next := t.Truncate(time.Minute).Add(time.Minute)
+		if next.After(end) {
+			return time.Time{}
+		}
+		return next
+	}), "every minute")
+
+	tests := []struct {
+		k      int
+		result int
+	}{
+		{0, 0},
+		{2, 2},
+		{10, 3},
+	}
+	for _, test := range tests {
+		result := s.Upcoming(test.k)
+		if len(result) != test.result {
+			t.Errorf("s.Upcoming(%v) = %v WANT %v times", test.k, result, test.result)
+			continue
+		}
+		for i, upcoming := range result {
+			if want := start.Add(time.Duration(i+1) * time.Minute); !upcoming.Equal(want) {
+				t.Errorf("s.Upcoming(%v)[%v] = %v WANT %v", test.k, i, upcoming, want)
+			}
+		}
+	}
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+
+	s.Stop()
+	if result := s.Upcoming(2); len(result) != 0 {
+		t.Errorf("s.Upcoming() after Stop = %v WANT empty", result)
+	}
+}
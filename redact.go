@@ -0,0 +1,52 @@
+package timequeue
+
+import "fmt"
+
+//Redactor returns a representation of message's Data that is safe to write to
+//archives, logs, and other observability systems, e.g. with sensitive fields
+//removed. Messages are still identified by their IDs and Topics, which are never
+//redacted.
+type Redactor func(message Message) string
+
+//RedactAll is a Redactor that hides all Data.
+func RedactAll(message Message) string {
+	return "[redacted]"
+}
+
+//WithRedactor sets the Redactor used for the Data of Messages wherever a TimeQueue
+//exposes Messages outside of its own channels, including the records given to
+//ArchiveSinks, and therefore returned from ReleasedBetween() and StateAt().
+//Data is not redacted by default.
+func WithRedactor(redactor Redactor) Option {
+	return func(c *config) {
+		c.redactor = redactor
+	}
+}
+
+//Redact returns message's Data as redacted by the Redactor given to
+//WithRedactor(), or formatted with fmt.Sprint() if there is none. It should be
+//used to log Messages from q.
+func (q *TimeQueue) Redact(message *Message) string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.redact(message)
+}
+
+//redact is the unexported version of Redact().
+//It should only be called when q is locked.
+func (q *TimeQueue) redact(message *Message) string {
+	if q.config.redactor == nil {
+		return fmt.Sprint(message.Data)
+	}
+	return q.config.redactor(*message)
+}
+
+//redactData returns message's Data as redacted by q.config.redactor, or Data
+//itself if there is none.
+//It should only be called when q is locked.
+func (q *TimeQueue) redactData(message *Message) interface{} {
+	if q.config.redactor == nil {
+		return message.Data
+	}
+	return q.config.redactor(*message)
+}
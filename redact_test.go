@@ -0,0 +1,35 @@
+package timequeue
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRedactor(t *testing.T) {
+	type card struct {
+		Number string
+	}
+	redactor := func(message Message) string {
+		number := message.Data.(card).Number
+		return "card ending " + number[len(number)-4:]
+	}
+	archive := NewMemoryArchive(0, 0)
+	q := New(WithArchive(archive), WithRedactor(redactor), WithCapacity(2))
+	message := q.Push(time.Now(), card{"4111111111111111"})
+	q.PopAll(true)
+
+	if got := q.Redact(message); got != "card ending 1111" {
+		t.Errorf("q.Redact() = %q WANT %q", got, "card ending 1111")
+	}
+	records := archive.Records()
+	if len(records) != 1 || records[0].Data != "card ending 1111" || records[0].ID != message.ID {
+		t.Errorf("archive.Records() = %+v WANT redacted record", records)
+	}
+	if q.Reconfigure(WithRedactor(RedactAll)); q.Redact(message) != "[redacted]" {
+		t.Errorf("q.Redact() = %q WANT %q", q.Redact(message), "[redacted]")
+	}
+	if got := New().Redact(message); !strings.Contains(got, "4111111111111111") {
+		t.Errorf("New().Redact() = %q WANT Data", got)
+	}
+}
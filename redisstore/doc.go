@@ -0,0 +1,15 @@
+//Package redisstore provides a timequeue.ClaimStore that mirrors a TimeQueue into
+//a Redis sorted set scored by release time, so that the TimeQueues of several
+//processes can share one schedule that survives restarts:
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	q := timequeue.New(timequeue.WithStore(redisstore.New(client, "myservice")))
+//	q.Start()
+//
+//Every TimeQueue that shares a Store loads all of its Messages when it is created,
+//and a due Message is claimed with a Lua script before it is released, so only
+//one of the TimeQueues releases it.
+//
+//Message Data is encoded with the Codec registered for its type with
+//timequeue.RegisterType(), or otherwise with encoding/gob, in which case the
+//concrete types of all Data values must be registered with gob.Register().
+package redisstore
@@ -0,0 +1,113 @@
+package redisstore
+
+import (
+	"context"
+
+	"github.com/gogolfing/timequeue"
+	"github.com/redis/go-redis/v9"
+)
+
+//claimScript removes a Message from the schedule and messages keys in KEYS and
+//returns 1, or returns 0 if it was not in the schedule.
+var claimScript = redis.NewScript(`
+local removed = redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("HDEL", KEYS[2], ARGV[1])
+return removed
+`)
+
+//Store is a timequeue.ClaimStore and timequeue.LoadStore that keeps pending
+//Messages in Redis.
+//The IDs of the Messages are members of a sorted set scored by their Times in
+//Unix milliseconds, and the encoded Messages are in a hash keyed by ID.
+type Store struct {
+	client   redis.UniversalClient
+	schedule string
+	messages string
+}
+
+//New returns a Store that uses client and the keys prefix+":schedule" and
+//prefix+":messages". Stores with the same prefix share the same Messages.
+func New(client redis.UniversalClient, prefix string) *Store {
+	return &Store{
+		client:   client,
+		schedule: prefix + ":schedule",
+		messages: prefix + ":messages",
+	}
+}
+
+//Append stores message in a transaction, replacing any Message with the same ID.
+func (s *Store) Append(message *timequeue.Message) error {
+	value, err := timequeue.EncodeMessage(message)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(context.Background(), s.schedule, redis.Z{
+			Score:  float64(message.Time.UnixMilli()),
+			Member: message.ID,
+		})
+		pipe.HSet(context.Background(), s.messages, message.ID, value)
+		return nil
+	})
+	return err
+}
+
+//Remove deletes the Message with id if it is stored.
+func (s *Store) Remove(id string) error {
+	_, err := s.Claim(id)
+	return err
+}
+
+//Claim atomically deletes the Message with id and returns true, or returns false
+//if it was already deleted, e.g. by another process.
+func (s *Store) Claim(id string) (bool, error) {
+	removed, err := claimScript.Run(context.Background(), s.client, []string{s.schedule, s.messages}, id).Int()
+	if err != nil {
+		return false, err
+	}
+	return removed == 1, nil
+}
+
+//LoadAll returns new Messages for every stored Message in order of Time.
+func (s *Store) LoadAll() ([]*timequeue.Message, error) {
+	ctx := context.Background()
+	ids, err := s.client.ZRange(ctx, s.schedule, 0, -1).Result()
+	if err != nil || len(ids) == 0 {
+		return []*timequeue.Message{}, err
+	}
+	values, err := s.client.HMGet(ctx, s.messages, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*timequeue.Message, 0, len(values))
+	for _, value := range values {
+		//the Message was claimed between ZRANGE and HMGET.
+		if value == nil {
+			continue
+		}
+		message, err := timequeue.DecodeMessage([]byte(value.(string)))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, message)
+	}
+	return result, nil
+}
+
+//Load returns a new Message for the stored Message with id, or nil if there is
+//none.
+func (s *Store) Load(id string) (*timequeue.Message, error) {
+	value, err := s.client.HGet(context.Background(), s.messages, id).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return timequeue.DecodeMessage(value)
+}
+
+//Compact does nothing and returns nil. Removed Messages are deleted immediately.
+func (s *Store) Compact() error {
+	return nil
+}
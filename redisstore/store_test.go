@@ -0,0 +1,73 @@
+package redisstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gogolfing/timequeue"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T, addr string) *Store {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	return New(client, "test")
+}
+
+func TestStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Now().Truncate(time.Millisecond)
+	s := newTestStore(t, server.Addr())
+	q := timequeue.New(timequeue.WithStore(s))
+	q.PushMessage(&timequeue.Message{Time: now.Add(2 * time.Hour), Data: "later", Key: "key"})
+	q.Push(now.Add(time.Hour), "sooner")
+	removed := q.Push(now, "removed")
+	q.Remove(removed, false)
+
+	messages, err := newTestStore(t, server.Addr()).LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v WANT nil", err)
+	}
+	if len(messages) != 2 || messages[0].Data != "sooner" || messages[1].Data != "later" ||
+		messages[1].Key != "key" || !messages[1].Time.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("LoadAll() = %v WANT sooner, later", messages)
+	}
+	if message, err := s.Load(messages[1].ID); err != nil || message == nil || message.Data != "later" {
+		t.Errorf("Load() = %v, %v WANT later, nil", message, err)
+	}
+	if message, err := s.Load(removed.ID); message != nil || err != nil {
+		t.Errorf("Load(removed) = %v, %v WANT nil, nil", message, err)
+	}
+	if err := q.Healthy(); err != timequeue.ErrNotRunning {
+		t.Errorf("q.Healthy() = %v WANT %v", err, timequeue.ErrNotRunning)
+	}
+}
+
+func TestStore_claim(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Now()
+	first := timequeue.New(timequeue.WithManualAdvance(now), timequeue.WithStore(newTestStore(t, server.Addr())))
+	for i := 0; i < 4; i++ {
+		first.Push(now.Add(time.Duration(i+1)*time.Second), i)
+	}
+	second := timequeue.New(timequeue.WithManualAdvance(now), timequeue.WithStore(newTestStore(t, server.Addr())))
+	if second.Size() != 4 {
+		t.Fatalf("second.Size() = %v WANT %v", second.Size(), 4)
+	}
+
+	released := first.Advance(now.Add(2500 * time.Millisecond))
+	released = append(released, second.Advance(now.Add(5*time.Second))...)
+	released = append(released, first.Advance(now.Add(5*time.Second))...)
+	if len(released) != 4 {
+		t.Fatalf("released = %v WANT 4 Messages", released)
+	}
+	for i, message := range released {
+		if message.Data != i {
+			t.Errorf("released[%v].Data = %v WANT %v", i, message.Data, i)
+		}
+	}
+	if first.Size() != 0 || second.Size() != 0 {
+		t.Errorf("Size() = %v, %v WANT 0, 0", first.Size(), second.Size())
+	}
+}
@@ -0,0 +1,101 @@
+package timequeue
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+//WithReorderBuffer places a reordering buffer in front of the channels that
+//Messages are released on. Every released Message is held in the buffer for
+//maxHold, and Messages are sent from the buffer strictly in Time order. Messages
+//released slightly out of order, e.g. by separate wakes whose sends race, by
+//retries, or by WithOutputs() shards, are therefore received in order as long as
+//they are released within maxHold of each other.
+//
+//A Message released more than maxHold after a later Message was sent is sent
+//immediately, so maxHold bounds how late the buffer makes any Message.
+//Messages released to Subscribers or functions are not buffered.
+//A maxHold less than or equal to zero disables the buffer, which is the default.
+func WithReorderBuffer(maxHold time.Duration) Option {
+	return func(c *config) {
+		c.reorderHold = maxHold
+	}
+}
+
+//reorderEntry is a released Message waiting in a reorderBuffer.
+type reorderEntry struct {
+	message *Message
+	out     chan<- *Message
+	//when the Message is sent whether or not earlier Messages arrive.
+	deadline time.Time
+}
+
+//reorderHeap orders reorderEntries like Messages in a messageHeap.
+type reorderHeap []*reorderEntry
+
+func (h reorderHeap) Len() int            { return len(h) }
+func (h reorderHeap) Less(i, j int) bool  { return messageBefore(h[i].message, h[j].message) }
+func (h reorderHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *reorderHeap) Push(x interface{}) { *h = append(*h, x.(*reorderEntry)) }
+func (h *reorderHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
+
+//reorderBuffer holds released Messages and sends them in Time order from a
+//go-routine that only runs while there are Messages to send.
+type reorderBuffer struct {
+	//protects entries and running.
+	lock    sync.Mutex
+	entries reorderHeap
+	//true if a go-routine is sending entries.
+	running bool
+	//called after each entry is sent, if not nil.
+	sent func()
+}
+
+//add holds message for hold before it is sent on out, and starts the go-routine
+//that sends entries if it is not running.
+func (b *reorderBuffer) add(out chan<- *Message, message *Message, hold time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	heap.Push(&b.entries, &reorderEntry{
+		message:  message,
+		out:      out,
+		deadline: time.Now().Add(hold),
+	})
+	if !b.running {
+		b.running = true
+		go b.drain()
+	}
+}
+
+//drain sends the earliest entry once its deadline passes until there are none.
+//Entries that arrive while it waits and are earlier become the next to send, and
+//since they arrived later their deadlines are too.
+func (b *reorderBuffer) drain() {
+	for {
+		b.lock.Lock()
+		if len(b.entries) == 0 {
+			b.running = false
+			b.lock.Unlock()
+			return
+		}
+		entry := b.entries[0]
+		if wait := time.Until(entry.deadline); wait > 0 {
+			b.lock.Unlock()
+			time.Sleep(wait)
+			continue
+		}
+		heap.Pop(&b.entries)
+		b.lock.Unlock()
+		entry.out <- entry.message
+		if b.sent != nil {
+			b.sent()
+		}
+	}
+}
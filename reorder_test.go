@@ -0,0 +1,47 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithReorderBuffer(t *testing.T) {
+	q := New(WithCapacity(4), WithReorderBuffer(50*time.Millisecond))
+	now := time.Now()
+	late := q.Push(now.Add(2*time.Second), "late")
+	early := q.Push(now.Add(time.Second), "early")
+	//released out of Time order, but within the hold of each other.
+	q.Remove(late, true)
+	q.Remove(early, true)
+	q.Push(now.Add(3*time.Second), "latest")
+	q.Pop(true)
+	for _, want := range []string{"early", "late", "latest"} {
+		select {
+		case message := <-q.Messages():
+			if message.Data != want {
+				t.Errorf("q.Messages() = %v WANT %v", message.Data, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("q.Messages() received nothing WANT %v", want)
+		}
+	}
+}
+
+func TestReorderBuffer_maxHold(t *testing.T) {
+	q := New(WithCapacity(2), WithReorderBuffer(10*time.Millisecond))
+	now := time.Now()
+	q.Push(now.Add(time.Second), "late")
+	early := q.Push(now, "early")
+	q.Remove(early, false)
+	start := time.Now()
+	q.Pop(true)
+	<-q.Messages()
+	if waited := time.Since(start); waited < 10*time.Millisecond {
+		t.Errorf("waited = %v WANT at least the max hold", waited)
+	}
+	q.PushMessage(early)
+	q.Pop(true)
+	if message := <-q.Messages(); message != early {
+		t.Errorf("q.Messages() = %v WANT %v sent after a later Message", message, early)
+	}
+}
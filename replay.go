@@ -0,0 +1,46 @@
+package timequeue
+
+import "time"
+
+//WithReplay causes a TimeQueue to replay the records of all Messages released in
+//the window before it is first started, so that consumers that were down, e.g.
+//during a deploy, can reconcile anything that fired while no one was listening.
+//
+//When the TimeQueue is first started, the records are read like ReleasedBetween()
+//from the ArchiveSinks given to WithArchive() and WithTopicArchive() that implement
+//ArchiveQuerier, which must therefore outlive the process, and sent on Replays() in
+//the order they were released.
+//A window less than or equal to zero disables replay, which is the default.
+//WithReplay may not be given to Reconfigure().
+func WithReplay(window time.Duration) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.replayWindow = window
+	}
+}
+
+//Replays returns the channel that records replayed by WithReplay() are sent on.
+//It has the same capacity as Messages() and is closed once every record has been
+//sent. If q was not given WithReplay(), then Replays returns nil.
+func (q *TimeQueue) Replays() <-chan ReleasedRecord {
+	return q.replays
+}
+
+//replay sends the records of Messages released in the replay window before now on
+//q.replays from a new go-routine, if q was given WithReplay() and has not replayed.
+//It should only be called when q is locked.
+func (q *TimeQueue) replay() {
+	if q.replays == nil || q.replayed {
+		return
+	}
+	q.replayed = true
+	queriers := q.queriers()
+	to := q.now()
+	from := to.Add(-q.config.replayWindow)
+	go func() {
+		defer close(q.replays)
+		for _, record := range releasedBetween(queriers, from, to) {
+			q.replays <- record
+		}
+	}()
+}
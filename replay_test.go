@@ -0,0 +1,39 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithReplay(t *testing.T) {
+	now := time.Now()
+	archive := NewMemoryArchive(0, 0)
+	//records archived by a previous process.
+	archive.Archive(ArchiveRecord{Event: ArchiveReleased, At: now.Add(-20 * time.Minute), ID: "old"})
+	archive.Archive(ArchiveRecord{Event: ArchiveReleased, At: now.Add(-2 * time.Minute), ID: "a"})
+	archive.Archive(ArchiveRecord{Event: ArchiveConsumed, At: now.Add(-2 * time.Minute), ID: "a"})
+	archive.Archive(ArchiveRecord{Event: ArchiveReleased, At: now.Add(-time.Minute), ID: "b"})
+
+	q := New(WithArchive(archive), WithReplay(10*time.Minute))
+	q.Start()
+	defer q.Stop()
+	ids := []string{}
+	for record := range q.Replays() {
+		ids = append(ids, record.ID)
+	}
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("q.Replays() = %v WANT a, b", ids)
+	}
+
+	q.Stop()
+	q.Start()
+	if _, ok := <-q.Replays(); ok {
+		t.Errorf("q.Replays() replayed again WANT closed")
+	}
+	if err := q.Reconfigure(WithReplay(time.Hour)); err != ErrNotReconfigurable {
+		t.Errorf("q.Reconfigure(WithReplay()) = %v WANT %v", err, ErrNotReconfigurable)
+	}
+	if replays := New().Replays(); replays != nil {
+		t.Errorf("New().Replays() = %v WANT nil", replays)
+	}
+}
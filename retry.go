@@ -0,0 +1,169 @@
+package timequeue
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+//BackoffPolicy returns the delay before retrying a Message that has failed
+//attempt times.
+type BackoffPolicy func(attempt int) time.Duration
+
+//ConstantBackoff returns a BackoffPolicy that always returns delay.
+func ConstantBackoff(delay time.Duration) BackoffPolicy {
+	return func(attempt int) time.Duration {
+		return delay
+	}
+}
+
+//BackoffSchedule returns a BackoffPolicy that returns delays[attempt-1], or the
+//last delay for attempts past the end of delays.
+//It returns 0 if delays is empty.
+func BackoffSchedule(delays ...time.Duration) BackoffPolicy {
+	return func(attempt int) time.Duration {
+		if len(delays) == 0 {
+			return 0
+		}
+		if attempt < 1 {
+			attempt = 1
+		}
+		if attempt > len(delays) {
+			attempt = len(delays)
+		}
+		return delays[attempt-1]
+	}
+}
+
+//ExponentialBackoff returns a BackoffPolicy that returns initial for the first
+//attempt and multiplies the delay by multiplier for every attempt after it, up to
+//max. A max less than or equal to zero does not cap the delay, although it never
+//overflows.
+func ExponentialBackoff(initial time.Duration, multiplier float64, max time.Duration) BackoffPolicy {
+	return func(attempt int) time.Duration {
+		if attempt < 1 {
+			attempt = 1
+		}
+		delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+		if max > 0 && delay > float64(max) {
+			return max
+		}
+		if delay >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return time.Duration(delay)
+	}
+}
+
+//Jitter returns a BackoffPolicy that randomizes the delays of policy by up to
+//fraction of each delay in either direction, e.g. a fraction of 0.1 returns
+//delays between 90% and 110% of those of policy. Jitter spreads out the retries of
+//Messages that failed together.
+func Jitter(policy BackoffPolicy, fraction float64) BackoffPolicy {
+	return func(attempt int) time.Duration {
+		delay := float64(policy(attempt))
+		delay += delay * fraction * (2*rand.Float64() - 1)
+		if delay < 0 {
+			return 0
+		}
+		if delay >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return time.Duration(delay)
+	}
+}
+
+//RequeueWithBackoff pushes the released message back to q to be released after
+//the delay that policy returns for the number of times message has been requeued
+//with RequeueWithBackoff(), including this one. message keeps its ID.
+//In ack mode message is no longer tracked as unacknowledged.
+//
+//message is treated like a retry, so its Tenant's quota is not checked.
+//ErrNilMessage, ErrMessageQueued, and ErrDuplicateKey are returned like they are
+//from PushMessage().
+func (q *TimeQueue) RequeueWithBackoff(message *Message, policy BackoffPolicy) error {
+	if message == nil {
+		return ErrNilMessage
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if message.mh != nil || message.storage != nil || q.isSpilled(message) {
+		return ErrMessageQueued
+	}
+	if message.Key != "" && q.keyed(message.Key) != nil {
+		return ErrDuplicateKey
+	}
+	message.requeues++
+	message.Time = q.now().Add(policy(message.requeues))
+	if q.unacked[message.ID] == message {
+		delete(q.unacked, message.ID)
+	}
+	q.pushStored(message)
+	q.afterHeapUpdate()
+	return nil
+}
+
+//retry holds the values given to WithRetry() or WithRetryQueue().
+type retry struct {
+	maxAttempts int
+	backoff     BackoffPolicy
+	//the TimeQueue to push retried Messages to. nil for the Dispatcher's TimeQueue.
+	queue *TimeQueue
+}
+
+//shouldRetry returns true if message has been attempted fewer than r.maxAttempts
+//times.
+func (r retry) shouldRetry(message *Message) bool {
+	return message.attempts < r.maxAttempts
+}
+
+//delay returns the delay before retrying message.
+func (r retry) delay(message *Message) time.Duration {
+	if r.backoff == nil {
+		return 0
+	}
+	return r.backoff(message.attempts)
+}
+
+//WithRetry causes a Dispatcher to push Messages whose Handler returned an error
+//or panicked back to its TimeQueue to be released again delay after the failure.
+//A Message is retried until it has been attempted maxAttempts times in total, see
+//Message.Attempts(). Messages are not retried by default.
+//See Retryable(), Fatal(), and RateLimited for how errors are classified.
+func WithRetry(maxAttempts int, delay time.Duration) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.retry = retry{
+			maxAttempts: maxAttempts,
+			backoff:     ConstantBackoff(delay),
+		}
+	}
+}
+
+//WithRetryQueue is like WithRetry() except that failed Messages are pushed to
+//retryQueue, instead of the Dispatcher's TimeQueue, to be released after the
+//delay returned from backoff.
+//The Dispatcher's workers receive Messages from both TimeQueues.
+//
+//This keeps retries from crowding the head of the primary TimeQueue and allows
+//the retry backlog to be observed separately, e.g. with retryQueue.Size().
+//retryQueue must be started for retried Messages to be released.
+func WithRetryQueue(retryQueue *TimeQueue, maxAttempts int, backoff BackoffPolicy) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.retry = retry{
+			maxAttempts: maxAttempts,
+			backoff:     backoff,
+			queue:       retryQueue,
+		}
+	}
+}
+
+//WithDeadLetter sets a function that is called with every Message that failed
+//and will not be retried, along with the error of its final attempt. A Message
+//whose retry cannot be pushed, e.g. because another Message with its Key was
+//pushed in the meantime, is given to hook with the error from PushMessage().
+//hook is called from the worker go-routine that handled the Message.
+func WithDeadLetter(hook func(message *Message, err error)) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.deadLetter = hook
+	}
+}
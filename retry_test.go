@@ -0,0 +1,196 @@
+package timequeue
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWithRetry(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handlerErr := errors.New("handler")
+	attempts := make(chan int, 3)
+	deadLetters := make(chan error, 1)
+	q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		attempts <- message.Attempts()
+		return handlerErr
+	}, WithRetry(3, time.Millisecond), WithDeadLetter(func(message *Message, err error) {
+		deadLetters <- err
+	}))
+
+	q.Push(time.Now(), 0)
+	for i := 1; i <= 3; i++ {
+		if attempt := <-attempts; attempt != i {
+			t.Errorf("message.Attempts() = %v WANT %v", attempt, i)
+		}
+	}
+	if err := <-deadLetters; err != handlerErr {
+		t.Errorf("dead letter err = %v WANT %v", err, handlerErr)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestDispatcher_fail_classified(t *testing.T) {
+	tests := []struct {
+		err         error
+		attempts    int
+		deadLetters int
+	}{
+		{Fatal(errors.New("fatal")), 2, 2},
+		{Retryable(errors.New("retryable")), 2, 1},
+		{&RateLimited{RetryAfter: time.Millisecond}, 0, 0},
+	}
+	for _, test := range tests {
+		q := New()
+		deadLetters := 0
+		d := q.Consume(context.Background(), nil, WithWorkers(0), WithRetry(2, time.Hour), WithDeadLetter(func(message *Message, err error) {
+			deadLetters++
+		}))
+		message := &Message{}
+		for i := 0; i < 2; i++ {
+			message.attempts++
+			q.Remove(message, false)
+			d.fail(message, test.err)
+		}
+		if message.attempts != test.attempts || deadLetters != test.deadLetters {
+			t.Errorf("d.fail(%v) attempts, deadLetters = %v, %v WANT %v, %v", test.err, message.attempts, deadLetters, test.attempts, test.deadLetters)
+		}
+	}
+}
+
+func TestBackoffSchedule(t *testing.T) {
+	tests := []struct {
+		delays  []time.Duration
+		attempt int
+		result  time.Duration
+	}{
+		{nil, 1, 0},
+		{[]time.Duration{time.Second, time.Minute}, 0, time.Second},
+		{[]time.Duration{time.Second, time.Minute}, 1, time.Second},
+		{[]time.Duration{time.Second, time.Minute}, 2, time.Minute},
+		{[]time.Duration{time.Second, time.Minute}, 5, time.Minute},
+	}
+	for _, test := range tests {
+		if result := BackoffSchedule(test.delays...)(test.attempt); result != test.result {
+			t.Errorf("BackoffSchedule(%v)(%v) = %v WANT %v", test.delays, test.attempt, result, test.result)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		initial    time.Duration
+		multiplier float64
+		max        time.Duration
+		attempt    int
+		result     time.Duration
+	}{
+		{time.Second, 2, 0, 0, time.Second},
+		{time.Second, 2, 0, 1, time.Second},
+		{time.Second, 2, 0, 4, 8 * time.Second},
+		{time.Second, 2, 5 * time.Second, 4, 5 * time.Second},
+		{time.Second, 1.5, 0, 3, 2250 * time.Millisecond},
+		{time.Second, 2, 0, 1000, math.MaxInt64},
+	}
+	for _, test := range tests {
+		policy := ExponentialBackoff(test.initial, test.multiplier, test.max)
+		if result := policy(test.attempt); result != test.result {
+			t.Errorf("ExponentialBackoff(%v, %v, %v)(%v) = %v WANT %v", test.initial, test.multiplier, test.max, test.attempt, result, test.result)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	policy := Jitter(ConstantBackoff(time.Second), 0.1)
+	for i := 0; i < 100; i++ {
+		if result := policy(1); result < 900*time.Millisecond || result > 1100*time.Millisecond {
+			t.Fatalf("Jitter()(1) = %v WANT between 900ms and 1.1s", result)
+		}
+	}
+}
+
+func TestTimeQueue_RequeueWithBackoff(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start), WithAckMode())
+	message := q.Push(start, 0)
+	policy := ExponentialBackoff(time.Second, 2, 0)
+	now := start
+	for _, delay := range []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second} {
+		now = now.Add(delay)
+		if released := q.Advance(now); len(released) != 1 || released[0] != message {
+			t.Fatalf("q.Advance(%v) = %v WANT %v", now, released, message)
+		}
+		if err := q.RequeueWithBackoff(message, policy); err != nil {
+			t.Fatalf("q.RequeueWithBackoff() = %v WANT nil", err)
+		}
+		if unacked := q.Unacked(); unacked != 0 {
+			t.Errorf("q.Unacked() = %v WANT %v", unacked, 0)
+		}
+	}
+	if err := q.RequeueWithBackoff(message, policy); err != ErrMessageQueued {
+		t.Errorf("q.RequeueWithBackoff(queued) = %v WANT %v", err, ErrMessageQueued)
+	}
+	if !message.Time.Equal(now.Add(8 * time.Second)) {
+		t.Errorf("message.Time = %v WANT %v", message.Time, now.Add(8*time.Second))
+	}
+}
+
+func TestWithRetryQueue(t *testing.T) {
+	q, retryQueue := New(), New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := make(chan int, 2)
+	q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		attempts <- message.Attempts()
+		return errors.New("failure")
+	}, WithRetryQueue(retryQueue, 2, BackoffSchedule(time.Millisecond)))
+
+	q.Push(time.Now(), 0)
+	<-attempts
+	for retryQueue.Size() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+
+	retryQueue.Start()
+	defer retryQueue.Stop()
+	if attempt := <-attempts; attempt != 2 {
+		t.Errorf("message.Attempts() = %v WANT %v", attempt, 2)
+	}
+}
+
+func TestDispatcher_redeliver_pushFailed(t *testing.T) {
+	q := New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deadLetters := make(chan error, 1)
+	q.Consume(ctx, func(ctx context.Context, message *Message) error {
+		//takes the Key of message so that its retry cannot be pushed.
+		q.PushMessage(&Message{Time: time.Now().Add(time.Hour), Key: message.Key})
+		return errors.New("handler")
+	}, WithRetry(3, time.Millisecond), WithDeadLetter(func(message *Message, err error) {
+		deadLetters <- err
+	}))
+
+	q.PushMessage(&Message{Time: time.Now(), Key: "key"})
+	if err := <-deadLetters; err != ErrDuplicateKey {
+		t.Errorf("dead letter err = %v WANT %v", err, ErrDuplicateKey)
+	}
+}
@@ -0,0 +1,107 @@
+package timequeue
+
+import (
+	"sort"
+	"time"
+)
+
+//ScopedQueue performs operations on a TimeQueue that are restricted to the
+//Messages of a single Tenant. This allows per-tenant APIs, e.g. for a tenant's
+//administrators, to be built on a TimeQueue shared by all tenants.
+type ScopedQueue struct {
+	q      *TimeQueue
+	tenant string
+}
+
+//Scoped returns a ScopedQueue for the Messages of q with Tenant tenant.
+//The empty tenant scopes to the Messages without a Tenant.
+func (q *TimeQueue) Scoped(tenant string) *ScopedQueue {
+	return &ScopedQueue{
+		q:      q,
+		tenant: tenant,
+	}
+}
+
+//Tenant returns the Tenant that s is scoped to.
+func (s *ScopedQueue) Tenant() string {
+	return s.tenant
+}
+
+//Push creates and pushes a Message with t, data, and the Tenant of s.
+//Errors are those returned from TimeQueue.PushMessage(), e.g. a *QuotaError.
+func (s *ScopedQueue) Push(t time.Time, data interface{}) (*Message, error) {
+	message := &Message{
+		Time: t,
+		Data: data,
+	}
+	if err := s.PushMessage(message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+//PushMessage sets the Tenant of message to that of s and pushes it.
+//Errors are those returned from TimeQueue.PushMessage().
+func (s *ScopedQueue) PushMessage(message *Message) error {
+	if message == nil {
+		return ErrNilMessage
+	}
+	message.Tenant = s.tenant
+	return s.q.PushMessage(message)
+}
+
+//Remove is TimeQueue.Remove() except that Messages of other Tenants are never
+//removed.
+func (s *ScopedQueue) Remove(message *Message, release bool) bool {
+	if message == nil || message.Tenant != s.tenant {
+		return false
+	}
+	return s.q.Remove(message, release)
+}
+
+//Inspect returns the Messages of s's Tenant that are in its TimeQueue, including
+//those held by a selective hold, in order of their Times.
+//The returned Messages are still in the TimeQueue and must not be modified.
+func (s *ScopedQueue) Inspect() []*Message {
+	s.q.lock.Lock()
+	defer s.q.lock.Unlock()
+	result := s.messages()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+//Size returns the number of Messages of s's Tenant in its TimeQueue.
+func (s *ScopedQueue) Size() int {
+	s.q.lock.Lock()
+	defer s.q.lock.Unlock()
+	return len(s.messages())
+}
+
+//messages returns the Messages of s's Tenant in its TimeQueue in no particular
+//order. The Messages of a Tenant are tracked, but those without a Tenant are
+//found by searching the entire TimeQueue.
+//It should only be called when s.q is locked.
+func (s *ScopedQueue) messages() []*Message {
+	result := []*Message{}
+	if s.tenant == "" {
+		add := func(message *Message) {
+			if message.Tenant == "" {
+				result = append(result, message)
+			}
+		}
+		s.q.storage.Each(add)
+		for _, message := range s.q.heldMessages.messages {
+			add(message)
+		}
+		return result
+	}
+	if t, ok := s.q.tenants[s.tenant]; ok {
+		s.q.tenantPending(t)
+		for message := range t.pending {
+			result = append(result, message)
+		}
+	}
+	return result
+}
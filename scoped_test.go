@@ -0,0 +1,48 @@
+package timequeue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScopedQueue(t *testing.T) {
+	q := New(WithTenantQuota(TenantQuota{MaxPending: 2}))
+	a, b := q.Scoped("a"), q.Scoped("b")
+	now := time.Now()
+	later, err := a.Push(now.Add(2*time.Hour), 1)
+	if err != nil || later.Tenant != "a" {
+		t.Fatalf("a.Push() = %v, %v WANT Tenant a, nil", later, err)
+	}
+	earlier := &Message{Time: now.Add(time.Hour), Tenant: "b"}
+	if err := a.PushMessage(earlier); err != nil || earlier.Tenant != "a" {
+		t.Errorf("a.PushMessage() = %v, Tenant %v WANT nil, a", err, earlier.Tenant)
+	}
+	if _, err := a.Push(now, 3); !errors.Is(err, ErrTenantPending) {
+		t.Errorf("a.Push() = %v WANT %v", err, ErrTenantPending)
+	}
+	other, _ := b.Push(now, "b")
+	untenanted := q.Push(now, "none")
+
+	if inspected := a.Inspect(); len(inspected) != 2 || inspected[0] != earlier || inspected[1] != later {
+		t.Errorf("a.Inspect() = %v WANT [%v %v]", inspected, earlier, later)
+	}
+	if inspected := q.Scoped("").Inspect(); len(inspected) != 1 || inspected[0] != untenanted {
+		t.Errorf("q.Scoped(\"\").Inspect() = %v WANT [%v]", inspected, untenanted)
+	}
+	if a.Remove(other, false) || a.Remove(nil, false) {
+		t.Errorf("a.Remove() of another tenant = true WANT false")
+	}
+	if !a.Remove(earlier, false) {
+		t.Errorf("a.Remove() = false WANT true")
+	}
+	if size := a.Size(); size != 1 {
+		t.Errorf("a.Size() = %v WANT %v", size, 1)
+	}
+	if size := q.Scoped("unknown").Size(); size != 0 {
+		t.Errorf("q.Scoped(unknown).Size() = %v WANT %v", size, 0)
+	}
+	if a.Tenant() != "a" {
+		t.Errorf("a.Tenant() = %v WANT %v", a.Tenant(), "a")
+	}
+}
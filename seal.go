@@ -0,0 +1,241 @@
+package timequeue
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//sealVersion is the first byte of every sealed envelope.
+const sealVersion = 1
+
+//flags in the second byte of a sealed envelope.
+const sealEncrypted = 1
+
+//sealHeaderSize is the size of the version and flags bytes and the signature that
+//start every sealed envelope.
+const sealHeaderSize = 2 + sha256.Size
+
+//DefaultMaxSealedSize is the maximum size in bytes of an envelope read by
+//PushHandler().
+const DefaultMaxSealedSize = 1 << 20
+
+var (
+	//ErrSignature is returned when a sealed envelope's signature is invalid, i.e.
+	//it was not sealed with the same signing key or was modified since.
+	ErrSignature = errors.New("timequeue: invalid signature")
+
+	//ErrSealed is returned when a sealed envelope is malformed, or is encrypted
+	//when the Sealer cannot decrypt it.
+	ErrSealed = errors.New("timequeue: malformed sealed envelope")
+
+	//ErrSigningKey is returned by NewSealer() when the signing key is empty.
+	ErrSigningKey = errors.New("timequeue: empty signing key")
+)
+
+//PushCommand is a request from a remote producer to push a Message.
+//Data is the JSON encoding of the Message's Data, which is pushed as a
+//json.RawMessage for the consumer to decode. A non-empty TraceID continues a trace
+//started by the producer.
+type PushCommand struct {
+	Time    time.Time       `json:"time"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Topic   string          `json:"topic,omitempty"`
+	Key     string          `json:"key,omitempty"`
+	Tenant  string          `json:"tenant,omitempty"`
+	Weight  int             `json:"weight,omitempty"`
+	TraceID string          `json:"trace_id,omitempty"`
+}
+
+//message creates a new Message from the values in c.
+func (c *PushCommand) message() *Message {
+	return &Message{
+		Time:    c.Time,
+		Data:    c.Data,
+		Topic:   c.Topic,
+		Key:     c.Key,
+		Tenant:  c.Tenant,
+		Weight:  c.Weight,
+		TraceID: c.TraceID,
+	}
+}
+
+//Sealer seals PushCommands into envelopes that are signed with HMAC-SHA256 and
+//optionally encrypted with AES-GCM, and opens those envelopes again.
+//
+//Envelopes are plain bytes, so they may be carried by any transport, e.g. an HTTP
+//request to PushHandler(), a gRPC field, or a NATS message, and are passed to
+//PushSealed() on the receiving side. A producer and a TimeQueue must share the same
+//keys.
+//
+//A signature proves that an envelope was sealed by a holder of the signing key, but
+//the same envelope may be pushed more than once. Give PushCommands a Key so that a
+//replayed envelope is rejected with ErrDuplicateKey while its Message is pending.
+//
+//A Sealer is safe for use by multiple go-routines.
+type Sealer struct {
+	signingKey []byte
+	//nil if envelopes are not encrypted.
+	aead cipher.AEAD
+}
+
+//NewSealer creates a Sealer that signs with signingKey and, if encryptionKey is
+//not empty, encrypts with encryptionKey, which must be 16, 24, or 32 bytes long to
+//select AES-128, AES-192, or AES-256.
+//A Sealer that encrypts can also open envelopes that are only signed.
+//ErrSigningKey is returned if signingKey is empty, since anyone could then forge
+//signatures.
+func NewSealer(signingKey, encryptionKey []byte) (*Sealer, error) {
+	if len(signingKey) == 0 {
+		return nil, ErrSigningKey
+	}
+	s := &Sealer{
+		signingKey: append([]byte(nil), signingKey...),
+	}
+	if len(encryptionKey) == 0 {
+		return s, nil
+	}
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if s.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//Seal returns the envelope of command.
+func (s *Sealer) Seal(command *PushCommand) ([]byte, error) {
+	payload, err := json.Marshal(command)
+	if err != nil {
+		return nil, err
+	}
+	flags := byte(0)
+	if s.aead != nil {
+		flags |= sealEncrypted
+		nonce := make([]byte, s.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		payload = s.aead.Seal(nonce, nonce, payload, nil)
+	}
+	envelope := make([]byte, sealHeaderSize, sealHeaderSize+len(payload))
+	envelope[0], envelope[1] = sealVersion, flags
+	envelope = append(envelope, payload...)
+	copy(envelope[2:sealHeaderSize], s.sign(envelope[:2], payload))
+	return envelope, nil
+}
+
+//Open verifies and decodes envelope.
+//ErrSignature is returned if the signature is invalid, and ErrSealed is returned
+//if envelope is otherwise malformed.
+func (s *Sealer) Open(envelope []byte) (*PushCommand, error) {
+	if len(envelope) < sealHeaderSize || envelope[0] != sealVersion {
+		return nil, ErrSealed
+	}
+	payload := envelope[sealHeaderSize:]
+	if !hmac.Equal(envelope[2:sealHeaderSize], s.sign(envelope[:2], payload)) {
+		return nil, ErrSignature
+	}
+	if envelope[1]&sealEncrypted != 0 {
+		if s.aead == nil || len(payload) < s.aead.NonceSize() {
+			return nil, ErrSealed
+		}
+		nonce := payload[:s.aead.NonceSize()]
+		var err error
+		if payload, err = s.aead.Open(nil, nonce, payload[len(nonce):], nil); err != nil {
+			return nil, ErrSealed
+		}
+	}
+	command := &PushCommand{}
+	if err := json.Unmarshal(payload, command); err != nil {
+		return nil, ErrSealed
+	}
+	return command, nil
+}
+
+//sign returns the signature of header and payload.
+func (s *Sealer) sign(header, payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write(header)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+//PushSealed opens envelope with sealer and pushes the Message of its PushCommand.
+//Errors are those returned from Sealer.Open() and TimeQueue.PushMessage(). Nothing
+//is pushed unless envelope is authentic.
+func (q *TimeQueue) PushSealed(sealer *Sealer, envelope []byte) (*Message, error) {
+	command, err := sealer.Open(envelope)
+	if err != nil {
+		return nil, err
+	}
+	message := command.message()
+	if err := q.PushMessage(message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+//PushHandler returns an http.Handler that calls PushSealed() with sealer and the
+//body of POST requests, which may be at most DefaultMaxSealedSize bytes.
+//It responds with:
+//	201 and the JSON object {"id": ID} of the pushed Message on success.
+//	401 if the envelope's signature is invalid.
+//	400 if the envelope is otherwise malformed.
+//	409 if the Message's Key is a duplicate.
+//	429 if the Message's Tenant is over quota, with a Retry-After header of the
+//	QuotaError's RetryAfter rounded up to whole seconds.
+//	405 for methods other than POST.
+//
+//Requests are checked by the Authorizer given with WithAuthorizer(), if any, with
+//EndpointPush before the envelope is read.
+func (q *TimeQueue) PushHandler(sealer *Sealer, opts ...HandlerOption) http.Handler {
+	return newHandlerConfig(opts).authorize(EndpointPush, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		envelope, err := io.ReadAll(http.MaxBytesReader(w, r.Body, DefaultMaxSealedSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		message, err := q.PushSealed(sealer, envelope)
+		if err != nil {
+			var quota *QuotaError
+			if errors.As(err, &quota) {
+				w.Header().Set("Retry-After", strconv.Itoa(int((quota.RetryAfter+time.Second-1)/time.Second)))
+			}
+			http.Error(w, err.Error(), pushStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": message.ID})
+	}))
+}
+
+//pushStatus returns the HTTP status code for an error from PushSealed().
+func pushStatus(err error) int {
+	var quota *QuotaError
+	switch {
+	case err == ErrSignature:
+		return http.StatusUnauthorized
+	case err == ErrDuplicateKey:
+		return http.StatusConflict
+	case errors.As(err, &quota):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadRequest
+}
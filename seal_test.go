@@ -0,0 +1,128 @@
+package timequeue
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSealer_SealOpen(t *testing.T) {
+	signed, _ := NewSealer([]byte("signing"), nil)
+	encrypted, err := NewSealer([]byte("signing"), bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("NewSealer() = %v WANT nil", err)
+	}
+	other, _ := NewSealer([]byte("other"), nil)
+	command := &PushCommand{
+		Time:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		Data:  json.RawMessage(`{"to":"gopher"}`),
+		Topic: "email",
+		Key:   "k",
+	}
+	tests := []struct {
+		sealer *Sealer
+		opener *Sealer
+		err    error
+	}{
+		{signed, signed, nil},
+		{encrypted, encrypted, nil},
+		{signed, encrypted, nil},
+		{encrypted, signed, ErrSealed},
+		{signed, other, ErrSignature},
+	}
+	for i, test := range tests {
+		envelope, err := test.sealer.Seal(command)
+		if err != nil {
+			t.Fatalf("%v: Seal() = %v WANT nil", i, err)
+		}
+		opened, err := test.opener.Open(envelope)
+		if err != test.err {
+			t.Errorf("%v: Open() = %v WANT %v", i, err, test.err)
+			continue
+		}
+		if err == nil && (!opened.Time.Equal(command.Time) || string(opened.Data) != string(command.Data) || opened.Key != "k") {
+			t.Errorf("%v: Open() = %+v WANT %+v", i, opened, command)
+		}
+	}
+
+	envelope, _ := encrypted.Seal(command)
+	if bytes.Contains(envelope, []byte("gopher")) {
+		t.Errorf("encrypted envelope contains plaintext")
+	}
+	envelope[len(envelope)-1] ^= 1
+	if _, err := encrypted.Open(envelope); err != ErrSignature {
+		t.Errorf("Open(modified) = %v WANT %v", err, ErrSignature)
+	}
+	if _, err := signed.Open([]byte{sealVersion}); err != ErrSealed {
+		t.Errorf("Open(short) = %v WANT %v", err, ErrSealed)
+	}
+	if _, err := NewSealer([]byte("signing"), []byte("short")); err == nil {
+		t.Errorf("NewSealer() with invalid key = nil WANT non-nil")
+	}
+}
+
+func TestNewSealer_emptySigningKey(t *testing.T) {
+	tests := [][]byte{nil, {}}
+	for _, signingKey := range tests {
+		if _, err := NewSealer(signingKey, nil); err != ErrSigningKey {
+			t.Errorf("NewSealer(%v) error = %v WANT %v", signingKey, err, ErrSigningKey)
+		}
+	}
+}
+
+func TestTimeQueue_PushHandler(t *testing.T) {
+	q := New()
+	sealer, _ := NewSealer([]byte("signing"), nil)
+	forger, _ := NewSealer([]byte("forged"), nil)
+	handler := q.PushHandler(sealer)
+	command := &PushCommand{Time: time.Now().Add(time.Hour), Data: json.RawMessage(`1`), Key: "once"}
+	post := func(s *Sealer) *httptest.ResponseRecorder {
+		envelope, _ := s.Seal(command)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewReader(envelope)))
+		return w
+	}
+
+	if w := post(forger); w.Code != http.StatusUnauthorized || q.Size() != 0 {
+		t.Errorf("POST forged = %v, size %v WANT %v, 0", w.Code, q.Size(), http.StatusUnauthorized)
+	}
+	w := post(sealer)
+	response := map[string]string{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusCreated || response["id"] == "" {
+		t.Errorf("POST = %v, %v WANT %v and an id", w.Code, response, http.StatusCreated)
+	}
+	if message := q.PeekMessage(); string(message.Data.(json.RawMessage)) != "1" {
+		t.Errorf("message.Data = %v WANT %v", message.Data, "1")
+	}
+	if w := post(sealer); w.Code != http.StatusConflict {
+		t.Errorf("POST replayed = %v WANT %v", w.Code, http.StatusConflict)
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET = %v WANT %v", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestTimeQueue_PushHandler_retryAfter(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start), WithTenantQuota(TenantQuota{MaxPending: 1}))
+	sealer, _ := NewSealer([]byte("signing"), nil)
+	handler := q.PushHandler(sealer)
+	command := &PushCommand{Time: start.Add(90*time.Second + time.Millisecond), Tenant: "a"}
+	envelope, _ := sealer.Seal(command)
+	for _, want := range []int{http.StatusCreated, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewReader(envelope)))
+		if w.Code != want {
+			t.Errorf("POST = %v WANT %v", w.Code, want)
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "91" {
+			t.Errorf("Retry-After = %q WANT %q", w.Header().Get("Retry-After"), "91")
+		}
+	}
+}
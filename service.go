@@ -0,0 +1,111 @@
+package timequeue
+
+import (
+	"context"
+	"errors"
+)
+
+//ErrAlreadyRunning is returned from Run() when the TimeQueue is already running.
+var ErrAlreadyRunning = errors.New("timequeue: already running")
+
+//Service is the lifecycle interface used by common service runners and
+//dependency injection frameworks, e.g. fx lifecycle hooks.
+//Start must not block beyond starting the service, and Stop must stop it.
+type Service interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+//service is the Service returned from TimeQueue.Service().
+type service struct {
+	q *TimeQueue
+}
+
+//Service returns a Service that starts and stops q.
+//
+//Start returns ctx.Err() without starting q if ctx is already done.
+//Stop stops q and returns nil. Both are nops if q is already in the desired state.
+//
+//With errgroup, the Service is started before the group's other members and
+//stopped when the group's context is done:
+//	svc := q.Service()
+//	g, ctx := errgroup.WithContext(ctx)
+//	if err := svc.Start(ctx); err != nil {
+//		return err
+//	}
+//	g.Go(func() error {
+//		<-ctx.Done()
+//		return svc.Stop(context.Background())
+//	})
+//	g.Go(func() error {
+//		return serve(ctx, q)
+//	})
+//	return g.Wait()
+//
+//With oklog/run, the execute function starts q and waits for the interrupt:
+//	done := make(chan struct{})
+//	g.Add(func() error {
+//		if err := svc.Start(ctx); err != nil {
+//			return err
+//		}
+//		<-done
+//		return nil
+//	}, func(error) {
+//		svc.Stop(context.Background())
+//		close(done)
+//	})
+func (q *TimeQueue) Service() Service {
+	return &service{q: q}
+}
+
+//Start starts s.q unless ctx is done.
+func (s *service) Start(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.q.Start()
+	return nil
+}
+
+//Stop stops s.q.
+func (s *service) Stop(ctx context.Context) error {
+	s.q.Stop()
+	return nil
+}
+
+//Run starts q, blocks until ctx is done, and then stops q gracefully: it returns
+//once the running go-routine of q has exited and the functions of all Messages
+//released by ScheduleFunc() have been called. Messages released on Messages() are
+//not waited for, since nothing guarantees that they are received.
+//Run returns nil after a graceful stop, and returns ErrAlreadyRunning without
+//blocking if q is already running.
+//
+//Run fits service runners that supervise blocking functions:
+//	g, ctx := errgroup.WithContext(ctx)
+//	g.Go(func() error {
+//		return q.Run(ctx)
+//	})
+//	g.Go(func() error {
+//		return serve(ctx, q)
+//	})
+//	return g.Wait()
+func (q *TimeQueue) Run(ctx context.Context) error {
+	var exited <-chan struct{}
+	err := q.administer("", AuditStart, "", func() error {
+		if q.isRunning() {
+			return ErrAlreadyRunning
+		}
+		q.start(false)
+		exited = q.exitWaiter(q.runs)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	q.Stop()
+	<-exited
+	<-q.callbacks.idle()
+	<-q.precise.idle()
+	return nil
+}
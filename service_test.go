@@ -0,0 +1,99 @@
+package timequeue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Service(t *testing.T) {
+	q := New()
+	svc := q.Service()
+	if err := svc.Start(context.Background()); err != nil || !q.IsRunning() {
+		t.Errorf("svc.Start() = %v, q.IsRunning() = %v WANT nil, true", err, q.IsRunning())
+	}
+	if err := svc.Stop(context.Background()); err != nil || q.IsRunning() {
+		t.Errorf("svc.Stop() = %v, q.IsRunning() = %v WANT nil, false", err, q.IsRunning())
+	}
+}
+
+func TestTimeQueue_Service_doneContext(t *testing.T) {
+	q := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := q.Service().Start(ctx); err != context.Canceled || q.IsRunning() {
+		t.Errorf("svc.Start() = %v, q.IsRunning() = %v WANT %v, false", err, q.IsRunning(), context.Canceled)
+	}
+}
+
+func TestTimeQueue_Run(t *testing.T) {
+	q := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- q.Run(ctx)
+	}()
+
+	called := make(chan struct{})
+	returned := false
+	q.ScheduleFunc(time.Now(), func(Message) {
+		close(called)
+		time.Sleep(10 * time.Millisecond)
+		returned = true
+	})
+	<-called
+	if err := q.Run(ctx); err != ErrAlreadyRunning {
+		t.Errorf("second q.Run() = %v WANT %v", err, ErrAlreadyRunning)
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("q.Run() = %v WANT nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("q.Run() did not return")
+	}
+	if !returned {
+		t.Errorf("q.Run() returned before the released function")
+	}
+	if state := q.State(); state != StateStopped {
+		t.Errorf("q.State() = %v WANT %v", state, StateStopped)
+	}
+}
+
+func TestTimeQueue_Run_restartedBeforeExit(t *testing.T) {
+	var q *TimeQueue
+	restarted := false
+	q = New(WithAuditHook(func(entry AuditEntry) {
+		//starts q again while it is locked by Stop(), before the running
+		//go-routine stopped by Run() can exit.
+		if entry.Action == AuditStop && !restarted {
+			restarted = true
+			q.start(false)
+		}
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- q.Run(ctx)
+	}()
+	for !q.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("q.Run() = %v WANT nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("q.Run() did not return")
+	}
+	if state := q.State(); state != StateRunning {
+		t.Errorf("q.State() = %v WANT %v", state, StateRunning)
+	}
+	q.Stop()
+}
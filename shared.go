@@ -0,0 +1,165 @@
+package timequeue
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+//DefaultSharedScheduler is a process-wide SharedScheduler that may be given to
+//WithSharedScheduler() by every TimeQueue in a process.
+var DefaultSharedScheduler = NewSharedScheduler()
+
+//SharedScheduler multiplexes the wake ups of any number of TimeQueues onto a
+//single time.Timer and go-routine.
+//A TimeQueue normally creates a timer and go-routine every time its earliest
+//Message changes. Applications with many TimeQueues, e.g. one per short-lived
+//session, may register them all with a single SharedScheduler, see
+//WithSharedScheduler(), to avoid that churn.
+//
+//A SharedScheduler starts its go-routine when it is first used, and the go-routine
+//runs for the life of the process.
+type SharedScheduler struct {
+	lock *sync.Mutex
+	//the registered wakeSignals ordered by wakeTime.
+	signals wakeHeap
+	//sent to when the earliest wakeSignal changes.
+	kick chan struct{}
+	//starts the go-routine.
+	once *sync.Once
+}
+
+//NewSharedScheduler creates a SharedScheduler with no TimeQueues registered.
+func NewSharedScheduler() *SharedScheduler {
+	return &SharedScheduler{
+		lock: &sync.Mutex{},
+		kick: make(chan struct{}, 1),
+		once: &sync.Once{},
+	}
+}
+
+//WithSharedScheduler causes a TimeQueue to schedule its wake ups with scheduler
+//instead of its own timers.
+//WithSharedScheduler may not be given to Reconfigure().
+func WithSharedScheduler(scheduler *SharedScheduler) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.scheduler = scheduler
+	}
+}
+
+//Len returns the number of wake ups currently scheduled with s. Each running
+//TimeQueue with Messages in it has at most one.
+func (s *SharedScheduler) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.signals.Len()
+}
+
+//newWakeSignal creates a wakeSignal that is scheduled with s when it is spawned.
+func (s *SharedScheduler) newWakeSignal(dst chan wake, wakeTime time.Time, generation uint64) *wakeSignal {
+	return &wakeSignal{
+		dst:        dst,
+		stop:       make(chan struct{}),
+		wakeTime:   wakeTime,
+		generation: generation,
+		scheduler:  s,
+		index:      notInIndex,
+	}
+}
+
+//add schedules w to fire at w.wakeTime.
+func (s *SharedScheduler) add(w *wakeSignal) {
+	s.once.Do(func() {
+		go s.run()
+	})
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	heap.Push(&s.signals, w)
+	if w.index == 0 {
+		select {
+		case s.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+//remove unschedules w if it has not fired.
+func (s *SharedScheduler) remove(w *wakeSignal) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if w.index != notInIndex {
+		heap.Remove(&s.signals, w.index)
+	}
+}
+
+//run fires the scheduled wakeSignals as their wakeTimes pass. It waits on a
+//single timer for the earliest one, at most maxWait at a time.
+func (s *SharedScheduler) run() {
+	timer := time.NewTimer(maxWait)
+	for {
+		select {
+		case <-timer.C:
+		case <-s.kick:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+		timer.Reset(s.fireDue(time.Now()))
+	}
+}
+
+//fireDue fires every wakeSignal with a wakeTime at or before now and returns the
+//duration to wait for the next one.
+func (s *SharedScheduler) fireDue(now time.Time) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for s.signals.Len() > 0 && !s.signals[0].wakeTime.After(now) {
+		heap.Pop(&s.signals).(*wakeSignal).fire(now)
+	}
+	if s.signals.Len() == 0 {
+		return maxWait
+	}
+	return waitDuration(s.signals[0].wakeTime, now)
+}
+
+//wakeHeap is a heap.Interface of wakeSignals ordered by wakeTime.
+type wakeHeap []*wakeSignal
+
+//Len returns the number of wakeSignals in h.
+func (h wakeHeap) Len() int {
+	return len(h)
+}
+
+//Less returns whether the wakeSignal at i wakes before the one at j.
+func (h wakeHeap) Less(i, j int) bool {
+	return h[i].wakeTime.Before(h[j].wakeTime)
+}
+
+//Swap swaps the wakeSignals at i and j.
+func (h wakeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+//Push appends value, which must be a *wakeSignal, to h.
+func (h *wakeHeap) Push(value interface{}) {
+	w := value.(*wakeSignal)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+//Pop removes and returns the last wakeSignal in h.
+func (h *wakeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = notInIndex
+	*h = old[:n-1]
+	return w
+}
@@ -0,0 +1,46 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithSharedScheduler(t *testing.T) {
+	s := NewSharedScheduler()
+	queues := []*TimeQueue{New(WithSharedScheduler(s)), New(WithSharedScheduler(s))}
+	now := time.Now()
+	for i, q := range queues {
+		q.Start()
+		defer q.Stop()
+		q.Push(now.Add(time.Duration(i+1)*10*time.Millisecond), i)
+		q.Push(now.Add(time.Hour), "later")
+	}
+	if n := s.Len(); n != 2 {
+		t.Errorf("s.Len() = %v WANT %v", n, 2)
+	}
+	for i, q := range queues {
+		select {
+		case message := <-q.Messages():
+			if message.Data != i {
+				t.Errorf("q.Messages() = %v WANT %v", message.Data, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("queue %v did not release", i)
+		}
+	}
+	//both queues are waiting for their later Messages.
+	for n := s.Len(); n != 2; n = s.Len() {
+		time.Sleep(time.Millisecond)
+	}
+	queues[0].Stop()
+	if n := s.Len(); n != 1 {
+		t.Errorf("s.Len() after Stop() = %v WANT %v", n, 1)
+	}
+}
+
+func TestTimeQueue_Reconfigure_sharedScheduler(t *testing.T) {
+	q := New()
+	if err := q.Reconfigure(WithSharedScheduler(DefaultSharedScheduler)); err != ErrNotReconfigurable {
+		t.Errorf("q.Reconfigure() = %v WANT %v", err, ErrNotReconfigurable)
+	}
+}
@@ -0,0 +1,211 @@
+package timequeue
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//skiplistMaxLevel is the maximum number of levels in a SkiplistStorage.
+//It allows for efficient operations on up to 4^skiplistMaxLevel Messages.
+const skiplistMaxLevel = 24
+
+//SkiplistStorage is a Storage backed by a skiplist ordered by Message Time,
+//Priority, and Tiebreaker. Messages that are otherwise equal are ordered by when
+//they were pushed.
+//
+//SkiplistStorage has its own read-write lock. Its read methods, Peek(), Len(),
+//CountBefore(), and Between(), may be called by any go-routine at any time
+//without locking the TimeQueue it is given to. This allows heavy inspection
+//traffic without pausing the release of Messages:
+//	storage := timequeue.NewSkiplistStorage()
+//	q := timequeue.New(timequeue.WithStorage(storage))
+//	//in another go-routine.
+//	dueSoon := storage.CountBefore(time.Now().Add(time.Minute))
+//
+//Messages returned from the read methods must not be modified.
+type SkiplistStorage struct {
+	lock *sync.RWMutex
+	head *skiplistNode
+	//the number of levels currently in use.
+	level int
+	//the sequence number of every Message in the SkiplistStorage.
+	seqs    map[*Message]uint64
+	nextSeq uint64
+	random  *rand.Rand
+}
+
+//skiplistNode is a single Message in a SkiplistStorage.
+type skiplistNode struct {
+	message *Message
+	seq     uint64
+	next    []*skiplistNode
+}
+
+//NewSkiplistStorage creates an empty SkiplistStorage.
+func NewSkiplistStorage() *SkiplistStorage {
+	return &SkiplistStorage{
+		lock:   &sync.RWMutex{},
+		head:   &skiplistNode{next: make([]*skiplistNode, skiplistMaxLevel)},
+		level:  1,
+		seqs:   map[*Message]uint64{},
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//less returns whether or not the node n is ordered before message and seq.
+func (n *skiplistNode) less(message *Message, seq uint64) bool {
+	if messageBefore(n.message, message) {
+		return true
+	}
+	if messageBefore(message, n.message) {
+		return false
+	}
+	return n.seq < seq
+}
+
+//randomLevel returns the level for a new node.
+//It should only be called when s is write locked.
+func (s *SkiplistStorage) randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && s.random.Intn(4) == 0 {
+		level++
+	}
+	return level
+}
+
+//findPredecessors returns the last node at every level for which before returns
+//true.
+//It should only be called when s is locked.
+func (s *SkiplistStorage) findPredecessors(before func(node *skiplistNode) bool) []*skiplistNode {
+	update := make([]*skiplistNode, skiplistMaxLevel)
+	node := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && before(node.next[i]) {
+			node = node.next[i]
+		}
+		update[i] = node
+	}
+	return update
+}
+
+//Push adds message to s.
+func (s *SkiplistStorage) Push(message *Message) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	seq := s.nextSeq
+	s.nextSeq++
+	update := s.findPredecessors(func(node *skiplistNode) bool {
+		return node.less(message, seq)
+	})
+	level := s.randomLevel()
+	for ; s.level < level; s.level++ {
+		update[s.level] = s.head
+	}
+	node := &skiplistNode{
+		message: message,
+		seq:     seq,
+		next:    make([]*skiplistNode, level),
+	}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	s.seqs[message] = seq
+}
+
+//Peek returns the earliest Message in s or nil if s is empty.
+func (s *SkiplistStorage) Peek() *Message {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if first := s.head.next[0]; first != nil {
+		return first.message
+	}
+	return nil
+}
+
+//PopDue removes and returns the earliest Message in s if it is before until.
+func (s *SkiplistStorage) PopDue(until time.Time) *Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	first := s.head.next[0]
+	if first == nil || !first.message.Before(until) {
+		return nil
+	}
+	s.remove(first.message)
+	return first.message
+}
+
+//Remove removes message from s.
+func (s *SkiplistStorage) Remove(message *Message) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.remove(message)
+}
+
+//remove removes message from s and returns whether or not it was in s.
+//It should only be called when s is write locked.
+func (s *SkiplistStorage) remove(message *Message) bool {
+	seq, ok := s.seqs[message]
+	if !ok {
+		return false
+	}
+	update := s.findPredecessors(func(node *skiplistNode) bool {
+		return node.less(message, seq)
+	})
+	node := update[0].next[0]
+	if node == nil || node.message != message {
+		return false
+	}
+	for i := range node.next {
+		update[i].next[i] = node.next[i]
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	delete(s.seqs, message)
+	return true
+}
+
+//Len returns the number of Messages in s.
+func (s *SkiplistStorage) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.seqs)
+}
+
+//Each calls fn with every Message in s in Time order.
+//fn must not call any methods on s.
+func (s *SkiplistStorage) Each(fn func(message *Message)) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for node := s.head.next[0]; node != nil; node = node.next[0] {
+		fn(node.message)
+	}
+}
+
+//CountBefore returns the number of Messages in s with Times before t.
+//It takes time proportional to the result.
+func (s *SkiplistStorage) CountBefore(t time.Time) int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	count := 0
+	for node := s.head.next[0]; node != nil && node.message.Before(t); node = node.next[0] {
+		count++
+	}
+	return count
+}
+
+//Between returns the Messages in s with Times in [from, to) in Time order.
+func (s *SkiplistStorage) Between(from, to time.Time) []*Message {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	result := []*Message{}
+	node := s.findPredecessors(func(node *skiplistNode) bool {
+		return node.message.Before(from)
+	})[0].next[0]
+	for ; node != nil && node.message.Before(to); node = node.next[0] {
+		result = append(result, node.message)
+	}
+	return result
+}
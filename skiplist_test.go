@@ -0,0 +1,94 @@
+package timequeue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSkiplistStorage(t *testing.T) {
+	s := NewSkiplistStorage()
+	now := time.Now()
+	messages := make([]*Message, 100)
+	for i := range messages {
+		//push in a scrambled order with some equal times.
+		j := (i * 37) % len(messages)
+		messages[j] = &Message{Time: now.Add(time.Duration(j/2) * time.Second), Data: j}
+	}
+	for i := range messages {
+		s.Push(messages[(i*37)%len(messages)])
+	}
+	if length := s.Len(); length != 100 {
+		t.Errorf("s.Len() = %v WANT %v", length, 100)
+	}
+	if count := s.CountBefore(now.Add(10 * time.Second)); count != 20 {
+		t.Errorf("s.CountBefore() = %v WANT %v", count, 20)
+	}
+	if between := s.Between(now.Add(5*time.Second), now.Add(7*time.Second)); len(between) != 4 || !between[0].Time.Equal(now.Add(5*time.Second)) {
+		t.Errorf("s.Between() = %v WANT 4 Messages starting at %v", between, now.Add(5*time.Second))
+	}
+
+	if !s.Remove(messages[50]) || s.Remove(messages[50]) {
+		t.Errorf("s.Remove() WANT true then false")
+	}
+	previous := time.Time{}
+	for count := 0; ; count++ {
+		message := s.PopDue(now.Add(time.Hour))
+		if message == nil {
+			if count != 99 {
+				t.Errorf("popped %v Messages WANT %v", count, 99)
+			}
+			break
+		}
+		if message.Time.Before(previous) || message == messages[50] {
+			t.Errorf("s.PopDue() = %v out of order or removed", message)
+		}
+		previous = message.Time
+	}
+	if peek := s.Peek(); peek != nil {
+		t.Errorf("s.Peek() = %v WANT nil", peek)
+	}
+}
+
+func TestSkiplistStorage_PopDue_notDue(t *testing.T) {
+	s := NewSkiplistStorage()
+	now := time.Now()
+	s.Push(&Message{Time: now})
+	if message := s.PopDue(now); message != nil {
+		t.Errorf("s.PopDue() = %v WANT nil", message)
+	}
+}
+
+func TestSkiplistStorage_concurrentReads(t *testing.T) {
+	s := NewSkiplistStorage()
+	q := New(WithStorage(s), WithCapacity(100))
+	q.Start()
+	defer q.Stop()
+
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				s.Peek()
+				s.CountBefore(time.Now())
+				s.Between(time.Now(), time.Now().Add(time.Second))
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		q.Push(time.Now().Add(time.Duration(i%10)*time.Millisecond), i)
+	}
+	for i := 0; i < 100; i++ {
+		<-q.Messages()
+	}
+	close(done)
+	wg.Wait()
+}
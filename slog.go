@@ -0,0 +1,14 @@
+package timequeue
+
+import "log/slog"
+
+//WithLogger sets the logger that a TimeQueue logs structured debug-level events
+//to: "timequeue: push" and "timequeue: release" for every Message, with its Data
+//redacted by Redact(), "timequeue: wake" every time the TimeQueue wakes to release
+//Messages, and "timequeue: stop".
+//A nil logger, which is the default, logs nothing.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
@@ -0,0 +1,49 @@
+package timequeue
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	q := New(WithLogger(logger), WithRedactor(RedactAll))
+	q.Start()
+	q.Push(time.Now(), "secret")
+	<-q.Messages()
+	q.Stop()
+	output := buf.String()
+	for _, event := range []string{"timequeue: push", "timequeue: wake", "timequeue: release", "timequeue: stop"} {
+		if !strings.Contains(output, event) {
+			t.Errorf("logged %q WANT %q", output, event)
+		}
+	}
+	if strings.Contains(output, "secret") || !strings.Contains(output, "[redacted]") {
+		t.Errorf("logged %q WANT redacted Data", output)
+	}
+
+	q = New(WithLogger(nil))
+	q.Push(time.Now(), 0)
+}
+
+func TestWithLogger_debugDisabled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	redacted := 0
+	q := New(WithLogger(logger), WithRedactor(func(message Message) string {
+		redacted++
+		return ""
+	}))
+	q.Push(time.Now(), 0)
+	q.PopAll(true)
+	if redacted != 0 {
+		t.Errorf("redacted %v times WANT %v", redacted, 0)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("logged %q WANT nothing", buf.String())
+	}
+}
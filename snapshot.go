@@ -0,0 +1,126 @@
+package timequeue
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gogolfing/timequeue/internal/record"
+)
+
+//SnapshotVersion is the version of the format written by Snapshot().
+//Like the handoff protocol, snapshots are encoded with encoding/gob so that a
+//snapshot written by one version of this package can be restored by another. The
+//version is only incremented when a change cannot be understood by an older
+//reader.
+const SnapshotVersion = 1
+
+var (
+	//ErrSnapshotVersion is returned by NewFromSnapshot() and ImportJSON() when a
+	//snapshot was written with a newer SnapshotVersion.
+	ErrSnapshotVersion = errors.New("timequeue: unsupported snapshot version")
+
+	//ErrSnapshotCount is returned by NewFromSnapshot() when the header of a
+	//snapshot has a negative number of Messages.
+	ErrSnapshotCount = errors.New("timequeue: invalid snapshot count")
+)
+
+//snapshotHeader is the first value in a snapshot.
+type snapshotHeader struct {
+	Version int
+	Count   int
+}
+
+//Snapshot writes every Message in q, including those held by a selective hold, to
+//w so that they may be restored with NewFromSnapshot(), e.g. after a restart.
+//q is not modified, and it is locked while the snapshot is written so that the
+//snapshot is consistent.
+//
+//Messages are written with the same fields as a handoff, and their Data is
+//encoded just the same. See HandoffTo().
+//Schedules and Messages pushed with ScheduleFunc() or PushQueue() are not
+//included, since their recurrences, functions, and TimeQueues cannot be encoded.
+func (q *TimeQueue) Snapshot(w io.Writer) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	messages := q.snapshotMessages()
+	records := make([]*record.Record, 0, len(messages))
+	for _, message := range messages {
+		r, err := newRecord(message)
+		if err != nil {
+			return err
+		}
+		records = append(records, r)
+	}
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(&snapshotHeader{Version: SnapshotVersion, Count: len(records)}); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//snapshotMessages returns every Message in q, including those held by a selective
+//hold, except those pushed with ScheduleFunc() or PushQueue(). Spilled Messages
+//are loaded first.
+//It should only be called when q is locked.
+func (q *TimeQueue) snapshotMessages() []*Message {
+	q.loadSpilled(time.Time{})
+	result := make([]*Message, 0, q.size())
+	add := func(message *Message) {
+		if message.fn == nil {
+			result = append(result, message)
+		}
+	}
+	q.storage.Each(add)
+	for _, message := range q.heldMessages.messages {
+		add(message)
+	}
+	return result
+}
+
+//NewFromSnapshot creates a new *TimeQueue configured with opts, like New(), that
+//contains the Messages read from a snapshot written by Snapshot().
+//The TimeQueue is in the stopped state. If the snapshot cannot be read completely,
+//then the error is returned and no TimeQueue is created.
+func NewFromSnapshot(r io.Reader, opts ...Option) (*TimeQueue, error) {
+	dec := gob.NewDecoder(r)
+	header := &snapshotHeader{}
+	if err := dec.Decode(header); err != nil {
+		return nil, err
+	}
+	if header.Version > SnapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+	if header.Count < 0 {
+		return nil, ErrSnapshotCount
+	}
+	prealloc := header.Count
+	if prealloc > maxHandoffPrealloc {
+		prealloc = maxHandoffPrealloc
+	}
+	messages := make([]*Message, 0, prealloc)
+	for i := 0; i < header.Count; i++ {
+		message, err := decodeRecord(dec)
+		if err != nil {
+			c := newConfig()
+			c.apply(opts)
+			c.finalize(messages, DropRestoreFailed)
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	q := New(opts...)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, message := range messages {
+		q.pushStored(message)
+	}
+	q.afterHeapUpdate()
+	return q, nil
+}
@@ -0,0 +1,59 @@
+package timequeue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Snapshot(t *testing.T) {
+	q := New()
+	now := time.Now().Truncate(time.Second)
+	q.Push(now.Add(time.Hour), "b")
+	q.PushMessage(&Message{Time: now, Data: "a", Key: "key", Topic: "held"})
+	q.ScheduleFunc(now.Add(time.Hour), func(Message) {})
+	q.HoldTopic("held", "")
+	q.Push(now.Add(-time.Hour), "due")
+	q.Start()
+	<-q.Messages()
+	q.Stop()
+
+	buf := &bytes.Buffer{}
+	if err := q.Snapshot(buf); err != nil {
+		t.Fatalf("q.Snapshot() = %v WANT nil", err)
+	}
+	if size := q.Size(); size != 3 {
+		t.Errorf("q.Size() after Snapshot() = %v WANT %v", size, 3)
+	}
+	restored, err := NewFromSnapshot(buf, WithCapacity(2))
+	if err != nil {
+		t.Fatalf("NewFromSnapshot() error = %v WANT nil", err)
+	}
+	if size := restored.Size(); size != 2 {
+		t.Errorf("restored.Size() = %v WANT %v", size, 2)
+	}
+	if !restored.Contains("key") {
+		t.Errorf("restored.Contains(key) = %v WANT %v", false, true)
+	}
+	messages := restored.PopAll(false)
+	if len(messages) != 2 || messages[0].Data != "a" || !messages[0].Time.Equal(now) || messages[1].Data != "b" {
+		t.Errorf("restored.PopAll() = %v WANT a, b", messages)
+	}
+}
+
+func TestNewFromSnapshot_newerVersion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gob.NewEncoder(buf).Encode(&snapshotHeader{Version: SnapshotVersion + 1})
+	if _, err := NewFromSnapshot(buf); err != ErrSnapshotVersion {
+		t.Errorf("NewFromSnapshot() error = %v WANT %v", err, ErrSnapshotVersion)
+	}
+}
+
+func TestNewFromSnapshot_negativeCount(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gob.NewEncoder(buf).Encode(&snapshotHeader{Version: SnapshotVersion, Count: -1})
+	if _, err := NewFromSnapshot(buf); err != ErrSnapshotCount {
+		t.Errorf("NewFromSnapshot() error = %v WANT %v", err, ErrSnapshotCount)
+	}
+}
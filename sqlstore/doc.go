@@ -0,0 +1,20 @@
+//Package sqlstore provides a timequeue.ClaimStore that keeps pending Messages in
+//a table of a database/sql database, e.g. Postgres or MySQL:
+//	db, err := sql.Open("postgres", dsn)
+//	//handle err.
+//	store := sqlstore.New(db, "timequeue", sqlstore.Postgres)
+//	if err := store.CreateTable(); err != nil {
+//		//handle err.
+//	}
+//	q := timequeue.New(timequeue.WithStore(store))
+//	q.Start()
+//
+//The table has a generic schema of an id primary key, the Message's Time as Unix
+//nanoseconds in at, and the encoded Message in message. Due Messages are claimed
+//with SELECT ... FOR UPDATE SKIP LOCKED, so TimeQueues in several processes may
+//share one table and each Message is released by only one of them.
+//
+//Message Data is encoded with the Codec registered for its type with
+//timequeue.RegisterType(), or otherwise with encoding/gob, in which case the
+//concrete types of all Data values must be registered with gob.Register().
+package sqlstore
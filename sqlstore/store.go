@@ -0,0 +1,187 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/gogolfing/timequeue"
+	"github.com/gogolfing/timequeue/internal/record"
+)
+
+//Dialect holds the parts of the SQL used by a Store that differ between
+//databases.
+type Dialect struct {
+	//Placeholder returns the placeholder of the nth parameter of a statement,
+	//starting at 1.
+	Placeholder func(n int) string
+	//Blob is the column type of the encoded Messages.
+	Blob string
+	//Lock is appended to the SELECT that claims a Message to lock its row, or
+	//skip it if it is already locked.
+	Lock string
+}
+
+//The Dialects of common databases.
+var (
+	//Postgres is the Dialect of PostgreSQL 9.5 and later.
+	Postgres = Dialect{
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		Blob:        "BYTEA",
+		Lock:        "FOR UPDATE SKIP LOCKED",
+	}
+	//MySQL is the Dialect of MySQL 8.0 and later.
+	MySQL = Dialect{
+		Placeholder: func(n int) string { return "?" },
+		Blob:        "LONGBLOB",
+		Lock:        "FOR UPDATE SKIP LOCKED",
+	}
+	//SQLite is the Dialect of SQLite, which locks the entire database in every
+	//write transaction and therefore needs no row locks.
+	SQLite = Dialect{
+		Placeholder: func(n int) string { return "?" },
+		Blob:        "BLOB",
+	}
+)
+
+//Store is a timequeue.ClaimStore and timequeue.LoadStore that keeps pending
+//Messages in a SQL table.
+type Store struct {
+	db      *sql.DB
+	table   string
+	dialect Dialect
+}
+
+//New returns a Store that uses table in db with the SQL of dialect. table is not
+//quoted and must be a valid identifier.
+func New(db *sql.DB, table string, dialect Dialect) *Store {
+	return &Store{
+		db:      db,
+		table:   table,
+		dialect: dialect,
+	}
+}
+
+//CreateTable creates the table of s if it does not exist.
+func (s *Store) CreateTable() error {
+	_, err := s.db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %v (id VARCHAR(64) PRIMARY KEY, at BIGINT NOT NULL, message %v NOT NULL)",
+		s.table, s.dialect.Blob,
+	))
+	return err
+}
+
+//Append stores message in a transaction, replacing any Message with the same ID.
+//Its Time is stored in the at column in Unix nanoseconds, clamped to the range of
+//BIGINT. See record.UnixNano().
+func (s *Store) Append(message *timequeue.Message) error {
+	value, err := timequeue.EncodeMessage(message)
+	if err != nil {
+		return err
+	}
+	return s.transact(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(s.sql("DELETE FROM %v WHERE id = %v", s.table, 1), message.ID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(
+			s.sql("INSERT INTO %v (id, at, message) VALUES (%v, %v, %v)", s.table, 1, 2, 3),
+			message.ID, record.UnixNano(message.Time), value,
+		)
+		return err
+	})
+}
+
+//Remove deletes the Message with id if it is stored.
+func (s *Store) Remove(id string) error {
+	_, err := s.db.Exec(s.sql("DELETE FROM %v WHERE id = %v", s.table, 1), id)
+	return err
+}
+
+//Claim deletes the Message with id and returns true, or returns false if it was
+//already deleted or is being claimed by another process.
+func (s *Store) Claim(id string) (bool, error) {
+	claimed := false
+	err := s.transact(func(tx *sql.Tx) error {
+		query := s.sql("SELECT id FROM %v WHERE id = %v", s.table, 1)
+		if s.dialect.Lock != "" {
+			query += " " + s.dialect.Lock
+		}
+		err := tx.QueryRow(query, id).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(s.sql("DELETE FROM %v WHERE id = %v", s.table, 1), id); err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	return claimed && err == nil, err
+}
+
+//LoadAll returns new Messages for every stored Message in order of Time.
+func (s *Store) LoadAll() ([]*timequeue.Message, error) {
+	rows, err := s.db.Query(s.sql("SELECT message FROM %v ORDER BY at, id", s.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := []*timequeue.Message{}
+	for rows.Next() {
+		value := []byte{}
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		message, err := timequeue.DecodeMessage(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, message)
+	}
+	return result, rows.Err()
+}
+
+//Load returns a new Message for the stored Message with id, or nil if there is
+//none.
+func (s *Store) Load(id string) (*timequeue.Message, error) {
+	value := []byte{}
+	err := s.db.QueryRow(s.sql("SELECT message FROM %v WHERE id = %v", s.table, 1), id).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return timequeue.DecodeMessage(value)
+}
+
+//Compact does nothing and returns nil. Removed Messages are deleted immediately.
+func (s *Store) Compact() error {
+	return nil
+}
+
+//sql formats query with table and the placeholders of s.dialect for the
+//parameter numbers in params.
+func (s *Store) sql(query string, table string, params ...int) string {
+	args := []interface{}{table}
+	for _, n := range params {
+		args = append(args, s.dialect.Placeholder(n))
+	}
+	return fmt.Sprintf(query, args...)
+}
+
+//transact calls fn in a transaction that is committed if fn returns nil and
+//rolled back otherwise.
+func (s *Store) transact(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
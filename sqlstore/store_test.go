@@ -0,0 +1,113 @@
+package sqlstore
+
+import (
+	"database/sql/driver"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gogolfing/timequeue"
+)
+
+func newTestStore(t *testing.T, dialect Dialect) (*Store, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return New(db, "messages", dialect), mock
+}
+
+func TestStore_AppendLoadAll(t *testing.T) {
+	s, mock := newTestStore(t, Postgres)
+	message := &timequeue.Message{Time: time.Unix(0, 100), Data: "data", ID: "a", Key: "key"}
+	value, _ := timequeue.EncodeMessage(message)
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS messages (id VARCHAR(64) PRIMARY KEY, at BIGINT NOT NULL, message BYTEA NOT NULL)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM messages WHERE id = $1")).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO messages (id, at, message) VALUES ($1, $2, $3)")).
+		WithArgs("a", int64(100), value).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT message FROM messages ORDER BY at, id")).
+		WillReturnRows(sqlmock.NewRows([]string{"message"}).AddRow(value))
+
+	if err := s.CreateTable(); err != nil {
+		t.Fatalf("CreateTable() = %v WANT nil", err)
+	}
+	if err := s.Append(message); err != nil {
+		t.Fatalf("Append() = %v WANT nil", err)
+	}
+	messages, err := s.LoadAll()
+	if err != nil || len(messages) != 1 || messages[0].ID != "a" || messages[0].Data != "data" ||
+		messages[0].Key != "key" || !messages[0].Time.Equal(message.Time) {
+		t.Errorf("LoadAll() = %v, %v WANT %v, nil", messages, err, message)
+	}
+}
+
+func TestStore_Claim(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		rows    *sqlmock.Rows
+		claimed bool
+	}{
+		{Postgres, "SELECT id FROM messages WHERE id = $1 FOR UPDATE SKIP LOCKED", sqlmock.NewRows([]string{"id"}).AddRow("a"), true},
+		{MySQL, "SELECT id FROM messages WHERE id = ? FOR UPDATE SKIP LOCKED", sqlmock.NewRows([]string{"id"}), false},
+		{SQLite, "SELECT id FROM messages WHERE id = ?", sqlmock.NewRows([]string{"id"}).AddRow("a"), true},
+	}
+	for i, test := range tests {
+		s, mock := newTestStore(t, test.dialect)
+		mock.ExpectBegin()
+		mock.ExpectQuery("^" + regexp.QuoteMeta(test.query) + "$").WithArgs("a").WillReturnRows(test.rows)
+		if test.claimed {
+			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM messages WHERE id = ")).WithArgs("a").WillReturnResult(driver.RowsAffected(1))
+		}
+		mock.ExpectCommit()
+		if claimed, err := s.Claim("a"); claimed != test.claimed || err != nil {
+			t.Errorf("%v: Claim() = %v, %v WANT %v, nil", i, claimed, err, test.claimed)
+		}
+	}
+}
+
+func TestStore_Load(t *testing.T) {
+	s, mock := newTestStore(t, MySQL)
+	message := &timequeue.Message{Time: time.Unix(0, 100), Data: "data", ID: "a"}
+	value, _ := timequeue.EncodeMessage(message)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT message FROM messages WHERE id = ?")).WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"message"}).AddRow(value))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT message FROM messages WHERE id = ?")).WithArgs("b").
+		WillReturnRows(sqlmock.NewRows([]string{"message"}))
+
+	if loaded, err := s.Load("a"); err != nil || loaded == nil || loaded.ID != "a" || loaded.Data != "data" {
+		t.Errorf("Load(a) = %v, %v WANT %v, nil", loaded, err, message)
+	}
+	if loaded, err := s.Load("b"); loaded != nil || err != nil {
+		t.Errorf("Load(b) = %v, %v WANT nil, nil", loaded, err)
+	}
+}
+
+func TestStore_Append_farTime(t *testing.T) {
+	s, mock := newTestStore(t, SQLite)
+	message := &timequeue.Message{Time: time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC), ID: "a"}
+	value, _ := timequeue.EncodeMessage(message)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM messages WHERE id = ?")).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO messages (id, at, message) VALUES (?, ?, ?)")).
+		WithArgs("a", int64(math.MaxInt64), value).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.Append(message); err != nil {
+		t.Errorf("Append() = %v WANT nil", err)
+	}
+}
@@ -0,0 +1,100 @@
+package timequeue
+
+//State is the lifecycle state of a TimeQueue. See State().
+type State int
+
+//States of a TimeQueue.
+const (
+	//StateStopped is the state of a TimeQueue that has not been started or has
+	//been stopped and whose running go-routine has exited.
+	StateStopped State = iota
+	//StateRunning is the state of a started TimeQueue that releases Messages as
+	//their times pass.
+	StateRunning
+	//StateDraining is the state of a TimeQueue that has been stopped, so that it
+	//releases no more Messages, but whose running go-routine has not yet exited.
+	StateDraining
+	//StatePaused is the state of a started TimeQueue that does not release Messages
+	//because it is held with Hold() or has not been activated after StartInactive().
+	StatePaused
+)
+
+//String returns the name of s.
+func (s State) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateRunning:
+		return "running"
+	case StateDraining:
+		return "draining"
+	case StatePaused:
+		return "paused"
+	}
+	return "unknown"
+}
+
+//State returns the current State of q.
+//
+//The lifecycle of q moves from StateStopped to StateRunning with Start(), from
+//StateRunning to StateDraining with Stop(), and from StateDraining to StateStopped
+//once its running go-routine exits. A TimeQueue advanced manually (see
+//WithManualAdvance()) has no running go-routine, so it moves straight to
+//StateStopped. A running TimeQueue that is held or inactive is StatePaused.
+func (q *TimeQueue) State() State {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.state()
+}
+
+//state is the unexported version of State().
+//It should only be called when q is locked.
+func (q *TimeQueue) state() State {
+	if q.runState == StateRunning && (q.held || q.inactive) {
+		return StatePaused
+	}
+	return q.runState
+}
+
+//exitWaiter is closed once the running go-routine of generation has exited.
+type exitWaiter struct {
+	generation uint64
+	exited     chan struct{}
+}
+
+//exitWaiter returns a channel that is closed once the running go-routine started
+//as the generation-th one has exited. Since every call to Stop() stops one running
+//go-routine, this is once generation running go-routines have exited, even if q
+//was started again in the meantime.
+//It should only be called when q is locked.
+func (q *TimeQueue) exitWaiter(generation uint64) <-chan struct{} {
+	exited := make(chan struct{})
+	if q.exits >= generation {
+		close(exited)
+	} else {
+		q.exitWaiters = append(q.exitWaiters, exitWaiter{generation, exited})
+	}
+	return exited
+}
+
+//exited records that a running go-routine of q has exited, closes the exit waiters
+//of its generation, and moves q from StateDraining to StateStopped once the last
+//running go-routine has exited. q may have been started again, in which case its
+//State is unchanged.
+func (q *TimeQueue) exited() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.exits++
+	waiters := q.exitWaiters[:0]
+	for _, waiter := range q.exitWaiters {
+		if waiter.generation <= q.exits {
+			close(waiter.exited)
+		} else {
+			waiters = append(waiters, waiter)
+		}
+	}
+	q.exitWaiters = waiters
+	if q.runState == StateDraining && q.exits >= q.runs {
+		q.runState = StateStopped
+	}
+}
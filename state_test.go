@@ -0,0 +1,69 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_State(t *testing.T) {
+	q := New()
+	if state := q.State(); state != StateStopped {
+		t.Errorf("q.State() = %v WANT %v", state, StateStopped)
+	}
+	q.StartInactive()
+	if state := q.State(); state != StatePaused {
+		t.Errorf("inactive q.State() = %v WANT %v", state, StatePaused)
+	}
+	q.Activate()
+	if state := q.State(); state != StateRunning {
+		t.Errorf("q.State() = %v WANT %v", state, StateRunning)
+	}
+	q.Hold("")
+	if state := q.State(); state != StatePaused {
+		t.Errorf("held q.State() = %v WANT %v", state, StatePaused)
+	}
+	q.Release()
+
+	q.lock.Lock()
+	q.stop()
+	if state := q.state(); state != StateDraining {
+		t.Errorf("stopped q.state() = %v WANT %v", state, StateDraining)
+	}
+	q.lock.Unlock()
+	for i := 0; q.State() != StateStopped; i++ {
+		if i > 1000 {
+			t.Fatalf("q.State() = %v WANT %v", q.State(), StateStopped)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTimeQueue_State_manual(t *testing.T) {
+	q := New(WithManualAdvance(time.Now()))
+	q.Start()
+	if state := q.State(); state != StateRunning {
+		t.Errorf("q.State() = %v WANT %v", state, StateRunning)
+	}
+	q.Stop()
+	if state := q.State(); state != StateStopped {
+		t.Errorf("q.State() = %v WANT %v", state, StateStopped)
+	}
+}
+
+func TestState_String(t *testing.T) {
+	tests := []struct {
+		s    State
+		want string
+	}{
+		{StateStopped, "stopped"},
+		{StateRunning, "running"},
+		{StateDraining, "draining"},
+		{StatePaused, "paused"},
+		{State(-1), "unknown"},
+	}
+	for _, test := range tests {
+		if got := test.s.String(); got != test.want {
+			t.Errorf("%d.String() = %v WANT %v", test.s, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package timequeue
+
+import "time"
+
+//Stats is a snapshot of the state of a TimeQueue.
+type Stats struct {
+	//Size is the number of Messages in the TimeQueue. See TimeQueue.Size().
+	Size int
+	//HeadAt is the Time of the earliest Message, or the zero Time if the TimeQueue
+	//is empty.
+	HeadAt time.Time
+	//OldestOverdue is the amount of time the earliest Message is past its Time,
+	//or 0 if it is not yet due.
+	OldestOverdue time.Duration
+
+	//TotalPushed, TotalReleased, and TotalRemoved are the numbers of Messages
+	//pushed to, released from, and removed from the TimeQueue without being
+	//released, since it was created.
+	TotalPushed   uint64
+	TotalReleased uint64
+	TotalRemoved  uint64
+	//MaxLag is the greatest amount of time any Message was released after its
+	//Time.
+	MaxLag time.Duration
+	//AverageLatency is the mean amount of time Messages were released after their
+	//Times. Messages released before their Times, e.g. with Pop(), count as 0.
+	AverageLatency time.Duration
+	//Lateness counts released Messages by how late they were released. See
+	//LatenessQuantiles().
+	Lateness LatenessHistogram
+
+	//State is the lifecycle State of the TimeQueue.
+	State State
+	//Running is true if the TimeQueue is running.
+	Running bool
+	//Active is true if the TimeQueue is running and has been activated.
+	Active bool
+	//Held is true if releases have been held with TimeQueue.Hold().
+	Held bool
+	//HoldReason is the reason given to TimeQueue.Hold().
+	HoldReason string
+	//Holds maps the names of all selective holds to their reasons.
+	Holds map[string]string
+	//HeldMessages is the number of due Messages held by selective holds.
+	HeldMessages int
+	//Unacked is the number of released Messages that have not been acknowledged.
+	//It is always 0 unless the TimeQueue is in ack mode, see WithAckMode().
+	Unacked int
+}
+
+//Stats returns a snapshot of the state of q.
+//Stats only locks q briefly and is cheap enough to call from health checks.
+func (q *TimeQueue) Stats() Stats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	holds := make(map[string]string, len(q.holds))
+	for name, h := range q.holds {
+		holds[name] = h.reason
+	}
+	pushed, released := q.counters.pushed.load(), q.counters.released.load()
+	result := Stats{
+		Size:          q.size(),
+		TotalPushed:   pushed,
+		TotalReleased: released,
+		MaxLag:        q.lag.max,
+		Lateness:      q.lag.histogram(),
+		State:         q.state(),
+		Running:       q.isRunning(),
+		Active:        q.isActive(),
+		Held:          q.held,
+		HoldReason:    q.holdReason,
+		Holds:         holds,
+		HeldMessages:  q.heldMessages.Len(),
+		Unacked:       len(q.unacked),
+	}
+	if pending := released + uint64(result.Size); pushed > pending {
+		result.TotalRemoved = pushed - pending
+	}
+	if q.lag.count > 0 {
+		result.AverageLatency = q.lag.total / time.Duration(q.lag.count)
+	}
+	if message := q.peekMessage(); message != nil {
+		result.HeadAt = message.Time
+		if overdue := q.now().Sub(message.Time); overdue > 0 {
+			result.OldestOverdue = overdue
+		}
+	}
+	return result
+}
+
+//lagStats accumulates how late Messages are released.
+type lagStats struct {
+	count uint64
+	total time.Duration
+	max   time.Duration
+	//counts of lateness. see LatenessHistogram.
+	buckets [latenessBuckets]uint64
+}
+
+//recordLag adds the lateness of message, released at now or the time of its
+//Clock, to the lagStats of q and calls the WithLateCallback() function if message
+//is late.
+//It should only be called when q is locked.
+func (q *TimeQueue) recordLag(message *Message, now time.Time) {
+	if message.Clock != "" {
+		now = q.clockDomain(message.Clock).now
+	}
+	lag := now.Sub(message.Time)
+	if lag < 0 {
+		lag = 0
+	}
+	q.lag.count++
+	q.lag.buckets[latenessBucket(lag)]++
+	q.lag.total += lag
+	if lag > q.lag.max {
+		q.lag.max = lag
+	}
+	if q.config.lateCallback != nil && lag > q.config.lateThreshold {
+		q.config.lateCallback(*message, lag)
+	}
+}
@@ -0,0 +1,55 @@
+package timequeue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_Stats(t *testing.T) {
+	q := New()
+	at := time.Now().Add(time.Hour)
+	q.Push(at, 0)
+	q.StartInactive()
+	defer q.Stop()
+	q.Hold("incident")
+	q.HoldTopic("email", "provider outage")
+	want := Stats{
+		Size:         1,
+		HeadAt:       at,
+		TotalPushed:  1,
+		State:        StatePaused,
+		Running:      true,
+		Active:       false,
+		Held:         true,
+		HoldReason:   "incident",
+		Holds:        map[string]string{HoldTopicName("email"): "provider outage"},
+		HeldMessages: 0,
+	}
+	if stats := q.Stats(); !reflect.DeepEqual(stats, want) {
+		t.Errorf("q.Stats() = %+v WANT %+v", stats, want)
+	}
+}
+
+func TestTimeQueue_Stats_totals(t *testing.T) {
+	start := time.Date(2017, 3, 4, 2, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start))
+	q.Push(start.Add(time.Minute), 0)
+	q.Push(start.Add(2*time.Minute), 1)
+	q.Push(start.Add(3*time.Minute), 2)
+	q.Push(start.Add(4*time.Minute), 3)
+	q.Advance(start.Add(2 * time.Minute))
+	q.Remove(q.PeekMessage(), false)
+	stats := q.Stats()
+	if stats.Size != 1 || stats.TotalPushed != 4 || stats.TotalReleased != 2 || stats.TotalRemoved != 1 {
+		t.Errorf("q.Stats() totals = %v, %v, %v, %v WANT 1, 4, 2, 1", stats.Size, stats.TotalPushed, stats.TotalReleased, stats.TotalRemoved)
+	}
+	if stats.MaxLag != time.Minute || stats.AverageLatency != 30*time.Second {
+		t.Errorf("q.Stats() lag = %v, %v WANT %v, %v", stats.MaxLag, stats.AverageLatency, time.Minute, 30*time.Second)
+	}
+	q.Advance(start.Add(5 * time.Minute))
+	q.Push(start.Add(time.Minute), 4)
+	if stats := q.Stats(); !stats.HeadAt.Equal(start.Add(time.Minute)) || stats.OldestOverdue != 4*time.Minute {
+		t.Errorf("q.Stats() head = %v, %v WANT %v, %v", stats.HeadAt, stats.OldestOverdue, start.Add(time.Minute), 4*time.Minute)
+	}
+}
@@ -0,0 +1,182 @@
+package timequeue
+
+import "time"
+
+//Storage is the container of the pending Messages in a TimeQueue.
+//The default Storage is a binary heap ordered by Message Time. Other structures,
+//e.g. a pairing heap or skiplist, may be given to New() with WithStorage() to be
+//evaluated without changing how a TimeQueue releases Messages.
+//
+//A TimeQueue only calls the methods of its Storage while it is locked, so a
+//Storage need not be safe for use by multiple go-routines. A Storage must not
+//call any methods on its TimeQueue or modify the Messages it holds.
+type Storage interface {
+	//Push adds message. message is not already in the Storage.
+	Push(message *Message)
+	//Peek returns the Message with the earliest Time without removing it, or nil
+	//if the Storage is empty.
+	Peek() *Message
+	//PopDue removes and returns the Message with the earliest Time if that Time is
+	//before until. Otherwise it returns nil.
+	PopDue(until time.Time) *Message
+	//Remove removes message and returns whether or not it was in the Storage.
+	Remove(message *Message) bool
+	//Len returns the number of Messages in the Storage.
+	Len() int
+	//Each calls fn with every Message in the Storage in no particular order.
+	Each(fn func(message *Message))
+}
+
+//WithStorage sets the Storage of a new TimeQueue. storage must be empty.
+//WithStorage may not be given to Reconfigure().
+func WithStorage(storage Storage) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.storage = storage
+	}
+}
+
+//heapStorage is the default Storage that uses a messageHeap.
+type heapStorage struct {
+	mh *messageHeap
+}
+
+//newHeapStorage creates an empty heapStorage.
+func newHeapStorage() *heapStorage {
+	return &heapStorage{
+		mh: newMessageHeap(),
+	}
+}
+
+//Push adds message to s.
+func (s *heapStorage) Push(message *Message) {
+	s.mh.pushMessage(message)
+}
+
+//Peek returns the earliest Message in s.
+func (s *heapStorage) Peek() *Message {
+	return s.mh.peekMessage()
+}
+
+//PopDue removes and returns the earliest Message in s if it is before until.
+func (s *heapStorage) PopDue(until time.Time) *Message {
+	if message := s.mh.peekMessage(); message == nil || !message.Before(until) {
+		return nil
+	}
+	return s.mh.popMessage()
+}
+
+//Remove removes message from s.
+func (s *heapStorage) Remove(message *Message) bool {
+	return s.mh.removeMessage(message)
+}
+
+//Len returns the number of Messages in s.
+func (s *heapStorage) Len() int {
+	return s.mh.Len()
+}
+
+//Each calls fn with every Message in s.
+func (s *heapStorage) Each(fn func(message *Message)) {
+	for _, message := range s.mh.messages {
+		fn(message)
+	}
+}
+
+//pushStored adds message to q.storage, or the storage of its Clock, gives it an ID
+//if it does not already have one, and indexes its Key.
+//It should only be called when q is locked.
+func (q *TimeQueue) pushStored(message *Message) {
+	if message.ID == "" {
+		message.ID = newMessageID()
+	}
+	if message.TraceID == "" {
+		message.TraceID = newMessageID()
+	}
+	message.storage = q.storageFor(message)
+	message.storage.Push(message)
+	q.indexKey(message)
+	q.trackTenant(message)
+	message.pushSeq = q.counters.pushed.add(1)
+	q.storePush(message)
+	q.archiveChange(ArchivePushed, message)
+	q.logMessage("timequeue: push", message)
+}
+
+//restoreStored adds message, which was previously pushed to q, back to q.storage.
+//It should only be called when q is locked.
+func (q *TimeQueue) restoreStored(message *Message) {
+	message.storage = q.storageFor(message)
+	message.storage.Push(message)
+}
+
+//pushStoredValues creates a Message with t and data, adds it to q.storage, and
+//returns it.
+//It should only be called when q is locked.
+func (q *TimeQueue) pushStoredValues(t time.Time, data interface{}) *Message {
+	message := &Message{
+		Time: t,
+		Data: data,
+	}
+	q.pushStored(message)
+	return message
+}
+
+//peekStored returns the earliest Message in q.storage or nil if it is empty.
+//It should only be called when q is locked.
+func (q *TimeQueue) peekStored() *Message {
+	return q.storage.Peek()
+}
+
+//popStoredDue removes and returns the earliest Message in q.storage if it is
+//before until, or nil otherwise.
+//It should only be called when q is locked.
+func (q *TimeQueue) popStoredDue(until time.Time) *Message {
+	message := q.storage.PopDue(until)
+	if message != nil {
+		message.storage = nil
+	}
+	return message
+}
+
+//popStored removes and returns the earliest Message in q.storage or nil if it
+//is empty.
+//It should only be called when q is locked.
+func (q *TimeQueue) popStored() *Message {
+	message := q.storage.Peek()
+	if message != nil {
+		q.removeStored(message)
+	}
+	return message
+}
+
+//removeStored removes message from q.storage, or the storage of its Clock, and
+//returns whether or not it was there.
+//It should only be called when q is locked.
+func (q *TimeQueue) removeStored(message *Message) bool {
+	if q.isSpilled(message) {
+		q.unspill([]*Message{message})
+	}
+	if !q.inStorage(message) || !message.storage.Remove(message) {
+		return false
+	}
+	message.storage = nil
+	q.markRemoved(message)
+	q.storeRemove(message)
+	q.archiveChange(ArchiveRemoved, message)
+	return true
+}
+
+//isStored returns whether or not message is in q.storage or the storage of its
+//Clock, or is spilled out of q.storage.
+//It should only be called when q is locked.
+func (q *TimeQueue) isStored(message *Message) bool {
+	return q.isSpilled(message) || q.inStorage(message)
+}
+
+//inStorage returns whether or not message is in q.storage or the storage of its
+//Clock.
+//It should only be called when q is locked.
+func (q *TimeQueue) inStorage(message *Message) bool {
+	return message != nil && message.storage != nil && message.storage == q.storageFor(message)
+}
@@ -0,0 +1,113 @@
+package timequeue
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+//sliceStorage is a Storage that keeps Messages in an unsorted slice, used to
+//test that a TimeQueue only depends on the Storage interface.
+type sliceStorage struct {
+	messages []*Message
+}
+
+func (s *sliceStorage) Push(message *Message) {
+	s.messages = append(s.messages, message)
+}
+
+func (s *sliceStorage) earliest() int {
+	result := -1
+	for i, message := range s.messages {
+		if result < 0 || message.Before(s.messages[result].Time) {
+			result = i
+		}
+	}
+	return result
+}
+
+func (s *sliceStorage) Peek() *Message {
+	if i := s.earliest(); i >= 0 {
+		return s.messages[i]
+	}
+	return nil
+}
+
+func (s *sliceStorage) PopDue(until time.Time) *Message {
+	i := s.earliest()
+	if i < 0 || !s.messages[i].Before(until) {
+		return nil
+	}
+	message := s.messages[i]
+	s.messages = append(s.messages[:i], s.messages[i+1:]...)
+	return message
+}
+
+func (s *sliceStorage) Remove(message *Message) bool {
+	for i, m := range s.messages {
+		if m == message {
+			s.messages = append(s.messages[:i], s.messages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sliceStorage) Len() int {
+	return len(s.messages)
+}
+
+func (s *sliceStorage) Each(fn func(message *Message)) {
+	for _, message := range s.messages {
+		fn(message)
+	}
+}
+
+func TestWithStorage(t *testing.T) {
+	storage := &sliceStorage{}
+	q := New(WithStorage(storage))
+	now := time.Now()
+	for _, i := range []int{3, 1, 2} {
+		q.Push(now.Add(time.Duration(i)*10*time.Millisecond), i)
+	}
+	removed := q.Push(now.Add(time.Hour), "removed")
+	if storage.Len() != 4 {
+		t.Errorf("storage.Len() = %v WANT %v", storage.Len(), 4)
+	}
+	if !q.Remove(removed, false) || q.Remove(removed, false) {
+		t.Errorf("q.Remove() WANT true then false")
+	}
+
+	q.Start()
+	defer q.Stop()
+	result := []int{}
+	for i := 0; i < 3; i++ {
+		result = append(result, (<-q.Messages()).Data.(int))
+	}
+	if !sort.IntsAreSorted(result) || len(result) != 3 {
+		t.Errorf("released = %v WANT [1 2 3]", result)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_pushStored_ID(t *testing.T) {
+	q := New()
+	message := &Message{Time: time.Now(), ID: "test_id"}
+	q.PushMessage(message)
+	other := q.Push(time.Now(), 1)
+	if message.ID != "test_id" {
+		t.Errorf("message.ID = %q WANT %q", message.ID, "test_id")
+	}
+	if other.ID == "" || other.ID == message.ID {
+		t.Errorf("other.ID = %q WANT unique non-empty", other.ID)
+	}
+}
+
+func TestTimeQueue_Reconfigure_storage(t *testing.T) {
+	q := New()
+	if err := q.Reconfigure(WithStorage(&sliceStorage{})); err != ErrNotReconfigurable {
+		t.Errorf("q.Reconfigure(WithStorage()) = %v WANT %v", err, ErrNotReconfigurable)
+	}
+}
@@ -0,0 +1,136 @@
+package timequeue
+
+//Store is a durable backend that a TimeQueue writes through to, so that its
+//pending Messages survive a restart. The Storage of a TimeQueue remains the
+//source of truth while it runs and a Store is only read when a TimeQueue is
+//created with WithStore(). WAL is the Store provided by this package, and others,
+//e.g. a database table, may be given to New() without changing how a TimeQueue
+//releases Messages.
+//
+//A TimeQueue calls the methods of its Store while it is locked, so a Store should
+//return quickly and need not be safe for use by multiple go-routines unless it is
+//shared with other code. A Store must not call any methods on its TimeQueue or
+//modify or keep the Messages it is given.
+//
+//Messages pushed with ScheduleFunc() or PushQueue(), or with a Clock, are never
+//written to a Store, and recurring Messages are loaded as single Messages, since
+//their functions, Schedules, and virtual clocks cannot be persisted.
+type Store interface {
+	//Append records that message, which has an ID, is pending.
+	Append(message *Message) error
+	//Remove records that the Message with id is no longer pending because it was
+	//released, or acknowledged in ack mode, or removed.
+	Remove(id string) error
+	//LoadAll returns new Messages for every pending Message in the Store.
+	LoadAll() ([]*Message, error)
+	//Compact discards everything the Store keeps for Messages that are no longer
+	//pending.
+	Compact() error
+}
+
+//ClaimStore is a Store that may be shared by the TimeQueues of several processes,
+//each of which loads every Message in the Store when it is created. Before a
+//TimeQueue releases a due Message, it claims the Message from its ClaimStore, and
+//the Message is dropped instead of released if another TimeQueue claimed it first.
+//Therefore every Message is released by only one of the TimeQueues that share a
+//ClaimStore.
+//
+//Only Messages released because their Time passed are claimed. Messages released
+//explicitly, e.g. with Pop() or Remove(), are not. A ClaimStore should not be
+//used in ack mode, since released Messages are kept in the Store until they are
+//acknowledged and could be claimed again.
+type ClaimStore interface {
+	Store
+	//Claim atomically removes the Message with id and returns true, or returns
+	//false if it was already removed.
+	Claim(id string) (bool, error)
+}
+
+//LoadStore is a Store that can load a single pending Message by its ID. A
+//TimeQueue given WithMemoryPressure() loads its spilled Messages back from a
+//LoadStore one at a time instead of loading every pending Message with LoadAll().
+type LoadStore interface {
+	Store
+	//Load returns a new Message for the pending Message with id, or nil if there
+	//is none.
+	Load(id string) (*Message, error)
+}
+
+//WithStore causes a new TimeQueue to write every push, release, and removal
+//through to store and to start with the Messages loaded from store. store must
+//not be used by more than one TimeQueue.
+//
+//Most TimeQueue methods cannot return errors, so the first error returned by
+//store is kept and returned from Healthy().
+//WithStore may not be given to Reconfigure().
+func WithStore(store Store) Option {
+	return func(c *config) {
+		c.fixed = true
+		c.store = store
+	}
+}
+
+//loadStore pushes the Messages loaded from q.config.store, if any, and then starts
+//writing through to it.
+//It should only be called when q is locked.
+func (q *TimeQueue) loadStore() {
+	if q.config.store == nil {
+		return
+	}
+	messages, err := q.config.store.LoadAll()
+	q.storeFailed(err)
+	q.loadCursor(q.config.store)
+	//loaded Messages are already in the Store.
+	for _, message := range messages {
+		q.pushStored(message)
+	}
+	q.storeSize()
+	q.store = q.config.store
+}
+
+//storePush writes message to q.store, if any.
+//It should only be called when q is locked.
+func (q *TimeQueue) storePush(message *Message) {
+	if q.store != nil && message.fn == nil && message.Clock == "" {
+		q.storeFailed(q.store.Append(message))
+	}
+}
+
+//storeRemove removes message from q.store, if any.
+//It should only be called when q is locked.
+func (q *TimeQueue) storeRemove(message *Message) {
+	if q.store != nil && message.fn == nil && message.Clock == "" {
+		q.storeFailed(q.store.Remove(message.ID))
+	}
+}
+
+//claim claims message from q.store if it is a ClaimStore and returns whether or
+//not q may release message. message is released if claiming it fails so that it
+//is not lost.
+//It should only be called when q is locked.
+func (q *TimeQueue) claim(message *Message) bool {
+	cs, ok := q.store.(ClaimStore)
+	if !ok || message.fn != nil {
+		return true
+	}
+	claimed, err := cs.Claim(message.ID)
+	if err != nil {
+		q.storeFailed(err)
+		return true
+	}
+	if !claimed {
+		q.unindexKey(message)
+		q.untrackTenant(message)
+		q.archiveChange(ArchiveRemoved, message)
+		q.finalize(message, DropClaimed)
+	}
+	return claimed
+}
+
+//storeFailed keeps err if it is the first error returned by q.store.
+//It should only be called when q is locked.
+func (q *TimeQueue) storeFailed(err error) {
+	if q.storeErr == nil {
+		q.storeErr = err
+	}
+}
@@ -0,0 +1,75 @@
+package timequeue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+//memoryStore is a Store that keeps the IDs and Data of pending Messages in a map.
+type memoryStore struct {
+	pending map[string]interface{}
+	err     error
+}
+
+func (s *memoryStore) Append(message *Message) error {
+	s.pending[message.ID] = message.Data
+	return s.err
+}
+
+func (s *memoryStore) Remove(id string) error {
+	delete(s.pending, id)
+	return s.err
+}
+
+func (s *memoryStore) LoadAll() ([]*Message, error) {
+	result := []*Message{}
+	for id, data := range s.pending {
+		result = append(result, &Message{ID: id, Time: time.Now(), Data: data})
+	}
+	return result, s.err
+}
+
+func (s *memoryStore) Compact() error {
+	return s.err
+}
+
+func TestWithStore(t *testing.T) {
+	store := &memoryStore{pending: map[string]interface{}{"loaded": "loaded"}}
+	q := New(WithStore(store))
+	if message := q.PeekMessage(); q.Size() != 1 || message.ID != "loaded" {
+		t.Fatalf("loaded = %v WANT loaded", message)
+	}
+	pending := q.Push(time.Now().Add(time.Hour), "pending")
+	removed := q.Push(time.Now().Add(time.Hour), "removed")
+	q.ScheduleFunc(time.Now().Add(time.Hour), func(Message) {})
+	q.Remove(removed, false)
+	q.Start()
+	<-q.Messages()
+	q.Stop()
+	if len(store.pending) != 1 || store.pending[pending.ID] != "pending" {
+		t.Errorf("store.pending = %v WANT %v", store.pending, pending.ID)
+	}
+
+	if err := q.Reconfigure(WithStore(&memoryStore{})); err != ErrNotReconfigurable {
+		t.Errorf("Reconfigure(WithStore()) = %v WANT %v", err, ErrNotReconfigurable)
+	}
+}
+
+func TestWithStore_error(t *testing.T) {
+	err := errors.New("store")
+	store := &memoryStore{pending: map[string]interface{}{}}
+	q := New(WithStore(store))
+	q.Start()
+	defer q.Stop()
+	if got := q.Healthy(); got != nil {
+		t.Fatalf("Healthy() = %v WANT nil", got)
+	}
+	store.err = err
+	q.Push(time.Now().Add(time.Hour), 1)
+	store.err = errors.New("later")
+	q.Push(time.Now().Add(time.Hour), 2)
+	if got := q.Healthy(); got != err {
+		t.Errorf("Healthy() = %v WANT %v", got, err)
+	}
+}
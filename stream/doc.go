@@ -0,0 +1,22 @@
+//Package stream provides composable adapters over channels such as the one
+//returned from timequeue.TimeQueue.Messages().
+//
+//Every adapter spawns a single go-routine that reads from its input channel and
+//sends on the returned channel(s). The returned channels are closed when the input
+//channel is closed or when ctx is done, so shutdown propagates down a pipeline
+//from either end:
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	due := stream.Filter(ctx, q.Messages(), func(m *timequeue.Message) bool {
+//		return m.Topic == "email"
+//	})
+//	emails := stream.Map(ctx, stream.Throttle(ctx, due, time.Second), func(m *timequeue.Message) Email {
+//		return m.Data.(Email)
+//	})
+//	for email := range emails {
+//		send(email)
+//	}
+//
+//TimeQueue.Messages() is never closed, so pipelines reading from it must be
+//stopped with ctx.
+package stream
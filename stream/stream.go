@@ -0,0 +1,147 @@
+//go:build go1.18
+// +build go1.18
+
+package stream
+
+import (
+	"context"
+	"time"
+)
+
+//send sends value on out and returns true, or returns false if ctx is done first.
+func send[T any](ctx context.Context, out chan<- T, value T) bool {
+	select {
+	case out <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+//receive receives a value from in. ok is false if in is closed or ctx is done.
+func receive[T any](ctx context.Context, in <-chan T) (value T, ok bool) {
+	select {
+	case value, ok = <-in:
+		return value, ok
+	case <-ctx.Done():
+		return value, false
+	}
+}
+
+//Map returns a channel of the results of calling fn with every value received
+//from in.
+func Map[T, U any](ctx context.Context, in <-chan T, fn func(T) U) <-chan U {
+	out := make(chan U)
+	go func() {
+		defer close(out)
+		for {
+			value, ok := receive(ctx, in)
+			if !ok || !send(ctx, out, fn(value)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+//Filter returns a channel of the values received from in for which keep returns
+//true.
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			value, ok := receive(ctx, in)
+			if !ok {
+				return
+			}
+			if keep(value) && !send(ctx, out, value) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+//Buffer returns a channel with capacity size that receives every value from in.
+//This allows in to be drained while the consumer of the returned channel is busy.
+func Buffer[T any](ctx context.Context, in <-chan T, size int) <-chan T {
+	out := make(chan T, size)
+	go func() {
+		defer close(out)
+		for {
+			value, ok := receive(ctx, in)
+			if !ok || !send(ctx, out, value) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+//Throttle returns a channel that receives every value from in, sending at most
+//one value per interval: every value is sent at least interval after the previous
+//one was. An interval less than or equal to zero does not throttle.
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		//the earliest time at which the next value may be sent.
+		next := time.Time{}
+		for {
+			value, ok := receive(ctx, in)
+			if !ok || !sleepUntil(ctx, next) || !send(ctx, out, value) {
+				return
+			}
+			next = time.Now().Add(interval)
+		}
+	}()
+	return out
+}
+
+//sleepUntil waits until t and returns true, or returns false if ctx is done first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+//Tee returns n channels that each receive every value from in.
+//A value is sent to all n channels before the next value is received, so every
+//returned channel must be drained.
+func Tee[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outs := make([]chan T, n)
+	result := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		result[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for {
+			value, ok := receive(ctx, in)
+			if !ok {
+				return
+			}
+			for _, out := range outs {
+				if !send(ctx, out, value) {
+					return
+				}
+			}
+		}
+	}()
+	return result
+}
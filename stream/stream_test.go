@@ -0,0 +1,146 @@
+//go:build go1.18
+// +build go1.18
+
+package stream
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//source returns a channel that sends values and is then closed.
+func source[T any](values ...T) <-chan T {
+	c := make(chan T)
+	go func() {
+		defer close(c)
+		for _, value := range values {
+			c <- value
+		}
+	}()
+	return c
+}
+
+//collect receives all values from c until it is closed.
+func collect[T any](c <-chan T) []T {
+	result := []T{}
+	for value := range c {
+		result = append(result, value)
+	}
+	return result
+}
+
+func TestMap(t *testing.T) {
+	result := collect(Map(context.Background(), source(1, 2, 3), strconv.Itoa))
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(result, want) {
+		t.Errorf("Map() = %v WANT %v", result, want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	result := collect(Filter(context.Background(), source(1, 2, 3, 4), func(i int) bool {
+		return i%2 == 0
+	}))
+	if want := []int{2, 4}; !reflect.DeepEqual(result, want) {
+		t.Errorf("Filter() = %v WANT %v", result, want)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	out := Buffer(context.Background(), source(1, 2, 3), 3)
+	if c := cap(out); c != 3 {
+		t.Errorf("cap(Buffer()) = %v WANT %v", c, 3)
+	}
+	if result, want := collect(out), []int{1, 2, 3}; !reflect.DeepEqual(result, want) {
+		t.Errorf("Buffer() = %v WANT %v", result, want)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	interval := 10 * time.Millisecond
+	start := time.Now()
+	result := collect(Throttle(context.Background(), source(1, 2, 3), interval))
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(result, want) {
+		t.Errorf("Throttle() = %v WANT %v", result, want)
+	}
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("Throttle() elapsed = %v WANT at least %v", elapsed, 2*interval)
+	}
+}
+
+func TestThrottle_afterIdle(t *testing.T) {
+	interval := 20 * time.Millisecond
+	in := make(chan int)
+	out := Throttle(context.Background(), in, interval)
+	go func() {
+		defer close(in)
+		in <- 1
+		time.Sleep(3 * interval)
+		in <- 2
+		in <- 3
+	}()
+	<-out
+	<-out
+	second := time.Now()
+	<-out
+	//allows for the time between the send of 2 and its receipt.
+	if gap := time.Since(second); gap < interval-time.Millisecond {
+		t.Errorf("gap after idle = %v WANT at least %v", gap, interval)
+	}
+}
+
+func TestThrottle_noInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		result := collect(Throttle(context.Background(), source(1, 2, 3), interval))
+		if want := []int{1, 2, 3}; !reflect.DeepEqual(result, want) {
+			t.Errorf("Throttle(%v) = %v WANT %v", interval, result, want)
+		}
+	}
+}
+
+func TestTee(t *testing.T) {
+	outs := Tee(context.Background(), source(1, 2), 2)
+	results := make([][]int, len(outs))
+	wg := &sync.WaitGroup{}
+	for i, out := range outs {
+		wg.Add(1)
+		go func(i int, out <-chan int) {
+			defer wg.Done()
+			results[i] = collect(out)
+		}(i, out)
+	}
+	wg.Wait()
+	for i, result := range results {
+		if want := []int{1, 2}; !reflect.DeepEqual(result, want) {
+			t.Errorf("Tee()[%v] = %v WANT %v", i, result, want)
+		}
+	}
+}
+
+func TestPipeline_cancel(t *testing.T) {
+	q := timequeue.New()
+	q.Start()
+	defer q.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	data := Map(ctx, Filter(ctx, q.Messages(), func(m *timequeue.Message) bool {
+		return m.Topic == "keep"
+	}), func(m *timequeue.Message) interface{} {
+		return m.Data
+	})
+	q.PushMessage(&timequeue.Message{Time: time.Now(), Data: 0})
+	q.PushMessage(&timequeue.Message{Time: time.Now(), Data: 1, Topic: "keep"})
+	if value := <-data; value != 1 {
+		t.Errorf("<-data = %v WANT %v", value, 1)
+	}
+
+	cancel()
+	if _, ok := <-data; ok {
+		t.Errorf("data not closed after cancel")
+	}
+}
@@ -0,0 +1,222 @@
+package timequeue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	//ErrTenantPending is the Err of a *QuotaError for a Tenant with MaxPending
+	//Messages already in a TimeQueue.
+	ErrTenantPending = errors.New("timequeue: tenant pending quota exceeded")
+
+	//ErrTenantRate is the Err of a *QuotaError for a Tenant that has pushed Rate
+	//Messages in the current window.
+	ErrTenantRate = errors.New("timequeue: tenant rate quota exceeded")
+)
+
+//QuotaError is returned from PushMessage() when a Message's Tenant is over quota.
+type QuotaError struct {
+	//Tenant is the Tenant of the rejected Message.
+	Tenant string
+	//Err is ErrTenantPending or ErrTenantRate.
+	Err error
+	//RetryAfter is the suggested duration after which to push again.
+	//For ErrTenantRate it is the time left in the current rate window. For
+	//ErrTenantPending it is the time until the Tenant's earliest pending Message
+	//is due, which is zero if it is already overdue.
+	RetryAfter time.Duration
+}
+
+//Error returns a description of the exceeded quota.
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v: %q", e.Err, e.Tenant)
+}
+
+//Unwrap returns e.Err.
+func (e *QuotaError) Unwrap() error {
+	return e.Err
+}
+
+//TenantQuota limits the Messages of a single Tenant in a TimeQueue.
+type TenantQuota struct {
+	//MaxPending is the maximum number of the Tenant's Messages in the TimeQueue.
+	//Zero or less is unlimited.
+	MaxPending int
+	//Rate is the maximum number of the Tenant's Messages pushed per Window.
+	//Windows are aligned to the zero time like those of WithBudget().
+	//A Rate or Window less than or equal to zero is unlimited.
+	Rate   int
+	Window time.Duration
+}
+
+//WithTenantQuota sets the TenantQuota of every Tenant that does not have its own
+//given to WithTenantQuotas(). There are no quotas by default.
+//
+//Quotas are checked by PushMessage() for Messages with a non-empty Tenant.
+//Messages pushed by a TimeQueue itself, e.g. recurring occurrences, and Messages
+//retried by a Dispatcher, i.e. with Attempts() greater than zero, count towards
+//their Tenant's pending Messages but are never rejected.
+func WithTenantQuota(quota TenantQuota) Option {
+	return func(c *config) {
+		c.tenantQuota = quota
+	}
+}
+
+//WithTenantQuotas sets the TenantQuota of individual Tenants. quotas is copied.
+func WithTenantQuotas(quotas map[string]TenantQuota) Option {
+	copied := make(map[string]TenantQuota, len(quotas))
+	for tenant, quota := range quotas {
+		copied[tenant] = quota
+	}
+	return func(c *config) {
+		c.tenantQuotas = copied
+	}
+}
+
+//TenantStats is a snapshot of the state of a single Tenant in a TimeQueue.
+type TenantStats struct {
+	//Pending is the number of the Tenant's Messages in the TimeQueue.
+	Pending int
+	//Pushed is the number of the Tenant's Messages pushed to the TimeQueue.
+	Pushed uint64
+	//Rejected is the number of the Tenant's Messages rejected with a *QuotaError.
+	Rejected uint64
+}
+
+//TenantStats returns the TenantStats of every Tenant that has pushed a Message to
+//q, keyed by Tenant.
+func (q *TimeQueue) TenantStats() map[string]TenantStats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	result := make(map[string]TenantStats, len(q.tenants))
+	for name, t := range q.tenants {
+		result[name] = TenantStats{
+			Pending:  q.tenantPending(t),
+			Pushed:   t.pushed,
+			Rejected: t.rejected,
+		}
+	}
+	return result
+}
+
+//tenant is the state of a single Tenant in a TimeQueue.
+type tenant struct {
+	//the Tenant's Messages that have been pushed. may contain stale entries for
+	//Messages that have left the TimeQueue.
+	pending map[*Message]struct{}
+	//the size of pending after stale entries were last removed.
+	pruned int
+	//the start of the current rate window and the pushes admitted in it.
+	window time.Time
+	pushes int
+	//running totals for TenantStats.
+	pushed   uint64
+	rejected uint64
+}
+
+//tenantQuota returns the TenantQuota of name.
+//It should only be called when q is locked.
+func (q *TimeQueue) tenantQuota(name string) TenantQuota {
+	if quota, ok := q.config.tenantQuotas[name]; ok {
+		return quota
+	}
+	return q.config.tenantQuota
+}
+
+//tenantNamed returns the state of the Tenant name, creating it if needed.
+//It should only be called when q is locked.
+func (q *TimeQueue) tenantNamed(name string) *tenant {
+	t, ok := q.tenants[name]
+	if !ok {
+		t = &tenant{pending: map[*Message]struct{}{}}
+		q.tenants[name] = t
+	}
+	return t
+}
+
+//admitTenant returns a *QuotaError if pushing message would exceed the quota of
+//its Tenant, and otherwise counts message against the quota.
+//It should only be called when q is locked.
+func (q *TimeQueue) admitTenant(message *Message) error {
+	if message.Tenant == "" || message.attempts > 0 {
+		return nil
+	}
+	t := q.tenantNamed(message.Tenant)
+	quota := q.tenantQuota(message.Tenant)
+	now := q.now()
+	var err error
+	var retryAfter time.Duration
+	if quota.MaxPending > 0 && q.tenantPending(t) >= quota.MaxPending {
+		err = ErrTenantPending
+		if earliest := tenantEarliest(t); earliest.After(now) {
+			retryAfter = earliest.Sub(now)
+		}
+	} else if quota.Rate > 0 && quota.Window > 0 {
+		window := now.Truncate(quota.Window)
+		if !window.Equal(t.window) {
+			t.window, t.pushes = window, 0
+		}
+		if t.pushes >= quota.Rate {
+			err = ErrTenantRate
+			retryAfter = window.Add(quota.Window).Sub(now)
+		} else {
+			t.pushes++
+		}
+	}
+	if err != nil {
+		t.rejected++
+		return &QuotaError{Tenant: message.Tenant, Err: err, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+//tenantEarliest returns the earliest Time of the pending Messages of t, which
+//must not contain stale entries, or the zero Time if there are none.
+func tenantEarliest(t *tenant) time.Time {
+	earliest := time.Time{}
+	for message := range t.pending {
+		if earliest.IsZero() || message.Time.Before(earliest) {
+			earliest = message.Time
+		}
+	}
+	return earliest
+}
+
+//tenantPending removes the stale entries of t and returns its number of pending
+//Messages.
+//It should only be called when q is locked.
+func (q *TimeQueue) tenantPending(t *tenant) int {
+	for message := range t.pending {
+		if !q.contains(message) {
+			delete(t.pending, message)
+		}
+	}
+	t.pruned = len(t.pending)
+	return t.pruned
+}
+
+//trackTenant counts message as pending for its Tenant, if it has one.
+//It should only be called when q is locked.
+func (q *TimeQueue) trackTenant(message *Message) {
+	if message.Tenant == "" {
+		return
+	}
+	t := q.tenantNamed(message.Tenant)
+	t.pending[message] = struct{}{}
+	t.pushed++
+	//Messages removed without being released leave stale entries. remove them
+	//whenever pending doubles so that they do not accumulate.
+	if len(t.pending) > 2*t.pruned+16 {
+		q.tenantPending(t)
+	}
+}
+
+//untrackTenant stops counting message as pending for its Tenant.
+//It should only be called when q is locked.
+func (q *TimeQueue) untrackTenant(message *Message) {
+	if t, ok := q.tenants[message.Tenant]; ok {
+		delete(t.pending, message)
+	}
+}
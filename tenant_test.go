@@ -0,0 +1,100 @@
+package timequeue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeQueue_PushMessage_tenantPending(t *testing.T) {
+	q := New(WithTenantQuota(TenantQuota{MaxPending: 2}), WithTenantQuotas(map[string]TenantQuota{
+		"big": {MaxPending: 3},
+	}))
+	at := time.Now().Add(time.Hour)
+	push := func(tenant string) error {
+		return q.PushMessage(&Message{Time: at, Tenant: tenant})
+	}
+	for i := 0; i < 2; i++ {
+		if err := push("small"); err != nil {
+			t.Fatalf("push() = %v WANT nil", err)
+		}
+	}
+	err := push("small")
+	if qe, ok := err.(*QuotaError); !ok || qe.Tenant != "small" || !errors.Is(err, ErrTenantPending) {
+		t.Errorf("push() = %v WANT *QuotaError for %q with %v", err, "small", ErrTenantPending)
+	}
+	for i := 0; i < 3; i++ {
+		if err := push("big"); err != nil {
+			t.Errorf("push() = %v WANT nil", err)
+		}
+	}
+	if err := push(""); err != nil {
+		t.Errorf("push() = %v WANT nil", err)
+	}
+
+	q.Pop(false)
+	if err := push("small"); err != nil {
+		t.Errorf("push() after Pop() = %v WANT nil", err)
+	}
+	stats := q.TenantStats()
+	if want := (TenantStats{Pending: 2, Pushed: 3, Rejected: 1}); stats["small"] != want {
+		t.Errorf("q.TenantStats()[small] = %+v WANT %+v", stats["small"], want)
+	}
+	if want := (TenantStats{Pending: 3, Pushed: 3}); stats["big"] != want {
+		t.Errorf("q.TenantStats()[big] = %+v WANT %+v", stats["big"], want)
+	}
+	if len(stats) != 2 {
+		t.Errorf("len(q.TenantStats()) = %v WANT %v", len(stats), 2)
+	}
+}
+
+func TestTimeQueue_PushMessage_tenantRate(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithManualAdvance(start), WithTenantQuota(TenantQuota{Rate: 2, Window: time.Minute}))
+	push := func() error {
+		return q.PushMessage(&Message{Time: start, Tenant: "a"})
+	}
+	push()
+	push()
+	if err := push(); !errors.Is(err, ErrTenantRate) {
+		t.Errorf("push() = %v WANT %v", err, ErrTenantRate)
+	}
+	if released := q.Advance(start.Add(time.Minute)); len(released) != 2 {
+		t.Errorf("len(q.Advance()) = %v WANT %v", len(released), 2)
+	}
+	if err := push(); err != nil {
+		t.Errorf("push() in next window = %v WANT nil", err)
+	}
+	retried := &Message{Time: start, Tenant: "a", attempts: 1}
+	push()
+	if err := q.PushMessage(retried); err != nil {
+		t.Errorf("q.PushMessage(retried) = %v WANT nil", err)
+	}
+}
+
+func TestQuotaError_RetryAfter(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		quota      TenantQuota
+		times      []time.Duration
+		now        time.Duration
+		err        error
+		retryAfter time.Duration
+	}{
+		{TenantQuota{MaxPending: 2}, []time.Duration{time.Hour, time.Minute}, 0, ErrTenantPending, time.Minute},
+		{TenantQuota{MaxPending: 1}, []time.Duration{time.Second}, 0, ErrTenantPending, time.Second},
+		{TenantQuota{Rate: 2, Window: time.Minute}, []time.Duration{time.Hour, time.Hour}, 20 * time.Second, ErrTenantRate, 40 * time.Second},
+	}
+	for i, test := range tests {
+		q := New(WithManualAdvance(start), WithTenantQuota(test.quota))
+		q.Advance(start.Add(test.now))
+		for _, d := range test.times {
+			q.PushMessage(&Message{Time: start.Add(d), Tenant: "a"})
+		}
+		err := q.PushMessage(&Message{Time: start.Add(time.Hour), Tenant: "a"})
+		qe, ok := err.(*QuotaError)
+		if !ok || qe.Err != test.err || qe.RetryAfter != test.retryAfter {
+			t.Errorf("%v: q.PushMessage() = %#v WANT %v with RetryAfter %v", i, err, test.err, test.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package timequeue
+
+import "time"
+
+//TieredStorage is a Storage that keeps only the Messages due within a horizon in
+//an in-memory heap and spills all later Messages to another Storage, e.g. an
+//MmapStorage. Far Messages are loaded back into memory as their horizon
+//approaches. This bounds the memory used by schedules that span months.
+//
+//TieredStorage inherits the limitations of its far Storage for Messages that are
+//spilled to it.
+type TieredStorage struct {
+	horizon time.Duration
+	near    *heapStorage
+	far     Storage
+	//returns the current time. replaced in tests.
+	now func() time.Time
+}
+
+//NewTieredStorage creates an empty TieredStorage that spills Messages due more
+//than horizon from now to far. far must be empty.
+func NewTieredStorage(horizon time.Duration, far Storage) *TieredStorage {
+	return &TieredStorage{
+		horizon: horizon,
+		near:    newHeapStorage(),
+		far:     far,
+		now:     time.Now,
+	}
+}
+
+//cutoff returns the time before which Messages are kept in memory.
+func (s *TieredStorage) cutoff() time.Time {
+	return s.now().Add(s.horizon)
+}
+
+//load moves all far Messages that are due before the cutoff into memory.
+func (s *TieredStorage) load() {
+	cutoff := s.cutoff()
+	for message := s.far.PopDue(cutoff); message != nil; message = s.far.PopDue(cutoff) {
+		s.near.Push(message)
+	}
+}
+
+//Push adds message to memory if it is due within the horizon and to the far
+//Storage otherwise.
+func (s *TieredStorage) Push(message *Message) {
+	if message.Before(s.cutoff()) {
+		s.near.Push(message)
+		return
+	}
+	s.far.Push(message)
+}
+
+//Peek returns the earliest Message in s, loading far Messages into memory first.
+func (s *TieredStorage) Peek() *Message {
+	s.load()
+	if message := s.near.Peek(); message != nil {
+		return message
+	}
+	return s.far.Peek()
+}
+
+//PopDue removes and returns the earliest Message in s if it is before until.
+func (s *TieredStorage) PopDue(until time.Time) *Message {
+	s.load()
+	if message := s.near.PopDue(until); message != nil || s.near.Len() > 0 {
+		return message
+	}
+	return s.far.PopDue(until)
+}
+
+//Remove removes message from s.
+func (s *TieredStorage) Remove(message *Message) bool {
+	return s.near.Remove(message) || s.far.Remove(message)
+}
+
+//Len returns the number of Messages in s.
+func (s *TieredStorage) Len() int {
+	return s.near.Len() + s.far.Len()
+}
+
+//Each calls fn with every Message in s.
+func (s *TieredStorage) Each(fn func(message *Message)) {
+	s.near.Each(fn)
+	s.far.Each(fn)
+}
+
+//NearLen returns the number of Messages in memory.
+func (s *TieredStorage) NearLen() int {
+	return s.near.Len()
+}
+
+//FarLen returns the number of Messages in the far Storage.
+func (s *TieredStorage) FarLen() int {
+	return s.far.Len()
+}
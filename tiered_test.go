@@ -0,0 +1,53 @@
+package timequeue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTieredStorage(t *testing.T) {
+	now := time.Now()
+	far := newHeapStorage()
+	s := NewTieredStorage(time.Hour, far)
+	s.now = func() time.Time { return now }
+
+	soon := &Message{Time: now.Add(time.Minute), Data: "soon"}
+	later := &Message{Time: now.Add(2 * time.Hour), Data: "later"}
+	latest := &Message{Time: now.Add(3 * time.Hour), Data: "latest"}
+	for _, message := range []*Message{latest, soon, later} {
+		s.Push(message)
+	}
+	if s.NearLen() != 1 || s.FarLen() != 2 || s.Len() != 3 {
+		t.Errorf("s.NearLen(), s.FarLen(), s.Len() = %v, %v, %v WANT 1, 2, 3", s.NearLen(), s.FarLen(), s.Len())
+	}
+	if peek := s.Peek(); peek != soon {
+		t.Errorf("s.Peek() = %v WANT %v", peek, soon)
+	}
+
+	now = now.Add(90 * time.Minute)
+	if peek := s.Peek(); peek != soon || s.NearLen() != 2 {
+		t.Errorf("s.Peek(), s.NearLen() = %v, %v WANT %v, 2", peek, s.NearLen(), soon)
+	}
+	if message := s.PopDue(now); message != soon {
+		t.Errorf("s.PopDue() = %v WANT %v", message, soon)
+	}
+	if !s.Remove(latest) || s.FarLen() != 0 {
+		t.Errorf("s.Remove(latest) WANT true and empty far Storage")
+	}
+	if message := s.PopDue(now.Add(24 * time.Hour)); message != later {
+		t.Errorf("s.PopDue() = %v WANT %v", message, later)
+	}
+	if s.Len() != 0 {
+		t.Errorf("s.Len() = %v WANT 0", s.Len())
+	}
+}
+
+func TestTieredStorage_PopDue_farOnly(t *testing.T) {
+	now := time.Now()
+	s := NewTieredStorage(time.Minute, newHeapStorage())
+	message := &Message{Time: now.Add(time.Hour)}
+	s.Push(message)
+	if popped := s.PopDue(now.Add(2 * time.Hour)); popped != message {
+		t.Errorf("s.PopDue() = %v WANT %v", popped, message)
+	}
+}
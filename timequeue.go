@@ -33,6 +33,7 @@
 package timequeue
 
 import (
+	"errors"
 	"sync"
 	"time"
 )
@@ -40,6 +41,22 @@ import (
 const (
 	//DefaultCapacity is the default capacity used for Messages() channels in New().
 	DefaultCapacity = 1
+
+	//DefaultWedgeThreshold is the default amount of time the earliest Message in a
+	//releasing TimeQueue may be overdue before Healthy() reports an error.
+	DefaultWedgeThreshold = time.Duration(5) * time.Second
+)
+
+var (
+	//ErrNilMessage is returned when a nil *Message is given where one is required.
+	ErrNilMessage = errors.New("timequeue: nil message")
+
+	//ErrMessageQueued is returned when pushing a Message that is already in a TimeQueue.
+	ErrMessageQueued = errors.New("timequeue: message already queued")
+
+	//ErrMessageNotQueued is returned when a Message is required to be in a TimeQueue
+	//but is not.
+	ErrMessageNotQueued = errors.New("timequeue: message not queued")
 )
 
 //TimeQueue is a queue of Messages that releases its Messages when their
@@ -54,6 +71,8 @@ const (
 //is running or not. Start() and Stop() may be called as many times as desired,
 //but Messsages will be released only between calls to Start() and Stop(), i.e.
 //while the TimeQueue is running and IsRunning() returns true.
+//StartInactive() and Activate() may be used to run a TimeQueue without releasing
+//Messages until some later point in time.
 //
 //Calls to Pop(), PopAll(), and PopAllUntil() may be called to remove Messages
 //from a TimeQueue, but this is required for normal use.
@@ -66,55 +85,234 @@ type TimeQueue struct {
 	//requiring the TimeQueue be locked in all unexported methods and
 	//before all use of unexported fields.
 
-	//protects all other members of a TimeQueue.
-	lock *sync.Mutex
-
-	//the heap of Messages in the TimeQueue.
-	messages *messageHeap
-
-	//flag determining if the TimeQueue is running.
-	//should be true between calls to Start() and Stop() and false otherwise.
-	running bool
-	//signal that sends to stopChan or wakeChan to wake or stop the running go-routine.
-	wakeSignal *wakeSignal
+	//fields that are not reassigned after New(). they are read by producers and
+	//consumers alike, so they are kept off of the cache lines written while q is
+	//locked.
 
+	//protects all other members of a TimeQueue. padded to fill its own cache line.
+	lock *sync.Mutex
+	//the Messages in the TimeQueue that are waiting to be released.
+	storage Storage
+	//filter of all Keys pushed to q. nil if not configured. never reassigned.
+	bloom *bloomFilter
 	//the channel to send released Messages on. should be receive only in client code.
 	messageChan chan *Message
+	//the channels to round-robin released Messages across instead of messageChan.
+	//see WithOutputs().
+	outputs []chan *Message
+	//counts the released Messages that are being sent on, or held in the reorder
+	//buffer for, messageChan or outputs. see Dispatcher.Shutdown().
+	sends inFlight
 	//send to this channel to wake the running go-routine and release Messages.
-	wakeChan chan time.Time
+	wakeChan chan wake
 	//send to this channel to stop the running go-routine.
 	stopChan chan struct{}
-}
+	//running totals that are updated atomically. see Counters().
+	counters *counters
+	//calls the functions of Messages pushed with ScheduleFunc().
+	callbacks *callbacks
+	//calls the functions of Messages pushed with ScheduleFunc() when given
+	//WithPreciseCallbacks().
+	precise *precisePool
+	//the channel that dead-lettered Messages are sent on. see DeadLetters().
+	deadLetters chan *DeadLetter
+	//the virtual clocks of Messages with Clocks, by name. see AdvanceClock().
+	clocks map[string]*clockDomain
+	//the channel that records are replayed on. nil unless given WithReplay().
+	replays chan ReleasedRecord
+	//true once q has replayed records when first started. see WithReplay().
+	replayed bool
+	//the Store that operations are written through to. nil if none or while
+	//loading.
+	store Store
+
+	_ cacheLinePad
 
-//New creates a new *TimeQueue with a call to New(DefaultCapacity).
-func New() *TimeQueue {
-	return NewCapacity(DefaultCapacity)
+	//fields that are written while q is locked.
+
+	//the heap of due Messages that matched a selective hold.
+	heldMessages *messageHeap
+	//the lifecycle state of the TimeQueue. StateRunning between calls to Start()
+	//and Stop(). never StatePaused, which is derived from held and inactive.
+	//see State().
+	runState State
+	//the number of running go-routines that have been started and that have
+	//exited.
+	runs, exits uint64
+	//closed once the running go-routines of their generations exit. see Run().
+	exitWaiters []exitWaiter
+	//flag determining if a running TimeQueue has yet to be activated.
+	//Messages are only released while running and not inactive or held.
+	inactive bool
+	//flag determining if releases have been administratively held.
+	held bool
+	//the reason given for the current hold.
+	holdReason string
+	//selective holds keyed by name.
+	holds map[string]*hold
+	//the most recent administrative actions performed on q.
+	audits *auditLog
+	//the number of releases spent in the current budget window.
+	budget budget
+	//channels to notify when the earliest time in q changes.
+	headWatchers map[chan time.Time]struct{}
+	//the earliest time in q when head watchers were last notified.
+	lastHead time.Time
+	//the head watcher returned from HeadChanges(). nil until it is first called.
+	headChanges chan time.Time
+	//signal that sends to stopChan or wakeChan to wake or stop the running go-routine.
+	wakeSignal *wakeSignal
+	//the Messages in q with non-empty Keys. may contain stale entries.
+	keys map[string]*Message
+	//released Messages that have not been acknowledged, keyed by ID. see WithAckMode().
+	unacked map[string]*Message
+	//the Sequence() of the most recently released Message in ack mode.
+	releaseSeq uint64
+	//the greatest Sequence() of an acknowledged Message and the cursor last saved
+	//to store. see Cursor().
+	cursor      uint64
+	savedCursor uint64
+	//the timer that redelivers unacknowledged Messages at visibilityAt. nil if
+	//none are waiting. see WithVisibilityTimeout().
+	visibility   *time.Timer
+	visibilityAt time.Time
+	//the index in outputs of the next channel to release on.
+	outputIndex int
+	//the open Subscribers in the order they subscribed and the index of the next
+	//one to release a Message without an Affinity to.
+	subscribers     []*Subscriber
+	subscriberIndex int
+	//wraps the dispatch of released Messages. see Use().
+	middleware []Middleware
+	//see Events().
+	events     chan Event
+	drained    bool
+	overflowed bool
+	//see WithMemoryPressure(). pressureDone is closed to stop watching for
+	//pressure when q stops.
+	pressured    bool
+	spilled      map[string]*Message
+	pressureDone chan struct{}
+	//the current time of q when it is advanced manually. see WithManualAdvance().
+	manualNow time.Time
+	//the state of every Tenant that has pushed a Message to q.
+	tenants map[string]*tenant
+	//the number and descriptions of removals and releases that were inconsistent.
+	//see ConsistencyCheck().
+	inconsistencies int
+	problems        []string
+	//holds released Messages to send them in order. see WithReorderBuffer().
+	reorder *reorderBuffer
+	//measures ReleasesPerSecond. see Vars().
+	releaseRate *rateMeter
+	//how late Messages are released. see Stats().
+	lag lagStats
+	//the first error returned by store. see WithStore().
+	storeErr error
+	//the options q was created or reconfigured with.
+	config config
 }
 
-//NewCapacity creates a new *TimeQueue where the channel returned from Messages()
-//has the capacity given by capacity.
+//New creates a new *TimeQueue configured with opts.
+//The channel returned from Messages() has a capacity of DefaultCapacity unless
+//WithCapacity() is given.
 //The new TimeQueue is in the stopped state and has no Messages in it.
-func NewCapacity(capacity int) *TimeQueue {
-	return &TimeQueue{
-		lock:        &sync.Mutex{},
-		messages:    newMessageHeap(),
-		running:     false,
-		wakeSignal:  nil,
-		messageChan: make(chan *Message, capacity),
-		wakeChan:    make(chan time.Time),
-		stopChan:    make(chan struct{}),
+func New(opts ...Option) *TimeQueue {
+	c := newConfig()
+	c.apply(opts)
+	if c.storage == nil {
+		c.storage = newHeapStorage()
+	}
+	var bloom *bloomFilter
+	if c.bloomKeys > 0 {
+		bloom = newBloomFilter(c.bloomKeys, c.bloomRate)
+	}
+	q := &TimeQueue{
+		lock:         newPaddedMutex(),
+		storage:      c.storage,
+		heldMessages: newMessageHeap(),
+		runState:     StateStopped,
+		holds:        map[string]*hold{},
+		audits:       newAuditLog(c.auditCapacity),
+		headWatchers: map[chan time.Time]struct{}{},
+		keys:         map[string]*Message{},
+		unacked:      map[string]*Message{},
+		bloom:        bloom,
+		wakeSignal:   nil,
+		config:       c,
+		messageChan:  make(chan *Message, c.capacity),
+		outputs:      newOutputs(c.outputs, c.capacity),
+		wakeChan:     make(chan wake),
+		stopChan:     make(chan struct{}),
+		counters:     &counters{},
+		callbacks:    &callbacks{},
+		precise:      &precisePool{},
+		releaseRate:  &rateMeter{},
+		reorder:      &reorderBuffer{},
+		events:       make(chan Event, EventsCapacity),
+		drained:      true,
+		spilled:      map[string]*Message{},
+		deadLetters:  make(chan *DeadLetter, c.capacity),
+		manualNow:    c.manualStart,
+		tenants:      map[string]*tenant{},
+		clocks:       map[string]*clockDomain{},
 	}
+	q.reorder.sent = q.sent
+	if c.replayWindow > 0 {
+		q.replays = make(chan ReleasedRecord, c.capacity)
+	}
+	q.loadStore()
+	return q
+}
+
+//NewCapacity creates a new *TimeQueue with a call to New(WithCapacity(capacity)).
+func NewCapacity(capacity int) *TimeQueue {
+	return New(WithCapacity(capacity))
 }
 
 //Push creates and adds a Message to q with t and data. The created Message is returned.
 func (q *TimeQueue) Push(t time.Time, data interface{}) *Message {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	message := q.messages.pushMessageValues(t, data)
+	message := q.pushStoredValues(t, data)
 	q.afterHeapUpdate()
 	return message
 }
 
+//PushMessage adds message to q.
+//This allows fields other than Time and Data, e.g. Topic, to be set before message
+//is in q.
+//ErrNilMessage is returned if message is nil, ErrMessageQueued is returned if
+//message is already in a TimeQueue, ErrDuplicateKey is returned if another
+//Message with message's Key is in q, and a *QuotaError is returned if message's
+//Tenant is over quota (see WithTenantQuota()).
+func (q *TimeQueue) PushMessage(message *Message) error {
+	if message == nil {
+		return ErrNilMessage
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if message.mh != nil || message.storage != nil || q.isSpilled(message) {
+		return ErrMessageQueued
+	}
+	if err := q.admitMessage(message); err != nil {
+		return err
+	}
+	q.pushStored(message)
+	q.afterHeapUpdate()
+	return nil
+}
+
+//admitMessage returns ErrDuplicateKey if another Message with the Key of message
+//is in q, or a *QuotaError if message would exceed the quota of its Tenant.
+//It should only be called when q is locked.
+func (q *TimeQueue) admitMessage(message *Message) error {
+	if message.Key != "" && q.keyed(message.Key) != nil {
+		return ErrDuplicateKey
+	}
+	return q.admitTenant(message)
+}
+
 //Peek returns (without removing) the Time and Data fields from the earliest
 //Message in q.
 //If q is empty, then the zero Time and nil are returned.
@@ -137,7 +335,7 @@ func (q *TimeQueue) PeekMessage() *Message {
 //peekMessage is the unexported version of PeekMessage().
 //It should only be called when q is locked.
 func (q *TimeQueue) peekMessage() *Message {
-	return q.messages.peekMessage()
+	return q.peekStored()
 }
 
 //Pop removes and returns the earliest Message in q or nil if q is empty.
@@ -146,7 +344,7 @@ func (q *TimeQueue) peekMessage() *Message {
 func (q *TimeQueue) Pop(release bool) *Message {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	message := q.messages.popMessage()
+	message := q.popStored()
 	if message == nil {
 		return nil
 	}
@@ -157,17 +355,20 @@ func (q *TimeQueue) Pop(release bool) *Message {
 	return message
 }
 
-//PopAll removes and returns a slice of all Messages in q.
+//PopAll removes and returns a slice of all Messages in q, including those
+//held by a selective hold and those on virtual clocks.
 //The returned slice will be non-nil but empty if q is itseld empty.
 //If release is true, then all returned Messages will also be sent on the channel
 //returned from Messages().
 func (q *TimeQueue) PopAll(release bool) []*Message {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	result := make([]*Message, 0, q.messages.Len())
-	for message := q.messages.popMessage(); message != nil; message = q.messages.popMessage() {
+	q.unholdMessages(true)
+	result := make([]*Message, 0, q.storage.Len())
+	for message := q.popStored(); message != nil; message = q.popStored() {
 		result = append(result, message)
 	}
+	result = append(result, q.popClocked()...)
 	if release {
 		q.releaseCopyToChan(result)
 	}
@@ -175,6 +376,38 @@ func (q *TimeQueue) PopAll(release bool) []*Message {
 	return result
 }
 
+//Clear removes all Messages in q, including those held by a selective hold,
+//without releasing them. Their finalizers are called with DropCleared, see
+//Finalizer.
+//Returns the number of Messages removed.
+func (q *TimeQueue) Clear() int {
+	count := 0
+	q.administer("", AuditClear, "", func() error {
+		count = q.clear()
+		return nil
+	})
+	return count
+}
+
+//clear is the unexported version of Clear().
+//It should only be called when q is locked.
+func (q *TimeQueue) clear() int {
+	q.unholdMessages(true)
+	q.loadSpilled(time.Time{})
+	count := 0
+	for message := q.popStored(); message != nil; message = q.popStored() {
+		q.finalize(message, DropCleared)
+		count++
+	}
+	for _, message := range q.popClocked() {
+		q.finalize(message, DropCleared)
+		count++
+	}
+	q.resetKeys()
+	q.afterHeapUpdate()
+	return count
+}
+
 //PopAllUntil removes and returns a slice of Messages in q with Time fields before,
 //but not equal to, until.
 //If release is true, then all returned Messages will also be sent on the channel
@@ -188,12 +421,18 @@ func (q *TimeQueue) PopAllUntil(until time.Time, release bool) []*Message {
 //popAllUntil is the unexported verson of PopAllUntil.
 //It should only be called when q is locked.
 func (q *TimeQueue) popAllUntil(until time.Time, release bool) []*Message {
-	result := make([]*Message, 0, q.messages.Len())
-	for message := q.messages.peekMessage(); message != nil && message.Before(until); message = q.messages.peekMessage() {
-		result = append(result, q.messages.popMessage())
+	result := make([]*Message, 0, q.storage.Len())
+	for message := q.popStoredDue(until); message != nil; message = q.popStoredDue(until) {
+		q.markRemoved(message)
+		result = append(result, message)
 	}
 	if release {
 		q.releaseCopyToChan(result)
+	} else {
+		for _, message := range result {
+			q.storeRemove(message)
+			q.archiveChange(ArchiveRemoved, message)
+		}
 	}
 	q.afterHeapUpdate()
 	return result
@@ -208,7 +447,7 @@ func (q *TimeQueue) popAllUntil(until time.Time, release bool) []*Message {
 func (q *TimeQueue) Remove(message *Message, release bool) bool {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	removed := q.messages.removeMessage(message)
+	removed := q.removeStored(message) || q.removeHeld(message)
 	if removed && release {
 		q.releaseMessage(message)
 	}
@@ -216,12 +455,16 @@ func (q *TimeQueue) Remove(message *Message, release bool) bool {
 	return removed
 }
 
-//afterHeapUpdate ensures the earliest time is in the next wake signal, if q is running.
+//afterHeapUpdate ensures the earliest time is in the next wake signal, if q is
+//releasing, and notifies head watchers if the earliest time changed.
 //It should only be called when q is locked.
 func (q *TimeQueue) afterHeapUpdate() {
-	if q.isRunning() {
+	q.storeSize()
+	if q.isReleasing() {
 		q.updateAndSpawnWakeSignal()
 	}
+	q.notifyHead()
+	q.emitDrained()
 }
 
 //Messages returns the receive only channel that all Messages are released on.
@@ -251,21 +494,107 @@ func (q *TimeQueue) Messages() <-chan *Message {
 func (q *TimeQueue) Size() int {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	return q.messages.Len()
+	return q.size()
+}
+
+//size is the unexported version of Size.
+//It should only be called when q is locked.
+func (q *TimeQueue) size() int {
+	return q.storage.Len() + q.heldMessages.Len() + q.clockedLen() + len(q.spilled)
 }
 
 //Start spawns a new go-routine to listen for wake times of Messages and sets the
 //state to running.
 //If q is already running, then Start is a nop.
 func (q *TimeQueue) Start() {
-	q.lock.Lock()
-	defer q.lock.Unlock()
+	q.administer("", AuditStart, "", func() error {
+		q.start(false)
+		return nil
+	})
+}
+
+//StartInactive is like Start() except that q does not release any Messages until
+//a call to Activate().
+//This allows a service to load Messages into q (e.g. via ReceiveHandoff()) and
+//finish warming up before any timed work starts firing.
+//If q is already running, then StartInactive is a nop.
+func (q *TimeQueue) StartInactive() {
+	q.administer("", AuditStartInactive, "", func() error {
+		q.start(true)
+		return nil
+	})
+}
+
+//start is the unexported version of Start() and StartInactive().
+//It should only be called when q is locked.
+func (q *TimeQueue) start(inactive bool) {
 	if q.isRunning() {
 		return
 	}
 	q.setRunning(true)
-	go q.run()
-	q.updateAndSpawnWakeSignal()
+	q.inactive = inactive
+	q.emit(EventStarted, time.Time{})
+	q.replay()
+	if !q.config.manual {
+		q.runs++
+		go q.run()
+	}
+	if q.config.pressure != nil {
+		q.pressureDone = make(chan struct{})
+		go q.watchPressure(q.pressureDone)
+	}
+	q.afterHeapUpdate()
+}
+
+//Activate allows q to begin releasing Messages after a call to StartInactive().
+//If q is already active, then Activate is a nop.
+//If q is not running, then Activate has no effect on the next call to Start()
+//or StartInactive().
+func (q *TimeQueue) Activate() {
+	q.administer("", AuditActivate, "", func() error {
+		q.activate()
+		return nil
+	})
+}
+
+//activate is the unexported version of Activate().
+//It should only be called when q is locked.
+func (q *TimeQueue) activate() {
+	if !q.inactive {
+		return
+	}
+	q.inactive = false
+	q.afterHeapUpdate()
+}
+
+//ActivateOn spawns a go-routine that calls Activate() once ready is closed or
+//receives a value. This can be used to tie activation to a readiness signal.
+func (q *TimeQueue) ActivateOn(ready <-chan struct{}) {
+	go func() {
+		<-ready
+		q.Activate()
+	}()
+}
+
+//IsActive returns whether or not q is running and has been activated.
+//If IsActive returns true, then Messages are being released unless q is held.
+func (q *TimeQueue) IsActive() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.isActive()
+}
+
+//isActive is the unexported version of IsActive.
+//It should only be called when q is locked.
+func (q *TimeQueue) isActive() bool {
+	return q.isRunning() && !q.inactive
+}
+
+//isReleasing returns whether or not q should be releasing Messages as their
+//times pass.
+//It should only be called when q is locked.
+func (q *TimeQueue) isReleasing() bool {
+	return q.isActive() && !q.held
 }
 
 //IsRunning returns whether or not q is running. E.g. in between calls to Start()
@@ -280,12 +609,12 @@ func (q *TimeQueue) IsRunning() bool {
 //isRunning is the unexported version of IsRunning.
 //It should only be called when q is locked.
 func (q *TimeQueue) isRunning() bool {
-	return q.running
+	return q.runState == StateRunning
 }
 
 //run is the run loop of a TimeQueue.
 //It is an infinite loop that selects between q.wakeChan and q.stopChan.
-//If q.wakeChan is selected, then q.onWake() is called.
+//If q.wakeChan is selected, then q.onWakeSignal() is called.
 //If q.wakeStop is selected, then this method returns.
 //Note that this method does not spawn a new go-routine.
 //That should be done outside of run.
@@ -295,70 +624,314 @@ func (q *TimeQueue) isRunning() bool {
 func (q *TimeQueue) run() {
 	for {
 		select {
-		case wakeTime := <-q.wakeChan:
-			q.onWake(wakeTime)
+		case w := <-q.wakeChan:
+			q.onWakeSignal(w)
 		case <-q.stopChan:
+			q.exited()
 			return
 		}
 	}
 }
 
-//onWake should be called when q receives a value on q.wakeChan.
+//onWakeSignal wakes q with w unless w is stale, i.e. it is from a wakeSignal that
+//has since been replaced.
+//A wakeSignal may fire while it is being replaced, in which case it may still
+//send after it is killed, so every wake must be checked. Stale wakes are discarded
+//without locking q, and the check is repeated once q is locked in case the
+//wakeSignal was replaced in between.
+func (q *TimeQueue) onWakeSignal(w wake) {
+	if q.isStaleWake(w) {
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.isStaleWake(w) {
+		return
+	}
+	q.awaken(w.time)
+}
+
+//isStaleWake returns whether or not w is from a wakeSignal other than the most
+//recent one.
+func (q *TimeQueue) isStaleWake(w wake) bool {
+	return w.generation != q.counters.wakeGeneration.load()
+}
+
+//onWake wakes q as if the current wakeSignal fired at wakeTime.
 //Because onWake will be called from a go-routine that we spawned, we lock and
 //defer unlock on q since this acts like an exported method of sorts in that
 //it starts execution of unexported code from an outside go-routine.
+//All Messages due by the time q is locked are released, not just those due by
+//wakeTime, so that a backlog of due Messages is released in a single wake.
 func (q *TimeQueue) onWake(wakeTime time.Time) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	q.popAllUntil(wakeTime, true)
+	q.awaken(wakeTime)
+}
+
+//awaken is the unexported version of onWake().
+//It should only be called when q is locked.
+func (q *TimeQueue) awaken(wakeTime time.Time) {
+	//the wake signal has fired and must be replaced.
+	q.killWakeSignal()
+	if q.held {
+		//a wake signal may have fired right before the hold.
+		return
+	}
+	q.logEvent("timequeue: wake", "wake_time", wakeTime, "size", q.size())
+	q.releaseUntil(laterOf(wakeTime, q.now()))
+	q.storeSize()
 	q.updateAndSpawnWakeSignal()
+	q.notifyHead()
+}
+
+//releaseUntil removes all Messages in q with Time fields before until and
+//releases them, except for those that match a selective hold which are moved to
+//q.heldMessages.
+//Releasing stops early if the budget for the current window is spent or the
+//WithWakeBatch() limit is reached, and nothing is released during a Calendar
+//blackout.
+//It should only be called when q is locked.
+func (q *TimeQueue) releaseUntil(until time.Time) {
+	q.releaseCopyToChan(q.popDue(until))
+}
+
+//popDue removes and returns the Messages that releaseUntil() releases.
+//It should only be called when q is locked.
+func (q *TimeQueue) popDue(until time.Time) []*Message {
+	now := q.now()
+	result := make([]*Message, 0)
+	if _, ok := q.blackoutEnd(now); ok {
+		return result
+	}
+	if q.config.fairness != nil {
+		return q.popDueFair(now, until)
+	}
+	for message := q.peekStored(); message != nil && message.Before(until.Add(q.lead(message))); message = q.peekStored() {
+		due := until.Add(q.lead(message))
+		if q.isHeldMessage(message) {
+			q.heldMessages.pushMessage(q.popStoredDue(due))
+			continue
+		}
+		if message.expired(now) {
+			message = q.popStoredDue(due)
+			q.markRemoved(message)
+			q.expire(message)
+			continue
+		}
+		if q.config.wakeBatch > 0 && len(result) >= q.config.wakeBatch {
+			break
+		}
+		if !q.spendBudget(now, message.weight()) {
+			break
+		}
+		message = q.popStoredDue(due)
+		q.markRemoved(message)
+		if q.claim(message) {
+			result = append(result, message)
+		}
+	}
+	return result
 }
 
 //releaseMessage is a utility method that spawns a go-routine to send message on
-//q.messageChan so that that calling go-routine does not have to wait.
+//its output channel so that that calling go-routine does not have to wait.
+//Messages pushed with ScheduleFunc() have their function called instead.
 func (q *TimeQueue) releaseMessage(message *Message) {
+	q.afterRelease(message)
+	q.chain(func(message *Message) {
+		out := q.outputFor(message)
+		if out == nil {
+			return
+		}
+		q.send(out, message)
+	})(message)
+}
+
+//send spawns a go-routine that sends message on out. The send is counted in
+//q.sends until message is received.
+//It should only be called when q is locked.
+func (q *TimeQueue) send(out chan<- *Message, message *Message) {
+	q.sends.add()
 	go func() {
-		q.messageChan <- message
+		out <- message
+		q.sent()
 	}()
 }
 
-//releaseCopyToChan is a utility method that copies messages to a new, buffered
-//channel, and empties that new channel by sending every messsage on q.messageChan.
+//sent counts a released Message that was received from an output channel.
+func (q *TimeQueue) sent() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.sends.done()
+}
+
+//sendsIdle returns a channel that is closed once no released Messages are being
+//sent on, or held in the reorder buffer for, the output channels of q.
+func (q *TimeQueue) sendsIdle() <-chan struct{} {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.sends.wait()
+}
+
+//releaseCopyToChan is a utility method that copies messages to new, buffered
+//channels, one per output channel, and empties those new channels by sending every
+//messsage on its output channel.
+//Messages pushed with ScheduleFunc() have their function called instead.
 func (q *TimeQueue) releaseCopyToChan(messages []*Message) {
-	copyChan := make(chan *Message, len(messages))
-	for _, message := range messages {
+	copyChans := map[chan *Message]chan *Message{}
+	release := q.chain(func(message *Message) {
+		out := q.outputFor(message)
+		if out == nil {
+			return
+		}
+		copyChan, ok := copyChans[out]
+		if !ok {
+			copyChan = make(chan *Message, len(messages))
+			copyChans[out] = copyChan
+			q.releaseChan(out, copyChan)
+		}
+		q.sends.add()
 		copyChan <- message
+	})
+	for _, message := range messages {
+		q.afterRelease(message)
+		release(message)
+	}
+	for _, copyChan := range copyChans {
+		close(copyChan)
+	}
+}
+
+//afterRelease performs all work that results from message being released, e.g.
+//pushing the next occurrence of a recurring Message.
+//It should only be called when q is locked.
+func (q *TimeQueue) afterRelease(message *Message) {
+	q.markReleased(message)
+	now := q.now()
+	message.releaseSeq = q.counters.released.add(1)
+	q.recordLag(message, now)
+	q.logMessage("timequeue: release", message)
+	if q.config.ackMode {
+		//Messages stay in the Store until they are acknowledged.
+		q.storePush(message)
+	} else {
+		q.storeRemove(message)
+	}
+	q.unindexKey(message)
+	q.untrackTenant(message)
+	q.trackUnacked(message)
+	q.archive(ArchiveReleased, now, message)
+	if message.schedule != nil {
+		message.schedule.recur(message)
+	}
+	q.pushChildren(message, now)
+}
+
+//outputFor returns the output channel that message should be sent on, or nil if it
+//was dispatched otherwise, e.g. to a Subscriber or the reorder buffer.
+//It should only be called when q is locked.
+func (q *TimeQueue) outputFor(message *Message) chan *Message {
+	if q.dispatched(message) {
+		return nil
+	}
+	out := q.nextOutput()
+	q.emitOverflowed(out)
+	if q.config.reorderHold > 0 {
+		q.sends.add()
+		q.reorder.add(out, message, q.config.reorderHold)
+		return nil
 	}
-	q.releaseChan(copyChan)
-	close(copyChan)
+	return out
+}
+
+//dispatched calls the function of message if it was pushed with ScheduleFunc(),
+//or releases it to a Subscriber, and returns true. Returns false if message should
+//be sent on an output channel instead.
+//It should only be called when q is locked.
+func (q *TimeQueue) dispatched(message *Message) bool {
+	if message.fn == nil {
+		return q.subscribed(message)
+	}
+	if q.config.preciseWorkers > 0 {
+		q.precise.run(message, q.config.preciseWorkers, !q.config.manual)
+	} else {
+		q.callbacks.run(message)
+	}
+	return true
 }
 
 //releaseChan is a utility method that spawns a go-routine to send every message
-//in messages on q.messageChan.
+//in messages on out. Each send must already be counted in q.sends.
 //Note that releaseChan reads from messages until it is closed, thus messages must
 //be closed by the calling function.
-func (q *TimeQueue) releaseChan(messages <-chan *Message) {
+func (q *TimeQueue) releaseChan(out chan<- *Message, messages <-chan *Message) {
 	go func() {
 		for message := range messages {
-			q.messageChan <- message
+			out <- message
+			q.sent()
 		}
 	}()
 }
 
 //updateAndSpawnSignal kills the current wake signal if it exists
 //and creates and spawns the next wake signal if there are any messages left in q.
+//The current wake signal is kept instead if it wakes q no later than needed.
 //Returns true if a new wakeSignal was spawned, false otherwise.
 //It should only be called when q is locked.
 func (q *TimeQueue) updateAndSpawnWakeSignal() bool {
-	q.killWakeSignal()
 	message := q.peekMessage()
-	if message == nil {
+	if message == nil || q.config.manual {
+		q.killWakeSignal()
 		return false
 	}
-	q.setWakeSignal(newWakeSignal(q.wakeChan, message.Time))
+	wakeTime := q.wakeTime(message.Time.Add(-q.lead(message)), message.weight())
+	if q.wakeSignal != nil && !q.wakeSignal.wakeTime.After(laterOf(wakeTime, time.Now())) {
+		//the current wake signal fires no later than needed. replacing it would
+		//only create another timer and go-routine, e.g. for every past due push.
+		return false
+	}
+	q.killWakeSignal()
+	q.counters.wakeGeneration.add(1)
+	var ws *wakeSignal
+	if s := q.config.scheduler; s != nil {
+		ws = s.newWakeSignal(q.wakeChan, wakeTime, q.counters.wakeGeneration.load())
+	} else {
+		ws = newWakeSignal(q.wakeChan, wakeTime, q.counters.wakeGeneration.load())
+	}
+	ws.current = &q.counters.wakeGeneration
+	q.setWakeSignal(ws)
+	q.emit(EventTimerReset, wakeTime)
 	return q.spawnWakeSignal()
 }
 
+//laterOf returns the later of a and b.
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+//wakeTime returns the time at which q should wake to release a Message with
+//Time t. This is t unless releases are deferred by an exhausted budget or a
+//Calendar blackout.
+//It should only be called when q is locked.
+func (q *TimeQueue) wakeTime(t time.Time, weight int) time.Time {
+	now := q.now()
+	if deferred := q.budgetDeferral(now, weight); deferred.After(t) {
+		t = deferred
+	}
+	check := t
+	if now.After(check) {
+		check = now
+	}
+	if end, ok := q.blackoutEnd(check); ok {
+		return end
+	}
+	return t
+}
+
 //setWakeSignal sets q.wakeSignal to wakeSignal.
 //It should only be called when q is locked.
 func (q *TimeQueue) setWakeSignal(wakeSignal *wakeSignal) {
@@ -377,12 +950,15 @@ func (q *TimeQueue) spawnWakeSignal() bool {
 }
 
 //killWakeSignal call kill() and sets q.wakeSignal to nil if it is not nil.
+//The generation is advanced so that a wake already sent by the old wakeSignal
+//is stale.
 //Returns true if the old wakeSignal is not nil, false otherwise.
 //It should only be called when q is locked.
 func (q *TimeQueue) killWakeSignal() bool {
 	if q.wakeSignal != nil {
 		q.wakeSignal.kill()
 		q.wakeSignal = nil
+		q.counters.wakeGeneration.add(1)
 		return true
 	}
 	return false
@@ -393,60 +969,160 @@ func (q *TimeQueue) killWakeSignal() bool {
 //and the state to be set to not running.
 //If q is already stopped, then Stop is a nop.
 func (q *TimeQueue) Stop() {
-	q.lock.Lock()
-	defer q.lock.Unlock()
+	q.administer("", AuditStop, "", func() error {
+		q.stop()
+		return nil
+	})
+}
+
+//stop is the unexported version of Stop().
+//It should only be called when q is locked.
+func (q *TimeQueue) stop() {
 	if !q.isRunning() {
 		return
 	}
 	q.killWakeSignal()
 	q.setRunning(false)
+	q.logEvent("timequeue: stop", "size", q.size())
+	q.emit(EventStopped, time.Time{})
+	if q.pressureDone != nil {
+		close(q.pressureDone)
+		q.pressureDone = nil
+		q.pressured = false
+		q.loadSpilled(time.Time{})
+	}
+	if q.config.manual {
+		return
+	}
+	q.runState = StateDraining
 	go func() {
 		q.stopChan <- struct{}{}
 	}()
 }
 
-//setRunning is the unexported version of SetRunning. Sets q.running to running.
+//setRunning is the unexported version of SetRunning. Sets q.runState to
+//StateRunning if running is true and StateStopped otherwise.
 //It should only be called when q is locked.
 func (q *TimeQueue) setRunning(running bool) {
-	q.running = running
+	if running {
+		q.runState = StateRunning
+	} else {
+		q.runState = StateStopped
+	}
+}
+
+//maxWait is the longest that a single timer waits. Waits for later times are
+//chunked into waits of at most maxWait so that durations never overflow and
+//adjustments to the wall clock are noticed at least every maxWait.
+const maxWait = 1000 * time.Hour
+
+//waitDuration returns the duration to wait from now until t, which is zero if t
+//is not after now, and at most maxWait.
+func waitDuration(t, now time.Time) time.Duration {
+	if !t.After(now) {
+		return 0
+	}
+	//Sub saturates, so a t hundreds of years from now does not overflow.
+	if d := t.Sub(now); d < maxWait {
+		return d
+	}
+	return maxWait
+}
+
+//wake is the value sent by a wakeSignal when its time passes.
+type wake struct {
+	//the time at which the wakeSignal fired.
+	time time.Time
+	//the generation of the wakeSignal.
+	generation uint64
 }
 
-//wakeSignal represents a signal that sends a time.Time value after that time has passed.
+//wakeSignal represents a signal that sends a wake value after a time has passed.
 //wakeSignals can be killed, which will prevent the signal from sending its value.
 type wakeSignal struct {
-	dst  chan time.Time
-	src  <-chan time.Time
-	stop chan struct{}
+	dst   chan wake
+	timer *time.Timer
+	stop  chan struct{}
+	//the time at which timer fires.
+	wakeTime time.Time
+	//identifies the wakeSignal among all of those created for a TimeQueue.
+	generation uint64
+	//the generation of the most recent wakeSignal. the wakeSignal does not send
+	//if it is no longer the most recent. may be nil.
+	current *counter
+	//the SharedScheduler that fires the wakeSignal instead of timer. nil if none.
+	scheduler *SharedScheduler
+	//the index of the wakeSignal in its scheduler. notInIndex if not scheduled.
+	index int
 }
 
-//newWakeSignal create a wakeSignal that sends wakeTime on dst when wakeTime passes.
+//newWakeSignal create a wakeSignal that sends a wake with generation on dst when
+//wakeTime passes.
 //this function should be used to create wakeSignals.
 //the zero value wakeSignal is not valid.
-func newWakeSignal(dst chan time.Time, wakeTime time.Time) *wakeSignal {
+func newWakeSignal(dst chan wake, wakeTime time.Time, generation uint64) *wakeSignal {
 	return &wakeSignal{
-		dst:  dst,
-		src:  time.After(wakeTime.Sub(time.Now())),
-		stop: make(chan struct{}),
+		dst:        dst,
+		timer:      time.NewTimer(waitDuration(wakeTime, time.Now())),
+		stop:       make(chan struct{}),
+		wakeTime:   wakeTime,
+		generation: generation,
 	}
 }
 
-//spawn starts a new go-routine that selects on w.src and w.stop.
-//If w.src is selected, the received value is sent on w.dst.
-//If w.stop is selected, then the function stops selecting.
-//In both cases, w.src is set to nil and the function returns.
+//spawn starts a new go-routine that selects on w.timer and w.stop.
+//If w.timer fires before w.wakeTime, because the wait was chunked by maxWait, then
+//w.timer is reset for the remaining wait. Otherwise a wake is sent on w.dst unless
+//w.stop is closed first or w is not the current generation.
+//If w.stop is selected, then w.timer is stopped.
+//In all cases the go-routine returns, so a killed wakeSignal never blocks on w.dst
+//after its TimeQueue stops receiving.
+//A wakeSignal with a SharedScheduler is scheduled with it instead.
 func (w *wakeSignal) spawn() {
+	if w.scheduler != nil {
+		w.scheduler.add(w)
+		return
+	}
 	go func() {
-		select {
-		case wakeTime := <-w.src:
-			w.dst <- wakeTime
-		case <-w.stop:
+		for {
+			select {
+			case fired := <-w.timer.C:
+				if fired.Before(w.wakeTime) {
+					w.timer.Reset(waitDuration(w.wakeTime, time.Now()))
+					continue
+				}
+				w.send(fired)
+			case <-w.stop:
+				w.timer.Stop()
+			}
+			return
 		}
-		w.src = nil
 	}()
 }
 
-//kill closes the w.stop channel.
+//fire spawns a go-routine that sends a wake at fired. It is called by a
+//SharedScheduler so that it is never blocked by w.dst.
+func (w *wakeSignal) fire(fired time.Time) {
+	go w.send(fired)
+}
+
+//send sends a wake at fired on w.dst unless w.stop is closed first or w is not
+//the current generation.
+func (w *wakeSignal) send(fired time.Time) {
+	if w.current != nil && w.current.load() != w.generation {
+		return
+	}
+	select {
+	case w.dst <- wake{time: fired, generation: w.generation}:
+	case <-w.stop:
+	}
+}
+
+//kill closes the w.stop channel and unschedules w from its SharedScheduler.
 //This is NOT idempotent. I.e. kill should only be called once a single wakeSignal.
 func (w *wakeSignal) kill() {
 	close(w.stop)
+	if w.scheduler != nil {
+		w.scheduler.remove(w)
+	}
 }
@@ -16,14 +16,14 @@ func TestNew(t *testing.T) {
 
 func TestNewCapacity(t *testing.T) {
 	q := NewCapacity(2)
-	if size := q.messages.Len(); size != 0 {
+	if size := q.storage.Len(); size != 0 {
 		t.Errorf("NewSize() q.messges.Len() = %v WANT %v", size, 0)
 	}
 	if q.lock == nil {
 		t.Errorf("NewSize() lock should be non-nil")
 	}
-	if q.running == true {
-		t.Errorf("NewSize() running = %v WANT %v", q.running, false)
+	if q.runState != StateStopped {
+		t.Errorf("NewSize() runState = %v WANT %v", q.runState, StateStopped)
 	}
 	if q.wakeSignal != nil {
 		t.Errorf("NewSize() wakeSignal should be nil")
@@ -39,14 +39,14 @@ func TestNewCapacity(t *testing.T) {
 func TestTimeQueue_Push(t *testing.T) {
 	q := New()
 	message := q.Push(time.Time{}, "test_data")
-	size := q.messages.Len()
+	size := q.storage.Len()
 	if size != 1 {
-		t.Errorf("q.messages.Len() = %v WANT %v", size, 1)
+		t.Errorf("q.storage.Len() = %v WANT %v", size, 1)
 	}
 	if message == nil {
 		t.Errorf("message = nil WANT non-nil")
 	}
-	if message != q.messages.peekMessage() {
+	if message != q.peekStored() {
 		t.Errorf("return message should equal peek message")
 	}
 	if !message.Time.Equal(time.Time{}) {
@@ -57,6 +57,26 @@ func TestTimeQueue_Push(t *testing.T) {
 	}
 }
 
+func TestTimeQueue_PushMessage(t *testing.T) {
+	q := New()
+	message := &Message{Time: time.Now(), Data: "test_data", Topic: "test_topic"}
+	if err := q.PushMessage(message); err != nil {
+		t.Errorf("q.PushMessage() = %v WANT %v", err, nil)
+	}
+	if peek := q.PeekMessage(); peek != message {
+		t.Errorf("q.PeekMessage() = %v WANT %v", peek, message)
+	}
+	if err := q.PushMessage(message); err != ErrMessageQueued {
+		t.Errorf("q.PushMessage() = %v WANT %v", err, ErrMessageQueued)
+	}
+	if err := New().PushMessage(message); err != ErrMessageQueued {
+		t.Errorf("New().PushMessage() = %v WANT %v", err, ErrMessageQueued)
+	}
+	if err := q.PushMessage(nil); err != ErrNilMessage {
+		t.Errorf("q.PushMessage(nil) = %v WANT %v", err, ErrNilMessage)
+	}
+}
+
 func TestTimeQueue_Peek_nil(t *testing.T) {
 	q := New()
 	peekTime, data := q.Peek()
@@ -159,6 +179,21 @@ func TestTimeQueue_PopAll(t *testing.T) {
 	}
 }
 
+func TestTimeQueue_Clear(t *testing.T) {
+	q := New()
+	q.Push(time.Now(), 0)
+	q.Push(time.Now().Add(time.Hour), 1)
+	if count := q.Clear(); count != 2 {
+		t.Errorf("q.Clear() = %v WANT %v", count, 2)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+	if size := len(q.Messages()); size != 0 {
+		t.Errorf("len(q.Messages()) = %v WANT %v", size, 0)
+	}
+}
+
 func TestTimeQueue_PopAllUntil(t *testing.T) {
 	now := time.Now()
 	tests := []struct {
@@ -190,8 +225,8 @@ func TestTimeQueue_PopAllUntil(t *testing.T) {
 		if test.release && !areChannelMessagesEqual(q.Messages(), want) {
 			t.Errorf("q.PopAllUntil() Messages() sorted WANT %v", want)
 		}
-		if q.messages.Len() != len(test.messageValues)-test.untilCount {
-			t.Errorf("len(q.messages) = %v WANT %v", q.messages.Len(), len(test.messageValues)-test.untilCount)
+		if q.storage.Len() != len(test.messageValues)-test.untilCount {
+			t.Errorf("q.storage.Len() = %v WANT %v", q.storage.Len(), len(test.messageValues)-test.untilCount)
 		}
 		if len(q.Messages()) != 0 {
 			t.Errorf("len(q.Messages()) = %v WANT %v", len(q.Messages()), 0)
@@ -305,7 +340,7 @@ func TestTimeQueue_Start_running(t *testing.T) {
 func TestTimeQueue_run(t *testing.T) {
 	q := New()
 	go func() {
-		q.wakeChan <- time.Now()
+		q.wakeChan <- wake{time: time.Now()}
 		q.stopChan <- struct{}{}
 	}()
 	q.run()
@@ -341,7 +376,9 @@ func TestTimeQueue_popAllUntil(t *testing.T) {
 	for i := 4; i >= 0; i-- {
 		q.Push(now.Add(time.Duration(i)), i)
 	}
+	q.lock.Lock()
 	q.popAllUntil(now.Add(5), true)
+	q.lock.Unlock()
 	for i := 0; i <= 4; i++ {
 		message := <-q.Messages()
 		if message.Data != i {
@@ -358,7 +395,9 @@ func TestTimeQueue_popAllUntil(t *testing.T) {
 
 func TestTimeQueue_releaseMessage(t *testing.T) {
 	q := New()
-	q.releaseMessage(&Message{time.Now(), 0, nil, notInIndex})
+	q.lock.Lock()
+	q.releaseMessage(&Message{Time: time.Now(), Data: 0, mh: nil, index: notInIndex})
+	q.lock.Unlock()
 	if message := <-q.Messages(); message.Data != 0 {
 		t.Errorf("message.Data = %v WANT %v", message.Data, 0)
 	}
@@ -370,11 +409,13 @@ func TestTimeQueue_releaseCopyToChan(t *testing.T) {
 	}{
 		{nil},
 		{[]*Message{}},
-		{[]*Message{{time.Now(), 0, nil, notInIndex}, {time.Now(), 1, nil, notInIndex}}},
+		{[]*Message{{Time: time.Now(), Data: 0, mh: nil, index: notInIndex}, {Time: time.Now(), Data: 1, mh: nil, index: notInIndex}}},
 	}
 	for _, test := range tests {
 		q := New()
+		q.lock.Lock()
 		q.releaseCopyToChan(test.messages)
+		q.lock.Unlock()
 		for _, wantMessage := range test.messages {
 			if message := <-q.Messages(); message != wantMessage {
 				t.Errorf("q.Messages() = %v	WANT %v", message, wantMessage)
@@ -389,7 +430,7 @@ func TestTimeQueue_releaseChan(t *testing.T) {
 	}{
 		{nil},
 		{[]*Message{}},
-		{[]*Message{{time.Now(), 0, nil, notInIndex}, {time.Now(), 1, nil, notInIndex}}},
+		{[]*Message{{Time: time.Now(), Data: 0, mh: nil, index: notInIndex}, {Time: time.Now(), Data: 1, mh: nil, index: notInIndex}}},
 	}
 	for _, test := range tests {
 		q := New()
@@ -400,7 +441,12 @@ func TestTimeQueue_releaseChan(t *testing.T) {
 			}
 			close(out)
 		}()
-		q.releaseChan(out)
+		q.lock.Lock()
+		for range test.messages {
+			q.sends.add()
+		}
+		q.lock.Unlock()
+		q.releaseChan(q.messageChan, out)
 		for _, wantMessage := range test.messages {
 			if message := <-q.Messages(); message != wantMessage {
 				t.Errorf("q.Messages() = %v	WANT %v", message, wantMessage)
@@ -431,9 +477,47 @@ func TestTimeQueue_updateAndSpawnWakeSignal_nonEmpty(t *testing.T) {
 	}
 }
 
+func TestWaitDuration(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		t      time.Time
+		result time.Duration
+	}{
+		{now.Add(-time.Hour), 0},
+		{now, 0},
+		{now.Add(time.Hour), time.Hour},
+		{now.Add(maxWait + time.Hour), maxWait},
+		{time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), maxWait},
+		{time.Unix(1<<62, 0), maxWait},
+	}
+	for _, test := range tests {
+		if result := waitDuration(test.t, now); result != test.result {
+			t.Errorf("waitDuration(%v) = %v WANT %v", test.t, result, test.result)
+		}
+	}
+}
+
+func TestWakeSignal_spawn_chunked(t *testing.T) {
+	dst := make(chan wake)
+	wakeTime := time.Now().Add(50 * time.Millisecond)
+	ws := newWakeSignal(dst, wakeTime, 1)
+	//simulate a chunked wait by firing well before wakeTime.
+	ws.timer.Reset(time.Millisecond)
+	ws.spawn()
+	defer ws.kill()
+	select {
+	case w := <-dst:
+		if w.time.Before(wakeTime) {
+			t.Errorf("wake.time = %v WANT at or after %v", w.time, wakeTime)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wakeSignal did not send")
+	}
+}
+
 func TestTimeQueue_setWakeSignal(t *testing.T) {
 	q := New()
-	ws := newWakeSignal(q.wakeChan, time.Now())
+	ws := newWakeSignal(q.wakeChan, time.Now(), 1)
 	q.setWakeSignal(ws)
 	if q.wakeSignal != ws {
 		t.Errorf("q.wakeSignal = %v WANT %v", q.wakeSignal, ws)
@@ -449,7 +533,7 @@ func TestTimeQueue_spawnWakeSignal_nil(t *testing.T) {
 
 func TestTimeQueue_spawnWakeSignal_nonNil(t *testing.T) {
 	q := New()
-	ws := newWakeSignal(q.wakeChan, time.Now().Add(time.Duration(1)*time.Second))
+	ws := newWakeSignal(q.wakeChan, time.Now().Add(time.Duration(1)*time.Second), 1)
 	ws.kill()
 	q.setWakeSignal(ws)
 	if result := q.spawnWakeSignal(); result != true {
@@ -466,7 +550,7 @@ func TestTimeQueue_killWakeSignal_nil(t *testing.T) {
 
 func TestTimeQueue_killWakeSignal_nonNil(t *testing.T) {
 	q := New()
-	q.setWakeSignal(newWakeSignal(q.wakeChan, time.Now().Add(time.Duration(1)*time.Second)))
+	q.setWakeSignal(newWakeSignal(q.wakeChan, time.Now().Add(time.Duration(1)*time.Second), 1))
 	if result := q.killWakeSignal(); result != true {
 		t.Errorf("q.killWakeSignal() = %v WANT %v", result, true)
 	}
@@ -487,6 +571,54 @@ func TestTimeQueue_Stop_running(t *testing.T) {
 	}
 }
 
+func TestTimeQueue_StartInactive(t *testing.T) {
+	q := New()
+	q.Push(time.Now(), "test_data")
+	q.StartInactive()
+	defer q.Stop()
+	if running := q.IsRunning(); !running {
+		t.Errorf("q.IsRunning() = %v WANT %v", running, true)
+	}
+	if active := q.IsActive(); active {
+		t.Errorf("q.IsActive() = %v WANT %v", active, false)
+	}
+	if q.wakeSignal != nil {
+		t.Errorf("q.wakeSignal = non-nil WANT nil")
+	}
+	select {
+	case message := <-q.Messages():
+		t.Errorf("<-q.Messages() = %v WANT no release", message)
+	case <-time.After(time.Duration(50) * time.Millisecond):
+	}
+}
+
+func TestTimeQueue_Activate(t *testing.T) {
+	q := New()
+	want := q.Push(time.Now(), "test_data")
+	q.StartInactive()
+	defer q.Stop()
+	q.Activate()
+	if active := q.IsActive(); !active {
+		t.Errorf("q.IsActive() = %v WANT %v", active, true)
+	}
+	if message := <-q.Messages(); message != want {
+		t.Errorf("<-q.Messages() = %v WANT %v", message, want)
+	}
+}
+
+func TestTimeQueue_ActivateOn(t *testing.T) {
+	q := New()
+	want := q.Push(time.Now(), "test_data")
+	q.StartInactive()
+	defer q.Stop()
+	ready := make(chan struct{})
+	q.ActivateOn(ready)
+	close(ready)
+	if message := <-q.Messages(); message != want {
+		t.Errorf("<-q.Messages() = %v WANT %v", message, want)
+	}
+}
+
 func TestTimeQueue_IsRunning(t *testing.T) {
 	tests := []struct {
 		value bool
@@ -496,7 +628,7 @@ func TestTimeQueue_IsRunning(t *testing.T) {
 	}
 	for _, test := range tests {
 		q := New()
-		q.running = test.value
+		q.setRunning(test.value)
 		if result := q.IsRunning(); result != test.value {
 			t.Errorf("q.IsRunning() = %v WANT %v", result, test.value)
 		}
@@ -513,24 +645,21 @@ func TestTimeQueue_setRunning(t *testing.T) {
 	for _, test := range tests {
 		q := New()
 		q.setRunning(test.value)
-		if result := q.running; result != test.value {
-			t.Errorf("q.running = %v WANT %v", result, test.value)
+		if result := q.runState == StateRunning; result != test.value {
+			t.Errorf("q.runState == StateRunning = %v WANT %v", result, test.value)
 		}
 	}
 }
 
 func TestNewWakeSignal(t *testing.T) {
-	dst := make(chan time.Time)
+	dst := make(chan wake)
 	wakeTime := time.Now()
-	ws := newWakeSignal(dst, wakeTime)
+	ws := newWakeSignal(dst, wakeTime, 2)
 	if ws.dst != dst {
 		t.Errorf("ws.dst = %v WANT %v", ws.dst, dst)
 	}
-	if ws.src == nil {
-		t.Errorf("ws.src = nil WANT non-nil")
-	}
-	if cap(ws.src) != 1 {
-		t.Errorf("cap(ws.src) = %v WANT %v", cap(ws.src), 1)
+	if ws.timer == nil {
+		t.Errorf("ws.timer = nil WANT non-nil")
 	}
 	if ws.stop == nil {
 		t.Errorf("ws.stop = nil WANT non-nil")
@@ -538,39 +667,69 @@ func TestNewWakeSignal(t *testing.T) {
 	if cap(ws.stop) != 0 {
 		t.Errorf("cap(ws.stop) = %v WANT %v", cap(ws.stop), 0)
 	}
+	if !ws.wakeTime.Equal(wakeTime) || ws.generation != 2 {
+		t.Errorf("ws.wakeTime, ws.generation = %v, %v WANT %v, %v", ws.wakeTime, ws.generation, wakeTime, 2)
+	}
 }
 
 func TestWakeSignal_spawn_wake(t *testing.T) {
-	dst := make(chan time.Time)
+	dst := make(chan wake)
 	now := time.Now()
-	ws := newWakeSignal(dst, now)
+	ws := newWakeSignal(dst, now, 3)
 	ws.spawn()
 	result := <-dst
-	time.Sleep(time.Duration(250) * time.Millisecond)
-	diff := result.Sub(now)
+	diff := result.time.Sub(now)
 	if diff < 0 {
 		diff = -diff
 	}
 	if diff > time.Duration(1)*time.Millisecond {
-		t.Errorf("<-ws.dst too far away from desired : %v WANT %v", result, now)
+		t.Errorf("<-ws.dst too far away from desired : %v WANT %v", result.time, now)
 	}
-	if ws.src != nil {
-		t.Errorf("ws.src = nil WANT non-nil")
+	if result.generation != 3 {
+		t.Errorf("result.generation = %v WANT %v", result.generation, 3)
 	}
 }
 
 func TestWakeSignal_spawn_stop(t *testing.T) {
-	ws := newWakeSignal(nil, time.Now().Add(time.Duration(1)*time.Second))
+	ws := newWakeSignal(nil, time.Now().Add(time.Duration(1)*time.Second), 1)
 	ws.spawn()
 	ws.stop <- struct{}{}
 	time.Sleep(time.Duration(250) * time.Millisecond)
-	if ws.src != nil {
-		t.Errorf("ws.src = nil WANT non-nil")
+	if ws.timer.Stop() {
+		t.Errorf("ws.timer.Stop() = true WANT false")
+	}
+}
+
+func TestWakeSignal_spawn_killAfterFire(t *testing.T) {
+	dst := make(chan wake)
+	ws := newWakeSignal(dst, time.Now(), 1)
+	ws.spawn()
+	time.Sleep(time.Duration(50) * time.Millisecond)
+	ws.kill()
+	time.Sleep(time.Duration(50) * time.Millisecond)
+	select {
+	case w := <-dst:
+		t.Errorf("<-dst = %v WANT no wake after kill", w)
+	default:
+	}
+}
+
+func TestTimeQueue_onWakeSignal_stale(t *testing.T) {
+	q := New()
+	now := time.Now()
+	q.Push(now, 0)
+	q.onWakeSignal(wake{time: now.Add(1), generation: q.counters.wakeGeneration.load() + 1})
+	if size := q.Size(); size != 1 {
+		t.Errorf("q.Size() = %v WANT %v", size, 1)
+	}
+	q.onWakeSignal(wake{time: now.Add(1), generation: q.counters.wakeGeneration.load()})
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
 	}
 }
 
 func TestWakeSignal_kill(t *testing.T) {
-	ws := newWakeSignal(nil, time.Now())
+	ws := newWakeSignal(nil, time.Now(), 1)
 	ws.kill()
 	defer func() {
 		if result := recover(); result == nil {
@@ -596,3 +755,79 @@ func areChannelMessagesEqual(actualChan <-chan *Message, want []*Message) bool {
 func areMessagesEqual(actual, want []*Message) bool {
 	return (len(actual) == 0 && len(want) == 0) || reflect.DeepEqual(actual, want)
 }
+
+func TestTimeQueue_onWake_pastDue(t *testing.T) {
+	q := New(WithCapacity(3))
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		q.Push(now.Add(time.Duration(i-3)*time.Millisecond), i)
+	}
+	q.onWake(now.Add(-time.Hour))
+	if size := q.Size(); size != 0 {
+		t.Errorf("q.Size() = %v WANT %v", size, 0)
+	}
+}
+
+func TestTimeQueue_onWake_wakeBatch(t *testing.T) {
+	q := New(WithCapacity(5), WithWakeBatch(2))
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		q.Push(now.Add(-time.Millisecond), i)
+	}
+	q.onWake(now)
+	if size := q.Size(); size != 3 {
+		t.Errorf("q.Size() = %v WANT %v", size, 3)
+	}
+	if q.wakeSignal == nil {
+		t.Errorf("q.wakeSignal = nil WANT non-nil")
+	}
+	q.killWakeSignal()
+}
+
+func TestTimeQueue_updateAndSpawnWakeSignal_keep(t *testing.T) {
+	q := New()
+	now := time.Now()
+	q.Push(now.Add(time.Hour), 0)
+	if result := q.updateAndSpawnWakeSignal(); result != true {
+		t.Fatalf("q.updateAndSpawnWakeSignal() = %v WANT %v", result, true)
+	}
+	ws := q.wakeSignal
+	q.Push(now.Add(2*time.Hour), 1)
+	if result := q.updateAndSpawnWakeSignal(); result != false || q.wakeSignal != ws {
+		t.Errorf("q.updateAndSpawnWakeSignal() = %v WANT %v and the same wake signal", result, false)
+	}
+	q.Push(now.Add(time.Minute), 2)
+	if result := q.updateAndSpawnWakeSignal(); result != true || q.wakeSignal == ws {
+		t.Errorf("q.updateAndSpawnWakeSignal() = %v WANT %v and a new wake signal", result, true)
+	}
+	q.killWakeSignal()
+}
+
+func TestTimeQueue_killWakeSignal_stale(t *testing.T) {
+	q := New()
+	q.Push(time.Now().Add(time.Hour), 0)
+	q.updateAndSpawnWakeSignal()
+	w := wake{time: time.Now(), generation: q.wakeSignal.generation}
+	if q.isStaleWake(w) {
+		t.Errorf("q.isStaleWake() = true WANT false")
+	}
+	q.killWakeSignal()
+	if !q.isStaleWake(w) {
+		t.Errorf("q.isStaleWake() = false WANT true")
+	}
+}
+
+func TestWakeSignal_spawn_staleGeneration(t *testing.T) {
+	dst := make(chan wake, 1)
+	current := &counter{}
+	ws := newWakeSignal(dst, time.Now(), 1)
+	ws.current = current
+	current.store(2)
+	ws.spawn()
+	time.Sleep(time.Duration(50) * time.Millisecond)
+	select {
+	case w := <-dst:
+		t.Errorf("<-dst = %v WANT no wake from a stale generation", w)
+	default:
+	}
+}
@@ -0,0 +1,177 @@
+//Package timequeuetest provides utilities for testing code that uses package
+//timequeue.
+package timequeuetest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//FakeCapacity is the capacity of the channel returned from Fake.Messages().
+const FakeCapacity = 1024
+
+//compile time checks that Fake implements the timequeue interfaces.
+var (
+	_ timequeue.Scheduler = (*Fake)(nil)
+	_ timequeue.Receiver  = (*Fake)(nil)
+)
+
+//Fake is an in-memory implementation of timequeue.Scheduler and
+//timequeue.Receiver for unit tests.
+//Fake never releases Messages on its own. Tests release Messages explicitly with
+//Release(), ReleaseUntil(), and ReleaseAll(), so no real timers are involved.
+//
+//Released Messages are buffered on Messages() up to FakeCapacity. Releasing more
+//Messages without receiving them blocks.
+//Fake is safe for use by multiple go-routines.
+type Fake struct {
+	lock *sync.Mutex
+	//every Message ever pushed, in push order.
+	pushed []*timequeue.Message
+	//Messages pushed and not yet released or removed, in push order.
+	pending  []*timequeue.Message
+	released []*timequeue.Message
+	messages chan *timequeue.Message
+}
+
+//NewFake creates an empty Fake.
+func NewFake() *Fake {
+	return &Fake{
+		lock:     &sync.Mutex{},
+		messages: make(chan *timequeue.Message, FakeCapacity),
+	}
+}
+
+//Push records a Message with t and data as pushed and pending.
+func (f *Fake) Push(t time.Time, data interface{}) *timequeue.Message {
+	message := &timequeue.Message{
+		Time: t,
+		Data: data,
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.pushed = append(f.pushed, message)
+	f.pending = append(f.pending, message)
+	return message
+}
+
+//Remove removes message from the pending Messages, releasing it if release is
+//true. It returns false if message is not pending.
+func (f *Fake) Remove(message *timequeue.Message, release bool) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !f.removePending(message) {
+		return false
+	}
+	if release {
+		f.release(message)
+	}
+	return true
+}
+
+//Messages returns the channel that Messages are sent on when released.
+func (f *Fake) Messages() <-chan *timequeue.Message {
+	return f.messages
+}
+
+//Next receives the next released Message or returns ctx.Err() if ctx is done
+//first.
+func (f *Fake) Next(ctx context.Context) (*timequeue.Message, error) {
+	select {
+	case message := <-f.messages:
+		return message, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//Pushed returns every Message pushed to f, in push order, including those that
+//have since been released or removed.
+func (f *Fake) Pushed() []*timequeue.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*timequeue.Message{}, f.pushed...)
+}
+
+//Pending returns the Messages pushed to f that have not been released or removed,
+//ordered by Time.
+func (f *Fake) Pending() []*timequeue.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.sortedPending()
+}
+
+//Released returns every Message released by f, in release order.
+func (f *Fake) Released() []*timequeue.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*timequeue.Message{}, f.released...)
+}
+
+//Release releases message if it is pending and returns whether or not it was.
+func (f *Fake) Release(message *timequeue.Message) bool {
+	return f.Remove(message, true)
+}
+
+//ReleaseUntil releases all pending Messages with Times before or equal to until,
+//in Time order. It returns the released Messages.
+func (f *Fake) ReleaseUntil(until time.Time) []*timequeue.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	result := []*timequeue.Message{}
+	for _, message := range f.sortedPending() {
+		if message.Time.After(until) {
+			break
+		}
+		f.removePending(message)
+		f.release(message)
+		result = append(result, message)
+	}
+	return result
+}
+
+//ReleaseAll releases all pending Messages in Time order and returns them.
+func (f *Fake) ReleaseAll() []*timequeue.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	result := f.sortedPending()
+	f.pending = nil
+	for _, message := range result {
+		f.release(message)
+	}
+	return result
+}
+
+//sortedPending returns a copy of f.pending sorted by Time.
+//It should only be called when f is locked.
+func (f *Fake) sortedPending() []*timequeue.Message {
+	result := append([]*timequeue.Message{}, f.pending...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+//removePending removes message from f.pending and returns whether or not it was
+//there.
+//It should only be called when f is locked.
+func (f *Fake) removePending(message *timequeue.Message) bool {
+	for i, pending := range f.pending {
+		if pending == message {
+			f.pending = append(f.pending[:i], f.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//release records message as released and sends it on f.messages.
+//It should only be called when f is locked.
+func (f *Fake) release(message *timequeue.Message) {
+	f.released = append(f.released, message)
+	f.messages <- message
+}
@@ -0,0 +1,64 @@
+package timequeuetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//schedule is code under test that depends on a timequeue.Scheduler.
+func schedule(s timequeue.Scheduler, now time.Time) {
+	s.Push(now.Add(2*time.Hour), "second")
+	s.Push(now.Add(time.Hour), "first")
+	s.Push(now.Add(3*time.Hour), "third")
+}
+
+func TestFake(t *testing.T) {
+	f := NewFake()
+	now := time.Now()
+	schedule(f, now)
+
+	if pushed := f.Pushed(); len(pushed) != 3 || pushed[0].Data != "second" {
+		t.Errorf("f.Pushed() = %v WANT 3 Messages starting with second", pushed)
+	}
+	if pending := f.Pending(); len(pending) != 3 || pending[0].Data != "first" {
+		t.Errorf("f.Pending() = %v WANT 3 Messages starting with first", pending)
+	}
+
+	released := f.ReleaseUntil(now.Add(2 * time.Hour))
+	if len(released) != 2 || released[0].Data != "first" || released[1].Data != "second" {
+		t.Errorf("f.ReleaseUntil() = %v WANT first, second", released)
+	}
+	for _, want := range []string{"first", "second"} {
+		if message, err := f.Next(context.Background()); err != nil || message.Data != want {
+			t.Errorf("f.Next() = %v, %v WANT %v, nil", message, err, want)
+		}
+	}
+
+	third := f.Pending()[0]
+	if !f.Remove(third, false) || f.Remove(third, false) {
+		t.Errorf("f.Remove(third) WANT true then false")
+	}
+	if released := f.ReleaseAll(); len(released) != 0 {
+		t.Errorf("f.ReleaseAll() = %v WANT empty", released)
+	}
+	if released := f.Released(); len(released) != 2 {
+		t.Errorf("f.Released() = %v WANT 2 Messages", released)
+	}
+}
+
+func TestFake_Release(t *testing.T) {
+	f := NewFake()
+	message := f.Push(time.Now(), 0)
+	if !f.Release(message) {
+		t.Errorf("f.Release() = false WANT true")
+	}
+	if received := <-f.Messages(); received != message {
+		t.Errorf("<-f.Messages() = %v WANT %v", received, message)
+	}
+	if f.Release(message) {
+		t.Errorf("f.Release() again = true WANT false")
+	}
+}
@@ -0,0 +1,164 @@
+package timequeuetest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//Release is a Message recorded by a Recorder and the time it was recorded at.
+type Release struct {
+	Message *timequeue.Message
+	At      time.Time
+}
+
+//Lateness returns how long after its Time the Message was recorded. It is
+//negative if the Message was released early.
+func (r Release) Lateness() time.Duration {
+	return r.At.Sub(r.Message.Time)
+}
+
+//Recorder records released Messages and asserts on the order and times of their
+//releases.
+//
+//A Recorder is deterministic when given the Now() method of a TimeQueue that is
+//advanced manually as its clock and the results of Advance() to Record():
+//	q := timequeue.New(timequeue.WithManualAdvance(start))
+//	r := timequeuetest.NewRecorder(q.Now)
+//	r.Record(q.Advance(start.Add(time.Hour))...)
+//	r.AssertSequence(t, "first", "second")
+//
+//A Recorder may also Subscribe() to a running TimeQueue, in which case times are
+//recorded as Messages are received.
+//A Recorder is safe for use by multiple go-routines.
+type Recorder struct {
+	clock func() time.Time
+
+	lock *sync.Mutex
+	//every recorded release, in the order recorded.
+	releases []Release
+	//closed and replaced whenever a release is recorded.
+	changed chan struct{}
+	//closed by Stop() to stop subscriptions.
+	stop chan struct{}
+	//tracks subscription go-routines.
+	wg *sync.WaitGroup
+}
+
+//NewRecorder creates a Recorder that records times with clock, or time.Now() if
+//clock is nil.
+func NewRecorder(clock func() time.Time) *Recorder {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &Recorder{
+		clock:   clock,
+		lock:    &sync.Mutex{},
+		changed: make(chan struct{}),
+		stop:    make(chan struct{}),
+		wg:      &sync.WaitGroup{},
+	}
+}
+
+//Subscribe records every Message received from receiver.Messages() until Stop()
+//is called.
+func (r *Recorder) Subscribe(receiver timequeue.Receiver) {
+	messages := receiver.Messages()
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case message := <-messages:
+				r.Record(message)
+			}
+		}
+	}()
+}
+
+//Stop stops all subscriptions and waits for them to return.
+//Messages received after Stop() are not recorded. Stop must be called at most once.
+func (r *Recorder) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+//Record records messages as released now according to r's clock.
+func (r *Recorder) Record(messages ...*timequeue.Message) {
+	at := r.clock()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, message := range messages {
+		r.releases = append(r.releases, Release{Message: message, At: at})
+	}
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+//Releases returns every recorded release in the order recorded.
+func (r *Recorder) Releases() []Release {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Release{}, r.releases...)
+}
+
+//Wait blocks until at least n releases are recorded or timeout passes.
+//Returns whether or not n releases were recorded.
+func (r *Recorder) Wait(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		r.lock.Lock()
+		count, changed := len(r.releases), r.changed
+		r.lock.Unlock()
+		if count >= n {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+//AssertSequence reports an error on t unless the Data of the recorded Messages is
+//exactly data, in order.
+func (r *Recorder) AssertSequence(t testing.TB, data ...interface{}) {
+	t.Helper()
+	releases := r.Releases()
+	got := make([]interface{}, len(releases))
+	for i, release := range releases {
+		got[i] = release.Message.Data
+	}
+	if len(got) != len(data) || (len(data) > 0 && !reflect.DeepEqual(got, data)) {
+		t.Errorf("released Data = %v WANT %v", got, data)
+	}
+}
+
+//AssertLateness reports an error on t for every recorded Message released before
+//its Time or more than max after it.
+func (r *Recorder) AssertLateness(t testing.TB, max time.Duration) {
+	t.Helper()
+	for _, release := range r.Releases() {
+		if lateness := release.Lateness(); lateness < 0 || lateness > max {
+			t.Errorf("%v released %v late WANT between 0 and %v", release.Message, lateness, max)
+		}
+	}
+}
+
+//AssertNoneBetween reports an error on t for every Message recorded at or after
+//from and before to.
+func (r *Recorder) AssertNoneBetween(t testing.TB, from, to time.Time) {
+	t.Helper()
+	for _, release := range r.Releases() {
+		if !release.At.Before(from) && release.At.Before(to) {
+			t.Errorf("%v released at %v WANT none between %v and %v", release.Message, release.At, from, to)
+		}
+	}
+}
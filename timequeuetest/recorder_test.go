@@ -0,0 +1,69 @@
+package timequeuetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//failures is a testing.TB that counts reported errors instead of failing.
+type failures struct {
+	testing.TB
+	count int
+}
+
+func (f *failures) Helper() {}
+
+func (f *failures) Errorf(format string, args ...interface{}) {
+	f.count++
+}
+
+func TestRecorder_manual(t *testing.T) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := timequeue.New(timequeue.WithManualAdvance(start))
+	r := NewRecorder(q.Now)
+	q.Push(start.Add(2*time.Minute), "second")
+	q.Push(start.Add(time.Minute), "first")
+	q.Push(start.Add(10*time.Minute), "third")
+	r.Record(q.Advance(start.Add(2 * time.Minute))...)
+	r.Record(q.Advance(start.Add(11 * time.Minute))...)
+
+	r.AssertSequence(t, "first", "second", "third")
+	r.AssertLateness(t, time.Minute)
+	r.AssertNoneBetween(t, start.Add(3*time.Minute), start.Add(11*time.Minute))
+
+	tests := []struct {
+		assert func(tb testing.TB)
+		count  int
+	}{
+		{func(tb testing.TB) { r.AssertSequence(tb, "first", "third", "second") }, 1},
+		{func(tb testing.TB) { r.AssertSequence(tb, "first", "second") }, 1},
+		{func(tb testing.TB) { r.AssertLateness(tb, 30*time.Second) }, 2},
+		{func(tb testing.TB) { r.AssertNoneBetween(tb, start, start.Add(3*time.Minute)) }, 2},
+	}
+	for i, test := range tests {
+		f := &failures{TB: t}
+		test.assert(f)
+		if f.count != test.count {
+			t.Errorf("%v: errors = %v WANT %v", i, f.count, test.count)
+		}
+	}
+}
+
+func TestRecorder_Subscribe(t *testing.T) {
+	q := timequeue.New()
+	q.Start()
+	defer q.Stop()
+	r := NewRecorder(nil)
+	r.Subscribe(q)
+	now := time.Now()
+	q.Push(now.Add(10*time.Millisecond), 1)
+	q.Push(now, 0)
+	if !r.Wait(2, time.Second) {
+		t.Fatalf("r.Wait() = %v WANT %v", false, true)
+	}
+	r.Stop()
+	r.AssertSequence(t, 0, 1)
+	r.AssertLateness(t, time.Second)
+}
@@ -0,0 +1,74 @@
+package timequeue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	//ErrNoTopicHandler is returned (classified with Fatal()) by TopicMux.Dispatch()
+	//for Messages with a Topic that has no Handler.
+	ErrNoTopicHandler = errors.New("timequeue: no handler for topic")
+
+	//ErrTopicType is returned (classified with Fatal()) for Messages whose Data is
+	//not of the type registered for their Topic.
+	ErrTopicType = errors.New("timequeue: data type does not match topic")
+)
+
+//TopicMux routes Messages to Handlers by their Topic. This allows a single
+//Dispatcher to consume a TimeQueue shared by many kinds of Messages:
+//	mux := timequeue.NewTopicMux()
+//	mux.Handle("email", sendEmail)
+//	mux.Handle("sms", sendSMS)
+//	q.Consume(ctx, mux.Dispatch)
+//
+//TopicMux also records the Data type of each Topic created with NewTopic() so that
+//the same Topic cannot be used with two different types.
+//TopicMux is safe for use by multiple go-routines.
+type TopicMux struct {
+	lock     *sync.RWMutex
+	handlers map[string]Handler
+	types    map[string]reflect.Type
+}
+
+//NewTopicMux creates an empty TopicMux.
+func NewTopicMux() *TopicMux {
+	return &TopicMux{
+		lock:     &sync.RWMutex{},
+		handlers: map[string]Handler{},
+		types:    map[string]reflect.Type{},
+	}
+}
+
+//Handle registers handler for Messages with topic, replacing any existing Handler.
+func (m *TopicMux) Handle(topic string, handler Handler) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.handlers[topic] = handler
+}
+
+//Dispatch calls the Handler registered for message's Topic.
+//Dispatch is a Handler and is meant to be given to TimeQueue.Consume().
+func (m *TopicMux) Dispatch(ctx context.Context, message *Message) error {
+	m.lock.RLock()
+	handler, ok := m.handlers[message.Topic]
+	m.lock.RUnlock()
+	if !ok {
+		return Fatal(fmt.Errorf("%w: %q", ErrNoTopicHandler, message.Topic))
+	}
+	return handler(ctx, message)
+}
+
+//registerType records that topic carries Data of type t.
+//It panics if topic is already registered with a different type.
+func (m *TopicMux) registerType(topic string, t reflect.Type) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if existing, ok := m.types[topic]; ok && existing != t {
+		panic(fmt.Sprintf("timequeue: topic %q registered with type %v and %v", topic, existing, t))
+	}
+	m.types[topic] = t
+}
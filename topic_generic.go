@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package timequeue
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+//Topic is a typed view of the Messages in a TimeQueue with a single Topic.
+//Pushing and subscribing through a Topic ensures at compile time that all Data
+//of the Topic is of type T.
+type Topic[T any] struct {
+	q    *TimeQueue
+	mux  *TopicMux
+	name string
+}
+
+//NewTopic creates a Topic named name for Messages in q that are routed by mux.
+//NewTopic panics if name was already created on mux with a different type.
+func NewTopic[T any](q *TimeQueue, mux *TopicMux, name string) *Topic[T] {
+	mux.registerType(name, reflect.TypeOf((*T)(nil)).Elem())
+	return &Topic[T]{
+		q:    q,
+		mux:  mux,
+		name: name,
+	}
+}
+
+//Name returns the Topic of all Messages pushed by t.
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+//Push pushes a Message with Time at, Data data, and Topic t.Name() to t's TimeQueue.
+func (t *Topic[T]) Push(at time.Time, data T) *Message {
+	message := &Message{
+		Time:  at,
+		Data:  data,
+		Topic: t.name,
+	}
+	t.q.PushMessage(message)
+	return message
+}
+
+//Data returns the Data of message as a T. It returns false if message is not of
+//t's Topic or its Data is not a T.
+func (t *Topic[T]) Data(message *Message) (T, bool) {
+	if message == nil || message.Topic != t.name {
+		var zero T
+		return zero, false
+	}
+	data, ok := message.Data.(T)
+	return data, ok
+}
+
+//Subscribe registers handler with t's TopicMux for all Messages of t.
+//Messages of t whose Data is not a T fail with an error wrapping ErrTopicType.
+func (t *Topic[T]) Subscribe(handler func(ctx context.Context, message *Message, data T) error) {
+	t.mux.Handle(t.name, func(ctx context.Context, message *Message) error {
+		data, ok := message.Data.(T)
+		if !ok {
+			return Fatal(fmt.Errorf("%w: topic %q data %T", ErrTopicType, t.name, message.Data))
+		}
+		return handler(ctx, message, data)
+	})
+}
@@ -0,0 +1,59 @@
+//go:build go1.18
+// +build go1.18
+
+package timequeue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testEmail struct {
+	To string
+}
+
+func TestTopic(t *testing.T) {
+	q, mux := New(), NewTopicMux()
+	emails := NewTopic[testEmail](q, mux, "email")
+	if name := emails.Name(); name != "email" {
+		t.Errorf("emails.Name() = %q WANT %q", name, "email")
+	}
+
+	message := emails.Push(time.Now(), testEmail{To: "a@example.com"})
+	if message.Topic != "email" || q.Size() != 1 {
+		t.Errorf("emails.Push() = %v, q.Size() = %v WANT topic email, 1", message, q.Size())
+	}
+	if data, ok := emails.Data(message); !ok || data.To != "a@example.com" {
+		t.Errorf("emails.Data() = %v, %v WANT a@example.com, true", data, ok)
+	}
+	if _, ok := emails.Data(&Message{Topic: "other"}); ok {
+		t.Errorf("emails.Data(other) ok = true WANT false")
+	}
+
+	received := ""
+	emails.Subscribe(func(ctx context.Context, message *Message, email testEmail) error {
+		received = email.To
+		return nil
+	})
+	if err := mux.Dispatch(context.Background(), message); err != nil || received != "a@example.com" {
+		t.Errorf("mux.Dispatch() = %v, received %q WANT nil, %q", err, received, "a@example.com")
+	}
+	err := mux.Dispatch(context.Background(), &Message{Topic: "email", Data: "not an email"})
+	if !errors.Is(err, ErrTopicType) || !IsFatal(err) {
+		t.Errorf("mux.Dispatch(wrong type) = %v WANT fatal %v", err, ErrTopicType)
+	}
+}
+
+func TestNewTopic_conflictingType(t *testing.T) {
+	q, mux := New(), NewTopicMux()
+	NewTopic[testEmail](q, mux, "email")
+	NewTopic[testEmail](q, mux, "email")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewTopic[string](email) did not panic")
+		}
+	}()
+	NewTopic[string](q, mux, "email")
+}
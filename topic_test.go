@@ -0,0 +1,24 @@
+package timequeue
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTopicMux_Dispatch(t *testing.T) {
+	mux := NewTopicMux()
+	handled := ""
+	mux.Handle("a", func(ctx context.Context, message *Message) error {
+		handled = message.Topic
+		return nil
+	})
+
+	if err := mux.Dispatch(context.Background(), &Message{Topic: "a"}); err != nil || handled != "a" {
+		t.Errorf("mux.Dispatch(a) = %v, handled %q WANT nil, %q", err, handled, "a")
+	}
+	err := mux.Dispatch(context.Background(), &Message{Topic: "b"})
+	if !errors.Is(err, ErrNoTopicHandler) || !IsFatal(err) {
+		t.Errorf("mux.Dispatch(b) = %v WANT fatal %v", err, ErrNoTopicHandler)
+	}
+}
@@ -0,0 +1,15 @@
+//Package typed provides TimeQueue[T], a TimeQueue whose Messages have Data of type
+//T instead of interface{}.
+//
+//A TimeQueue[T] wraps a timequeue.TimeQueue, so it releases Messages in the same
+//way, and receivers no longer need to type assert the Data of every Message:
+//	q := typed.New[Email]()
+//	q.Start()
+//	q.Push(time.Now().Add(time.Minute), Email{To: "gopher@example.com"})
+//	for message := range q.Messages() {
+//		send(message.Data)
+//	}
+//
+//The wrapped timequeue.TimeQueue is returned from Queue() for the parts of its API
+//that do not involve Data, e.g. holds and Stats().
+package typed
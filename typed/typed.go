@@ -0,0 +1,233 @@
+//go:build go1.18
+// +build go1.18
+
+package typed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+//Message is a timequeue.Message with Data of type T.
+//
+//A Message is a copy of the fields of the timequeue.Message it was created from
+//or for. Changing its fields does not change the Message in a TimeQueue.
+type Message[T any] struct {
+	time.Time
+	Data T
+
+	//ID uniquely identifies the Message. See timequeue.Message.
+	ID string
+	//ParentID is the ID of the Message that caused this Message to be created.
+	ParentID string
+	//TraceID correlates the Message across subsystems. See timequeue.Message.
+	TraceID string
+	//Key optionally identifies the Message for deduplication.
+	Key string
+	//Topic is an optional classification of the Message.
+	Topic string
+	//Tenant optionally identifies who the Message belongs to for quotas.
+	Tenant string
+	//Weight is the cost of releasing the Message. See timequeue.WithBudget().
+	Weight int
+	//Priority orders Messages with equal Times. See timequeue.Message.
+	Priority int
+	//TTL limits how long after its Time the Message may be processed.
+	TTL time.Duration
+
+	//the Message in a TimeQueue that this Message is a copy of.
+	message *timequeue.Message
+}
+
+//newMessage creates a Message from message.
+//The Data of message is the zero T if it is not a T.
+func newMessage[T any](message *timequeue.Message) *Message[T] {
+	data, _ := message.Data.(T)
+	return &Message[T]{
+		Time:     message.Time,
+		Data:     data,
+		ID:       message.ID,
+		ParentID: message.ParentID,
+		TraceID:  message.TraceID,
+		Key:      message.Key,
+		Topic:    message.Topic,
+		Tenant:   message.Tenant,
+		Weight:   message.Weight,
+		Priority: message.Priority,
+		TTL:      message.TTL,
+		message:  message,
+	}
+}
+
+//newMessages creates a Message from each of messages.
+func newMessages[T any](messages []*timequeue.Message) []*Message[T] {
+	result := make([]*Message[T], len(messages))
+	for i, message := range messages {
+		result[i] = newMessage[T](message)
+	}
+	return result
+}
+
+//Untyped returns the timequeue.Message that m is a copy of, or nil if m was not
+//created by a TimeQueue.
+func (m *Message[T]) Untyped() *timequeue.Message {
+	return m.message
+}
+
+//String returns the standard string representation of a struct.
+func (m *Message[T]) String() string {
+	return fmt.Sprintf("&typed.Message{%v %v}", m.Time, m.Data)
+}
+
+//TimeQueue is a timequeue.TimeQueue whose Messages have Data of type T.
+//A TimeQueue is safe for use by multiple go-routines.
+type TimeQueue[T any] struct {
+	q *timequeue.TimeQueue
+
+	//creates messages the first time Messages() is called.
+	once *sync.Once
+	//the channel that released Messages are sent on once converted.
+	messages chan *Message[T]
+}
+
+//New creates a new *TimeQueue configured with opts. See timequeue.New().
+func New[T any](opts ...timequeue.Option) *TimeQueue[T] {
+	return &TimeQueue[T]{
+		q:    timequeue.New(opts...),
+		once: &sync.Once{},
+	}
+}
+
+//Queue returns the timequeue.TimeQueue that t wraps.
+//Messages pushed directly to it with Data that is not a T are received from t
+//with the zero T.
+func (t *TimeQueue[T]) Queue() *timequeue.TimeQueue {
+	return t.q
+}
+
+//Push creates and adds a Message to t with at and data. The created Message is
+//returned.
+func (t *TimeQueue[T]) Push(at time.Time, data T) *Message[T] {
+	return newMessage[T](t.q.Push(at, data))
+}
+
+//PushMessage adds a Message with the fields of message to t, and sets the ID and
+//TraceID of message to those of the added Message.
+//Errors are the same as those returned from timequeue.TimeQueue.PushMessage().
+func (t *TimeQueue[T]) PushMessage(message *Message[T]) error {
+	if message == nil {
+		return timequeue.ErrNilMessage
+	}
+	untyped := &timequeue.Message{
+		Time:     message.Time,
+		Data:     message.Data,
+		ID:       message.ID,
+		ParentID: message.ParentID,
+		TraceID:  message.TraceID,
+		Key:      message.Key,
+		Topic:    message.Topic,
+		Tenant:   message.Tenant,
+		Weight:   message.Weight,
+		Priority: message.Priority,
+		TTL:      message.TTL,
+	}
+	if err := t.q.PushMessage(untyped); err != nil {
+		return err
+	}
+	message.ID = untyped.ID
+	message.TraceID = untyped.TraceID
+	message.message = untyped
+	return nil
+}
+
+//Remove removes message from t. See timequeue.TimeQueue.Remove().
+func (t *TimeQueue[T]) Remove(message *Message[T], release bool) bool {
+	if message == nil || message.message == nil {
+		return false
+	}
+	return t.q.Remove(message.message, release)
+}
+
+//Pop removes and returns the earliest Message in t or nil if t is empty.
+//See timequeue.TimeQueue.Pop().
+func (t *TimeQueue[T]) Pop(release bool) *Message[T] {
+	message := t.q.Pop(release)
+	if message == nil {
+		return nil
+	}
+	return newMessage[T](message)
+}
+
+//PopAll removes and returns all Messages in t. See timequeue.TimeQueue.PopAll().
+func (t *TimeQueue[T]) PopAll(release bool) []*Message[T] {
+	return newMessages[T](t.q.PopAll(release))
+}
+
+//PopAllUntil removes and returns the Messages in t with Times before until.
+//See timequeue.TimeQueue.PopAllUntil().
+func (t *TimeQueue[T]) PopAllUntil(until time.Time, release bool) []*Message[T] {
+	return newMessages[T](t.q.PopAllUntil(until, release))
+}
+
+//Size returns the number of Messages in t.
+func (t *TimeQueue[T]) Size() int {
+	return t.q.Size()
+}
+
+//Start starts releasing Messages from t. See timequeue.TimeQueue.Start().
+func (t *TimeQueue[T]) Start() {
+	t.q.Start()
+}
+
+//Stop stops releasing Messages from t. See timequeue.TimeQueue.Stop().
+func (t *TimeQueue[T]) Stop() {
+	t.q.Stop()
+}
+
+//IsRunning returns whether or not t is running.
+func (t *TimeQueue[T]) IsRunning() bool {
+	return t.q.IsRunning()
+}
+
+//Messages returns the channel that all Messages are released on. It is the same
+//instance on every call and is never closed.
+//
+//The first call to Messages() spawns a go-routine for the lifetime of t that
+//receives released Messages from the wrapped timequeue.TimeQueue, including from
+//all of its timequeue.WithOutputs() channels, and sends them on the returned
+//channel. The returned channel has the same capacity as the wrapped TimeQueue's.
+func (t *TimeQueue[T]) Messages() <-chan *Message[T] {
+	t.once.Do(func() {
+		untyped := t.q.Outputs()
+		if untyped == nil {
+			untyped = []<-chan *timequeue.Message{t.q.Messages()}
+		}
+		t.messages = make(chan *Message[T], cap(untyped[0]))
+		for _, in := range untyped {
+			go t.convert(in)
+		}
+	})
+	return t.messages
+}
+
+//convert sends every Message received from in on t.messages.
+func (t *TimeQueue[T]) convert(in <-chan *timequeue.Message) {
+	for message := range in {
+		t.messages <- newMessage[T](message)
+	}
+}
+
+//Next receives the next Message released on t.Messages().
+//It returns ctx.Err() if ctx is done before a Message is released.
+func (t *TimeQueue[T]) Next(ctx context.Context) (*Message[T], error) {
+	select {
+	case message := <-t.Messages():
+		return message, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,97 @@
+//go:build go1.18
+// +build go1.18
+
+package typed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogolfing/timequeue"
+)
+
+type email struct {
+	To string
+}
+
+func TestTimeQueue_Messages(t *testing.T) {
+	q := New[email](timequeue.WithCapacity(2))
+	now := time.Now()
+	q.Push(now.Add(time.Millisecond), email{To: "b"})
+	first := &Message[email]{Time: now, Data: email{To: "a"}, Topic: "mail"}
+	if err := q.PushMessage(first); err != nil || first.ID == "" {
+		t.Fatalf("q.PushMessage() = %v, ID %q WANT nil and an ID", err, first.ID)
+	}
+	q.Start()
+	defer q.Stop()
+	for _, want := range []string{"a", "b"} {
+		message, err := q.Next(context.Background())
+		if err != nil || message.Data.To != want {
+			t.Errorf("q.Next() = %v, %v WANT Data.To %v", message, err, want)
+		}
+	}
+	if first.Untyped() == nil || first.Topic != first.Untyped().Topic {
+		t.Errorf("first.Untyped() = %v WANT Topic %v", first.Untyped(), first.Topic)
+	}
+}
+
+func TestTimeQueue_Remove(t *testing.T) {
+	q := New[int]()
+	message := q.Push(time.Now().Add(time.Hour), 1)
+	if q.Size() != 1 {
+		t.Errorf("q.Size() = %v WANT %v", q.Size(), 1)
+	}
+	if !q.Remove(message, false) || q.Remove(message, false) {
+		t.Errorf("q.Remove() WANT true then false")
+	}
+	if q.Remove(&Message[int]{}, false) || q.Remove(nil, false) {
+		t.Errorf("q.Remove() = true WANT false")
+	}
+	if err := q.PushMessage(nil); err != timequeue.ErrNilMessage {
+		t.Errorf("q.PushMessage(nil) = %v WANT %v", err, timequeue.ErrNilMessage)
+	}
+}
+
+func TestTimeQueue_Pop(t *testing.T) {
+	q := New[int]()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		q.Push(now.Add(time.Duration(i)), i)
+	}
+	if message := q.Pop(false); message.Data != 0 {
+		t.Errorf("q.Pop().Data = %v WANT %v", message.Data, 0)
+	}
+	if messages := q.PopAllUntil(now.Add(2), false); len(messages) != 1 || messages[0].Data != 1 {
+		t.Errorf("q.PopAllUntil() = %v WANT Data [1]", messages)
+	}
+	if messages := q.PopAll(false); len(messages) != 1 || messages[0].Data != 2 {
+		t.Errorf("q.PopAll() = %v WANT Data [2]", messages)
+	}
+	if message := q.Pop(false); message != nil {
+		t.Errorf("q.Pop() = %v WANT nil", message)
+	}
+}
+
+func TestTimeQueue_Messages_untyped(t *testing.T) {
+	q := New[int]()
+	q.Queue().Push(time.Now(), "not an int")
+	q.Start()
+	defer q.Stop()
+	if message := <-q.Messages(); message.Data != 0 {
+		t.Errorf("message.Data = %v WANT %v", message.Data, 0)
+	}
+}
+
+func TestTimeQueue_Messages_outputs(t *testing.T) {
+	q := New[int](timequeue.WithOutputs(2))
+	now := time.Now()
+	q.Push(now, 0)
+	q.Push(now, 1)
+	q.Start()
+	defer q.Stop()
+	sum := (<-q.Messages()).Data + (<-q.Messages()).Data
+	if sum != 1 {
+		t.Errorf("sum of Data = %v WANT %v", sum, 1)
+	}
+}
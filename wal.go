@@ -0,0 +1,373 @@
+package timequeue
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/gogolfing/timequeue/internal/record"
+)
+
+//DefaultWALSegmentSize is the size in bytes at which a WAL rotates to a new
+//segment unless WithSegmentSize() is given.
+const DefaultWALSegmentSize = 64 << 20
+
+//ErrWALClosed is returned from WAL.Err() after the WAL is closed.
+var ErrWALClosed = errors.New("timequeue: wal is closed")
+
+//walOp is the kind of a walEntry.
+type walOp int
+
+//walOps of walEntries.
+const (
+	//the Message was pushed. the entry contains the whole Message.
+	walPush walOp = iota
+	//the Message was released or removed.
+	walRemove
+	//the cursor was saved. the entry contains only the Cursor.
+	walCursor
+)
+
+//walEntry is the encoded form of a single operation in a WAL.
+type walEntry struct {
+	Op      walOp
+	ID      string
+	Message *record.Record
+	Cursor  uint64
+}
+
+//WALOption configures a WAL created with OpenWAL().
+type WALOption func(*walConfig)
+
+//walConfig holds all values that may be set by WALOptions.
+type walConfig struct {
+	segmentSize int64
+	syncEvery   int
+}
+
+//WithSegmentSize sets the size in bytes at which a WAL rotates to a new segment.
+//The default is DefaultWALSegmentSize.
+func WithSegmentSize(size int64) WALOption {
+	return func(c *walConfig) {
+		c.segmentSize = size
+	}
+}
+
+//WithSyncEvery sets the fsync policy of a WAL. The segment is synced after every
+//n entries, so 1, the default, syncs every entry before the operation that it
+//records returns. A larger n trades the durability of the last n-1 entries for
+//throughput, and an n less than or equal to zero leaves syncing to the operating
+//system.
+func WithSyncEvery(n int) WALOption {
+	return func(c *walConfig) {
+		c.syncEvery = n
+	}
+}
+
+//WAL is a write-ahead log CursorStore and LoadStore that journals every push,
+//release, and removal of a TimeQueue given WithStore(), so that its Messages can
+//be recovered exactly after a crash.
+//
+//A WAL is a directory of segment files. Entries are appended to the newest
+//segment until it reaches the segment size, at which point the WAL is compacted:
+//a new segment is started with only the Messages that are still pending, and all
+//older segments, including every entry for released and removed Messages, are
+//deleted. Opening a WAL replays its segments and compacts them the same way.
+//A WAL keeps the encoded form of every pending Message in memory to compact
+//without reading its segments.
+//
+//Messages are encoded like a handoff, so the concrete types of all Data values must
+//be registered with RegisterType() or gob.Register(). See HandoffTo().
+//
+//The first error writing a WAL is kept, no more entries are written, and the error
+//is returned from Err() and every later Append() and Remove().
+type WAL struct {
+	lock   *sync.Mutex
+	dir    string
+	config walConfig
+
+	//the encoded form of every pending Message keyed by ID.
+	live map[string]*record.Record
+	//the last saved cursor.
+	cursor uint64
+	//the current segment, its sequence number, and the bytes written to it.
+	file    *os.File
+	enc     *gob.Encoder
+	segment int
+	size    int64
+	//the number of entries written since the last sync.
+	unsynced int
+	//the first error writing the WAL.
+	err error
+}
+
+//OpenWAL opens the WAL in dir, which is created if it does not exist, replays its
+//segments, and compacts them. The Messages that were pending when the WAL was last
+//written are pushed to a TimeQueue created with WithStore().
+//
+//An incomplete entry at the end of the last segment, e.g. from a crash while it
+//was being written, is ignored.
+func OpenWAL(dir string, opts ...WALOption) (*WAL, error) {
+	c := walConfig{
+		segmentSize: DefaultWALSegmentSize,
+		syncEvery:   1,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	w := &WAL{
+		lock:   &sync.Mutex{},
+		dir:    dir,
+		config: c,
+		live:   map[string]*record.Record{},
+	}
+	segments, err := w.segments()
+	if err != nil {
+		return nil, err
+	}
+	for i, segment := range segments {
+		if err := w.replay(segment, i == len(segments)-1); err != nil {
+			return nil, err
+		}
+		w.segment = segment
+	}
+	if err := w.compact(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+//Append journals the push of message.
+func (w *WAL) Append(message *Message) error {
+	r, err := newRecord(message)
+	if err != nil {
+		return err
+	}
+	return w.append(&walEntry{Op: walPush, ID: message.ID, Message: r})
+}
+
+//Remove journals the release or removal of the Message with id.
+func (w *WAL) Remove(id string) error {
+	return w.append(&walEntry{Op: walRemove, ID: id})
+}
+
+//SaveCursor journals cursor.
+func (w *WAL) SaveCursor(cursor uint64) error {
+	return w.append(&walEntry{Op: walCursor, Cursor: cursor})
+}
+
+//LoadCursor returns the last cursor saved to w.
+func (w *WAL) LoadCursor() (uint64, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.cursor, nil
+}
+
+//LoadAll returns new Messages for every pending Message in w.
+func (w *WAL) LoadAll() ([]*Message, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	result := make([]*Message, 0, len(w.live))
+	for _, r := range w.live {
+		message, err := recordMessage(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, message)
+	}
+	return result, nil
+}
+
+//Load returns a new Message for the pending Message with id in w, or nil if there
+//is none.
+func (w *WAL) Load(id string) (*Message, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	r, ok := w.live[id]
+	if !ok {
+		return nil, nil
+	}
+	return recordMessage(r)
+}
+
+//Err returns the first error that occurred writing w, or nil if there was none.
+func (w *WAL) Err() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.err
+}
+
+//Compact starts a new segment with only the pending Messages and deletes all
+//older segments.
+func (w *WAL) Compact() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.compact()
+	return w.err
+}
+
+//Close syncs and closes the current segment of w. No more entries are written
+//after Close, and Err() returns ErrWALClosed if there was no other error.
+func (w *WAL) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Sync()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file = nil
+	if w.err == nil {
+		w.err = ErrWALClosed
+	}
+	return err
+}
+
+//segmentPath returns the path of the segment with sequence number segment.
+func (w *WAL) segmentPath(segment int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%020d.wal", segment))
+}
+
+//segments returns the sequence numbers of the segments in w.dir in order.
+func (w *WAL) segments() ([]int, error) {
+	paths, err := filepath.Glob(filepath.Join(w.dir, "*.wal"))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]int, 0, len(paths))
+	for _, path := range paths {
+		segment := 0
+		if _, err := fmt.Sscanf(filepath.Base(path), "%020d.wal", &segment); err == nil {
+			result = append(result, segment)
+		}
+	}
+	sort.Ints(result)
+	return result, nil
+}
+
+//replay applies the entries in segment to w. If last is true, then an
+//incomplete final entry is ignored.
+func (w *WAL) replay(segment int, last bool) error {
+	file, err := os.Open(w.segmentPath(segment))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	dec := gob.NewDecoder(file)
+	for {
+		entry := &walEntry{}
+		err := dec.Decode(entry)
+		if err == io.EOF || (last && err == io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		w.apply(entry)
+	}
+}
+
+//apply applies entry to w.live or w.cursor.
+func (w *WAL) apply(entry *walEntry) {
+	switch entry.Op {
+	case walPush:
+		w.live[entry.ID] = entry.Message
+	case walRemove:
+		delete(w.live, entry.ID)
+	case walCursor:
+		w.cursor = entry.Cursor
+	}
+}
+
+//compact starts the next segment with the cursor and a push entry for every live
+//Message, syncs it, and deletes all older segments.
+//It should only be called when w is locked.
+func (w *WAL) compact() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+	w.segment++
+	file, err := os.OpenFile(w.segmentPath(w.segment), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.enc = gob.NewEncoder(&countingWriter{w: file, n: &w.size})
+	w.size = 0
+	if err := w.enc.Encode(&walEntry{Op: walCursor, Cursor: w.cursor}); err != nil {
+		return err
+	}
+	for id, r := range w.live {
+		if err := w.enc.Encode(&walEntry{Op: walPush, ID: id, Message: r}); err != nil {
+			return err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	w.unsynced = 0
+	segments, err := w.segments()
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		if segment < w.segment {
+			if err := os.Remove(w.segmentPath(segment)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//append writes entry to the current segment, syncing and compacting according to
+//w.config. Nothing is written after an error.
+func (w *WAL) append(entry *walEntry) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.apply(entry)
+	if w.err = w.enc.Encode(entry); w.err != nil {
+		return w.err
+	}
+	w.unsynced++
+	if w.config.syncEvery > 0 && w.unsynced >= w.config.syncEvery {
+		w.err = w.file.Sync()
+		w.unsynced = 0
+	}
+	if w.err == nil && w.size >= w.config.segmentSize {
+		w.err = w.compact()
+	}
+	return w.err
+}
+
+//countingWriter is an io.Writer that counts the bytes written to w in n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+//Write writes p to c.w and adds the number of bytes written to c.n.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
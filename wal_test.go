@@ -0,0 +1,94 @@
+package timequeue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestWALDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "timequeue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func openTestWAL(t *testing.T, dir string, opts ...WALOption) *WAL {
+	w, err := OpenWAL(dir, opts...)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v WANT nil", err)
+	}
+	return w
+}
+
+func TestWAL_recover(t *testing.T) {
+	dir := newTestWALDir(t)
+	now := time.Now().Truncate(time.Second)
+	w := openTestWAL(t, dir)
+	q := New(WithStore(w))
+	released := q.Push(now, "released")
+	removed := q.Push(now.Add(time.Hour), "removed")
+	q.PushMessage(&Message{Time: now.Add(2 * time.Hour), Data: "pending", Key: "key"})
+	q.HoldTopic("held", "")
+	q.PushMessage(&Message{Time: now.Add(-time.Hour), Data: "held", Topic: "held"})
+	q.ScheduleFunc(now, func(Message) {})
+	q.Start()
+	if message := <-q.Messages(); message != released {
+		t.Fatalf("q.Messages() = %v WANT %v", message, released)
+	}
+	q.Stop()
+	q.Remove(removed, false)
+	if err := w.Err(); err != nil {
+		t.Fatalf("w.Err() = %v WANT nil", err)
+	}
+
+	//the WAL is not closed, as if the process crashed.
+	recovered := New(WithStore(openTestWAL(t, dir)))
+	if !recovered.Contains("key") {
+		t.Errorf("recovered.Contains(key) = %v WANT %v", false, true)
+	}
+	messages := recovered.PopAll(false)
+	if len(messages) != 2 || messages[0].Data != "held" || messages[1].Data != "pending" || !messages[1].Time.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("recovered.PopAll() = %v WANT held, pending", messages)
+	}
+	if message, err := w.Load(removed.ID); message != nil || err != nil {
+		t.Errorf("w.Load(removed) = %v, %v WANT nil, nil", message, err)
+	}
+	if again := New(WithStore(openTestWAL(t, dir))); again.Size() != 0 {
+		t.Errorf("Size() after PopAll() = %v WANT %v", again.Size(), 0)
+	}
+}
+
+func TestWAL_compaction(t *testing.T) {
+	dir := newTestWALDir(t)
+	w := openTestWAL(t, dir, WithSegmentSize(1024), WithSyncEvery(0))
+	q := New(WithStore(w))
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		q.Push(now, i)
+		q.Pop(false)
+	}
+	q.Push(now, "last")
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v WANT nil", err)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	if len(segments) != 1 {
+		t.Errorf("segments = %v WANT 1", segments)
+	}
+	if err := w.Err(); err != ErrWALClosed {
+		t.Errorf("w.Err() = %v WANT %v", err, ErrWALClosed)
+	}
+
+	//a partial entry from a crash mid-write is ignored.
+	f, _ := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write([]byte{0x40, 0x01})
+	f.Close()
+	recovered := New(WithStore(openTestWAL(t, dir)))
+	if message := recovered.PeekMessage(); recovered.Size() != 1 || message.Data != "last" {
+		t.Errorf("recovered = %v WANT last", message)
+	}
+}
@@ -0,0 +1,147 @@
+package timequeue
+
+import "time"
+
+//wheelStorage is a Storage that is a timing wheel. Messages due within slots
+//ticks of the earliest are kept in a ring of small heaps, one per tick, and all
+//later Messages are kept in an overflow heap until the wheel turns to them.
+//Pushing a Message that is due soon therefore only costs a push onto a small
+//heap, which is cheaper than the default Storage when many Messages are pending
+//and pushed quickly.
+//
+//Messages are still released in exact Time order, since the Messages within each
+//tick are ordered by their heap.
+type wheelStorage struct {
+	tick  time.Duration
+	slots []*messageHeap
+	//the tick of slots[base % len(slots)], which holds the earliest Messages.
+	base int64
+	//the time of tick 0.
+	origin time.Time
+	//the number of Messages in slots.
+	count    int
+	overflow *messageHeap
+}
+
+//newWheelStorage creates an empty wheelStorage with slots slots of tick each.
+func newWheelStorage(tick time.Duration, slots int, origin time.Time) *wheelStorage {
+	s := &wheelStorage{
+		tick:     tick,
+		slots:    make([]*messageHeap, slots),
+		origin:   origin,
+		overflow: newMessageHeap(),
+	}
+	for i := range s.slots {
+		s.slots[i] = newMessageHeap()
+	}
+	return s
+}
+
+//tickOf returns the tick that t falls in.
+func (s *wheelStorage) tickOf(t time.Time) int64 {
+	return int64(t.Sub(s.origin) / s.tick)
+}
+
+//slot returns the heap for tick, which must be within the wheel.
+func (s *wheelStorage) slot(tick int64) *messageHeap {
+	n := int64(len(s.slots))
+	return s.slots[((tick%n)+n)%n]
+}
+
+//push adds message to the slot of its tick, or to the overflow heap if it is
+//beyond the wheel. Messages before base are added to the base slot.
+func (s *wheelStorage) push(message *Message) {
+	tick := s.tickOf(message.Time)
+	if tick >= s.base+int64(len(s.slots)) {
+		s.overflow.pushMessage(message)
+		return
+	}
+	if tick < s.base {
+		tick = s.base
+	}
+	s.slot(tick).pushMessage(message)
+	s.count++
+}
+
+//turn advances base to the earliest non-empty slot, moving overflow Messages
+//into the wheel as it turns.
+func (s *wheelStorage) turn() {
+	if s.count == 0 {
+		if message := s.overflow.peekMessage(); message != nil {
+			s.base = s.tickOf(message.Time)
+			s.fill()
+		}
+		return
+	}
+	for s.slot(s.base).Len() == 0 {
+		s.base++
+		s.fill()
+	}
+}
+
+//fill moves the overflow Messages that are within the wheel into it.
+func (s *wheelStorage) fill() {
+	end := s.base + int64(len(s.slots))
+	for message := s.overflow.peekMessage(); message != nil && s.tickOf(message.Time) < end; message = s.overflow.peekMessage() {
+		s.push(s.overflow.popMessage())
+	}
+}
+
+//Push adds message to s.
+func (s *wheelStorage) Push(message *Message) {
+	if s.count == 0 && s.overflow.Len() == 0 {
+		s.base = s.tickOf(message.Time)
+	}
+	s.push(message)
+}
+
+//Peek returns the earliest Message in s.
+func (s *wheelStorage) Peek() *Message {
+	s.turn()
+	if s.count == 0 {
+		return nil
+	}
+	return s.slot(s.base).peekMessage()
+}
+
+//PopDue removes and returns the earliest Message in s if it is before until.
+func (s *wheelStorage) PopDue(until time.Time) *Message {
+	if message := s.Peek(); message == nil || !message.Before(until) {
+		return nil
+	}
+	s.count--
+	return s.slot(s.base).popMessage()
+}
+
+//Remove removes message from s.
+func (s *wheelStorage) Remove(message *Message) bool {
+	if s.overflow.removeMessage(message) {
+		return true
+	}
+	tick := s.tickOf(message.Time)
+	if tick < s.base {
+		tick = s.base
+	}
+	if tick >= s.base+int64(len(s.slots)) || !s.slot(tick).removeMessage(message) {
+		return false
+	}
+	s.count--
+	return true
+}
+
+//Len returns the number of Messages in s.
+func (s *wheelStorage) Len() int {
+	return s.count + s.overflow.Len()
+}
+
+//Each calls fn with every Message in s.
+func (s *wheelStorage) Each(fn func(message *Message)) {
+	for _, slot := range s.slots {
+		for _, message := range slot.messages {
+			fn(message)
+		}
+	}
+	for _, message := range s.overflow.messages {
+		fn(message)
+	}
+}
@@ -0,0 +1,59 @@
+package timequeue
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestWheelStorage(t *testing.T) {
+	now := time.Now()
+	s := newWheelStorage(time.Millisecond, 8, now)
+	random := rand.New(rand.NewSource(1))
+	messages := map[*Message]bool{}
+	for i := 0; i < 200; i++ {
+		message := &Message{Time: now.Add(time.Duration(random.Intn(100)-10) * time.Millisecond / 2)}
+		s.Push(message)
+		messages[message] = true
+	}
+	removed := 0
+	for message := range messages {
+		if removed == 50 {
+			break
+		}
+		if !s.Remove(message) {
+			t.Errorf("s.Remove() = false WANT true")
+		}
+		delete(messages, message)
+		removed++
+	}
+	if s.Len() != 150 {
+		t.Errorf("s.Len() = %v WANT %v", s.Len(), 150)
+	}
+	var last *Message
+	for message := s.PopDue(now.Add(time.Hour)); message != nil; message = s.PopDue(now.Add(time.Hour)) {
+		if !messages[message] {
+			t.Fatalf("s.PopDue() = %v WANT a pushed Message", message)
+		}
+		if last != nil && message.Time.Before(last.Time) {
+			t.Fatalf("s.PopDue() = %v before %v", message.Time, last.Time)
+		}
+		last = message
+	}
+	if s.Len() != 0 || s.Peek() != nil {
+		t.Errorf("s.Len(), s.Peek() = %v, %v WANT 0, nil", s.Len(), s.Peek())
+	}
+}
+
+func TestWheelStorage_PopDue_notDue(t *testing.T) {
+	now := time.Now()
+	s := newWheelStorage(time.Millisecond, 4, now)
+	message := &Message{Time: now.Add(time.Hour)}
+	s.Push(message)
+	if popped := s.PopDue(now); popped != nil {
+		t.Errorf("s.PopDue() = %v WANT nil", popped)
+	}
+	if s.Remove(&Message{Time: now}) {
+		t.Errorf("s.Remove(not pushed) = true WANT false")
+	}
+}
@@ -0,0 +1,49 @@
+//Package zstdcodec provides a timequeue.Codec wrapper that compresses encoded
+//Data with Zstandard, which keeps snapshots, WALs, and Stores small when there are
+//many far-future Messages with large Data:
+//	timequeue.RegisterType("report.v1", Report{}, zstdcodec.Wrap(timequeue.GobCodec))
+package zstdcodec
+
+import (
+	"reflect"
+
+	"github.com/gogolfing/timequeue"
+	"github.com/klauspost/compress/zstd"
+)
+
+//encoder and decoder are shared by all Codecs. Their EncodeAll() and DecodeAll()
+//methods are safe for use by multiple go-routines.
+var (
+	encoder, _ = zstd.NewWriter(nil)
+	decoder, _ = zstd.NewReader(nil)
+)
+
+//codec is a Codec returned from Wrap().
+type codec struct {
+	codec timequeue.Codec
+}
+
+//Wrap returns a Codec that compresses the output of c with Zstandard.
+//It should be wrapped by, not wrap, timequeue.EncryptCodec(), since encrypted data
+//does not compress.
+func Wrap(c timequeue.Codec) timequeue.Codec {
+	return &codec{codec: c}
+}
+
+//Encode encodes value with c.codec and compresses the result.
+func (c *codec) Encode(value interface{}) ([]byte, error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.EncodeAll(data, nil), nil
+}
+
+//Decode decompresses data and decodes the result with c.codec.
+func (c *codec) Decode(data []byte, t reflect.Type) (interface{}, error) {
+	plain, err := decoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.codec.Decode(plain, t)
+}
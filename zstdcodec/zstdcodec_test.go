@@ -0,0 +1,33 @@
+package zstdcodec
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogolfing/timequeue"
+)
+
+type report struct {
+	Body string
+}
+
+func TestWrap(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	encrypted, _ := timequeue.EncryptCodec(Wrap(timequeue.JSONCodec), key)
+	value := report{Body: strings.Repeat("scheduled ", 1000)}
+	plain, _ := timequeue.JSONCodec.Encode(value)
+	for i, codec := range []timequeue.Codec{Wrap(timequeue.JSONCodec), Wrap(timequeue.GobCodec), encrypted} {
+		data, err := codec.Encode(value)
+		if err != nil || len(data) >= len(plain)/10 {
+			t.Errorf("%v: Encode() = %v bytes, %v WANT fewer than %v, nil", i, len(data), err, len(plain)/10)
+		}
+		if decoded, err := codec.Decode(data, reflect.TypeOf(value)); decoded != value || err != nil {
+			t.Errorf("%v: Decode() equal, error = %v, %v WANT true, nil", i, decoded == value, err)
+		}
+	}
+	if _, err := Wrap(timequeue.JSONCodec).Decode([]byte("not zstd"), reflect.TypeOf(value)); err == nil {
+		t.Errorf("Decode(invalid) = nil WANT non-nil")
+	}
+}